@@ -0,0 +1,67 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AnalyzeBatch sends all of the requests at once, so that KataGo can search them in parallel and fill
+// the batches of the GPU, and returns the responses in the same order as the requests. Unlike Analyze,
+// a failing request does not stop the others: the returned error joins the errors of all the failed
+// requests, and their responses are left empty. Each request gets one response, so a request that
+// analyzes several turns fails with ErrBadRequest, and should be sent with Analyze instead. When the
+// context is cancelled, the running requests are terminated, their results so far are returned, and
+// the error includes the error of the context.
+func (k *KataGo) AnalyzeBatch(ctx context.Context, requests []AnalysisRequest) ([]AnalysisResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	done := make([]chan struct{}, len(requests))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	stop := context.AfterFunc(ctx, func() {
+		for i, request := range requests {
+			go func() {
+				// The query may not have been sent yet, so it is terminated again until it is done
+				for k.Terminate(request.ID) != nil {
+					select {
+					case <-done[i]:
+						return
+					case <-time.After(10 * time.Millisecond):
+					}
+				}
+			}()
+		}
+	})
+	defer stop()
+
+	responses := make([]AnalysisResponse, len(requests))
+	errs := make([]error, len(requests))
+	var wg sync.WaitGroup
+	for i, request := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			if request.turns() > 1 {
+				errs[i] = fmt.Errorf("%w: request %s analyzes %d turns, use Analyze", ErrBadRequest, request.ID, len(request.AnalyzeTurns))
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			result, err := k.Analyze([]AnalysisRequest{request})
+			if err != nil {
+				errs[i] = fmt.Errorf("request %s: %w", request.ID, err)
+				return
+			}
+			responses[i] = result[0]
+		}()
+	}
+	wg.Wait()
+	return responses, errors.Join(append(errs, ctx.Err())...)
+}
@@ -0,0 +1,96 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeBatch(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	var requests []AnalysisRequest
+	for _, move := range []Vertex{"E5", "C3", "G7"} {
+		request := NewRequest9x9()
+		request.Moves = []Move{{"B", move}}
+		request.AnalyzeTurns = []int{1}
+		request.MaxVisits = 50
+		requests = append(requests, request)
+	}
+	bad := NewRequest9x9()
+	bad.Rules = "unknown"
+	requests = append(requests, bad)
+
+	responses, err := k.AnalyzeBatch(context.Background(), requests)
+	if err == nil {
+		t.Errorf("Expected an error for the request with unknown rules")
+	}
+	if len(responses) != len(requests) {
+		t.Fatalf("Expected %d responses, got %d", len(requests), len(responses))
+	}
+	for i, request := range requests[:3] {
+		if responses[i].ID != request.ID {
+			t.Errorf("Expected response %d to be for %s, got %s", i, request.ID, responses[i].ID)
+		}
+	}
+	if responses[3].ID != "" {
+		t.Errorf("Expected an empty response for the failed request, got %s", responses[3].ID)
+	}
+}
+
+func TestAnalyzeBatchCancel(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.MaxVisits = 100000
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	responses, err := k.AnalyzeBatch(ctx, []AnalysisRequest{request})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the request to be terminated, it took %v", elapsed)
+	}
+	if responses[0].RootInfo.Visits >= 100000 {
+		t.Errorf("Expected a partial search, got %d visits", responses[0].RootInfo.Visits)
+	}
+}
+
+func TestAnalyzeBatchCancelWaiting(t *testing.T) {
+	// The second request waits for the first one, and is sent after the context is cancelled
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithRateLimit(RateLimit{MaxVisits: 100000}))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	first, second := NewRequest9x9(), NewRequest9x9()
+	first.MaxVisits, second.MaxVisits = 100000, 100000
+	second.Moves = []Move{{"B", "E5"}}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := k.AnalyzeBatch(ctx, []AnalysisRequest{first, second}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected both requests to be terminated, it took %v", elapsed)
+	}
+}
+
+func TestAnalyzeBatchTurns(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.Moves = []Move{{"B", "E5"}}
+	request.AnalyzeTurns = []int{0, 1}
+	responses, err := k.AnalyzeBatch(context.Background(), []AnalysisRequest{request})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest for several turns, got %v", err)
+	}
+	if len(responses) != 1 || responses[0].ID != "" {
+		t.Errorf("Expected an empty response, got %v", responses)
+	}
+}
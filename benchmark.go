@@ -0,0 +1,195 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BenchmarkOptions are the settings for Benchmark
+type BenchmarkOptions struct {
+	ConfigFile string
+	ModelFile  string
+	// ThreadCounts are the values of numSearchThreads to test. KataGo is restarted for each of them.
+	ThreadCounts []int
+	// BatchSizes are the numbers of queries that are sent at the same time
+	BatchSizes []int
+	// Visits is the number of visits for each query
+	Visits int
+	// Queries is the number of queries for each combination of thread count and batch size
+	Queries int
+	// Positions are the positions that are analyzed, in turn. The default is a few opening positions on 19x19.
+	Positions []Position
+}
+
+// DefaultBenchmarkOptions returns options that test 1 to 16 search threads, and batches of 1 and 4 queries
+func DefaultBenchmarkOptions(configFile, modelFile string) BenchmarkOptions {
+	return BenchmarkOptions{
+		ConfigFile:   configFile,
+		ModelFile:    modelFile,
+		ThreadCounts: []int{1, 2, 4, 8, 16},
+		BatchSizes:   []int{1, 4},
+		Visits:       200,
+		Queries:      8,
+	}
+}
+
+// BenchmarkResult is the measured speed for one combination of thread count and batch size
+type BenchmarkResult struct {
+	Threads   int
+	BatchSize int
+	Queries   int
+	// Visits is the total number of visits of all the queries
+	Visits   int
+	Duration time.Duration
+	// VisitsPerSecond is the total number of visits divided by the time it took to run all the queries
+	VisitsPerSecond float64
+	// The latencies are measured from sending a query until the response is received
+	MeanLatency   time.Duration
+	MedianLatency time.Duration
+	MaxLatency    time.Duration
+}
+
+// String returns a summary of the result, as one line
+func (r BenchmarkResult) String() string {
+	return fmt.Sprintf("threads %d, batch %d: %.0f visits/s, latency mean %v, median %v, max %v", r.Threads, r.BatchSize, r.VisitsPerSecond, r.MeanLatency.Round(time.Millisecond), r.MedianLatency.Round(time.Millisecond), r.MaxLatency.Round(time.Millisecond))
+}
+
+// FastestBenchmark returns the result with the most visits per second
+func FastestBenchmark(results []BenchmarkResult) (BenchmarkResult, bool) {
+	if len(results) == 0 {
+		return BenchmarkResult{}, false
+	}
+	fastest := results[0]
+	for _, r := range results[1:] {
+		if r.VisitsPerSecond > fastest.VisitsPerSecond {
+			fastest = r
+		}
+	}
+	return fastest, true
+}
+
+// benchmarkPositions are the default positions for Benchmark
+func benchmarkPositions() []Position {
+	openings := [][]Move{
+		{},
+		{{"B", "Q16"}},
+		{{"B", "Q16"}, {"W", "D4"}},
+		{{"B", "Q16"}, {"W", "D4"}, {"B", "Q3"}},
+		{{"B", "Q16"}, {"W", "D4"}, {"B", "Q3"}, {"W", "D16"}},
+		{{"B", "R16"}, {"W", "D4"}, {"B", "Q3"}, {"W", "C16"}, {"B", "E16"}},
+	}
+	positions := make([]Position, len(openings))
+	for i, moves := range openings {
+		positions[i] = NewPosition(19, 19)
+		positions[i].Moves = moves
+	}
+	return positions
+}
+
+// Benchmark measures the speed of KataGo with each of the thread counts and batch sizes, similar to
+// "katago benchmark", to find the best settings for the hardware. The queries are sent without a cache,
+// and each query in a run analyzes a different position, so that no results are reused.
+func Benchmark(ctx context.Context, opts BenchmarkOptions) ([]BenchmarkResult, error) {
+	if len(opts.ThreadCounts) == 0 || len(opts.BatchSizes) == 0 {
+		return nil, errors.New("no thread counts or batch sizes to benchmark")
+	}
+	if opts.Visits <= 0 || opts.Queries <= 0 {
+		return nil, errors.New("the number of visits and queries must be positive")
+	}
+	positions := opts.Positions
+	if len(positions) == 0 {
+		positions = benchmarkPositions()
+	}
+	var results []BenchmarkResult
+	for _, threads := range opts.ThreadCounts {
+		if threads < 1 {
+			return nil, fmt.Errorf("invalid thread count: %d", threads)
+		}
+		// Every query of the largest batch gets its own analysis thread, so that the queries run at the same time
+		k, err := NewKataGo(opts.ConfigFile, opts.ModelFile, WithSearchThreads(threads), WithAnalysisThreads(slices.Max(opts.BatchSizes)))
+		if err != nil {
+			return nil, err
+		}
+		for _, batchSize := range opts.BatchSizes {
+			result, err := k.benchmarkRun(ctx, positions, opts.Visits, opts.Queries, batchSize)
+			if err != nil {
+				k.Close()
+				return results, err
+			}
+			result.Threads = threads
+			results = append(results, result)
+		}
+		if err := k.Close(); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// benchmarkRun sends the queries, with batchSize queries running at a time, and measures the speed
+func (k *KataGo) benchmarkRun(ctx context.Context, positions []Position, visits, queries, batchSize int) (BenchmarkResult, error) {
+	if batchSize < 1 {
+		return BenchmarkResult{}, fmt.Errorf("invalid batch size: %d", batchSize)
+	}
+	stop := context.AfterFunc(ctx, func() { k.TerminateAll() })
+	defer stop()
+
+	latencies := make([]time.Duration, queries)
+	totalVisits := make([]int, queries)
+	errs := make([]error, queries)
+	slots := make(chan struct{}, batchSize)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < queries; i++ {
+		if ctx.Err() != nil {
+			break
+		}
+		slots <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			p := positions[i%len(positions)]
+			// Each round through the positions uses another komi, so that the positions are not the same
+			p.Komi += float64(i / len(positions))
+			request := p.Request(k.newID("benchmark"))
+			request.MaxVisits = visits
+			queryStart := time.Now()
+			responses, err := k.Analyze([]AnalysisRequest{request})
+			latencies[i] = time.Since(queryStart)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			totalVisits[i] = responses[0].RootInfo.Visits
+		}()
+	}
+	wg.Wait()
+	duration := time.Since(start)
+	if err := ctx.Err(); err != nil {
+		return BenchmarkResult{}, err
+	}
+	if err := errors.Join(errs...); err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	result := BenchmarkResult{BatchSize: batchSize, Queries: queries, Duration: duration}
+	var totalLatency time.Duration
+	for i := range latencies {
+		result.Visits += totalVisits[i]
+		totalLatency += latencies[i]
+		result.MaxLatency = max(result.MaxLatency, latencies[i])
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	result.MeanLatency = totalLatency / time.Duration(queries)
+	result.MedianLatency = latencies[queries/2]
+	if duration > 0 {
+		result.VisitsPerSecond = float64(result.Visits) / duration.Seconds()
+	}
+	return result, nil
+}
@@ -0,0 +1,50 @@
+package katago
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBenchmark(t *testing.T) {
+	opts := DefaultBenchmarkOptions("analysis_example.cfg", "model.bin.gz")
+	opts.ThreadCounts = []int{1, 4}
+	opts.BatchSizes = []int{1, 3}
+	opts.Visits = 20
+	opts.Queries = 7
+	results, err := Benchmark(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if r.Queries != 7 || r.Visits != 7*20 || r.VisitsPerSecond <= 0 || r.MaxLatency < r.MedianLatency {
+			t.Errorf("Unexpected result: %+v", r)
+		}
+	}
+	if results[3].Threads != 4 || results[3].BatchSize != 3 {
+		t.Errorf("Expected the last result to be for 4 threads and batches of 3, got %v", results[3])
+	}
+	if _, ok := FastestBenchmark(results); !ok {
+		t.Errorf("Expected a fastest result")
+	}
+}
+
+func TestBenchmarkCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	opts := DefaultBenchmarkOptions("analysis_example.cfg", "model.bin.gz")
+	opts.ThreadCounts = []int{1}
+	if _, err := Benchmark(ctx, opts); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+func TestBenchmarkOptions(t *testing.T) {
+	opts := DefaultBenchmarkOptions("analysis_example.cfg", "model.bin.gz")
+	opts.BatchSizes = nil
+	if _, err := Benchmark(context.Background(), opts); err == nil {
+		t.Errorf("Expected an error without batch sizes")
+	}
+}
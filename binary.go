@@ -0,0 +1,126 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrBinaryNotFound is returned, wrapped in a *BinaryNotFoundError, when the KataGo binary can not be found
+var ErrBinaryNotFound = errors.New("KataGo binary not found")
+
+// BinaryEnv is the environment variable that can give the path of the KataGo binary
+const BinaryEnv = "KATAGO_BINARY"
+
+// BinaryNotFoundError lists the places where FindBinary looked for KataGo
+type BinaryNotFoundError struct {
+	// Names are the file names that were searched for in PATH and in the directories
+	Names []string
+	// Tried are the paths that were checked, in order
+	Tried []string
+}
+
+// Error describes where KataGo was searched for
+func (e *BinaryNotFoundError) Error() string {
+	return fmt.Sprintf("%v: searched PATH for %s, and tried %s. Install KataGo, or give its path with %s or WithBinary",
+		ErrBinaryNotFound, strings.Join(e.Names, " and "), strings.Join(e.Tried, ", "), BinaryEnv)
+}
+
+// Unwrap returns ErrBinaryNotFound
+func (e *BinaryNotFoundError) Unwrap() error {
+	return ErrBinaryNotFound
+}
+
+// binaryNames returns the file names of KataGo on this platform
+func binaryNames() []string {
+	if runtime.GOOS == "windows" {
+		return []string{"katago.exe"}
+	}
+	return []string{"katago"}
+}
+
+// binaryDirs returns the directories where KataGo is commonly installed on this platform, besides PATH
+func binaryDirs() []string {
+	var dirs []string
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		for _, env := range []string{"ProgramFiles", "ProgramFiles(x86)", "LOCALAPPDATA"} {
+			if dir := os.Getenv(env); dir != "" {
+				dirs = append(dirs, filepath.Join(dir, "KataGo"))
+			}
+		}
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "katago"), filepath.Join(home, "KataGo"))
+		}
+	case "darwin":
+		// Homebrew on Apple Silicon and on Intel, and MacPorts
+		dirs = append(dirs, "/opt/homebrew/bin", "/usr/local/bin", "/opt/local/bin")
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "bin"), filepath.Join(home, ".local", "bin"))
+		}
+	default:
+		dirs = append(dirs, "/usr/local/bin", "/usr/bin", "/opt/katago", "/snap/bin", "/home/linuxbrew/.linuxbrew/bin")
+		if home != "" {
+			dirs = append(dirs, filepath.Join(home, "bin"), filepath.Join(home, ".local", "bin"), filepath.Join(home, "katago"))
+		}
+	}
+	return dirs
+}
+
+// isExecutable reports whether path is a file that can be run
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0o111 != 0
+}
+
+// FindBinary returns the path of the KataGo binary. The path in the KATAGO_BINARY environment variable is
+// used if it is set, and otherwise KataGo is searched for in PATH and then in the directories where
+// it is commonly installed, like the Homebrew directories on macOS and Program Files on Windows.
+// If KataGo is not found, the error is a *BinaryNotFoundError that lists what was tried.
+func FindBinary() (string, error) {
+	if path := os.Getenv(BinaryEnv); path != "" {
+		if !isExecutable(path) {
+			return "", &BinaryNotFoundError{Names: binaryNames(), Tried: []string{path + " (from " + BinaryEnv + ")"}}
+		}
+		return path, nil
+	}
+	return findBinary(binaryNames(), binaryDirs())
+}
+
+// findBinary searches for KataGo in PATH and then in the given directories
+func findBinary(names, dirs []string) (string, error) {
+	for _, name := range names {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	var tried []string
+	for _, dir := range dirs {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if isExecutable(path) {
+				return path, nil
+			}
+			tried = append(tried, path)
+		}
+	}
+	return "", &BinaryNotFoundError{Names: names, Tried: tried}
+}
+
+// WithBinary runs the KataGo binary at the given path, instead of searching for it with FindBinary
+func WithBinary(path string) Option {
+	return func(k *KataGo) {
+		k.binary = path
+	}
+}
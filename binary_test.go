@@ -0,0 +1,66 @@
+package katago
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFindBinary(t *testing.T) {
+	path, err := FindBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(filepath.Base(path), "katago") {
+		t.Errorf("Expected the path of katago, got %s", path)
+	}
+
+	t.Setenv(BinaryEnv, path)
+	if found, err := FindBinary(); err != nil || found != path {
+		t.Errorf("Expected %s from %s, got %s (%v)", path, BinaryEnv, found, err)
+	}
+	missing := filepath.Join(t.TempDir(), "katago")
+	t.Setenv(BinaryEnv, missing)
+	_, err = FindBinary()
+	var notFound *BinaryNotFoundError
+	if !errors.As(err, &notFound) || !errors.Is(err, ErrBinaryNotFound) || !strings.Contains(err.Error(), missing) {
+		t.Errorf("Expected a BinaryNotFoundError that mentions %s, got %v", missing, err)
+	}
+}
+
+func TestBinaryNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	dirs := []string{t.TempDir(), t.TempDir()}
+	_, err := findBinary([]string{"katago"}, dirs)
+	var notFound *BinaryNotFoundError
+	if !errors.As(err, &notFound) || len(notFound.Tried) != 2 {
+		t.Fatalf("Expected a BinaryNotFoundError with the 2 paths that were tried, got %v", err)
+	}
+	if !strings.Contains(err.Error(), filepath.Join(dirs[1], "katago")) {
+		t.Errorf("Expected the error to mention %s, got %v", dirs[1], err)
+	}
+	if err := os.WriteFile(filepath.Join(dirs[1], "katago"), []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findBinary([]string{"katago"}, dirs); err != nil || path != filepath.Join(dirs[1], "katago") {
+		t.Errorf("Expected KataGo to be found in %s, got %s (%v)", dirs[1], path, err)
+	}
+}
+
+func TestWithBinary(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "fake-katago")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nexit 1\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithBinary(script))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	if k.current().cmd.Path != script {
+		t.Errorf("Expected %s to be run, got %s", script, k.current().cmd.Path)
+	}
+}
@@ -0,0 +1,595 @@
+// Package board provides a model of a Go board, with captures, ko and superko detection and legality checks
+package board
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Color is the color of a stone, or Empty for an empty intersection
+type Color int8
+
+// Colors
+const (
+	Empty Color = iota
+	Black
+	White
+)
+
+// Opponent returns the other color
+func (c Color) Opponent() Color {
+	switch c {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return Empty
+}
+
+// String returns "B", "W" or an empty string
+func (c Color) String() string {
+	switch c {
+	case Black:
+		return "B"
+	case White:
+		return "W"
+	}
+	return ""
+}
+
+// ParseColor parses a color like "B", "w", "black" or "White"
+func ParseColor(s string) (Color, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "b", "black":
+		return Black, nil
+	case "w", "white":
+		return White, nil
+	}
+	return Empty, fmt.Errorf("invalid color: %q", s)
+}
+
+// Point is an intersection on the board, where (0, 0) is the top left corner
+type Point struct {
+	X, Y int
+}
+
+// Pass is the point used for passing instead of placing a stone
+var Pass = Point{-1, -1}
+
+// IsPass checks if this point represents a pass
+func (p Point) IsPass() bool {
+	return p == Pass
+}
+
+// KoRule decides which board repetitions are forbidden
+type KoRule int
+
+// Ko rules
+const (
+	// SimpleKo only forbids immediately retaking a single stone ko
+	SimpleKo KoRule = iota
+	// PositionalSuperko forbids recreating any earlier board position
+	PositionalSuperko
+	// SituationalSuperko forbids recreating any earlier board position with the same player to move next
+	SituationalSuperko
+)
+
+// Errors returned when a move is not legal
+var (
+	ErrOutOfBounds = errors.New("point is outside of the board")
+	ErrOccupied    = errors.New("point is already occupied")
+	ErrSuicide     = errors.New("move is suicide")
+	ErrKo          = errors.New("move retakes a ko")
+	ErrSuperko     = errors.New("move repeats an earlier position")
+	ErrNoMoves     = errors.New("no moves to undo")
+)
+
+// Move is a stone of a given color placed at a point, or a pass
+type Move struct {
+	Color Color
+	Point Point
+}
+
+// snapshot is the state needed to undo a move
+type snapshot struct {
+	grid     []Color
+	toPlay   Color
+	ko       Point
+	captures [3]int
+}
+
+// Board is a Go board with stones, the player to move and a history of moves
+type Board struct {
+	width, height int
+	grid          []Color
+	toPlay        Color
+	ko            Point
+	captures      [3]int
+	setup         []Move
+	moves         []Move
+	undo          []snapshot
+	seen          map[string]int
+	seenPlayer    map[string]int
+
+	// KoRule is the rule used when checking for repeated positions
+	KoRule KoRule
+	// Suicide allows multi-stone suicide, as in the Tromp-Taylor and New Zealand rules
+	Suicide bool
+}
+
+// New creates an empty board of the given size, with black to move
+func New(width, height int) (*Board, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	b := &Board{
+		width:      width,
+		height:     height,
+		grid:       make([]Color, width*height),
+		toPlay:     Black,
+		ko:         Pass,
+		seen:       make(map[string]int),
+		seenPlayer: make(map[string]int),
+	}
+	b.remember()
+	return b, nil
+}
+
+// Width returns the number of columns
+func (b *Board) Width() int {
+	return b.width
+}
+
+// Height returns the number of rows
+func (b *Board) Height() int {
+	return b.height
+}
+
+// Contains checks if the point is on the board
+func (b *Board) Contains(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < b.width && p.Y < b.height
+}
+
+// At returns the color of the stone at the given point, or Empty
+func (b *Board) At(p Point) Color {
+	if !b.Contains(p) {
+		return Empty
+	}
+	return b.grid[p.Y*b.width+p.X]
+}
+
+func (b *Board) set(p Point, c Color) {
+	b.grid[p.Y*b.width+p.X] = c
+}
+
+// ToPlay returns the color of the player to move next
+func (b *Board) ToPlay() Color {
+	return b.toPlay
+}
+
+// SetToPlay sets the color of the player to move next
+func (b *Board) SetToPlay(c Color) {
+	b.forget()
+	b.toPlay = c
+	b.remember()
+}
+
+// Captures returns the number of stones that the given color has captured
+func (b *Board) Captures(c Color) int {
+	return b.captures[c]
+}
+
+// Ko returns the point that can not be played because of a simple ko, or Pass if there is none
+func (b *Board) Ko() Point {
+	return b.ko
+}
+
+// Clone returns a deep copy of the board
+func (b *Board) Clone() *Board {
+	c := *b
+	c.grid = append([]Color(nil), b.grid...)
+	c.setup = append([]Move(nil), b.setup...)
+	c.moves = append([]Move(nil), b.moves...)
+	c.undo = append([]snapshot(nil), b.undo...)
+	c.seen = copyCounts(b.seen)
+	c.seenPlayer = copyCounts(b.seenPlayer)
+	return &c
+}
+
+func copyCounts(m map[string]int) map[string]int {
+	c := make(map[string]int, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// neighbors returns the points next to p that are on the board
+func (b *Board) neighbors(p Point) []Point {
+	ns := make([]Point, 0, 4)
+	for _, n := range [4]Point{{p.X - 1, p.Y}, {p.X + 1, p.Y}, {p.X, p.Y - 1}, {p.X, p.Y + 1}} {
+		if b.Contains(n) {
+			ns = append(ns, n)
+		}
+	}
+	return ns
+}
+
+// Group returns the points of the chain of stones that p is part of, and the number of liberties of the chain
+func (b *Board) Group(p Point) ([]Point, int) {
+	c := b.At(p)
+	if c == Empty {
+		return nil, 0
+	}
+	visited := map[Point]bool{p: true}
+	liberties := make(map[Point]bool)
+	stack := []Point{p}
+	var group []Point
+	for len(stack) > 0 {
+		q := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		group = append(group, q)
+		for _, n := range b.neighbors(q) {
+			switch b.At(n) {
+			case Empty:
+				liberties[n] = true
+			case c:
+				if !visited[n] {
+					visited[n] = true
+					stack = append(stack, n)
+				}
+			}
+		}
+	}
+	return group, len(liberties)
+}
+
+// Liberties returns the number of liberties of the chain that p is part of
+func (b *Board) Liberties(p Point) int {
+	_, liberties := b.Group(p)
+	return liberties
+}
+
+// key returns a string that identifies the stones on the board, and optionally the player to move
+func (b *Board) key(withPlayer bool) string {
+	var sb strings.Builder
+	sb.Grow(len(b.grid) + 1)
+	for _, c := range b.grid {
+		sb.WriteByte(byte('0' + c))
+	}
+	if withPlayer {
+		sb.WriteByte(byte('0' + b.toPlay))
+	}
+	return sb.String()
+}
+
+// Key returns a string that identifies the stones on the board and the player to move,
+// which can be used for finding transpositions
+func (b *Board) Key() string {
+	return b.key(true)
+}
+
+// remember records the current position, both with and without the player to move
+func (b *Board) remember() {
+	b.seen[b.key(false)]++
+	b.seenPlayer[b.key(true)]++
+}
+
+// forget removes the current position from the record of earlier positions
+func (b *Board) forget() {
+	decrement(b.seen, b.key(false))
+	decrement(b.seenPlayer, b.key(true))
+}
+
+func decrement(m map[string]int, k string) {
+	if m[k]--; m[k] <= 0 {
+		delete(m, k)
+	}
+}
+
+// repeats checks if the position on the given board was seen earlier, according to the ko rule
+func (b *Board) repeats(position *Board) bool {
+	switch b.KoRule {
+	case PositionalSuperko:
+		return b.seen[position.key(false)] > 0
+	case SituationalSuperko:
+		return b.seenPlayer[position.key(true)] > 0
+	}
+	return false
+}
+
+// Setup places a stone directly on the board, like a handicap stone, without resolving captures.
+// Setup stones can only be placed before any moves have been played.
+func (b *Board) Setup(c Color, p Point) error {
+	if len(b.moves) > 0 {
+		return errors.New("setup stones must be placed before any moves are played")
+	}
+	if !b.Contains(p) {
+		return ErrOutOfBounds
+	}
+	if b.At(p) != Empty {
+		return ErrOccupied
+	}
+	b.forget()
+	b.set(p, c)
+	b.remember()
+	b.setup = append(b.setup, Move{c, p})
+	return nil
+}
+
+// play places the stone and resolves captures, without any legality checks beyond the point being empty.
+// The number of captured stones is returned, together with the single stone that was captured, if any.
+func (b *Board) play(c Color, p Point) (int, Point) {
+	b.set(p, c)
+	captured, lastCaptured := 0, Pass
+	for _, n := range b.neighbors(p) {
+		if b.At(n) != c.Opponent() {
+			continue
+		}
+		if group, liberties := b.Group(n); liberties == 0 {
+			for _, q := range group {
+				b.set(q, Empty)
+				lastCaptured = q
+			}
+			captured += len(group)
+		}
+	}
+	if group, liberties := b.Group(p); liberties == 0 {
+		// Suicide, only reached when it is allowed
+		for _, q := range group {
+			b.set(q, Empty)
+		}
+		b.captures[c.Opponent()] += len(group)
+	}
+	b.captures[c] += captured
+	return captured, lastCaptured
+}
+
+// Check returns an error if placing a stone of the given color at p is not legal.
+// Passing is always legal.
+func (b *Board) Check(c Color, p Point) error {
+	if c != Black && c != White {
+		return fmt.Errorf("invalid color: %d", c)
+	}
+	if p.IsPass() {
+		return nil
+	}
+	if !b.Contains(p) {
+		return ErrOutOfBounds
+	}
+	if b.At(p) != Empty {
+		return ErrOccupied
+	}
+	if b.KoRule == SimpleKo && p == b.ko && c == b.toPlay {
+		return ErrKo
+	}
+	trial := &Board{width: b.width, height: b.height, grid: append([]Color(nil), b.grid...), toPlay: c.Opponent()}
+	captured, _ := trial.play(c, p)
+	if captured == 0 && trial.At(p) == Empty {
+		// Single stone suicide is never allowed, since it does not change anything
+		if !b.Suicide || !b.hasNeighbor(p, c) {
+			return ErrSuicide
+		}
+	}
+	if b.repeats(trial) {
+		return ErrSuperko
+	}
+	return nil
+}
+
+// hasNeighbor checks if any of the points next to p has a stone of the given color
+func (b *Board) hasNeighbor(p Point, c Color) bool {
+	for _, n := range b.neighbors(p) {
+		if b.At(n) == c {
+			return true
+		}
+	}
+	return false
+}
+
+// IsLegal checks if placing a stone of the given color at p is legal
+func (b *Board) IsLegal(c Color, p Point) bool {
+	return b.Check(c, p) == nil
+}
+
+// Play places a stone of the given color at p, or passes if p is Pass, and resolves captures
+func (b *Board) Play(c Color, p Point) error {
+	if err := b.Check(c, p); err != nil {
+		return err
+	}
+	b.undo = append(b.undo, snapshot{
+		grid:     append([]Color(nil), b.grid...),
+		toPlay:   b.toPlay,
+		ko:       b.ko,
+		captures: b.captures,
+	})
+	b.ko = Pass
+	if !p.IsPass() {
+		captured, lastCaptured := b.play(c, p)
+		// A single stone that captured a single stone and has a single liberty creates a ko
+		if group, liberties := b.Group(p); captured == 1 && len(group) == 1 && liberties == 1 {
+			b.ko = lastCaptured
+		}
+	}
+	b.toPlay = c.Opponent()
+	b.moves = append(b.moves, Move{c, p})
+	b.remember()
+	return nil
+}
+
+// Undo takes back the last move
+func (b *Board) Undo() error {
+	if len(b.moves) == 0 {
+		return ErrNoMoves
+	}
+	b.forget()
+	s := b.undo[len(b.undo)-1]
+	b.undo = b.undo[:len(b.undo)-1]
+	b.moves = b.moves[:len(b.moves)-1]
+	b.grid = s.grid
+	b.toPlay = s.toPlay
+	b.ko = s.ko
+	b.captures = s.captures
+	return nil
+}
+
+// Moves returns the moves that have been played, in order
+func (b *Board) Moves() []Move {
+	return append([]Move(nil), b.moves...)
+}
+
+// SetupStones returns the stones that were placed with Setup
+func (b *Board) SetupStones() []Move {
+	return append([]Move(nil), b.setup...)
+}
+
+// toPairs converts moves to the [color, vertex] pairs used by the KataGo analysis protocol
+func (b *Board) toPairs(moves []Move) [][2]string {
+	pairs := make([][2]string, 0, len(moves))
+	for _, m := range moves {
+		pairs = append(pairs, [2]string{m.Color.String(), b.Vertex(m.Point)})
+	}
+	return pairs
+}
+
+// InitialStones returns the setup stones in the format used by the "initialStones" field of an analysis request
+func (b *Board) InitialStones() [][2]string {
+	return b.toPairs(b.setup)
+}
+
+// MovePairs returns the played moves in the format used by the "moves" field of an analysis request
+func (b *Board) MovePairs() [][2]string {
+	return b.toPairs(b.moves)
+}
+
+// FromPairs creates a board from the "initialStones" and "moves" fields of an analysis request,
+// checking that each of the moves is legal
+func FromPairs(width, height int, initialStones, moves [][2]string) (*Board, error) {
+	b, err := New(width, height)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Load(initialStones, moves); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Load places the initial stones and plays the moves, in the format used by the "initialStones" and "moves"
+// fields of an analysis request, checking that each of the moves is legal
+func (b *Board) Load(initialStones, moves [][2]string) error {
+	for _, stone := range initialStones {
+		c, p, err := b.parsePair(stone)
+		if err != nil {
+			return err
+		}
+		if err := b.Setup(c, p); err != nil {
+			return fmt.Errorf("initial stone %s %s: %w", stone[0], stone[1], err)
+		}
+	}
+	if len(moves) > 0 {
+		c, _, _ := b.parsePair(moves[0])
+		b.SetToPlay(c)
+	}
+	for i, move := range moves {
+		c, p, err := b.parsePair(move)
+		if err != nil {
+			return err
+		}
+		if err := b.Play(c, p); err != nil {
+			return fmt.Errorf("move %d (%s %s): %w", i+1, move[0], move[1], err)
+		}
+	}
+	return nil
+}
+
+func (b *Board) parsePair(pair [2]string) (Color, Point, error) {
+	c, err := ParseColor(pair[0])
+	if err != nil {
+		return Empty, Pass, err
+	}
+	p, err := b.ParseVertex(pair[1])
+	if err != nil {
+		return Empty, Pass, err
+	}
+	return c, p, nil
+}
+
+// Stones returns the current position as a list of stones, in the format used by the "initialStones" field.
+// This is useful for analyzing a position without its move history.
+func (b *Board) Stones() [][2]string {
+	var pairs [][2]string
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			p := Point{x, y}
+			if c := b.At(p); c != Empty {
+				pairs = append(pairs, [2]string{c.String(), b.Vertex(p)})
+			}
+		}
+	}
+	return pairs
+}
+
+// starLines returns the lines with star points along one side of the board, and the center line if there is one
+func starLines(size int) ([]int, int) {
+	var edges []int
+	switch {
+	case size >= 12:
+		edges = []int{3, size - 4}
+	case size >= 7:
+		edges = []int{2, size - 3}
+	}
+	center := -1
+	if size%2 == 1 && size >= 5 {
+		center = size / 2
+	}
+	return edges, center
+}
+
+// StarPoints returns the star points (hoshi) of a board of the given size
+func StarPoints(width, height int) []Point {
+	xs, cx := starLines(width)
+	ys, cy := starLines(height)
+	var points []Point
+	for _, y := range ys {
+		for _, x := range xs {
+			points = append(points, Point{x, y})
+		}
+	}
+	if cx >= 0 && cy >= 0 {
+		points = append(points, Point{cx, cy})
+	}
+	if width >= 15 && height >= 15 && cx >= 0 && cy >= 0 {
+		for _, x := range xs {
+			points = append(points, Point{x, cy})
+		}
+		for _, y := range ys {
+			points = append(points, Point{cx, y})
+		}
+	}
+	return points
+}
+
+// String returns a simple text drawing of the board
+func (b *Board) String() string {
+	var sb strings.Builder
+	for y := 0; y < b.height; y++ {
+		for x := 0; x < b.width; x++ {
+			if x > 0 {
+				sb.WriteByte(' ')
+			}
+			switch b.At(Point{x, y}) {
+			case Black:
+				sb.WriteByte('X')
+			case White:
+				sb.WriteByte('O')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
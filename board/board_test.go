@@ -0,0 +1,154 @@
+package board
+
+import "testing"
+
+func newBoard(t *testing.T, size int) *Board {
+	t.Helper()
+
+	b, err := New(size, size)
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	return b
+}
+
+func mustPlay(t *testing.T, b *Board, c Color, p Point) {
+	t.Helper()
+
+	if err := b.Play(c, p); err != nil {
+		t.Fatalf("Failed to play %v at %v: %v", c, p, err)
+	}
+}
+
+// setupKo creates a ko shape in the top left corner, where black can capture at (2, 1)
+func setupKo(t *testing.T, b *Board) {
+	t.Helper()
+
+	for _, p := range []Point{{1, 0}, {0, 1}, {1, 2}} {
+		if err := b.Setup(Black, p); err != nil {
+			t.Fatalf("Failed to set up stone: %v", err)
+		}
+	}
+	for _, p := range []Point{{2, 0}, {3, 1}, {2, 2}, {1, 1}} {
+		if err := b.Setup(White, p); err != nil {
+			t.Fatalf("Failed to set up stone: %v", err)
+		}
+	}
+}
+
+func TestCapture(t *testing.T) {
+	b := newBoard(t, 9)
+	mustPlay(t, b, Black, Point{1, 0})
+	mustPlay(t, b, White, Point{0, 0})
+	mustPlay(t, b, Black, Point{0, 1})
+	if b.At(Point{0, 0}) != Empty {
+		t.Errorf("Expected the white corner stone to be captured")
+	}
+	if b.Captures(Black) != 1 {
+		t.Errorf("Expected black to have captured 1 stone, got %d", b.Captures(Black))
+	}
+	if err := b.Play(White, Point{1, 0}); err != ErrOccupied {
+		t.Errorf("Expected ErrOccupied, got %v", err)
+	}
+	if err := b.Play(White, Point{9, 0}); err != ErrOutOfBounds {
+		t.Errorf("Expected ErrOutOfBounds, got %v", err)
+	}
+}
+
+func TestKo(t *testing.T) {
+	b := newBoard(t, 9)
+	setupKo(t, b)
+	mustPlay(t, b, Black, Point{2, 1})
+	if b.Ko() != (Point{1, 1}) {
+		t.Fatalf("Expected a ko at (1, 1), got %v", b.Ko())
+	}
+	if err := b.Play(White, Point{1, 1}); err != ErrKo {
+		t.Errorf("Expected ErrKo, got %v", err)
+	}
+	mustPlay(t, b, White, Point{8, 8})
+	mustPlay(t, b, Black, Point{7, 7})
+	mustPlay(t, b, White, Point{1, 1})
+	if b.At(Point{2, 1}) != Empty {
+		t.Errorf("Expected the ko to be retaken")
+	}
+}
+
+func TestSuperko(t *testing.T) {
+	b := newBoard(t, 9)
+	b.KoRule = PositionalSuperko
+	setupKo(t, b)
+	mustPlay(t, b, Black, Point{2, 1})
+	if err := b.Check(White, Point{1, 1}); err != ErrSuperko {
+		t.Errorf("Expected ErrSuperko, got %v", err)
+	}
+}
+
+func TestSuicide(t *testing.T) {
+	b := newBoard(t, 9)
+	mustPlay(t, b, Black, Point{0, 0})
+	mustPlay(t, b, White, Point{1, 0})
+	mustPlay(t, b, Black, Point{8, 8})
+	mustPlay(t, b, White, Point{1, 1})
+	mustPlay(t, b, Black, Point{8, 7})
+	mustPlay(t, b, White, Point{0, 2})
+	if err := b.Check(Black, Point{0, 1}); err != ErrSuicide {
+		t.Errorf("Expected ErrSuicide, got %v", err)
+	}
+	b.Suicide = true
+	mustPlay(t, b, Black, Point{0, 1})
+	if b.At(Point{0, 0}) != Empty || b.Captures(White) != 2 {
+		t.Errorf("Expected the suicided stones to be removed and counted")
+	}
+}
+
+func TestUndo(t *testing.T) {
+	b := newBoard(t, 9)
+	mustPlay(t, b, Black, Point{1, 0})
+	mustPlay(t, b, White, Point{0, 0})
+	mustPlay(t, b, Black, Point{0, 1})
+	if err := b.Undo(); err != nil {
+		t.Fatalf("Failed to undo: %v", err)
+	}
+	if b.At(Point{0, 0}) != White || b.Captures(Black) != 0 || b.ToPlay() != Black {
+		t.Errorf("Expected the capture to be undone")
+	}
+	if len(b.Moves()) != 2 {
+		t.Errorf("Expected 2 moves after undo, got %d", len(b.Moves()))
+	}
+	b.Undo()
+	b.Undo()
+	if err := b.Undo(); err != ErrNoMoves {
+		t.Errorf("Expected ErrNoMoves, got %v", err)
+	}
+}
+
+func TestRequestFields(t *testing.T) {
+	b := newBoard(t, 19)
+	if err := b.Setup(Black, Point{15, 3}); err != nil {
+		t.Fatalf("Failed to set up stone: %v", err)
+	}
+	mustPlay(t, b, White, Point{3, 15})
+	mustPlay(t, b, Black, Pass)
+	stones := b.InitialStones()
+	if len(stones) != 1 || stones[0] != [2]string{"B", "Q16"} {
+		t.Errorf("Expected initial stones [[B Q16]], got %v", stones)
+	}
+	moves := b.MovePairs()
+	if len(moves) != 2 || moves[0] != [2]string{"W", "D4"} || moves[1] != [2]string{"B", "pass"} {
+		t.Errorf("Expected moves [[W D4] [B pass]], got %v", moves)
+	}
+}
+
+func TestStarPoints(t *testing.T) {
+	cases := map[int]int{19: 9, 13: 5, 9: 5, 5: 1}
+	for size, expected := range cases {
+		if n := len(StarPoints(size, size)); n != expected {
+			t.Errorf("Expected %d star points on a %dx%d board, got %d", expected, size, size, n)
+		}
+	}
+	for _, p := range StarPoints(19, 19) {
+		if p.X != 3 && p.X != 9 && p.X != 15 {
+			t.Errorf("Unexpected star point %v on a 19x19 board", p)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package board
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MaxSize is the largest supported board width or height, limited by the number of GTP column letters
+const MaxSize = 25
+
+// columns are the column letters used by GTP, which skips the letter I
+const columns = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// sgfLetters are the letters used for SGF coordinates, for boards up to 52x52
+const sgfLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func checkSize(width, height int) error {
+	if width < 1 || height < 1 || width > MaxSize || height > MaxSize {
+		return fmt.Errorf("invalid board size: %dx%d", width, height)
+	}
+	return nil
+}
+
+// ColumnName returns the GTP letter for a column, like "J" for the ninth column
+func ColumnName(x int) string {
+	if x < 0 || x >= len(columns) {
+		return ""
+	}
+	return columns[x : x+1]
+}
+
+// Vertex returns the GTP vertex for a point, like "Q16" or "pass", on a board with the given height.
+// Points outside of the GTP column range return an empty string.
+func Vertex(p Point, height int) string {
+	if p.IsPass() {
+		return "pass"
+	}
+	if p.X < 0 || p.X >= len(columns) || p.Y < 0 || p.Y >= height {
+		return ""
+	}
+	return string(columns[p.X]) + strconv.Itoa(height-p.Y)
+}
+
+// ParseVertex parses a GTP vertex like "Q16", "q16" or "pass" on a board of the given size.
+// The letter I is not used for columns, so "J1" is the ninth column.
+func ParseVertex(s string, width, height int) (Point, error) {
+	if err := checkSize(width, height); err != nil {
+		return Pass, err
+	}
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "PASS" {
+		return Pass, nil
+	}
+	if len(s) < 2 {
+		return Pass, fmt.Errorf("invalid vertex: %q", s)
+	}
+	x := strings.IndexByte(columns, s[0])
+	if x < 0 {
+		return Pass, fmt.Errorf("invalid column in vertex: %q", s)
+	}
+	row, err := strconv.Atoi(s[1:])
+	if err != nil {
+		return Pass, fmt.Errorf("invalid row in vertex: %q", s)
+	}
+	if x >= width || row < 1 || row > height {
+		return Pass, fmt.Errorf("vertex %s is outside of a %dx%d board", s, width, height)
+	}
+	return Point{x, height - row}, nil
+}
+
+// SGF returns the SGF coordinates for a point, like "pd". A pass is an empty string.
+func SGF(p Point) string {
+	if p.IsPass() || p.X < 0 || p.Y < 0 || p.X >= len(sgfLetters) || p.Y >= len(sgfLetters) {
+		return ""
+	}
+	return string(sgfLetters[p.X]) + string(sgfLetters[p.Y])
+}
+
+// ParseSGF parses SGF coordinates like "pd" on a board of the given size.
+// Both an empty string and "tt" on boards up to 19x19 are treated as a pass.
+func ParseSGF(s string, width, height int) (Point, error) {
+	if err := checkSize(width, height); err != nil {
+		return Pass, err
+	}
+	s = strings.TrimSpace(s)
+	if s == "" || (s == "tt" && width <= 19 && height <= 19) {
+		return Pass, nil
+	}
+	if len(s) != 2 {
+		return Pass, fmt.Errorf("invalid SGF coordinates: %q", s)
+	}
+	x, y := strings.IndexByte(sgfLetters, s[0]), strings.IndexByte(sgfLetters, s[1])
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return Pass, fmt.Errorf("SGF coordinates %q are outside of a %dx%d board", s, width, height)
+	}
+	return Point{x, y}, nil
+}
+
+// GTPToSGF converts a GTP vertex like "Q16" to SGF coordinates like "pd"
+func GTPToSGF(vertex string, width, height int) (string, error) {
+	p, err := ParseVertex(vertex, width, height)
+	if err != nil {
+		return "", err
+	}
+	return SGF(p), nil
+}
+
+// SGFToGTP converts SGF coordinates like "pd" to a GTP vertex like "Q16"
+func SGFToGTP(coords string, width, height int) (string, error) {
+	p, err := ParseSGF(coords, width, height)
+	if err != nil {
+		return "", err
+	}
+	return Vertex(p, height), nil
+}
+
+// Vertex returns the GTP vertex for a point on this board, like "Q16" or "pass"
+func (b *Board) Vertex(p Point) string {
+	return Vertex(p, b.height)
+}
+
+// ParseVertex parses a GTP vertex like "Q16" or "pass" on this board
+func (b *Board) ParseVertex(s string) (Point, error) {
+	return ParseVertex(s, b.width, b.height)
+}
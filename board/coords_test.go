@@ -0,0 +1,71 @@
+package board
+
+import "testing"
+
+func TestVertex(t *testing.T) {
+	cases := map[string]Point{
+		"A19":  {0, 0},
+		"Q16":  {15, 3},
+		"J1":   {8, 18},
+		"T1":   {18, 18},
+		"pass": Pass,
+	}
+	for s, expected := range cases {
+		p, err := ParseVertex(s, 19, 19)
+		if err != nil {
+			t.Errorf("Failed to parse vertex %s: %v", s, err)
+			continue
+		}
+		if p != expected {
+			t.Errorf("Expected %v for %s, got %v", expected, s, p)
+		}
+		if v := Vertex(p, 19); v != s {
+			t.Errorf("Expected vertex %s, got %s", s, v)
+		}
+	}
+	for _, s := range []string{"I5", "U1", "A20", "A0", "Q", ""} {
+		if _, err := ParseVertex(s, 19, 19); err == nil {
+			t.Errorf("Expected an error when parsing %q", s)
+		}
+	}
+	if p, err := ParseVertex("m3", 13, 9); err != nil || p != (Point{11, 6}) {
+		t.Errorf("Expected (11, 6) for m3 on a 13x9 board, got %v (%v)", p, err)
+	}
+}
+
+func TestSGF(t *testing.T) {
+	p, err := ParseSGF("pd", 19, 19)
+	if err != nil || p != (Point{15, 3}) {
+		t.Errorf("Expected (15, 3) for pd, got %v (%v)", p, err)
+	}
+	if SGF(p) != "pd" {
+		t.Errorf("Expected pd, got %s", SGF(p))
+	}
+	for _, s := range []string{"", "tt"} {
+		if p, err := ParseSGF(s, 19, 19); err != nil || !p.IsPass() {
+			t.Errorf("Expected %q to be a pass", s)
+		}
+	}
+	if _, err := ParseSGF("jj", 9, 9); err == nil {
+		t.Errorf("Expected an error for jj on a 9x9 board")
+	}
+	if v, err := SGFToGTP("aa", 9, 9); err != nil || v != "A9" {
+		t.Errorf("Expected A9, got %s (%v)", v, err)
+	}
+	if s, err := GTPToSGF("J9", 9, 9); err != nil || s != "ia" {
+		t.Errorf("Expected ia, got %s (%v)", s, err)
+	}
+}
+
+func TestFromPairs(t *testing.T) {
+	b, err := FromPairs(19, 19, [][2]string{{"B", "Q16"}}, [][2]string{{"W", "D4"}, {"B", "D16"}})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	if b.At(Point{3, 15}) != White || b.ToPlay() != White {
+		t.Errorf("Expected a white stone at D4 and white to play")
+	}
+	if _, err := FromPairs(19, 19, nil, [][2]string{{"W", "D4"}, {"B", "D4"}}); err == nil {
+		t.Errorf("Expected an error when playing on an occupied point")
+	}
+}
@@ -0,0 +1,52 @@
+package board
+
+import "fmt"
+
+// MaxHandicap returns the largest number of fixed handicap stones for a board of the given size,
+// following the GTP conventions: none below 7x7, 4 when a side is even and 9 otherwise
+func MaxHandicap(width, height int) int {
+	switch {
+	case width < 7 || height < 7:
+		return 0
+	case width%2 == 0 || height%2 == 0:
+		return 4
+	}
+	return 9
+}
+
+// HandicapPoints returns the standard placement of fixed handicap stones, for 2 to 9 stones.
+// The stones are placed on the star points, starting with the upper right and lower left corners.
+func HandicapPoints(width, height, stones int) ([]Point, error) {
+	if err := checkSize(width, height); err != nil {
+		return nil, err
+	}
+	if max := MaxHandicap(width, height); stones < 2 || stones > max {
+		return nil, fmt.Errorf("a %dx%d board supports 2 to %d handicap stones, not %d", width, height, max, stones)
+	}
+	xs, cx := starLines(width)
+	ys, cy := starLines(height)
+	left, right := xs[0], xs[1]
+	top, bottom := ys[0], ys[1]
+	// The corners, in the traditional order: upper right, lower left, lower right and upper left
+	points := []Point{{right, top}, {left, bottom}, {right, bottom}, {left, top}}
+	if stones <= 4 {
+		return points[:stones], nil
+	}
+	center := Point{cx, cy}
+	sides := []Point{{left, cy}, {right, cy}, {cx, top}, {cx, bottom}}
+	switch stones {
+	case 5:
+		points = append(points, center)
+	case 6:
+		points = append(points, sides[:2]...)
+	case 7:
+		points = append(points, sides[:2]...)
+		points = append(points, center)
+	case 8:
+		points = append(points, sides...)
+	case 9:
+		points = append(points, sides...)
+		points = append(points, center)
+	}
+	return points, nil
+}
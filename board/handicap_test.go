@@ -0,0 +1,38 @@
+package board
+
+import "testing"
+
+func TestHandicapPoints(t *testing.T) {
+	points, err := HandicapPoints(19, 19, 2)
+	if err != nil {
+		t.Fatalf("Failed to place handicap stones: %v", err)
+	}
+	if Vertex(points[0], 19) != "Q16" || Vertex(points[1], 19) != "D4" {
+		t.Errorf("Expected Q16 and D4, got %v", points)
+	}
+	for stones := 2; stones <= 9; stones++ {
+		points, err := HandicapPoints(19, 19, stones)
+		if err != nil {
+			t.Fatalf("Failed to place %d handicap stones: %v", stones, err)
+		}
+		if len(points) != stones {
+			t.Errorf("Expected %d points, got %d", stones, len(points))
+		}
+		seen := make(map[Point]bool)
+		for _, p := range points {
+			if seen[p] {
+				t.Errorf("Duplicate handicap point %v for %d stones", p, stones)
+			}
+			seen[p] = true
+		}
+	}
+	if points, _ := HandicapPoints(9, 9, 5); Vertex(points[4], 9) != "E5" {
+		t.Errorf("Expected the fifth stone on a 9x9 board at E5, got %v", points)
+	}
+	if _, err := HandicapPoints(10, 10, 5); err == nil {
+		t.Errorf("Expected an error for 5 stones on an even board")
+	}
+	if _, err := HandicapPoints(5, 5, 2); err == nil {
+		t.Errorf("Expected an error for handicap on a 5x5 board")
+	}
+}
@@ -0,0 +1,105 @@
+package board
+
+import "fmt"
+
+// Symmetry is one of the 8 ways of rotating and reflecting a board. Bit 0 flips the columns,
+// bit 1 flips the rows, and bit 2 transposes the board first, which is only possible on square boards.
+type Symmetry uint8
+
+// Identity is the symmetry that leaves the board as it is
+const Identity Symmetry = 0
+
+const (
+	flipX     Symmetry = 1
+	flipY     Symmetry = 2
+	transpose Symmetry = 4
+)
+
+// Symmetries returns the symmetries of a board of the given size, which are all 8 for square boards,
+// and the 4 that do not transpose the board for rectangular ones. Identity is the first.
+func Symmetries(width, height int) []Symmetry {
+	if width != height {
+		return []Symmetry{0, 1, 2, 3}
+	}
+	return []Symmetry{0, 1, 2, 3, 4, 5, 6, 7}
+}
+
+// Transposes checks if the symmetry swaps the rows and the columns
+func (s Symmetry) Transposes() bool {
+	return s&transpose != 0
+}
+
+// Apply returns where the point ends up on a board of the given size. Passing stays passing.
+func (s Symmetry) Apply(p Point, width, height int) Point {
+	if p.IsPass() {
+		return p
+	}
+	x, y := p.X, p.Y
+	if s&transpose != 0 {
+		x, y = y, x
+	}
+	if s&flipX != 0 {
+		x = width - 1 - x
+	}
+	if s&flipY != 0 {
+		y = height - 1 - y
+	}
+	return Point{X: x, Y: y}
+}
+
+// Inverse returns the symmetry that undoes this one
+func (s Symmetry) Inverse() Symmetry {
+	if s&transpose == 0 {
+		return s
+	}
+	// Flipping and then transposing is the same as transposing and then flipping the other axis
+	return transpose | (s&flipX)<<1 | (s&flipY)>>1
+}
+
+// String returns the symmetry as a number from 0 to 7
+func (s Symmetry) String() string {
+	return fmt.Sprintf("symmetry %d", uint8(s))
+}
+
+// Transform returns a new board with the stones, the player to move and the ko point moved by the symmetry.
+// The history is not kept, so the new board only knows about the current position for superko.
+func (b *Board) Transform(s Symmetry) (*Board, error) {
+	if s > 7 {
+		return nil, fmt.Errorf("invalid %v", s)
+	}
+	if s.Transposes() && b.width != b.height {
+		return nil, fmt.Errorf("can not transpose a %dx%d board", b.width, b.height)
+	}
+	t, err := New(b.width, b.height)
+	if err != nil {
+		return nil, err
+	}
+	t.KoRule, t.Suicide = b.KoRule, b.Suicide
+	t.forget()
+	for i, c := range b.grid {
+		if c != Empty {
+			t.set(s.Apply(Point{X: i % b.width, Y: i / b.width}, b.width, b.height), c)
+		}
+	}
+	t.toPlay = b.toPlay
+	t.ko = s.Apply(b.ko, b.width, b.height)
+	t.captures = b.captures
+	t.remember()
+	return t, nil
+}
+
+// SwapColors returns a new board where black and white have changed places, including the player to move
+// and the captures. The history is not kept.
+func (b *Board) SwapColors() *Board {
+	t, _ := New(b.width, b.height)
+	t.KoRule, t.Suicide = b.KoRule, b.Suicide
+	t.forget()
+	for i, c := range b.grid {
+		t.grid[i] = c.Opponent()
+	}
+	t.toPlay = b.toPlay.Opponent()
+	t.ko = b.ko
+	t.captures[Black], t.captures[White] = b.captures[White], b.captures[Black]
+	t.remember()
+	return t
+}
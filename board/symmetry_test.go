@@ -0,0 +1,49 @@
+package board
+
+import "testing"
+
+func TestSymmetryInverse(t *testing.T) {
+	p := Point{X: 1, Y: 4}
+	for _, s := range Symmetries(9, 9) {
+		q := s.Apply(p, 9, 9)
+		if back := s.Inverse().Apply(q, 9, 9); back != p {
+			t.Errorf("Expected the inverse of %v to give back %v, got %v", s, p, back)
+		}
+	}
+	if len(Symmetries(9, 13)) != 4 {
+		t.Errorf("Expected 4 symmetries for a 9x13 board, got %d", len(Symmetries(9, 13)))
+	}
+	if got := Symmetry(4).Apply(Point{X: 2, Y: 0}, 9, 9); got != (Point{X: 0, Y: 2}) {
+		t.Errorf("Expected the transpose of (2, 0) to be (0, 2), got %v", got)
+	}
+	if !Symmetry(5).Apply(Pass, 9, 9).IsPass() {
+		t.Error("Expected passing to stay passing")
+	}
+}
+
+func TestTransform(t *testing.T) {
+	a, err := FromPairs(9, 9, nil, [][2]string{{"B", "C3"}, {"W", "E5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The mirror image of the same opening
+	b, err := FromPairs(9, 9, nil, [][2]string{{"B", "G3"}, {"W", "E5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirrored, err := a.Transform(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mirrored.Hash() != b.Hash() || mirrored.ToPlay() != Black {
+		t.Errorf("Expected the mirrored board to be the same as the other opening")
+	}
+	rect, _ := New(9, 13)
+	if _, err := rect.Transform(4); err == nil {
+		t.Error("Expected an error when transposing a rectangular board")
+	}
+	swapped := a.SwapColors()
+	if swapped.At(Point{X: 2, Y: 6}) != White || swapped.At(Point{X: 4, Y: 4}) != Black || swapped.ToPlay() != White {
+		t.Errorf("Expected the colors to be swapped:\n%s", swapped)
+	}
+}
@@ -0,0 +1,65 @@
+package board
+
+import "fmt"
+
+// Hash is a Zobrist hash of a position, which is the same for all move orders that lead to the position
+type Hash uint64
+
+// String returns the hash as 16 hexadecimal digits
+func (h Hash) String() string {
+	return fmt.Sprintf("%016x", uint64(h))
+}
+
+// zobrist holds a random number for each color on each point of the largest board, followed by
+// the numbers for white to play, for each possible ko point and for each board width and height
+var zobrist = newZobristTable()
+
+const (
+	zobristPoints  = MaxSize * MaxSize
+	zobristWhite   = 2 * zobristPoints
+	zobristKo      = zobristWhite + 1
+	zobristWidth   = zobristKo + zobristPoints
+	zobristHeight  = zobristWidth + MaxSize + 1
+	zobristEntries = zobristHeight + MaxSize + 1
+)
+
+// newZobristTable fills the table with splitmix64, using a fixed seed so that hashes are stable between runs
+func newZobristTable() []uint64 {
+	table := make([]uint64, zobristEntries)
+	state := uint64(0x9e3779b97f4a7c15)
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}
+
+// StonesHash returns the Zobrist hash of the stones on the board and the board size
+func (b *Board) StonesHash() Hash {
+	h := zobrist[zobristWidth+b.width] ^ zobrist[zobristHeight+b.height]
+	for i, c := range b.grid {
+		if c == Empty {
+			continue
+		}
+		x, y := i%b.width, i/b.width
+		h ^= zobrist[int(c-1)*zobristPoints+y*MaxSize+x]
+	}
+	return Hash(h)
+}
+
+// Hash returns the Zobrist hash of the position, which covers the stones, the board size,
+// the player to move and the point that can not be played because of a simple ko.
+// Transpositions have the same hash, while the earlier positions that matter for superko are not included.
+func (b *Board) Hash() Hash {
+	h := b.StonesHash()
+	if b.toPlay == White {
+		h ^= Hash(zobrist[zobristWhite])
+	}
+	if !b.ko.IsPass() {
+		h ^= Hash(zobrist[zobristKo+b.ko.Y*MaxSize+b.ko.X])
+	}
+	return h
+}
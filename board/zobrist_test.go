@@ -0,0 +1,43 @@
+package board
+
+import "testing"
+
+func TestHashTransposition(t *testing.T) {
+	a, err := FromPairs(9, 9, nil, [][2]string{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := FromPairs(9, 9, nil, [][2]string{{"B", "G7"}, {"W", "C3"}, {"B", "E5"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() != b.Hash() {
+		t.Errorf("Expected transpositions to have the same hash, got %s and %s", a.Hash(), b.Hash())
+	}
+	c, err := FromPairs(9, 9, nil, [][2]string{{"B", "E5"}, {"W", "C3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() == c.Hash() {
+		t.Errorf("Expected different positions to have different hashes")
+	}
+	before := c.Hash()
+	c.Play(Black, Point{X: 6, Y: 2})
+	c.Undo()
+	if c.Hash() != before {
+		t.Errorf("Expected the hash to be restored by Undo, got %s instead of %s", c.Hash(), before)
+	}
+}
+
+func TestHashPlayerAndSize(t *testing.T) {
+	b, _ := New(9, 9)
+	empty := b.Hash()
+	b.SetToPlay(White)
+	if b.Hash() == empty || b.StonesHash() != empty {
+		t.Errorf("Expected only the player to move to change the hash")
+	}
+	other, _ := New(9, 13)
+	if other.StonesHash() == b.StonesHash() {
+		t.Errorf("Expected boards of different sizes to have different hashes")
+	}
+}
@@ -0,0 +1,185 @@
+// Package book builds and queries opening books, where each position of the opening is stored
+// together with KataGo's evaluation of it and the recommended continuations
+package book
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xyproto/katago"
+)
+
+// ErrNotFound is returned when a position is not in the book
+var ErrNotFound = errors.New("position not found in the opening book")
+
+// Continuation is a move that can be played from a position in the book
+type Continuation struct {
+	Move      string  `json:"move"`
+	Visits    int     `json:"visits"`
+	Winrate   float64 `json:"winrate"`
+	ScoreLead float64 `json:"scoreLead"`
+	Prior     float64 `json:"prior"`
+	// Played is how many of the added games continued with this move
+	Played int `json:"played,omitempty"`
+}
+
+// Entry is a position in the book. The winrates and score leads are from the point of view of the player to move.
+type Entry struct {
+	// Moves is the first move sequence that reached the position
+	Moves         []katago.Move  `json:"moves"`
+	ToPlay        string         `json:"toPlay"`
+	Visits        int            `json:"visits"`
+	Winrate       float64        `json:"winrate"`
+	ScoreLead     float64        `json:"scoreLead"`
+	Continuations []Continuation `json:"continuations"`
+	// Games is how many of the added games reached the position
+	Games int `json:"games,omitempty"`
+}
+
+// Best returns the continuation that KataGo recommends, which is the one with the most visits
+func (e *Entry) Best() (Continuation, bool) {
+	if e == nil || len(e.Continuations) == 0 {
+		return Continuation{}, false
+	}
+	best := e.Continuations[0]
+	for _, c := range e.Continuations[1:] {
+		if c.Visits > best.Visits {
+			best = c
+		}
+	}
+	return best, true
+}
+
+// continuation returns the continuation with the given move, or nil
+func (e *Entry) continuation(move string) *Continuation {
+	for i := range e.Continuations {
+		if e.Continuations[i].Move == move {
+			return &e.Continuations[i]
+		}
+	}
+	return nil
+}
+
+// Popular returns the continuations that were played in the added games, the most played first
+func (e *Entry) Popular() []Continuation {
+	var popular []Continuation
+	for _, c := range e.Continuations {
+		if c.Played > 0 {
+			popular = append(popular, c)
+		}
+	}
+	sort.SliceStable(popular, func(i, j int) bool {
+		return popular[i].Played > popular[j].Played
+	})
+	return popular
+}
+
+// Book is an opening book for one board size, ruleset and komi.
+// Positions are identified by the stones on the board and the player to move, so transpositions share an entry.
+type Book struct {
+	BoardXSize int          `json:"boardXSize"`
+	BoardYSize int          `json:"boardYSize"`
+	Rules      katago.Rules `json:"rules"`
+	Komi       float64      `json:"komi"`
+	// Normalized books store each position in one orientation, so that the games of a fuseki database
+	// that were played in other corners share their entries. Query turns the entries back.
+	Normalized bool              `json:"normalized,omitempty"`
+	Entries    map[string]*Entry `json:"entries"`
+}
+
+// New creates an empty opening book
+func New(width, height int, rules katago.Rules, komi float64) *Book {
+	return &Book{
+		BoardXSize: width,
+		BoardYSize: height,
+		Rules:      rules,
+		Komi:       komi,
+		Entries:    make(map[string]*Entry),
+	}
+}
+
+// Position returns the position after the given moves, with the board size, rules and komi of the book
+func (b *Book) Position(moves []katago.Move) katago.Position {
+	return katago.Position{
+		Moves:      moves,
+		Rules:      b.Rules,
+		Komi:       b.Komi,
+		BoardXSize: b.BoardXSize,
+		BoardYSize: b.BoardYSize,
+	}
+}
+
+// key returns the key of the position after the given moves
+func (b *Book) key(moves []katago.Move) (string, error) {
+	_, _, key, err := b.canonical(moves)
+	return key, err
+}
+
+// Lookup returns the entry for the position after the given moves, or ErrNotFound.
+// In a Normalized book, the moves of the entry may be in another orientation, see Query.
+func (b *Book) Lookup(moves []katago.Move) (*Entry, error) {
+	entry, _, err := b.find(moves)
+	return entry, err
+}
+
+// Len returns the number of positions in the book
+func (b *Book) Len() int {
+	return len(b.Entries)
+}
+
+// Save writes the book as JSON
+func (b *Book) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(b); err != nil {
+		return fmt.Errorf("failed to write the opening book: %v", err)
+	}
+	return nil
+}
+
+// SaveFile writes the book to the given file, with SaveCompact if the name ends with .gz, or else as JSON
+func (b *Book) SaveFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	save := b.Save
+	if strings.HasSuffix(filename, ".gz") {
+		save = b.SaveCompact
+	}
+	if err := save(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Load reads a book that was written by Save
+func Load(r io.Reader) (*Book, error) {
+	var b Book
+	if err := json.NewDecoder(r).Decode(&b); err != nil {
+		return nil, fmt.Errorf("failed to read the opening book: %v", err)
+	}
+	if b.Entries == nil {
+		b.Entries = make(map[string]*Entry)
+	}
+	return &b, nil
+}
+
+// LoadFile reads a book that was written by SaveFile
+func LoadFile(filename string) (*Book, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.HasSuffix(filename, ".gz") {
+		return LoadCompact(f)
+	}
+	return Load(f)
+}
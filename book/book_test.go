@@ -0,0 +1,54 @@
+package book
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestLookupTransposition(t *testing.T) {
+	b := New(9, 9, katago.Chinese, 7)
+	entry := &Entry{Moves: []katago.Move{{Color: "B", Vertex: "E5"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "G7"}}, ToPlay: "W", Continuations: []Continuation{
+		{Move: "C7", Visits: 30, Played: 1},
+		{Move: "G3", Visits: 60},
+	}}
+	key, err := b.key(entry.Moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b.Entries[key] = entry
+	found, err := b.Lookup([]katago.Move{{Color: "B", Vertex: "G7"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "E5"}})
+	if err != nil {
+		t.Fatalf("Expected the transposed position to be found, got %v", err)
+	}
+	if best, ok := found.Best(); !ok || best.Move != "G3" {
+		t.Errorf("Expected G3 to be the best continuation, got %v", best)
+	}
+	if popular := found.Popular(); len(popular) != 1 || popular[0].Move != "C7" {
+		t.Errorf("Expected C7 to be the only played continuation, got %v", popular)
+	}
+	if _, err := b.Lookup([]katago.Move{{Color: "B", Vertex: "E5"}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	b := New(9, 9, katago.Japanese, 6.5)
+	b.Entries["key"] = &Entry{ToPlay: "B", Visits: 100, Continuations: []Continuation{{Move: "E5", Visits: 100}}}
+	var buf bytes.Buffer
+	if err := b.Save(&buf); err != nil {
+		t.Fatalf("Failed to save the book: %v", err)
+	}
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatalf("Failed to load the book: %v", err)
+	}
+	if loaded.Rules != katago.Japanese || loaded.Komi != 6.5 || loaded.Len() != 1 {
+		t.Errorf("Expected the loaded book to match, got %+v", loaded)
+	}
+	if entry := loaded.Entries["key"]; entry == nil || entry.Continuations[0].Move != "E5" {
+		t.Errorf("Expected the entry to be loaded, got %v", entry)
+	}
+}
@@ -0,0 +1,162 @@
+package book
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago"
+)
+
+// Builder adds positions to an opening book by analyzing them with KataGo
+type Builder struct {
+	KataGo *katago.KataGo
+	Book   *Book
+	// Visits is the number of visits used for analyzing each position
+	Visits int
+	// MaxDepth is the number of moves from each game that are added to the book
+	MaxDepth int
+	// Branching is how many of the best continuations are followed by Expand
+	Branching int
+	nextID    int
+}
+
+// NewBuilder creates a builder that adds positions to the given book, with 1000 visits per position,
+// the first 20 moves of each game and the 3 best continuations of each position
+func NewBuilder(k *katago.KataGo, b *Book) *Builder {
+	return &Builder{KataGo: k, Book: b, Visits: 1000, MaxDepth: 20, Branching: 3}
+}
+
+// analyze adds entries for the positions after the given move sequences, unless they are already in the book.
+// All the positions are sent to KataGo at once, so that they can be analyzed in parallel.
+func (bd *Builder) analyze(sequences [][]katago.Move) error {
+	var (
+		requests []katago.AnalysisRequest
+		keys     []string
+		pending  = make(map[string]bool)
+	)
+	for _, moves := range sequences {
+		moves, _, key, err := bd.Book.canonical(moves)
+		if err != nil {
+			return err
+		}
+		if _, ok := bd.Book.Entries[key]; ok || pending[key] {
+			continue
+		}
+		bd.nextID++
+		request := bd.Book.Position(moves).Request(fmt.Sprintf("book-%d", bd.nextID))
+		request.MaxVisits = bd.Visits
+		requests = append(requests, request)
+		keys = append(keys, key)
+		pending[key] = true
+	}
+	if len(requests) == 0 {
+		return nil
+	}
+	responses, err := bd.KataGo.Analyze(requests)
+	if err != nil {
+		return fmt.Errorf("failed to analyze the opening: %w", err)
+	}
+	for i, response := range responses {
+		position := bd.Book.Position(requests[i].Moves)
+		bd.Book.Entries[keys[i]] = newEntry(requests[i].Moves, position.ToPlay(), response)
+	}
+	return nil
+}
+
+// newEntry creates a book entry from the analysis of a position
+func newEntry(moves []katago.Move, toPlay string, response katago.AnalysisResponse) *Entry {
+	entry := &Entry{
+		Moves:     append([]katago.Move{}, moves...),
+		ToPlay:    toPlay,
+		Visits:    response.RootInfo.Visits,
+		Winrate:   response.RootInfo.Winrate,
+		ScoreLead: response.RootInfo.ScoreLead,
+	}
+	if response.RootInfo.CurrentPlayer != "" {
+		entry.ToPlay = response.RootInfo.CurrentPlayer
+	}
+	for _, info := range response.MoveInfos {
+		entry.Continuations = append(entry.Continuations, Continuation{
+			Move:      info.Move,
+			Visits:    info.Visits,
+			Winrate:   info.Winrate,
+			ScoreLead: info.ScoreLead,
+			Prior:     info.Prior,
+		})
+	}
+	return entry
+}
+
+// AddGame analyzes the first MaxDepth moves of a game and adds the positions to the book,
+// while counting how often each position and continuation occurs
+func (bd *Builder) AddGame(moves []katago.Move) error {
+	depth := min(len(moves), bd.MaxDepth)
+	sequences := make([][]katago.Move, depth+1)
+	for i := range sequences {
+		sequences[i] = moves[:i]
+	}
+	if err := bd.analyze(sequences); err != nil {
+		return err
+	}
+	for i, prefix := range sequences {
+		entry, symmetries, err := bd.Book.find(prefix)
+		if err != nil {
+			return err
+		}
+		entry.Games++
+		if i == len(moves) {
+			continue
+		}
+		c, err := bd.Book.played(entry, string(moves[i].Vertex), symmetries)
+		if err != nil {
+			return err
+		}
+		c.Played++
+	}
+	return nil
+}
+
+// AddGames adds several games to the book
+func (bd *Builder) AddGames(games [][]katago.Move) error {
+	for i, moves := range games {
+		if err := bd.AddGame(moves); err != nil {
+			return fmt.Errorf("game %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Expand builds the book from the empty board by following the Branching best continuations of each position,
+// down to the given depth. The number of positions grows quickly, as Branching to the power of depth.
+func (bd *Builder) Expand(depth int) error {
+	frontier := [][]katago.Move{{}}
+	for d := 0; ; d++ {
+		if err := bd.analyze(frontier); err != nil {
+			return err
+		}
+		if d == depth {
+			return nil
+		}
+		var next [][]katago.Move
+		for _, moves := range frontier {
+			entry, err := bd.Book.Lookup(moves)
+			if err != nil {
+				return err
+			}
+			followed := 0
+			for _, c := range entry.Continuations {
+				if followed == bd.Branching {
+					break
+				}
+				if c.Visits == 0 || strings.EqualFold(c.Move, "pass") {
+					continue
+				}
+				// The continuations are in the orientation of the moves of the entry
+				child := append(append([]katago.Move{}, entry.Moves...), katago.Move{Color: katago.Color(entry.ToPlay), Vertex: katago.Vertex(c.Move)})
+				next = append(next, child)
+				followed++
+			}
+		}
+		frontier = next
+	}
+}
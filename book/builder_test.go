@@ -0,0 +1,95 @@
+package book
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func initKataGo(t *testing.T) *katago.KataGo {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return k
+}
+
+func TestBuilderAddGames(t *testing.T) {
+	b := New(9, 9, katago.Chinese, 7)
+	bd := NewBuilder(initKataGo(t), b)
+	bd.Visits = 50
+	bd.MaxDepth = 2
+	games := [][]katago.Move{
+		{{Color: "B", Vertex: "E5"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "G7"}},
+		{{Color: "B", Vertex: "E5"}, {Color: "W", Vertex: "G3"}},
+	}
+	if err := bd.AddGames(games); err != nil {
+		t.Fatalf("Failed to add games: %v", err)
+	}
+	// The empty board, E5, E5 C3 and E5 G3
+	if b.Len() != 4 {
+		t.Errorf("Expected 4 positions, got %d", b.Len())
+	}
+	root, err := b.Lookup(nil)
+	if err != nil {
+		t.Fatalf("Expected the empty board to be in the book: %v", err)
+	}
+	if root.Games != 2 || root.Visits == 0 || root.ToPlay != "B" {
+		t.Errorf("Expected an analyzed root reached by 2 games, got %+v", root)
+	}
+	if popular := root.Popular(); len(popular) != 1 || popular[0].Move != "E5" || popular[0].Played != 2 {
+		t.Errorf("Expected E5 to be played twice, got %v", popular)
+	}
+	filename := filepath.Join(t.TempDir(), "book.json")
+	if err := b.SaveFile(filename); err != nil {
+		t.Fatalf("Failed to save the book: %v", err)
+	}
+	loaded, err := LoadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to load the book: %v", err)
+	}
+	if loaded.Len() != b.Len() {
+		t.Errorf("Expected %d positions, got %d", b.Len(), loaded.Len())
+	}
+}
+
+func TestBuilderExpand(t *testing.T) {
+	b := New(9, 9, katago.Chinese, 7)
+	bd := NewBuilder(initKataGo(t), b)
+	bd.Visits = 20
+	bd.Branching = 2
+	if err := bd.Expand(2); err != nil {
+		t.Fatalf("Failed to expand the book: %v", err)
+	}
+	// At most 1 + 2 + 4 positions, fewer if there are transpositions
+	if b.Len() < 5 || b.Len() > 7 {
+		t.Errorf("Expected between 5 and 7 positions, got %d", b.Len())
+	}
+	root, err := b.Lookup(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, ok := root.Best()
+	if !ok {
+		t.Fatal("Expected a recommended move on the empty board")
+	}
+	if _, err := b.Lookup([]katago.Move{{Color: katago.Black, Vertex: katago.Vertex(best.Move)}}); err != nil {
+		t.Errorf("Expected the best continuation %s to be in the book: %v", best.Move, err)
+	}
+}
+
+func TestBuilderError(t *testing.T) {
+	bd := NewBuilder(initKataGo(t), New(9, 9, katago.Chinese, 7.25))
+	bd.Visits = 10
+	if err := bd.AddGames([][]katago.Move{{{Color: "B", Vertex: "E5"}}}); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
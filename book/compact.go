@@ -0,0 +1,66 @@
+package book
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/xyproto/katago"
+)
+
+// compactBook is the format of SaveCompact, where the entries are a list without their keys
+type compactBook struct {
+	BoardXSize int          `json:"boardXSize"`
+	BoardYSize int          `json:"boardYSize"`
+	Rules      katago.Rules `json:"rules"`
+	Komi       float64      `json:"komi"`
+	Normalized bool         `json:"normalized,omitempty"`
+	Entries    []*Entry     `json:"entries"`
+}
+
+// SaveCompact writes the book as gzipped JSON without the keys of the positions, which LoadCompact finds
+// again from the moves of the entries. This is many times smaller than Save, for large fuseki databases.
+func (b *Book) SaveCompact(w io.Writer) error {
+	keys := make([]string, 0, len(b.Entries))
+	for key := range b.Entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	c := compactBook{BoardXSize: b.BoardXSize, BoardYSize: b.BoardYSize, Rules: b.Rules, Komi: b.Komi, Normalized: b.Normalized}
+	for _, key := range keys {
+		c.Entries = append(c.Entries, b.Entries[key])
+	}
+	zw := gzip.NewWriter(w)
+	if err := json.NewEncoder(zw).Encode(c); err != nil {
+		return fmt.Errorf("failed to write the opening book: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to write the opening book: %v", err)
+	}
+	return nil
+}
+
+// LoadCompact reads a book that was written by SaveCompact
+func LoadCompact(r io.Reader) (*Book, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the opening book: %v", err)
+	}
+	defer zr.Close()
+	var c compactBook
+	if err := json.NewDecoder(zr).Decode(&c); err != nil {
+		return nil, fmt.Errorf("failed to read the opening book: %v", err)
+	}
+	b := New(c.BoardXSize, c.BoardYSize, c.Rules, c.Komi)
+	b.Normalized = c.Normalized
+	for i, entry := range c.Entries {
+		key, err := b.key(entry.Moves)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the opening book: entry %d: %v", i+1, err)
+		}
+		b.Entries[key] = entry
+	}
+	return b, nil
+}
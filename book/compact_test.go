@@ -0,0 +1,52 @@
+package book
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestSaveLoadCompact(t *testing.T) {
+	b := New(9, 9, katago.Japanese, 6.5)
+	b.Normalized = true
+	for _, moves := range [][]katago.Move{nil, {{Color: "B", Vertex: "G7"}}, {{Color: "B", Vertex: "G7"}, {Color: "W", Vertex: "C3"}}} {
+		moves, _, key, err := b.canonical(moves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b.Entries[key] = &Entry{Moves: moves, Visits: 100, Continuations: []Continuation{{Move: "E5", Visits: 100, Played: 2}}}
+	}
+	var compact, indented bytes.Buffer
+	if err := b.SaveCompact(&compact); err != nil {
+		t.Fatalf("Failed to save the book: %v", err)
+	}
+	if err := b.Save(&indented); err != nil {
+		t.Fatal(err)
+	}
+	if compact.Len() >= indented.Len() {
+		t.Errorf("Expected the compact book to be smaller than %d bytes, got %d", indented.Len(), compact.Len())
+	}
+	filename := filepath.Join(t.TempDir(), "fuseki.json.gz")
+	if err := b.SaveFile(filename); err != nil {
+		t.Fatalf("Failed to save the book: %v", err)
+	}
+	loaded, err := LoadFile(filename)
+	if err != nil {
+		t.Fatalf("Failed to load the book: %v", err)
+	}
+	if loaded.Rules != katago.Japanese || loaded.Komi != 6.5 || !loaded.Normalized || loaded.Len() != 3 {
+		t.Errorf("Expected the loaded book to match, got %+v", loaded)
+	}
+	entry, err := loaded.Query([]katago.Move{{Color: "B", Vertex: "C3"}, {Color: "W", Vertex: "G7"}})
+	if err != nil {
+		t.Fatalf("Expected the position to be found in any orientation: %v", err)
+	}
+	if entry.Visits != 100 || entry.Continuations[0].Played != 2 {
+		t.Errorf("Expected the entry to be loaded, got %+v", entry)
+	}
+	if _, err := LoadCompact(&indented); err == nil {
+		t.Error("Expected an error for a book that is not compact")
+	}
+}
@@ -0,0 +1,126 @@
+package book
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// find returns the entry for the position after the given moves, with the symmetries that turn the moves
+// into the orientation of the entry, or ErrNotFound
+func (b *Book) find(moves []katago.Move) (*Entry, []board.Symmetry, error) {
+	_, symmetries, key, err := b.canonical(moves)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, ok := b.Entries[key]
+	if !ok {
+		return nil, symmetries, ErrNotFound
+	}
+	return entry, symmetries, nil
+}
+
+// canonical returns the moves and the key of the position after them. In a Normalized book, the moves are
+// turned by the rotation or reflection that gives the position the smallest key, so that the same position in
+// any orientation, and reached in any order, has the same entry. All the symmetries that give the smallest key
+// are returned, which is more than one for symmetric positions, and the first one is used for the moves.
+func (b *Book) canonical(moves []katago.Move) ([]katago.Move, []board.Symmetry, string, error) {
+	bd, err := b.Position(moves).Board()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	symmetries, key := []board.Symmetry{board.Identity}, bd.Key()
+	if !b.Normalized {
+		return moves, symmetries, key, nil
+	}
+	for _, s := range board.Symmetries(b.BoardXSize, b.BoardYSize)[1:] {
+		t, err := bd.Transform(s)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		switch k := t.Key(); {
+		case k < key:
+			symmetries, key = []board.Symmetry{s}, k
+		case k == key:
+			symmetries = append(symmetries, s)
+		}
+	}
+	turned := make([]katago.Move, len(moves))
+	for i, move := range moves {
+		vertex, err := b.turn(string(move.Vertex), symmetries[0])
+		if err != nil {
+			return nil, nil, "", err
+		}
+		turned[i] = katago.Move{Color: move.Color, Vertex: katago.Vertex(vertex)}
+	}
+	return turned, symmetries, key, nil
+}
+
+// played returns the continuation of the entry for a move that was played in a game, where the symmetries turn
+// the move to the orientation of the entry. In symmetric positions, like the empty board, the move is the same
+// as more than one continuation, and the one with the most visits is used, so that the games are not split up.
+// If the move is not one of KataGo's candidates, it is added without an evaluation.
+func (b *Book) played(entry *Entry, vertex string, symmetries []board.Symmetry) (*Continuation, error) {
+	var (
+		found *Continuation
+		first string
+	)
+	for _, s := range symmetries {
+		move, err := b.turn(vertex, s)
+		if err != nil {
+			return nil, err
+		}
+		if first == "" || move < first {
+			first = move
+		}
+		if c := entry.continuation(move); c != nil && (found == nil || c.Visits > found.Visits) {
+			found = c
+		}
+	}
+	if found != nil {
+		return found, nil
+	}
+	entry.Continuations = append(entry.Continuations, Continuation{Move: first})
+	return &entry.Continuations[len(entry.Continuations)-1], nil
+}
+
+// turn returns the vertex moved by the symmetry
+func (b *Book) turn(vertex string, s board.Symmetry) (string, error) {
+	if s == board.Identity {
+		return vertex, nil
+	}
+	p, err := board.ParseVertex(vertex, b.BoardXSize, b.BoardYSize)
+	if err != nil {
+		return "", err
+	}
+	return board.Vertex(s.Apply(p, b.BoardXSize, b.BoardYSize), b.BoardYSize), nil
+}
+
+// Query returns the position after the given moves for browsing a fuseki database, as a copy of the entry
+// where the moves are the given ones, and the continuations are turned to the orientation of the moves and
+// ordered by how often they were played, and then by visits. It returns ErrNotFound if the position is not
+// in the book.
+func (b *Book) Query(moves []katago.Move) (*Entry, error) {
+	entry, symmetries, err := b.find(moves)
+	if err != nil {
+		return nil, err
+	}
+	result := *entry
+	result.Moves = slices.Clone(moves)
+	result.Continuations = make([]Continuation, len(entry.Continuations))
+	for i, c := range entry.Continuations {
+		if c.Move, err = b.turn(c.Move, symmetries[0].Inverse()); err != nil {
+			return nil, err
+		}
+		result.Continuations[i] = c
+	}
+	slices.SortStableFunc(result.Continuations, func(a, b Continuation) int {
+		if c := cmp.Compare(b.Played, a.Played); c != 0 {
+			return c
+		}
+		return cmp.Compare(b.Visits, a.Visits)
+	})
+	return &result, nil
+}
@@ -0,0 +1,81 @@
+package book
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestNormalizedAddGames(t *testing.T) {
+	b := New(9, 9, katago.Chinese, 7)
+	b.Normalized = true
+	bd := NewBuilder(initKataGo(t), b)
+	bd.Visits = 20
+	bd.MaxDepth = 2
+	// The same openings, played in other corners
+	games := [][]katago.Move{
+		{{Color: "B", Vertex: "C3"}, {Color: "W", Vertex: "E5"}},
+		{{Color: "B", Vertex: "G7"}, {Color: "W", Vertex: "E5"}},
+		{{Color: "B", Vertex: "C7"}, {Color: "W", Vertex: "G3"}},
+	}
+	if err := bd.AddGames(games); err != nil {
+		t.Fatalf("Failed to add games: %v", err)
+	}
+	// The empty board, a 3-3 point, tengen and the opposite 3-3 point
+	if b.Len() != 4 {
+		t.Errorf("Expected 4 positions, got %d", b.Len())
+	}
+	root, err := b.Query(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Games != 3 || len(root.Continuations) == 0 || root.Continuations[0].Played != 3 {
+		t.Errorf("Expected the 3-3 point to be played in all 3 games, got %+v", root)
+	}
+	entry, err := b.Query([]katago.Move{{Color: "B", Vertex: "G7"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.Games != 3 || len(entry.Moves) != 1 || entry.Moves[0].Vertex != "G7" {
+		t.Errorf("Expected the position after G7 from all 3 games, got %+v", entry)
+	}
+	played := entry.Popular()
+	if len(played) != 2 || played[0].Move != "E5" || played[0].Played != 2 || played[1].Move != "C3" || played[1].Played != 1 {
+		t.Errorf("Expected E5 twice and C3 once after G7, got %v", played)
+	}
+	if entry.Continuations[0].Move != "E5" {
+		t.Errorf("Expected the most played continuation first, got %v", entry.Continuations)
+	}
+	if _, err := b.Query([]katago.Move{{Color: "B", Vertex: "E5"}}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestNormalizedExpand(t *testing.T) {
+	b := New(9, 9, katago.Chinese, 7)
+	b.Normalized = true
+	bd := NewBuilder(initKataGo(t), b)
+	bd.Visits = 20
+	bd.Branching = 2
+	if err := bd.Expand(2); err != nil {
+		t.Fatalf("Failed to expand the book: %v", err)
+	}
+	root, err := b.Query(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range root.Continuations[:2] {
+		entry, err := b.Query([]katago.Move{{Color: katago.Black, Vertex: katago.Vertex(c.Move)}})
+		if err != nil {
+			t.Fatalf("Expected the continuation %s to be in the book: %v", c.Move, err)
+		}
+		best, ok := entry.Best()
+		if !ok {
+			t.Fatalf("Expected a recommended move after %s", c.Move)
+		}
+		if _, err := b.Query(append(entry.Moves, katago.Move{Color: katago.White, Vertex: katago.Vertex(best.Move)})); err != nil {
+			t.Errorf("Expected %s %s to be in the book: %v", c.Move, best.Move, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package katago
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// BudgetScoutVisits is how many visits AnalyzeGameWithin uses for the quick pass over the game that measures
+// how complex each position is
+var BudgetScoutVisits = 20
+
+// minComplexity is the weight that every turn gets in AnalyzeGameWithin, on top of its complexity, so that
+// the simple turns are also searched
+const minComplexity = 0.25
+
+// SetMaxTime limits the search time of the request with the maxTime setting, which KataGo applies to each
+// analyzed turn. MaxVisits still applies, and the search stops at whichever limit comes first.
+func (r *AnalysisRequest) SetMaxTime(d time.Duration) {
+	r.SetOverride("maxTime", d.Seconds())
+}
+
+// complexity returns how hard the position of a response is to read, from 0 to 1, as the share of the visits
+// that went to other moves than the most visited one. One obvious move gives 0.
+func complexity(response AnalysisResponse) float64 {
+	total, most := 0, 0
+	for _, info := range response.MoveInfos {
+		total += info.Visits
+		most = max(most, info.Visits)
+	}
+	if total == 0 {
+		return 0
+	}
+	return 1 - float64(most)/float64(total)
+}
+
+// AnalyzeGameWithin analyzes every turn of the moves in the request, like AnalyzeGame, within a wall clock
+// budget for the whole game, for "review this game in 3 minutes". A quick pass with BudgetScoutVisits visits
+// measures how complex each position is, and the rest of the budget is spread over the turns with the maxTime
+// setting, so that the positions where KataGo considers several moves get more time than the forced ones.
+// The turns are searched at the same time, and the budget counts the analysis threads set with
+// WithAnalysisThreads, or one thread if they are not set. MaxVisits still limits each turn. If the quick pass
+// uses up the budget, its responses are returned.
+func (k *KataGo) AnalyzeGameWithin(ctx context.Context, request AnalysisRequest, budget time.Duration) ([]AnalysisResponse, error) {
+	start := time.Now()
+	scout := request
+	scout.ID = request.ID + "-scout"
+	scout.MaxVisits = BudgetScoutVisits
+	scouted, err := k.AnalyzeGame(scout)
+	if err != nil {
+		return nil, err
+	}
+	for i := range scouted {
+		scouted[i].ID = request.ID
+	}
+	remaining := budget - time.Since(start)
+	if remaining <= 0 {
+		return scouted, nil
+	}
+
+	weights := make([]float64, len(scouted))
+	total := 0.0
+	for i, response := range scouted {
+		weights[i] = minComplexity + complexity(response)
+		total += weights[i]
+	}
+	threads := max(1, k.analysisThreads)
+	requests := make([]AnalysisRequest, len(scouted))
+	for turn := range scouted {
+		r := request
+		r.ID = fmt.Sprintf("%s-%d", request.ID, turn)
+		r.AnalyzeTurns = []int{turn}
+		r.OverrideSettings = make(map[string]any, len(request.OverrideSettings)+1)
+		for key, value := range request.OverrideSettings {
+			r.OverrideSettings[key] = value
+		}
+		r.SetMaxTime(time.Duration(float64(remaining) * float64(threads) * weights[turn] / total))
+		requests[turn] = r
+	}
+	responses, err := k.AnalyzeBatch(ctx, requests)
+	if err != nil {
+		return nil, err
+	}
+	for i := range responses {
+		responses[i].ID = request.ID
+	}
+	return responses, nil
+}
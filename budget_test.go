@@ -0,0 +1,66 @@
+package katago
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetMaxTime(t *testing.T) {
+	request := NewRequest9x9()
+	request.SetMaxTime(1500 * time.Millisecond)
+	if request.OverrideSettings["maxTime"] != 1.5 {
+		t.Errorf("Expected maxTime 1.5, got %v", request.OverrideSettings["maxTime"])
+	}
+}
+
+func TestComplexity(t *testing.T) {
+	forced := AnalysisResponse{MoveInfos: []MoveInfoExt{{Move: "D4", Visits: 100}}}
+	if c := complexity(forced); c != 0 {
+		t.Errorf("Expected no complexity for a single move, got %f", c)
+	}
+	contested := AnalysisResponse{MoveInfos: []MoveInfoExt{{Move: "D4", Visits: 30}, {Move: "C3", Visits: 30}, {Move: "E5", Visits: 40}}}
+	if c := complexity(contested); c < 0.59 || c > 0.61 {
+		t.Errorf("Expected a complexity of 0.6, got %f", c)
+	}
+	if c := complexity(AnalysisResponse{}); c != 0 {
+		t.Errorf("Expected no complexity without moves, got %f", c)
+	}
+}
+
+func TestAnalyzeGameWithin(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}, {"W", "C7"}}
+	// Without a budget, each turn would take several seconds
+	request.MaxVisits = 100000
+	start := time.Now()
+	responses, err := k.AnalyzeGameWithin(context.Background(), request, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the game to be analyzed within the budget, took %v", elapsed)
+	}
+	if len(responses) != len(request.Moves)+1 {
+		t.Fatalf("Expected %d responses, got %d", len(request.Moves)+1, len(responses))
+	}
+	for turn, response := range responses {
+		if response.TurnNumber != turn || response.ID != request.ID {
+			t.Errorf("Expected turn %d of %s, got turn %d of %s", turn, request.ID, response.TurnNumber, response.ID)
+		}
+		if response.RootInfo.Visits <= BudgetScoutVisits {
+			t.Errorf("Expected more visits than the quick pass for turn %d, got %d", turn, response.RootInfo.Visits)
+		}
+	}
+
+	// The quick pass is returned if it uses up the budget
+	responses, err = k.AnalyzeGameWithin(context.Background(), request, time.Nanosecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != len(request.Moves)+1 || responses[0].RootInfo.Visits > BudgetScoutVisits {
+		t.Errorf("Expected the responses of the quick pass, got %d responses with %d visits", len(responses), responses[0].RootInfo.Visits)
+	}
+}
@@ -0,0 +1,86 @@
+package katago
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Cache stores analysis responses by the hash of the request, as returned by AnalysisRequest.CanonicalHash.
+// The responses are stored for the canonical orientation of the position, and are turned back by Analyze.
+type Cache interface {
+	Get(key board.Hash) (AnalysisResponse, bool)
+	Put(key board.Hash, response AnalysisResponse)
+}
+
+// lruEntry is an element of the LRU list
+type lruEntry struct {
+	key      board.Hash
+	response AnalysisResponse
+}
+
+// LRUCache is an in-memory cache that holds a fixed number of responses, and evicts the least recently used one
+// when it is full. This makes it fast to go back and forth between positions in a review.
+type LRUCache struct {
+	mut      sync.Mutex
+	capacity int
+	order    *list.List
+	entries  map[board.Hash]*list.Element
+}
+
+// NewLRUCache creates a cache that holds up to capacity responses
+func NewLRUCache(capacity int) *LRUCache {
+	return &LRUCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[board.Hash]*list.Element),
+	}
+}
+
+// Get returns the cached response for the key, and marks it as recently used
+func (c *LRUCache) Get(key board.Hash) (AnalysisResponse, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return AnalysisResponse{}, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*lruEntry).response, true
+}
+
+// Put stores a response, evicting the least recently used one if the cache is full
+func (c *LRUCache) Put(key board.Hash, response AnalysisResponse) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	if c.capacity <= 0 {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*lruEntry).response = response
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&lruEntry{key, response})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*lruEntry).key)
+	}
+}
+
+// Len returns the number of cached responses
+func (c *LRUCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.order.Len()
+}
+
+// Clear removes all cached responses
+func (c *LRUCache) Clear() {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.order.Init()
+	c.entries = make(map[board.Hash]*list.Element)
+}
@@ -0,0 +1,111 @@
+package katago
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestLRUCacheEviction(t *testing.T) {
+	c := NewLRUCache(2)
+	c.Put(1, AnalysisResponse{ID: "one"})
+	c.Put(2, AnalysisResponse{ID: "two"})
+	if _, ok := c.Get(1); !ok {
+		t.Fatal("Expected 1 to be cached")
+	}
+	// 2 is now the least recently used
+	c.Put(3, AnalysisResponse{ID: "three"})
+	if _, ok := c.Get(2); ok {
+		t.Error("Expected 2 to be evicted")
+	}
+	if r, ok := c.Get(1); !ok || r.ID != "one" {
+		t.Errorf("Expected 1 to still be cached, got %v", r)
+	}
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 cached responses, got %d", c.Len())
+	}
+	c.Clear()
+	if c.Len() != 0 {
+		t.Errorf("Expected an empty cache, got %d", c.Len())
+	}
+}
+
+// countingCache counts the cache hits of an LRU cache
+type countingCache struct {
+	*LRUCache
+	hits int
+}
+
+func (c *countingCache) Get(key board.Hash) (AnalysisResponse, bool) {
+	response, ok := c.LRUCache.Get(key)
+	if ok {
+		c.hits++
+	}
+	return response, ok
+}
+
+func TestAnalyzeCache(t *testing.T) {
+	cache := &countingCache{LRUCache: NewLRUCache(10)}
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(cache))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{"B", "E5"}}
+	first := p.Request("first")
+	first.MaxVisits = 10
+	responses, err := katago.Analyze([]AnalysisRequest{first})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if cache.Len() != 1 || cache.hits != 0 {
+		t.Errorf("Expected one cached response and no hits, got %d and %d", cache.Len(), cache.hits)
+	}
+	second := first
+	second.ID = "second"
+	again, err := katago.Analyze([]AnalysisRequest{second})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if cache.hits != 1 || again[0].ID != "second" || again[0].RootInfo != responses[0].RootInfo {
+		t.Errorf("Expected the second response to come from the cache, got %v", again[0])
+	}
+}
+
+func TestAnalyzeCacheTerminated(t *testing.T) {
+	cache := NewLRUCache(10)
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(cache))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+
+	request := NewPosition(9, 9).Request("long")
+	request.MaxVisits = 100000
+	done := make(chan error, 1)
+	var responses []AnalysisResponse
+	go func() {
+		var err error
+		responses, err = katago.Analyze([]AnalysisRequest{request})
+		done <- err
+	}()
+	for !katago.isPending("long") {
+		time.Sleep(time.Millisecond)
+	}
+	if err := katago.Terminate("long"); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].RootInfo.Visits >= 100000 {
+		t.Fatalf("Expected the results of a stopped search, got %v", responses)
+	}
+	// The results are partial, so they are not cached under the key for the full search
+	if cache.Len() != 0 {
+		t.Errorf("Expected no cached responses, got %d", cache.Len())
+	}
+}
@@ -0,0 +1,339 @@
+// Package cluster spreads analysis across several hosts. Each host runs an agent, like cmd/katago-worker,
+// which serves its local engine with the server package, and a Coordinator sends the queries to the
+// workers that are up, so that idle machines on a network can share the work.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// DefaultHealthInterval is how often Watch checks the workers, if no interval is given
+const DefaultHealthInterval = 30 * time.Second
+
+// Coordinator sends analysis requests to remote workers. The requests of a call are shared between the
+// healthy workers, with each request going to the worker with the fewest requests in flight. If a worker
+// can not be reached, or its engine has stopped, it is marked as down, and its requests are sent to
+// another worker.
+type Coordinator struct {
+	// HTTPClient is used for all the calls to the workers
+	HTTPClient *http.Client
+	mut        sync.Mutex
+	workers    []*worker
+	// idPrefix is random, so that the IDs that are given by different coordinators do not collide on a worker
+	idPrefix string
+	nextID   atomic.Uint64
+}
+
+// worker is one remote host, and its fields are protected by the mut of the coordinator
+type worker struct {
+	url      string
+	healthy  bool
+	inFlight int
+	answered int
+	lastErr  error
+}
+
+// WorkerStatus is the state of one worker of a coordinator
+type WorkerStatus struct {
+	URL     string
+	Healthy bool
+	// InFlight is the number of requests that have been sent to the worker and that are not answered yet
+	InFlight int
+	// Answered is the number of requests that the worker has answered
+	Answered int
+	// LastError is why the worker was last marked as down, or nil
+	LastError error
+}
+
+// errWorkerDown is returned by a worker that could not answer, so that the requests are sent to another one
+var errWorkerDown = errors.New("worker is down")
+
+// NewCoordinator creates a coordinator for the workers at the given base URLs, like "http://10.0.0.5:8080".
+// The workers are assumed to be healthy until a call to them fails, or CheckHealth says otherwise.
+func NewCoordinator(urls ...string) *Coordinator {
+	c := &Coordinator{HTTPClient: http.DefaultClient, idPrefix: newIDPrefix()}
+	for _, url := range urls {
+		c.Add(url)
+	}
+	return c
+}
+
+// newIDPrefix returns a random prefix for the IDs that a coordinator gives to requests
+func newIDPrefix() string {
+	var b [6]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("cluster-%x", time.Now().UnixNano())
+	}
+	return "cluster-" + hex.EncodeToString(b[:])
+}
+
+// Add adds a worker, if it is not already added
+func (c *Coordinator) Add(url string) {
+	url = strings.TrimSuffix(url, "/")
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for _, w := range c.workers {
+		if w.url == url {
+			return
+		}
+	}
+	c.workers = append(c.workers, &worker{url: url, healthy: true})
+}
+
+// Remove removes a worker. Requests that are already sent to it are still answered.
+func (c *Coordinator) Remove(url string) {
+	url = strings.TrimSuffix(url, "/")
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	for i, w := range c.workers {
+		if w.url == url {
+			c.workers = append(c.workers[:i], c.workers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Workers returns the state of each worker, in the order they were added
+func (c *Coordinator) Workers() []WorkerStatus {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	statuses := make([]WorkerStatus, len(c.workers))
+	for i, w := range c.workers {
+		statuses[i] = WorkerStatus{
+			URL:       w.url,
+			Healthy:   w.healthy,
+			InFlight:  w.inFlight,
+			Answered:  w.answered,
+			LastError: w.lastErr,
+		}
+	}
+	return statuses
+}
+
+// CheckHealth calls GET /health on every worker, and marks each one as up or down
+func (c *Coordinator) CheckHealth(ctx context.Context) {
+	c.mut.Lock()
+	workers := append([]*worker(nil), c.workers...)
+	c.mut.Unlock()
+	var wg sync.WaitGroup
+	for _, w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := c.health(ctx, w)
+			c.mut.Lock()
+			w.healthy = err == nil
+			if err != nil {
+				w.lastErr = err
+			}
+			c.mut.Unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+// health checks one worker
+func (c *Coordinator) health(ctx context.Context, w *worker) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.url+"/health", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Watch checks the health of the workers at the given interval, or DefaultHealthInterval, until the
+// context is done, so that workers that come back online are used again
+func (c *Coordinator) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultHealthInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.CheckHealth(ctx)
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// pick returns the healthy worker with the fewest requests in flight, and counts n more requests for it
+func (c *Coordinator) pick(n int) (*worker, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	var best *worker
+	for _, w := range c.workers {
+		if w.healthy && (best == nil || w.inFlight < best.inFlight) {
+			best = w
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: no worker is available", katago.ErrEngineCrashed)
+	}
+	best.inFlight += n
+	return best, nil
+}
+
+// done counts n requests of the worker as finished, and marks the worker as down if it failed
+func (c *Coordinator) done(w *worker, n int, err error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	w.inFlight -= n
+	if err == nil {
+		w.answered += n
+	} else if errors.Is(err, errWorkerDown) {
+		w.healthy = false
+		w.lastErr = err
+	}
+}
+
+// Analyze shares the requests between the healthy workers, and returns the responses in the order of the
+// requests, as for KataGo.Analyze. Requests without an ID are given one. If the context has a deadline,
+// it is sent to the workers with the X-Timeout header, for workers that use a scheduler.
+func (c *Coordinator) Analyze(ctx context.Context, requests []katago.AnalysisRequest) ([]katago.AnalysisResponse, error) {
+	requests = append([]katago.AnalysisRequest(nil), requests...)
+	for i := range requests {
+		if requests[i].ID == "" {
+			requests[i].ID = fmt.Sprintf("%s-%d", c.idPrefix, c.nextID.Add(1))
+		}
+	}
+	groups := make(map[*worker][]katago.AnalysisRequest)
+	for _, request := range requests {
+		w, err := c.pick(1)
+		if err != nil {
+			c.mut.Lock()
+			for w, group := range groups {
+				w.inFlight -= len(group)
+			}
+			c.mut.Unlock()
+			return nil, err
+		}
+		groups[w] = append(groups[w], request)
+	}
+	var (
+		wg      sync.WaitGroup
+		mut     sync.Mutex
+		results = make(map[string][]katago.AnalysisResponse)
+		errs    []error
+	)
+	for w, group := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses, err := c.analyzeOn(ctx, w, group)
+			mut.Lock()
+			defer mut.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			for _, response := range responses {
+				results[response.ID] = append(results[response.ID], response)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	// A request for several turns has one response for each turn
+	var responses []katago.AnalysisResponse
+	for _, request := range requests {
+		responses = append(responses, results[request.ID]...)
+	}
+	return responses, nil
+}
+
+// analyzeOn sends a group of requests to a worker that has already counted them as in flight, and
+// sends them to another worker for as long as the worker that gets them is down
+func (c *Coordinator) analyzeOn(ctx context.Context, w *worker, group []katago.AnalysisRequest) ([]katago.AnalysisResponse, error) {
+	for {
+		responses, err := c.post(ctx, w, group)
+		c.done(w, len(group), err)
+		if !errors.Is(err, errWorkerDown) || ctx.Err() != nil {
+			if err != nil {
+				return nil, fmt.Errorf("worker %s: %w", w.url, err)
+			}
+			return responses, nil
+		}
+		if w, err = c.pick(len(group)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// errorResponse is the body of the error responses of the server package
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// post sends the requests to POST /analyze of a worker
+func (c *Coordinator) post(ctx context.Context, w *worker, group []katago.AnalysisRequest) ([]katago.AnalysisResponse, error) {
+	body, err := json.Marshal(group)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url+"/analyze", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Header.Set("X-Timeout", time.Until(deadline).String())
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errWorkerDown, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e errorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&e); err != nil || e.Error == "" {
+			e.Error = http.StatusText(resp.StatusCode)
+		}
+		switch resp.StatusCode {
+		case http.StatusBadRequest:
+			return nil, fmt.Errorf("%w: %s", katago.ErrBadRequest, e.Error)
+		case http.StatusConflict:
+			return nil, fmt.Errorf("%w: %s", katago.ErrDuplicateID, e.Error)
+		case http.StatusGatewayTimeout:
+			return nil, fmt.Errorf("%w: %s", katago.ErrQueryTimeout, e.Error)
+		case http.StatusServiceUnavailable, http.StatusBadGateway:
+			return nil, fmt.Errorf("%w: %s", errWorkerDown, e.Error)
+		}
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, e.Error)
+	}
+	var responses []katago.AnalysisResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		return nil, fmt.Errorf("%w: invalid response: %v", errWorkerDown, err)
+	}
+	return responses, nil
+}
@@ -0,0 +1,140 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/server"
+)
+
+func newTestWorker(t *testing.T) *httptest.Server {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	ts := httptest.NewServer(server.New(k))
+	t.Cleanup(func() {
+		ts.Close()
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return ts
+}
+
+func newTestRequests() []katago.AnalysisRequest {
+	var requests []katago.AnalysisRequest
+	for _, vertex := range []katago.Vertex{"C3", "E5", "D4", "C4"} {
+		request := katago.NewRequest9x9()
+		request.MaxVisits = 10
+		request.Moves = []katago.Move{{Color: katago.Black, Vertex: vertex}}
+		request.AnalyzeTurns = []int{0, 1}
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+func TestAnalyze(t *testing.T) {
+	a, b := newTestWorker(t), newTestWorker(t)
+	c := NewCoordinator(a.URL, b.URL+"/")
+	requests := newTestRequests()
+	requests[0].ID = ""
+	responses, err := c.Analyze(context.Background(), requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 8 {
+		t.Fatalf("Expected 8 responses, got %d", len(responses))
+	}
+	if responses[0].ID == "" || responses[0].ID != responses[1].ID {
+		t.Errorf("Expected the request without an ID to be given one, got %q and %q", responses[0].ID, responses[1].ID)
+	}
+	for i, response := range responses[2:] {
+		request := requests[1+i/2]
+		if response.ID != request.ID || response.TurnNumber != i%2 {
+			t.Errorf("Expected response %d to be for %s turn %d, got %s turn %d", i+2, request.ID, i%2, response.ID, response.TurnNumber)
+		}
+	}
+	workers := c.Workers()
+	if len(workers) != 2 {
+		t.Fatalf("Expected 2 workers, got %v", workers)
+	}
+	for _, w := range workers {
+		if !w.Healthy || w.InFlight != 0 || w.Answered != 2 {
+			t.Errorf("Expected a healthy worker that answered 2 requests, got %+v", w)
+		}
+	}
+
+	// Another coordinator gives other IDs, so that both can share the workers
+	other, err := NewCoordinator(a.URL).Analyze(context.Background(), requests[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other[0].ID == "" || other[0].ID == responses[0].ID {
+		t.Errorf("Expected another ID than %q from another coordinator, got %q", responses[0].ID, other[0].ID)
+	}
+}
+
+func TestAnalyzeFailover(t *testing.T) {
+	up := newTestWorker(t)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	c := NewCoordinator(down.URL, up.URL)
+	responses, err := c.Analyze(context.Background(), newTestRequests())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 8 {
+		t.Errorf("Expected 8 responses, got %d", len(responses))
+	}
+	workers := c.Workers()
+	if workers[0].Healthy || workers[0].LastError == nil || workers[0].InFlight != 0 {
+		t.Errorf("Expected the unreachable worker to be marked as down, got %+v", workers[0])
+	}
+	if !workers[1].Healthy || workers[1].Answered != 4 {
+		t.Errorf("Expected the other worker to answer all the requests, got %+v", workers[1])
+	}
+
+	c.Remove(up.URL)
+	if _, err := c.Analyze(context.Background(), newTestRequests()); !errors.Is(err, katago.ErrEngineCrashed) {
+		t.Errorf("Expected ErrEngineCrashed without any workers, got %v", err)
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	up := newTestWorker(t)
+	stopped := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer stopped.Close()
+	c := NewCoordinator(up.URL, stopped.URL)
+	c.Add(up.URL)
+	c.CheckHealth(context.Background())
+	workers := c.Workers()
+	if len(workers) != 2 {
+		t.Fatalf("Expected 2 workers, got %v", workers)
+	}
+	if !workers[0].Healthy {
+		t.Errorf("Expected the running worker to be healthy, got %+v", workers[0])
+	}
+	if workers[1].Healthy || workers[1].LastError == nil {
+		t.Errorf("Expected the stopped worker to be marked as down, got %+v", workers[1])
+	}
+}
+
+func TestAnalyzeBadRequest(t *testing.T) {
+	c := NewCoordinator(newTestWorker(t).URL)
+	request := katago.NewRequest9x9()
+	request.Rules = "unknown"
+	if _, err := c.Analyze(context.Background(), []katago.AnalysisRequest{request}); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	if w := c.Workers()[0]; !w.Healthy {
+		t.Errorf("Expected a bad request to leave the worker healthy, got %+v", w)
+	}
+}
@@ -0,0 +1,327 @@
+// Command katago-analyze analyzes a position or an SGF file with KataGo, and prints the results as a table or as JSON.
+//
+// Usage:
+//
+//	katago-analyze [flags] [file.sgf | file.json | -]
+//
+// The input is an SGF file, a JSON file with an analysis request, or standard input.
+// Without a file, the moves can be given with -moves, like -moves "D4 Q16 C16".
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+	"github.com/xyproto/katago/sgf"
+	"github.com/xyproto/katago/terminal"
+)
+
+// options are the settings from the command line
+type options struct {
+	visits    int
+	turns     string
+	ownership bool
+	format    string
+	top       int
+	showBoard bool
+	color     bool
+}
+
+// parseSize parses a board size like "19" or "9x13"
+func parseSize(s string) (int, int, error) {
+	ws, hs, rect := strings.Cut(strings.ToLower(s), "x")
+	width, err := strconv.Atoi(ws)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid board size: %q", s)
+	}
+	height := width
+	if rect {
+		if height, err = strconv.Atoi(hs); err != nil {
+			return 0, 0, fmt.Errorf("invalid board size: %q", s)
+		}
+	}
+	return width, height, nil
+}
+
+// parseMoves parses a list of vertices, like "D4 Q16 pass", where the players alternate, starting with black.
+// A move can also have a color, like "W:Q16".
+func parseMoves(s string) []katago.Move {
+	var moves []katago.Move
+	color := "B"
+	for _, field := range strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' }) {
+		if c, v, ok := strings.Cut(field, ":"); ok {
+			color, field = strings.ToUpper(c), v
+		}
+		moves = append(moves, katago.Move{Color: katago.Color(color), Vertex: katago.Vertex(field)})
+		if color == "B" {
+			color = "W"
+		} else {
+			color = "B"
+		}
+	}
+	return moves
+}
+
+// parseTurns parses which turns to analyze: "last", "all", or a list of turns and ranges, like "0,10,20-30"
+func parseTurns(spec string, moves int) ([]int, error) {
+	switch spec {
+	case "", "last":
+		return []int{moves}, nil
+	case "all":
+		turns := make([]int, moves+1)
+		for i := range turns {
+			turns[i] = i
+		}
+		return turns, nil
+	}
+	seen := make(map[int]bool)
+	var turns []int
+	for _, part := range strings.Split(spec, ",") {
+		from, to, isRange := strings.Cut(strings.TrimSpace(part), "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid turn: %q", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil || last < first {
+				return nil, fmt.Errorf("invalid range of turns: %q", part)
+			}
+		}
+		for turn := first; turn <= last; turn++ {
+			if turn < 0 || turn > moves {
+				return nil, fmt.Errorf("turn %d is outside of the game, which has %d moves", turn, moves)
+			}
+			if !seen[turn] {
+				seen[turn] = true
+				turns = append(turns, turn)
+			}
+		}
+	}
+	sort.Ints(turns)
+	return turns, nil
+}
+
+// readPosition reads a position from an SGF file or from a JSON analysis request
+func readPosition(r io.Reader) (katago.Position, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return katago.Position{}, err
+	}
+	trimmed := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(trimmed, "{") {
+		return sgf.ParsePosition(trimmed)
+	}
+	var request katago.AnalysisRequest
+	if err := json.Unmarshal(data, &request); err != nil {
+		return katago.Position{}, fmt.Errorf("invalid analysis request: %v", err)
+	}
+	p := katago.Position{
+		InitialStones:      request.InitialStones,
+		Moves:              request.Moves,
+		InitialPlayer:      request.InitialPlayer,
+		Rules:              request.Rules,
+		Komi:               request.Komi,
+		WhiteHandicapBonus: request.WhiteHandicapBonus,
+		BoardXSize:         request.BoardXSize,
+		BoardYSize:         request.BoardYSize,
+	}
+	if p.Rules == "" {
+		p.Rules = katago.DefaultRules
+	}
+	if p.BoardXSize == 0 && p.BoardYSize == 0 {
+		p.BoardXSize, p.BoardYSize = 19, 19
+	}
+	return p, nil
+}
+
+// requests creates one request for each turn
+func requests(p katago.Position, turns []int, opts options) []katago.AnalysisRequest {
+	var requests []katago.AnalysisRequest
+	for _, turn := range turns {
+		q := p
+		q.Moves = p.Moves[:turn]
+		request := q.Request(fmt.Sprintf("turn-%d", turn))
+		request.MaxVisits = opts.visits
+		request.IncludeOwnership = opts.ownership
+		requests = append(requests, request)
+	}
+	return requests
+}
+
+// writeTable writes the analysis of each turn as a table of candidate moves
+func writeTable(w io.Writer, p katago.Position, turns []int, responses []katago.AnalysisResponse, opts options) error {
+	for i, response := range responses {
+		turn := turns[i]
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		q := p
+		q.Moves = p.Moves[:turn]
+		root := response.RootInfo
+		toPlay := root.CurrentPlayer
+		if toPlay == "" {
+			toPlay = q.ToPlay()
+		}
+		fmt.Fprintf(w, "Turn %d, %s to play: winrate %.1f%%, score lead %+.1f, %d visits\n", turn, toPlay, 100*root.Winrate, root.ScoreLead, root.Visits)
+		candidates := response.TopMoves(opts.top)
+		if opts.showBoard {
+			b, err := q.Board()
+			if err != nil {
+				return err
+			}
+			var ownership *katago.OwnershipMap
+			if opts.ownership {
+				if ownership, err = response.OwnershipMap(p.BoardXSize, p.BoardYSize); err != nil {
+					return err
+				}
+			}
+			if err := terminal.Render(w, b, terminal.Options{Color: opts.color, Candidates: candidates, Ownership: ownership}); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Fprintf(w, "%-6s %7s %8s %7s %7s  %s\n", "Move", "Visits", "Winrate", "Lead", "Prior", "PV")
+		for _, info := range candidates {
+			fmt.Fprintf(w, "%-6s %7d %7.1f%% %+7.1f %6.1f%%  %s\n", info.Move, info.Visits, 100*info.Winrate, info.ScoreLead, 100*info.Prior, strings.Join(info.PV, " "))
+		}
+		if opts.ownership {
+			if err := writeOwnership(w, response, p.BoardXSize, p.BoardYSize); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeOwnership writes the predicted owner of each point, as X for black, O for white and . for neither
+func writeOwnership(w io.Writer, response katago.AnalysisResponse, width, height int) error {
+	ownership, err := response.OwnershipMap(width, height)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Ownership: black %d points, white %d points\n", ownership.Count(board.Black, 0.5), ownership.Count(board.White, 0.5))
+	for y := 0; y < height; y++ {
+		var sb strings.Builder
+		for x := 0; x < width; x++ {
+			switch ownership.Owner(x, y, 0.5) {
+			case board.Black:
+				sb.WriteString(" X")
+			case board.White:
+				sb.WriteString(" O")
+			default:
+				sb.WriteString(" .")
+			}
+		}
+		fmt.Fprintf(w, "%2d%s\n", height-y, sb.String())
+	}
+	return nil
+}
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelFile := flag.String("model", "model.bin.gz", "KataGo model file")
+	movesFlag := flag.String("moves", "", "moves to play from the empty board, like \"D4 Q16 C16\", when no file is given")
+	size := flag.String("size", "19", "board size when no file is given, like 19 or 9x13")
+	rules := flag.String("rules", "", "rules, which override the rules of the input")
+	komi := flag.String("komi", "", "komi, which overrides the komi of the input")
+	var opts options
+	flag.IntVar(&opts.visits, "visits", 500, "maximum number of visits for each turn")
+	flag.StringVar(&opts.turns, "turns", "last", "turns to analyze: last, all, or a list like 0,10,20-30")
+	flag.BoolVar(&opts.ownership, "ownership", false, "include the predicted ownership of each point")
+	flag.StringVar(&opts.format, "format", "table", "output format: table or json")
+	flag.IntVar(&opts.top, "top", 5, "number of candidate moves to show in the table, or 0 for all")
+	flag.BoolVar(&opts.showBoard, "board", false, "draw the board with the candidate moves instead of a table")
+	flag.BoolVar(&opts.color, "color", false, "use ANSI colors when drawing the board")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+
+	if opts.format != "table" && opts.format != "json" {
+		return fmt.Errorf("unknown output format: %q", opts.format)
+	}
+
+	var p katago.Position
+	switch flag.NArg() {
+	case 0:
+		width, height, err := parseSize(*size)
+		if err != nil {
+			return err
+		}
+		p = katago.NewPosition(width, height)
+		p.Moves = parseMoves(*movesFlag)
+	case 1:
+		var r io.Reader = os.Stdin
+		if name := flag.Arg(0); name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		var err error
+		if p, err = readPosition(r); err != nil {
+			return err
+		}
+	default:
+		return errors.New("expected at most one input file")
+	}
+	if *rules != "" {
+		p.Rules = katago.Rules(*rules)
+	}
+	if *komi != "" {
+		value, err := strconv.ParseFloat(*komi, 64)
+		if err != nil {
+			return fmt.Errorf("invalid komi: %q", *komi)
+		}
+		p.Komi = value
+	}
+	if err := p.Rules.Validate(); err != nil {
+		return err
+	}
+	if _, err := p.Board(); err != nil {
+		return err
+	}
+	turns, err := parseTurns(opts.turns, len(p.Moves))
+	if err != nil {
+		return err
+	}
+
+	k, err := katago.NewKataGo(*configFile, *modelFile)
+	if err != nil {
+		return fmt.Errorf("failed to start KataGo: %v", err)
+	}
+	defer k.Close()
+	responses, err := k.Analyze(requests(p, turns, opts))
+	if err != nil {
+		return err
+	}
+
+	if opts.format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(responses)
+	}
+	return writeTable(os.Stdout, p, turns, responses, opts)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-analyze: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestParseTurns(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected []int
+	}{
+		{"last", []int{3}},
+		{"all", []int{0, 1, 2, 3}},
+		{"2,0-1,1", []int{0, 1, 2}},
+	}
+	for _, test := range tests {
+		turns, err := parseTurns(test.spec, 3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(turns) != len(test.expected) {
+			t.Fatalf("Expected %v for %q, got %v", test.expected, test.spec, turns)
+		}
+		for i := range turns {
+			if turns[i] != test.expected[i] {
+				t.Errorf("Expected %v for %q, got %v", test.expected, test.spec, turns)
+			}
+		}
+	}
+	for _, spec := range []string{"4", "x", "2-1", "-1"} {
+		if _, err := parseTurns(spec, 3); err == nil {
+			t.Errorf("Expected an error for %q", spec)
+		}
+	}
+}
+
+func TestParseMoves(t *testing.T) {
+	moves := parseMoves("D4 Q16, W:C3 pass")
+	expected := []katago.Move{{Color: "B", Vertex: "D4"}, {Color: "W", Vertex: "Q16"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "pass"}}
+	if len(moves) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, moves)
+	}
+	for i := range expected {
+		if moves[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], moves[i])
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	width, height, err := parseSize("9x13")
+	if err != nil || width != 9 || height != 13 {
+		t.Errorf("Expected 9x13, got %dx%d (%v)", width, height, err)
+	}
+	if _, _, err := parseSize("big"); err == nil {
+		t.Errorf("Expected an error for an invalid size")
+	}
+}
+
+func TestReadPosition(t *testing.T) {
+	p, err := readPosition(strings.NewReader(`{"id":"x","moves":[["B","E5"]],"komi":7,"boardXSize":9,"boardYSize":9}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BoardXSize != 9 || len(p.Moves) != 1 || p.Rules != katago.DefaultRules {
+		t.Errorf("Unexpected position from JSON: %+v", p)
+	}
+	p, err = readPosition(strings.NewReader("(;SZ[13]KM[6.5];B[dd];W[jj])"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BoardXSize != 13 || p.Komi != 6.5 || len(p.Moves) != 2 {
+		t.Errorf("Unexpected position from SGF: %+v", p)
+	}
+}
@@ -0,0 +1,48 @@
+// Command katago-gtp runs KataGo's analysis engine as a GTP engine, for GUIs like Sabaki, q5Go and Lizzie.
+//
+// Usage:
+//
+//	katago-gtp [flags]
+//
+// The GTP commands are read from standard input, and the responses are written to standard output.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/gtp"
+)
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelFile := flag.String("model", "model.bin.gz", "KataGo model file")
+	visits := flag.Int("visits", 500, "maximum number of visits for genmove")
+	resign := flag.Float64("resign", 0, "resign when the winrate is below this, or never if 0")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo to standard error")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	k, err := katago.NewKataGo(*configFile, *modelFile)
+	if err != nil {
+		return fmt.Errorf("failed to start KataGo: %v", err)
+	}
+	defer k.Close()
+	e := gtp.NewEngine(k)
+	e.Visits = *visits
+	e.ResignThreshold = *resign
+	return e.Serve(os.Stdin, os.Stdout)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-gtp: %v\n", err)
+		os.Exit(1)
+	}
+}
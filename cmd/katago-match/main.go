@@ -0,0 +1,169 @@
+// Command katago-match plays a match between two KataGo configurations, like two networks or two numbers
+// of visits, and reports the Elo difference with a 95% confidence interval, for evaluating tuning changes.
+//
+// Usage:
+//
+//	katago-match [flags] [opening.sgf ...]
+//
+// The games start from the given SGF files in turn, or from an empty board, and each opening is played
+// twice, with the colors swapped. With -sprt, the match stops as soon as the result is clear.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/match"
+	"github.com/xyproto/katago/sgf"
+)
+
+// readOpenings reads the positions of the SGF files, or returns an empty board of the given size
+func readOpenings(files []string, size int) ([]katago.Position, error) {
+	if len(files) == 0 {
+		return []katago.Position{katago.NewPosition(size, size)}, nil
+	}
+	var openings []katago.Position
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		root, err := sgf.ParseGame(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		p, err := sgf.Position(root)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		openings = append(openings, p)
+	}
+	return openings, nil
+}
+
+// writeLog writes the results to a file, as CSV, TSV if the name ends with .tsv, or JSON lines
+func writeLog(name string, results []match.Result, asCSV bool) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if asCSV {
+		comma := ','
+		if strings.HasSuffix(name, ".tsv") {
+			comma = '\t'
+		}
+		err = match.WriteCSV(f, results, comma)
+	} else {
+		err = match.WriteJSON(f, results)
+	}
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelA := flag.String("model-a", "model.bin.gz", "model of the first configuration")
+	modelB := flag.String("model-b", "model.bin.gz", "model of the second configuration")
+	nameA := flag.String("name-a", "A", "name of the first configuration")
+	nameB := flag.String("name-b", "B", "name of the second configuration")
+	visitsA := flag.Int("visits-a", 200, "visits for each move of the first configuration")
+	visitsB := flag.Int("visits-b", 200, "visits for each move of the second configuration")
+	games := flag.Int("games", 100, "largest number of games to play")
+	size := flag.Int("size", 19, "board size, when no openings are given")
+	maxMoves := flag.Int("max-moves", 0, "largest number of moves in a game, or 0 for twice the number of points")
+	adjudicate := flag.Float64("adjudicate", 0, "points behind for a player to resign, or 0 to play out every game")
+	adjudicateMoves := flag.Int("adjudicate-moves", 3, "moves in a row that a player must be behind to resign")
+	sprt := flag.Bool("sprt", false, "stop the match when an SPRT between -elo0 and -elo1 is decided")
+	elo0 := flag.Float64("elo0", match.DefaultSPRT.Elo0, "Elo difference of the null hypothesis of the SPRT")
+	elo1 := flag.Float64("elo1", match.DefaultSPRT.Elo1, "Elo difference of the alternative hypothesis of the SPRT")
+	jsonFile := flag.String("json", "", "write the results to this file, as JSON lines")
+	csvFile := flag.String("csv", "", "write the results to this CSV file, or TSV if the name ends with .tsv")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *nameA == *nameB {
+		return errors.New("the configurations need different names")
+	}
+	openings, err := readOpenings(flag.Args(), *size)
+	if err != nil {
+		return err
+	}
+	a, err := katago.NewKataGo(*configFile, *modelA)
+	if err != nil {
+		return fmt.Errorf("failed to start KataGo: %v", err)
+	}
+	defer a.Close()
+	// Configurations that only differ in their settings share one engine
+	b := a
+	if *modelB != *modelA {
+		if b, err = katago.NewKataGo(*configFile, *modelB); err != nil {
+			return fmt.Errorf("failed to start KataGo: %v", err)
+		}
+		defer b.Close()
+	}
+
+	m := match.Match{
+		A:               match.Player{Name: *nameA, Engine: a, Visits: *visitsA},
+		B:               match.Player{Name: *nameB, Engine: b, Visits: *visitsB},
+		Openings:        openings,
+		Games:           *games,
+		MaxMoves:        *maxMoves,
+		AdjudicateScore: *adjudicate,
+		AdjudicateMoves: *adjudicateMoves,
+		OnResult: func(r match.Result, s match.Score) {
+			fmt.Printf("game %d: %s (B) vs %s (W), %s by %s, score %d-%d-%d, Elo %v\n", r.Game, r.Black, r.White, r.Result, r.Reason, s.Wins, s.Draws, s.Losses, s.Elo(0.95))
+		},
+	}
+	if *sprt {
+		test := match.DefaultSPRT
+		test.Elo0, test.Elo1 = *elo0, *elo1
+		m.SPRT = &test
+	}
+	// Stop after the running game on Ctrl-C, and still write the results so far
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	results, err := m.Run(ctx)
+	if err != nil && !errors.Is(err, context.Canceled) {
+		return err
+	}
+	if *jsonFile != "" {
+		if err := writeLog(*jsonFile, results, false); err != nil {
+			return err
+		}
+	}
+	if *csvFile != "" {
+		if err := writeLog(*csvFile, results, true); err != nil {
+			return err
+		}
+	}
+
+	s := match.Tally(results, *nameA)
+	fmt.Printf("%s vs %s: %d wins, %d draws, %d losses\n", *nameA, *nameB, s.Wins, s.Draws, s.Losses)
+	fmt.Printf("Elo difference: %v, LOS %.1f%%\n", s.Elo(0.95), 100*s.LOS())
+	if m.SPRT != nil {
+		lower, upper := m.SPRT.Bounds()
+		fmt.Printf("SPRT [%g, %g]: LLR %.2f (%.2f, %.2f), %v\n", m.SPRT.Elo0, m.SPRT.Elo1, m.SPRT.LLR(s), lower, upper, m.SPRT.Decide(s))
+	}
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-match: %v\n", err)
+		os.Exit(1)
+	}
+}
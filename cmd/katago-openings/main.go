@@ -0,0 +1,111 @@
+// Command katago-openings groups SGF games by their first moves, normalized over the rotations and
+// reflections of the board, and prints how often each opening was played and how KataGo evaluates it,
+// as text or as JSON for an opening explorer.
+//
+// Usage:
+//
+//	katago-openings [flags] game.sgf ...
+//
+// SGF files with more than one game add all of them.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/corpus"
+	"github.com/xyproto/katago/sgf"
+)
+
+// readGames reads the positions of all the games in the SGF files
+func readGames(files []string) ([]katago.Position, error) {
+	var games []katago.Position
+	for _, name := range files {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		roots, err := sgf.Parse(f)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		for _, root := range roots {
+			p, err := sgf.Position(root)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", name, err)
+			}
+			games = append(games, p)
+		}
+	}
+	return games, nil
+}
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelFile := flag.String("model", "model.bin.gz", "KataGo model file")
+	moves := flag.Int("moves", corpus.DefaultOpeningMoves, "number of moves in an opening")
+	top := flag.Int("top", 20, "number of openings to show, or 0 for all")
+	visits := flag.Int("visits", 200, "visits for evaluating each opening, or 0 to only count them")
+	format := flag.String("format", "text", "output format: text or json")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if flag.NArg() == 0 {
+		return errors.New("no SGF files given")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+	games, err := readGames(flag.Args())
+	if err != nil {
+		return err
+	}
+	lines, err := corpus.Openings(games, *moves)
+	if err != nil {
+		return err
+	}
+	if *top > 0 && len(lines) > *top {
+		lines = lines[:*top]
+	}
+	if *visits > 0 {
+		k, err := katago.NewKataGo(*configFile, *modelFile)
+		if err != nil {
+			return fmt.Errorf("failed to start KataGo: %v", err)
+		}
+		defer k.Close()
+		if err := corpus.EvaluateOpenings(k, lines, *visits); err != nil {
+			return err
+		}
+	}
+
+	if *format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(lines)
+	}
+	for _, line := range lines {
+		fmt.Printf("%5.1f%% %5d  %s", 100*line.Frequency, line.Games, line)
+		if line.Evaluated {
+			fmt.Printf("  B %.1f%%  %s  next %s", 100*line.BlackWinrate, katago.FormatLead(line.BlackScoreLead), line.BestMove)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-openings: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,411 @@
+// Command katago-review reviews an SGF game with KataGo. It writes an annotated copy of the game, with a comment
+// and a grade for each move and KataGo's variation for the mistakes, and prints a summary with the mistakes
+// and the accuracy of each player, the points lost in the opening, the middlegame and the endgame, and the
+// biggest blunders.
+//
+// Usage:
+//
+//	katago-review [flags] game.sgf
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+	"github.com/xyproto/katago/report"
+	"github.com/xyproto/katago/sgf"
+)
+
+// PlayerReport is the summary for one player
+type PlayerReport struct {
+	Color             string         `json:"color"`
+	Name              string         `json:"name,omitempty"`
+	Moves             int            `json:"moves"`
+	Accuracy          float64        `json:"accuracy"`
+	AveragePointsLost float64        `json:"averagePointsLost"`
+	Grades            map[string]int `json:"grades"`
+	Phases            []PhaseReport  `json:"phases"`
+}
+
+// PhaseReport is the summary for one player in one phase of the game
+type PhaseReport struct {
+	Phase             string  `json:"phase"`
+	Moves             int     `json:"moves"`
+	PointsLost        float64 `json:"pointsLost"`
+	AveragePointsLost float64 `json:"averagePointsLost"`
+}
+
+// Blunder is one of the moves that lost the most points
+type Blunder struct {
+	MoveNumber int     `json:"moveNumber"`
+	Color      string  `json:"color"`
+	Move       string  `json:"move"`
+	BestMove   string  `json:"bestMove"`
+	PointsLost float64 `json:"pointsLost"`
+	Grade      string  `json:"grade"`
+}
+
+// Report is the summary of a review
+type Report struct {
+	Players  []PlayerReport `json:"players"`
+	Blunders []Blunder      `json:"blunders"`
+}
+
+// formatLead formats black's score lead, like "B+2.5" or "W+0.3"
+func formatLead(blackLead float64) string {
+	if blackLead < 0 {
+		return fmt.Sprintf("W+%.1f", -blackLead)
+	}
+	return fmt.Sprintf("B+%.1f", blackLead)
+}
+
+// colorName returns "Black" or "White"
+func colorName(color string) string {
+	if strings.EqualFold(color, "W") {
+		return "White"
+	}
+	return "Black"
+}
+
+// variation creates a branch with a principal variation, where the first move is played by the given color
+func variation(pv []string, color katago.Color, width, height int) (*sgf.Node, error) {
+	var first, last *sgf.Node
+	for _, vertex := range pv {
+		node, err := sgf.MoveNode(katago.Move{Color: color, Vertex: katago.Vertex(vertex)}, width, height)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = node
+		} else {
+			last.AddChild(node)
+		}
+		last = node
+		color = color.Opponent()
+	}
+	return first, nil
+}
+
+// annotate adds the grades, the winrates and KataGo's variations to the main line of the game.
+// The responses are indexed by turn.
+func annotate(root *sgf.Node, p katago.Position, responses []katago.AnalysisResponse, graded []katago.GradedMove, variations bool) error {
+	grades := make(map[int]katago.GradedMove, len(graded))
+	for _, move := range graded {
+		grades[move.Turn] = move
+	}
+	turn := 0
+	for _, node := range root.MainLine() {
+		move, ok, err := node.Move(p.BoardXSize, p.BoardYSize)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		var lines []string
+		if g, ok := grades[turn]; ok {
+			if g.Grade >= katago.Inaccuracy {
+				lines = append(lines, fmt.Sprintf("%s: lost %.1f points. KataGo prefers %s.", g.Grade, g.PointsLost, g.BestMove))
+			} else {
+				lines = append(lines, fmt.Sprintf("%s (%.1f points lost)", g.Grade, g.PointsLost))
+			}
+			switch g.Grade {
+			case katago.Blunder:
+				node.Set("BM", "2")
+			case katago.Mistake:
+				node.Set("BM", "1")
+			case katago.Inaccuracy:
+				node.Set("DO", "")
+			}
+			if variations && g.Grade >= katago.Mistake && node.Parent != nil {
+				for _, info := range responses[turn].MoveInfos {
+					if info.Move != g.BestMove || len(info.PV) == 0 {
+						continue
+					}
+					branch, err := variation(info.PV, move.Color, p.BoardXSize, p.BoardYSize)
+					if err != nil {
+						return err
+					}
+					report.AddComment(branch, fmt.Sprintf("KataGo's variation: winrate %.1f%% and score lead %+.1f for %s", 100*info.Winrate, info.ScoreLead, colorName(string(move.Color))))
+					node.Parent.AddChild(branch)
+					break
+				}
+			}
+		}
+		if turn+1 < len(responses) {
+			after := responses[turn+1]
+			toPlay, err := board.ParseColor(string(move.Color))
+			if err != nil {
+				return err
+			}
+			winrate, lead := report.BlackView(after, toPlay.Opponent())
+			lines = append(lines, fmt.Sprintf("Black winrate %.1f%%, %s", 100*winrate, formatLead(lead)))
+		}
+		if len(lines) > 0 {
+			report.AddComment(node, strings.Join(lines, "\n"))
+		}
+		turn++
+	}
+	return nil
+}
+
+// summarize creates the report, with the given number of blunders
+func summarize(root *sgf.Node, p katago.Position, graded []katago.GradedMove, blunders int) (Report, error) {
+	var report Report
+	summaries := katago.SummarizeGrades(graded)
+	phases, err := katago.SummarizePhases(p, graded)
+	if err != nil {
+		return Report{}, err
+	}
+	for _, color := range []string{"B", "W"} {
+		summary, ok := summaries[color]
+		if !ok {
+			continue
+		}
+		player := PlayerReport{
+			Color:             color,
+			Name:              root.Get("P" + color),
+			Moves:             summary.Moves,
+			Accuracy:          summary.Accuracy(),
+			AveragePointsLost: summary.AveragePointsLost(),
+			Grades:            make(map[string]int),
+		}
+		for _, g := range katago.Grades {
+			player.Grades[g.String()] = summary.Counts[g]
+		}
+		for _, phase := range katago.Phases {
+			if s, ok := phases[color][phase]; ok {
+				player.Phases = append(player.Phases, PhaseReport{
+					Phase:             phase.String(),
+					Moves:             s.Moves,
+					PointsLost:        s.TotalPointsLost,
+					AveragePointsLost: s.AveragePointsLost(),
+				})
+			}
+		}
+		report.Players = append(report.Players, player)
+	}
+	sorted := append([]katago.GradedMove(nil), graded...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].PointsLost > sorted[j].PointsLost })
+	for _, move := range sorted {
+		if len(report.Blunders) >= blunders || move.Grade < katago.Mistake {
+			break
+		}
+		report.Blunders = append(report.Blunders, Blunder{
+			MoveNumber: move.Turn + 1,
+			Color:      move.Color,
+			Move:       move.Move,
+			BestMove:   move.BestMove,
+			PointsLost: move.PointsLost,
+			Grade:      move.Grade.String(),
+		})
+	}
+	return report, nil
+}
+
+// writeText writes the report as text
+func writeText(w io.Writer, report Report) {
+	for _, player := range report.Players {
+		name := player.Color
+		if player.Name != "" {
+			name = fmt.Sprintf("%s (%s)", player.Name, player.Color)
+		}
+		fmt.Fprintf(w, "%s: %d moves, accuracy %.1f%%, %.2f points lost per move\n", name, player.Moves, player.Accuracy, player.AveragePointsLost)
+		for _, g := range katago.Grades {
+			fmt.Fprintf(w, "  %-10s %d\n", g, player.Grades[g.String()])
+		}
+		for _, phase := range player.Phases {
+			fmt.Fprintf(w, "  %-10s %d moves, %.1f points lost, %.2f per move\n", phase.Phase, phase.Moves, phase.PointsLost, phase.AveragePointsLost)
+		}
+	}
+	if len(report.Blunders) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "Biggest mistakes:")
+	for _, b := range report.Blunders {
+		fmt.Fprintf(w, "  move %d, %s %s: lost %.1f points, KataGo prefers %s\n", b.MoveNumber, b.Color, b.Move, b.PointsLost, b.BestMove)
+	}
+}
+
+// newReview returns the review for the report package
+func newReview(root *sgf.Node, p katago.Position, responses []katago.AnalysisResponse, graded []katago.GradedMove) report.Review {
+	title := root.Get("GN")
+	if title == "" && (root.Has("PB") || root.Has("PW")) {
+		title = root.Get("PB") + " vs " + root.Get("PW")
+	}
+	return report.Review{
+		Title:     title,
+		Black:     root.Get("PB"),
+		White:     root.Get("PW"),
+		Position:  p,
+		Responses: responses,
+		Graded:    graded,
+	}
+}
+
+// writeFile creates a file and writes to it
+func writeFile(name string, write func(io.Writer) error) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// outputName returns the name of the annotated file, next to the input file
+func outputName(input string) string {
+	return strings.TrimSuffix(input, ".sgf") + "-review.sgf"
+}
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelFile := flag.String("model", "model.bin.gz", "KataGo model file")
+	output := flag.String("o", "", "annotated SGF file to write, or - for standard output (default: game-review.sgf)")
+	format := flag.String("format", "text", "summary format: text or json")
+	htmlFile := flag.String("html", "", "also write the review as an HTML page to this file")
+	katrainFile := flag.String("katrain", "", "also write the game with the analysis for KaTrain to this SGF file")
+	csvFile := flag.String("csv", "", "also write one row for each turn to this CSV file, or TSV if the name ends with .tsv")
+	visits := flag.Int("visits", 500, "maximum number of visits for each turn")
+	budget := flag.Duration("budget", 0, "analyze the whole game within this time, like 3m, giving more time to the complex positions")
+	blunders := flag.Int("blunders", 5, "number of biggest mistakes to list")
+	variations := flag.Bool("variations", true, "add KataGo's variation for each mistake and blunder")
+	thresholds := katago.DefaultGradeThresholds
+	flag.Float64Var(&thresholds.Good, "good", thresholds.Good, "points lost for a move to be graded Good")
+	flag.Float64Var(&thresholds.Inaccuracy, "inaccuracy", thresholds.Inaccuracy, "points lost for a move to be an Inaccuracy")
+	flag.Float64Var(&thresholds.Mistake, "mistake", thresholds.Mistake, "points lost for a move to be a Mistake")
+	flag.Float64Var(&thresholds.Blunder, "blunder", thresholds.Blunder, "points lost for a move to be a Blunder")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown summary format: %q", *format)
+	}
+	if flag.NArg() != 1 {
+		return errors.New("expected one SGF file")
+	}
+	input := flag.Arg(0)
+	data, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+	root, err := sgf.ParseGame(string(data))
+	if err != nil {
+		return err
+	}
+	p, err := sgf.Position(root)
+	if err != nil {
+		return err
+	}
+	if _, err := p.Board(); err != nil {
+		return err
+	}
+
+	k, err := katago.NewKataGo(*configFile, *modelFile)
+	if err != nil {
+		return fmt.Errorf("failed to start KataGo: %v", err)
+	}
+	defer k.Close()
+	request := p.Request("review")
+	request.MaxVisits = *visits
+	if *katrainFile != "" {
+		// KaTrain shows the ownership and the policy of each move
+		request.IncludeOwnership = true
+		request.IncludePolicy = true
+	}
+	var responses []katago.AnalysisResponse
+	if *budget > 0 {
+		responses, err = k.AnalyzeGameWithin(context.Background(), request, *budget)
+	} else {
+		responses, err = k.AnalyzeGame(request)
+	}
+	if err != nil {
+		return err
+	}
+	graded, err := katago.GradeMoves(p.Moves, responses, thresholds)
+	if err != nil {
+		return err
+	}
+	// Write the reports before annotate adds the variations to the game
+	review := newReview(root, p, responses, graded)
+	if *htmlFile != "" {
+		if err := writeFile(*htmlFile, func(w io.Writer) error { return report.WriteHTML(w, review) }); err != nil {
+			return err
+		}
+	}
+	if *katrainFile != "" {
+		game, err := sgf.ParseGame(string(data))
+		if err != nil {
+			return err
+		}
+		if err := report.AddKaTrainAnalysis(game, review); err != nil {
+			return err
+		}
+		if err := os.WriteFile(*katrainFile, []byte(sgf.Format(game)), 0o644); err != nil {
+			return err
+		}
+	}
+	if *csvFile != "" {
+		comma := ','
+		if strings.HasSuffix(*csvFile, ".tsv") {
+			comma = '\t'
+		}
+		if err := writeFile(*csvFile, func(w io.Writer) error { return report.WriteCSV(w, review, comma) }); err != nil {
+			return err
+		}
+	}
+	if err := annotate(root, p, responses, graded, *variations); err != nil {
+		return err
+	}
+
+	summary := io.Writer(os.Stdout)
+	switch *output {
+	case "-":
+		if err := sgf.Write(os.Stdout, root); err != nil {
+			return err
+		}
+		summary = os.Stderr
+	default:
+		name := *output
+		if name == "" {
+			name = outputName(input)
+		}
+		if err := os.WriteFile(name, []byte(sgf.Format(root)), 0o644); err != nil {
+			return err
+		}
+	}
+	report, err := summarize(root, p, graded, *blunders)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(summary)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	writeText(summary, report)
+	return nil
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-review: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/sgf"
+)
+
+func TestAnnotate(t *testing.T) {
+	root, err := sgf.ParseGame("(;SZ[9]KM[7]PB[Alice];B[ee]C[opening];W[aa])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := sgf.Position(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses := []katago.AnalysisResponse{
+		{TurnNumber: 0, RootInfo: katago.RootInfo{Winrate: 0.6, ScoreLead: 1, CurrentPlayer: "B"}, MoveInfos: []katago.MoveInfoExt{{Move: "E5", ScoreLead: 1}}},
+		{TurnNumber: 1, RootInfo: katago.RootInfo{Winrate: 0.4, ScoreLead: -1, CurrentPlayer: "W"}, MoveInfos: []katago.MoveInfoExt{{Move: "C3", ScoreLead: -1, PV: []string{"C3", "G7"}}}},
+		{TurnNumber: 2, RootInfo: katago.RootInfo{Winrate: 0.9, ScoreLead: 9, CurrentPlayer: "B"}},
+	}
+	graded, err := katago.GradeMoves(p.Moves, responses, katago.DefaultGradeThresholds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := annotate(root, p, responses, graded, true); err != nil {
+		t.Fatal(err)
+	}
+	line := root.MainLine()
+	if comment := line[1].Get("C"); !strings.HasPrefix(comment, "opening\n\nExcellent") || !strings.Contains(comment, "Black winrate 60.0%, B+1.0") {
+		t.Errorf("Unexpected comment: %q", comment)
+	}
+	if line[2].Get("BM") != "2" || !strings.Contains(line[2].Get("C"), "Blunder: lost 8.0 points. KataGo prefers C3.") {
+		t.Errorf("Expected the second move to be marked as a blunder, got %v", line[2].Properties)
+	}
+	if variations := line[1].Children; len(variations) != 2 || variations[1].Get("W") != "cg" || variations[1].Children[0].Get("B") != "gc" {
+		t.Errorf("Expected KataGo's variation after the first move, got %q", sgf.Format(root))
+	}
+
+	report, err := summarize(root, p, graded, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Players) != 2 || report.Players[0].Name != "Alice" || report.Players[1].Accuracy != 0 {
+		t.Errorf("Unexpected players: %+v", report.Players)
+	}
+	if len(report.Blunders) != 1 || report.Blunders[0].MoveNumber != 2 || report.Blunders[0].BestMove != "C3" {
+		t.Errorf("Unexpected blunders: %+v", report.Blunders)
+	}
+	if phases := report.Players[1].Phases; len(phases) != 1 || phases[0].Phase != "Opening" || phases[0].PointsLost != 8 {
+		t.Errorf("Expected white to lose 8 points in the opening, got %+v", phases)
+	}
+}
+
+func TestOutputName(t *testing.T) {
+	if name := outputName("games/game.sgf"); name != "games/game-review.sgf" {
+		t.Errorf("Expected games/game-review.sgf, got %s", name)
+	}
+}
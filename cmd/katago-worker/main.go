@@ -0,0 +1,57 @@
+// Command katago-worker serves the local KataGo engine over HTTP, as a worker for a cluster.Coordinator
+// on another host.
+//
+// Usage:
+//
+//	katago-worker [flags]
+//
+// The endpoints are the ones of the server package. With -slots, the engine is shared fairly between
+// the coordinators and other clients that use it.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/server"
+)
+
+func run() error {
+	configFile := flag.String("config", "analysis_example.cfg", "KataGo analysis configuration file")
+	modelFile := flag.String("model", "model.bin.gz", "KataGo model file")
+	addr := flag.String("listen", ":8080", "address to listen on")
+	slots := flag.Int("slots", 0, "number of calls that run at the same time, with fair scheduling, or 0 for no scheduler")
+	trustClientID := flag.Bool("trust-client-id", false, "identify the clients of the scheduler by the X-Client-ID header, behind a trusted proxy")
+	verbose := flag.Bool("v", false, "log the requests, the responses and the output of KataGo to standard error")
+	flag.Parse()
+
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	k, err := katago.NewKataGo(*configFile, *modelFile)
+	if err != nil {
+		return fmt.Errorf("failed to start KataGo: %v", err)
+	}
+	defer k.Close()
+	s := server.New(k)
+	if *slots > 0 {
+		s.SetScheduler(katago.NewScheduler(*slots))
+	}
+	if *trustClientID {
+		s.TrustClientIDHeader()
+	}
+	fmt.Fprintf(os.Stderr, "katago-worker: listening on %s\n", *addr)
+	return http.ListenAndServe(*addr, s)
+}
+
+func main() {
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "katago-worker: %v\n", err)
+		os.Exit(1)
+	}
+}
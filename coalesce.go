@@ -0,0 +1,95 @@
+package katago
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xyproto/katago/board"
+)
+
+// flight is a query that has been sent for a position, which concurrent calls for the same position
+// can wait for instead of sending their own
+type flight struct {
+	done chan struct{}
+	// response is for the canonical orientation of the position, and is set when done is closed without an error
+	response AnalysisResponse
+	err      error
+}
+
+// flightKey identifies the queries that give the same response. Requests with different timeouts
+// are not coalesced, since a request that times out returns the results that were found so far.
+type flightKey struct {
+	hash    board.Hash
+	timeout time.Duration
+}
+
+// joinFlights returns the running queries that the requests with the given indices can wait for, by index
+func (k *KataGo) joinFlights(requests []AnalysisRequest, keys map[int]cacheKey, indices []int) map[int]*flight {
+	// A request with the ID of a running query is sent, so that it gets ErrDuplicateID
+	k.mut.Lock()
+	running := make(map[int]bool)
+	for _, i := range indices {
+		_, running[i] = k.pending[requests[i].ID]
+	}
+	k.mut.Unlock()
+	joined := make(map[int]*flight)
+	k.flightMut.Lock()
+	defer k.flightMut.Unlock()
+	for _, i := range indices {
+		key, ok := keys[i]
+		if !ok || running[i] {
+			continue
+		}
+		if f, ok := k.flights[flightKey{key.hash, requests[i].Timeout}]; ok {
+			joined[i] = f
+		}
+	}
+	return joined
+}
+
+// startFlights makes the queries that were sent for the requests with the given indices available
+// to concurrent calls, unless another call already sent a query for the same position
+func (k *KataGo) startFlights(requests []AnalysisRequest, keys map[int]cacheKey, indices []int) map[int]*flight {
+	started := make(map[int]*flight)
+	k.flightMut.Lock()
+	defer k.flightMut.Unlock()
+	if k.flights == nil {
+		k.flights = make(map[flightKey]*flight)
+	}
+	for _, i := range indices {
+		key, ok := keys[i]
+		if !ok {
+			continue
+		}
+		fk := flightKey{key.hash, requests[i].Timeout}
+		if _, ok := k.flights[fk]; ok {
+			continue
+		}
+		f := &flight{done: make(chan struct{})}
+		k.flights[fk] = f
+		started[i] = f
+	}
+	return started
+}
+
+// landFlights gives the responses, or the error, to the calls that wait for the started queries.
+// The landed queries are removed from started, so it is safe to call it again.
+func (k *KataGo) landFlights(requests []AnalysisRequest, keys map[int]cacheKey, started map[int]*flight, responseMap map[string][]AnalysisResponse, err error) {
+	k.flightMut.Lock()
+	defer k.flightMut.Unlock()
+	for i, f := range started {
+		fk := flightKey{keys[i].hash, requests[i].Timeout}
+		if k.flights[fk] == f {
+			delete(k.flights, fk)
+		}
+		if responses := responseMap[requests[i].ID]; err == nil && len(responses) > 0 {
+			f.response = keys[i].toCanonical(responses[0])
+		} else if err != nil {
+			f.err = err
+		} else {
+			f.err = fmt.Errorf("%w: %s", ErrQueryTerminated, requests[i].ID)
+		}
+		close(f.done)
+		delete(started, i)
+	}
+}
@@ -0,0 +1,118 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+// flightCount returns the number of queries that concurrent calls can share
+func flightCount(k *KataGo) int {
+	k.flightMut.Lock()
+	defer k.flightMut.Unlock()
+	return len(k.flights)
+}
+
+func TestAnalyzeCoalescing(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	first := NewRequest9x9()
+	first.MaxVisits = 10000
+	first.Moves = []Move{{Black, "C3"}}
+	first.AnalyzeTurns = []int{1}
+	done := make(chan error, 1)
+	var firstResponses []AnalysisResponse
+	go func() {
+		var err error
+		firstResponses, err = katago.Analyze([]AnalysisRequest{first})
+		done <- err
+	}()
+	for flightCount(katago) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The mirrored position waits for the query of the first call
+	second := first
+	second.ID = "second"
+	second.Moves = []Move{{Black, "G3"}}
+	responses, err := katago.Analyze([]AnalysisRequest{second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].ID != "second" || responses[0].TurnNumber != 1 {
+		t.Fatalf("Expected one response for the second request, got %v", responses)
+	}
+	if responses[0].RootInfo != firstResponses[0].RootInfo {
+		t.Errorf("Expected the results of the first query, got %v and %v", responses[0].RootInfo, firstResponses[0].RootInfo)
+	}
+	if flightCount(katago) != 0 {
+		t.Errorf("Expected no running queries, got %d", flightCount(katago))
+	}
+}
+
+func TestAnalyzeCoalescingDifferentSettings(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	first := NewRequest9x9()
+	first.MaxVisits = 10000
+	go katago.Analyze([]AnalysisRequest{first})
+	for flightCount(katago) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	second := first
+	second.ID = "second"
+	second.MaxVisits = 10
+	start := time.Now()
+	if _, err := katago.Analyze([]AnalysisRequest{second}); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Errorf("Expected a request with other settings to be sent on its own, it took %v", elapsed)
+	}
+}
+
+func TestAnalyzeCoalescingTerminated(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	first := NewRequest9x9()
+	first.MaxVisits = 20000
+	first.Moves = []Move{{Black, "C3"}}
+	done := make(chan error, 1)
+	go func() {
+		_, err := katago.Analyze([]AnalysisRequest{first})
+		done <- err
+	}()
+	for !katago.isPending(first.ID) {
+		time.Sleep(time.Millisecond)
+	}
+	second := first
+	second.ID = "second"
+	second.Moves = []Move{{Black, "G3"}}
+	secondDone := make(chan error, 1)
+	var responses []AnalysisResponse
+	go func() {
+		var err error
+		responses, err = katago.Analyze([]AnalysisRequest{second})
+		secondDone <- err
+	}()
+	// Give the second call time to wait for the first query, which is then stopped
+	time.Sleep(100 * time.Millisecond)
+	if err := katago.Terminate(first.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	// The second call did not ask for the search to stop, so it gets a full search of its own
+	if err := <-secondDone; err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].ID != "second" || responses[0].RootInfo.Visits != 20000 {
+		t.Errorf("Expected a full search for the second request, got %v", responses)
+	}
+}
@@ -0,0 +1,108 @@
+package katago
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeConcurrently(t *testing.T) {
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(NewLRUCache(50)))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+
+	const callers = 200
+	vertices := []string{"C3", "G3", "C7", "G7", "E5", "D4"}
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			request := NewRequest9x9()
+			request.MaxVisits = 10
+			// Many of the positions are the same, or mirrored, so the cache and the deduplication are used too
+			request.Moves = []Move{{Black, Vertex(vertices[i%len(vertices)])}}
+			request.AnalyzeTurns = []int{1}
+			var responses []AnalysisResponse
+			var err error
+			switch i % 4 {
+			case 0:
+				var response AnalysisResponse
+				response, err = katago.AnalyzeStream(request, func(AnalysisResponse) {})
+				responses = []AnalysisResponse{response}
+			case 1:
+				request.AnalyzeTurns = []int{0, 1}
+				responses, err = katago.Analyze([]AnalysisRequest{request})
+			default:
+				responses, err = katago.Analyze([]AnalysisRequest{request})
+			}
+			if err != nil {
+				errs <- err
+				return
+			}
+			for _, response := range responses {
+				if response.ID != request.ID || len(response.MoveInfos) == 0 {
+					errs <- fmt.Errorf("caller %d got %v", i, response)
+				}
+			}
+		}()
+	}
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(30 * time.Second):
+		t.Fatal("Expected all the callers to get their responses")
+	}
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestUnknownResponseIsDropped(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	// A response for an ID that nobody is waiting for must not block the read loop
+	for i := range 10 {
+		katago.deliver([]byte(fmt.Sprintf(`{"id":"nobody-%d","turnNumber":0,"moveInfos":[]}`, i)))
+	}
+	if _, err := katago.Analyze([]AnalysisRequest{NewRequest9x9()}); err != nil {
+		t.Errorf("Expected the engine to keep answering, got %v", err)
+	}
+}
+
+func TestTerminateFromInterim(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.MaxVisits = 100000
+	request.ReportDuringSearchEvery = 0.05
+	var once sync.Once
+	done := make(chan error, 1)
+	go func() {
+		_, err := katago.AnalyzeStream(request, func(AnalysisResponse) {
+			// Terminating waits for KataGo to acknowledge it, which needs the read loop to keep running
+			once.Do(func() {
+				if err := katago.Terminate(request.ID); err != nil {
+					t.Errorf("Failed to terminate: %v", err)
+				}
+			})
+		})
+		done <- err
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected terminating from the interim function not to deadlock")
+	}
+}
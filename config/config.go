@@ -0,0 +1,326 @@
+// Package config writes KataGo analysis configuration files from Go values, so that a .cfg file does not
+// need to be shipped or edited by hand
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// WinrateReporting decides from whose point of view KataGo reports winrates and scores
+type WinrateReporting string
+
+// Winrate reporting perspectives
+const (
+	// SideToMove reports the values for the player to move, which is what this module expects
+	SideToMove WinrateReporting = "SIDETOMOVE"
+	Black      WinrateReporting = "BLACK"
+	White      WinrateReporting = "WHITE"
+)
+
+// Config holds the settings of a KataGo analysis configuration file
+type Config struct {
+	// LogDir is the directory for the log files, or empty for no log files
+	LogDir        string
+	LogSearchInfo bool
+	LogToStderr   bool
+
+	// MaxVisits is the default number of visits for each query
+	MaxVisits int
+	// MaxTime is the maximum search time for each query, in seconds, or 0 for no limit
+	MaxTime float64
+
+	// NumAnalysisThreads is how many positions are searched at the same time
+	NumAnalysisThreads int
+	// NumSearchThreads is how many threads search each position
+	NumSearchThreads int
+
+	ReportAnalysisWinratesAs WinrateReporting
+	UseLcbForSelection       bool
+	LcbStdevs                float64
+	MinVisitPropForLCB       float64
+
+	// NNCacheSizePowerOfTwo sets the size of the neural network cache to 2^NNCacheSizePowerOfTwo entries
+	NNCacheSizePowerOfTwo     int
+	NNMaxBatchSize            int
+	NNMutexPoolSizePowerOfTwo int
+	NNRandomize               bool
+
+	// Extra holds any other settings, by key, like "numEigenThreadsPerModel" or "cudaUseFP16"
+	Extra map[string]string
+}
+
+// Default returns the same settings as analysis_example.cfg, without the settings for the Eigen backend
+func Default() Config {
+	return Config{
+		LogDir:                    "analysis_logs",
+		LogSearchInfo:             true,
+		LogToStderr:               true,
+		MaxVisits:                 500,
+		NumAnalysisThreads:        4,
+		NumSearchThreads:          32,
+		ReportAnalysisWinratesAs:  SideToMove,
+		UseLcbForSelection:        true,
+		LcbStdevs:                 5.0,
+		MinVisitPropForLCB:        0.15,
+		NNCacheSizePowerOfTwo:     20,
+		NNMaxBatchSize:            16,
+		NNMutexPoolSizePowerOfTwo: 16,
+		NNRandomize:               true,
+	}
+}
+
+// CacheSizePowerOfTwo returns the smallest power of two that holds the given number of neural network
+// cache entries, for NNCacheSizePowerOfTwo
+func CacheSizePowerOfTwo(entries int) int {
+	power := 0
+	for 1<<power < entries {
+		power++
+	}
+	return power
+}
+
+// Validate checks that the settings are within the ranges that KataGo accepts
+func (c Config) Validate() error {
+	switch c.ReportAnalysisWinratesAs {
+	case SideToMove, Black, White, "":
+	default:
+		return fmt.Errorf("invalid reportAnalysisWinratesAs: %q", c.ReportAnalysisWinratesAs)
+	}
+	switch {
+	case c.MaxVisits < 0:
+		return fmt.Errorf("maxVisits must not be negative, got %d", c.MaxVisits)
+	case c.MaxTime < 0:
+		return fmt.Errorf("maxTime must not be negative, got %v", c.MaxTime)
+	case c.NumAnalysisThreads < 1:
+		return fmt.Errorf("numAnalysisThreads must be at least 1, got %d", c.NumAnalysisThreads)
+	case c.NumSearchThreads < 1:
+		return fmt.Errorf("numSearchThreads must be at least 1, got %d", c.NumSearchThreads)
+	case c.NNCacheSizePowerOfTwo < 0 || c.NNCacheSizePowerOfTwo > 48:
+		return fmt.Errorf("nnCacheSizePowerOfTwo must be between 0 and 48, got %d", c.NNCacheSizePowerOfTwo)
+	case c.NNMaxBatchSize < 1:
+		return fmt.Errorf("nnMaxBatchSize must be at least 1, got %d", c.NNMaxBatchSize)
+	case c.NNMutexPoolSizePowerOfTwo < 0 || c.NNMutexPoolSizePowerOfTwo > 24:
+		return fmt.Errorf("nnMutexPoolSizePowerOfTwo must be between 0 and 24, got %d", c.NNMutexPoolSizePowerOfTwo)
+	}
+	for key, value := range c.Extra {
+		if key == "" || strings.ContainsAny(key, " =#\n") {
+			return fmt.Errorf("invalid setting name: %q", key)
+		}
+		if strings.ContainsAny(value, "#\n") {
+			return fmt.Errorf("invalid value for %s: %q", key, value)
+		}
+	}
+	return nil
+}
+
+// formatFloat formats a float so that it is always read back as a float
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
+
+// WriteTo writes the configuration file
+func (c Config) WriteTo(w io.Writer) (int64, error) {
+	if err := c.Validate(); err != nil {
+		return 0, err
+	}
+	var sb strings.Builder
+	section := func(comment string) {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("# " + comment + "\n")
+	}
+	set := func(key, value string) {
+		sb.WriteString(key + " = " + value + "\n")
+	}
+
+	section("Logging")
+	if c.LogDir != "" {
+		set("logDir", c.LogDir)
+	}
+	set("logSearchInfo", strconv.FormatBool(c.LogSearchInfo))
+	set("logToStderr", strconv.FormatBool(c.LogToStderr))
+
+	section("Search limits")
+	if c.MaxVisits > 0 {
+		set("maxVisits", strconv.Itoa(c.MaxVisits))
+	}
+	if c.MaxTime > 0 {
+		set("maxTime", formatFloat(c.MaxTime))
+	}
+
+	section("Threads and batching")
+	set("numAnalysisThreads", strconv.Itoa(c.NumAnalysisThreads))
+	set("numSearchThreads", strconv.Itoa(c.NumSearchThreads))
+
+	section("Reporting and selection")
+	reporting := c.ReportAnalysisWinratesAs
+	if reporting == "" {
+		reporting = SideToMove
+	}
+	set("reportAnalysisWinratesAs", string(reporting))
+	set("useLcbForSelection", strconv.FormatBool(c.UseLcbForSelection))
+	if c.UseLcbForSelection {
+		set("lcbStdevs", formatFloat(c.LcbStdevs))
+		set("minVisitPropForLCB", formatFloat(c.MinVisitPropForLCB))
+	}
+
+	section("Neural network cache and batching")
+	set("nnCacheSizePowerOfTwo", strconv.Itoa(c.NNCacheSizePowerOfTwo))
+	set("nnMaxBatchSize", strconv.Itoa(c.NNMaxBatchSize))
+	set("nnMutexPoolSizePowerOfTwo", strconv.Itoa(c.NNMutexPoolSizePowerOfTwo))
+	set("nnRandomize", strconv.FormatBool(c.NNRandomize))
+
+	if len(c.Extra) > 0 {
+		section("Other settings")
+		keys := make([]string, 0, len(c.Extra))
+		for key := range c.Extra {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			set(key, c.Extra[key])
+		}
+	}
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
+
+// String returns the contents of the configuration file
+func (c Config) String() string {
+	var sb strings.Builder
+	if _, err := c.WriteTo(&sb); err != nil {
+		return "# " + err.Error() + "\n"
+	}
+	return sb.String()
+}
+
+// WriteFile writes the configuration to a file
+func (c Config) WriteFile(filename string) error {
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// WriteTemp writes the configuration to a new temporary file and returns its name.
+// The caller should remove the file when KataGo has been closed.
+func (c Config) WriteTemp() (string, error) {
+	f, err := os.CreateTemp("", "katago-*.cfg")
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// Parse reads a configuration file. Settings that do not have a field in Config are kept in Extra.
+// Settings that are missing keep their values from Default.
+func Parse(r io.Reader) (Config, error) {
+	c := Default()
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return Config{}, fmt.Errorf("line %d: expected key = value, got %q", lineNumber, line)
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if err := c.set(key, value); err != nil {
+			return Config{}, fmt.Errorf("line %d: %v", lineNumber, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Config{}, err
+	}
+	return c, nil
+}
+
+// ParseFile reads a configuration file
+func ParseFile(filename string) (Config, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return Config{}, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// set sets one setting by its key
+func (c *Config) set(key, value string) error {
+	var err error
+	switch key {
+	case "logDir":
+		c.LogDir = value
+	case "logSearchInfo":
+		c.LogSearchInfo, err = strconv.ParseBool(value)
+	case "logToStderr":
+		c.LogToStderr, err = strconv.ParseBool(value)
+	case "maxVisits":
+		c.MaxVisits, err = strconv.Atoi(value)
+	case "maxTime":
+		c.MaxTime, err = strconv.ParseFloat(value, 64)
+	case "numAnalysisThreads":
+		c.NumAnalysisThreads, err = strconv.Atoi(value)
+	case "numSearchThreads":
+		c.NumSearchThreads, err = strconv.Atoi(value)
+	case "reportAnalysisWinratesAs":
+		c.ReportAnalysisWinratesAs = WinrateReporting(strings.ToUpper(value))
+	case "useLcbForSelection":
+		c.UseLcbForSelection, err = strconv.ParseBool(value)
+	case "lcbStdevs":
+		c.LcbStdevs, err = strconv.ParseFloat(value, 64)
+	case "minVisitPropForLCB":
+		c.MinVisitPropForLCB, err = strconv.ParseFloat(value, 64)
+	case "nnCacheSizePowerOfTwo":
+		c.NNCacheSizePowerOfTwo, err = strconv.Atoi(value)
+	case "nnMaxBatchSize":
+		c.NNMaxBatchSize, err = strconv.Atoi(value)
+	case "nnMutexPoolSizePowerOfTwo":
+		c.NNMutexPoolSizePowerOfTwo, err = strconv.Atoi(value)
+	case "nnRandomize":
+		c.NNRandomize, err = strconv.ParseBool(value)
+	default:
+		if c.Extra == nil {
+			c.Extra = make(map[string]string)
+		}
+		c.Extra[key] = value
+	}
+	if err != nil {
+		return fmt.Errorf("invalid value for %s: %q", key, value)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseExample(t *testing.T) {
+	c, err := ParseFile("../analysis_example.cfg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.NumSearchThreads != 32 || c.MaxVisits != 500 || c.ReportAnalysisWinratesAs != SideToMove {
+		t.Errorf("Unexpected settings: %+v", c)
+	}
+	if c.Extra["numEigenThreadsPerModel"] != "12" {
+		t.Errorf("Expected numEigenThreadsPerModel to be kept in Extra, got %v", c.Extra)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := Default()
+	c.MaxTime = 2
+	c.NumAnalysisThreads = 2
+	c.NNCacheSizePowerOfTwo = CacheSizePowerOfTwo(100000)
+	c.Extra = map[string]string{"cudaUseFP16": "true"}
+	s := c.String()
+	if !strings.Contains(s, "maxTime = 2.0\n") || !strings.Contains(s, "nnCacheSizePowerOfTwo = 17\n") {
+		t.Errorf("Unexpected configuration file:\n%s", s)
+	}
+	parsed, err := Parse(strings.NewReader(s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.String() != s {
+		t.Errorf("Expected the same configuration after parsing, got:\n%s", parsed.String())
+	}
+}
+
+func TestValidate(t *testing.T) {
+	c := Default()
+	c.NumSearchThreads = 0
+	if err := c.Validate(); err == nil {
+		t.Errorf("Expected an error for 0 search threads")
+	}
+	c = Default()
+	c.ReportAnalysisWinratesAs = "SOMEONE"
+	if _, err := c.WriteTo(&strings.Builder{}); err == nil {
+		t.Errorf("Expected an error for invalid winrate reporting")
+	}
+	c = Default()
+	c.Extra = map[string]string{"bad key": "1"}
+	if err := c.Validate(); err == nil {
+		t.Errorf("Expected an error for an invalid key")
+	}
+}
+
+func TestWriteTemp(t *testing.T) {
+	filename, err := Default().WriteTemp()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(filename)
+	c, err := ParseFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.String() != Default().String() {
+		t.Errorf("Expected the default configuration, got:\n%s", c.String())
+	}
+}
+
+func TestCacheSizePowerOfTwo(t *testing.T) {
+	for entries, expected := range map[int]int{1: 0, 2: 1, 1000: 10, 1 << 20: 20} {
+		if power := CacheSizePowerOfTwo(entries); power != expected {
+			t.Errorf("Expected %d for %d entries, got %d", expected, entries, power)
+		}
+	}
+}
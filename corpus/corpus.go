@@ -0,0 +1,87 @@
+// Package corpus gathers statistics over collections of analyzed games, like the games of a club or of the
+// students of a teaching program: the accuracy by rank, the winrate of each opening and the kinds of blunders.
+// It also groups the games by opening and searches them for local patterns.
+package corpus
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+	"github.com/xyproto/katago/sgf"
+)
+
+// Game is a game of a collection, with its graded moves
+type Game struct {
+	Position katago.Position
+	// BlackRank and WhiteRank are the ranks of the players, like "5k" or "3d", or empty if they are not known
+	BlackRank string
+	WhiteRank string
+	// Result is the result in SGF notation, like "B+3.5", "W+R" or "0" for a draw
+	Result string
+	// Graded are the graded moves, as returned by GradeMoves
+	Graded []katago.GradedMove
+}
+
+// GameFromSGF returns the game of an SGF file, with the ranks and the result from the root node.
+// The moves still need to be analyzed and graded.
+func GameFromSGF(root *sgf.Node) (Game, error) {
+	p, err := sgf.Position(root)
+	if err != nil {
+		return Game{}, err
+	}
+	return Game{Position: p, BlackRank: root.Get("BR"), WhiteRank: root.Get("WR"), Result: root.Get("RE")}, nil
+}
+
+// Winner returns the color of the winner of the game, "B" or "W", or empty for a draw, and false if the
+// result is not known
+func (g Game) Winner() (string, bool) {
+	result := strings.ToUpper(strings.TrimSpace(g.Result))
+	switch {
+	case strings.HasPrefix(result, "B+"):
+		return "B", true
+	case strings.HasPrefix(result, "W+"):
+		return "W", true
+	case result == "0" || result == "DRAW" || result == "JIGO":
+		return "", true
+	}
+	return "", false
+}
+
+// NormalizeOpening returns the moves turned by the rotation or reflection of the board that gives the
+// smallest list of vertices, so that the same opening played in another orientation gives the same moves
+func NormalizeOpening(moves []katago.Move, width, height int) ([]katago.Move, error) {
+	points := make([]board.Point, len(moves))
+	for i, move := range moves {
+		p, err := board.ParseVertex(string(move.Vertex), width, height)
+		if err != nil {
+			return nil, err
+		}
+		points[i] = p
+	}
+	var best []string
+	for _, s := range board.Symmetries(width, height) {
+		vertices := make([]string, len(points))
+		for i, p := range points {
+			vertices[i] = board.Vertex(s.Apply(p, width, height), height)
+		}
+		if best == nil || slices.Compare(vertices, best) < 0 {
+			best = vertices
+		}
+	}
+	normalized := make([]katago.Move, len(moves))
+	for i, move := range moves {
+		normalized[i] = katago.Move{Color: katago.Color(strings.ToUpper(string(move.Color))), Vertex: katago.Vertex(best[i])}
+	}
+	return normalized, nil
+}
+
+// formatMoves formats moves as their vertices, like "Q16 D4 R4"
+func formatMoves(moves []katago.Move) string {
+	vertices := make([]string, len(moves))
+	for i, move := range moves {
+		vertices[i] = string(move.Vertex)
+	}
+	return strings.Join(vertices, " ")
+}
@@ -0,0 +1,64 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/sgf"
+)
+
+func TestGameFromSGF(t *testing.T) {
+	root, err := sgf.ParseGame("(;SZ[9]KM[7]BR[5k]WR[2d]RE[W+R];B[ee];W[cc])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := GameFromSGF(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.BlackRank != "5k" || g.WhiteRank != "2d" || len(g.Position.Moves) != 2 {
+		t.Errorf("Expected the ranks and the moves of the game, got %+v", g)
+	}
+	if winner, ok := g.Winner(); !ok || winner != "W" {
+		t.Errorf("Expected white to win, got %q and %v", winner, ok)
+	}
+}
+
+func TestWinner(t *testing.T) {
+	cases := []struct {
+		result, winner string
+		known          bool
+	}{
+		{"B+3.5", "B", true},
+		{"w+t", "W", true},
+		{"0", "", true},
+		{"Draw", "", true},
+		{"Void", "", false},
+		{"", "", false},
+	}
+	for _, c := range cases {
+		if winner, ok := (Game{Result: c.result}).Winner(); winner != c.winner || ok != c.known {
+			t.Errorf("Expected %q and %v for %q, got %q and %v", c.winner, c.known, c.result, winner, ok)
+		}
+	}
+}
+
+func TestNormalizeOpening(t *testing.T) {
+	a := []katago.Move{{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "D4"}, {Color: "B", Vertex: "R4"}}
+	// The same opening, reflected along the diagonal and then rotated
+	b := []katago.Move{{Color: "b", Vertex: "D4"}, {Color: "W", Vertex: "Q16"}, {Color: "B", Vertex: "C16"}}
+	na, err := NormalizeOpening(a, 19, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nb, err := NormalizeOpening(b, 19, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if formatMoves(na) != formatMoves(nb) || na[0].Color != katago.Black {
+		t.Errorf("Expected the same opening, got %v and %v", na, nb)
+	}
+	if _, err := NormalizeOpening([]katago.Move{{Color: "B", Vertex: "Z99"}}, 19, 19); err == nil {
+		t.Error("Expected an error for an invalid vertex")
+	}
+}
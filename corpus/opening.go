@@ -0,0 +1,107 @@
+package corpus
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/xyproto/katago"
+)
+
+// OpeningLine is an opening that was played in a collection of games, for an opening explorer
+type OpeningLine struct {
+	// Moves are the first moves of the games, normalized by NormalizeOpening
+	Moves []katago.Move `json:"moves"`
+	Games int           `json:"games"`
+	// Frequency is the fraction of the games, from 0 to 1, that started with this line
+	Frequency float64 `json:"frequency"`
+	// Position is the position after the line, with the board size, rules and komi of the first game that played it
+	Position katago.Position `json:"-"`
+	// Evaluated is set by EvaluateOpenings, together with black's winrate and score lead after the line,
+	// and the move that KataGo prefers
+	Evaluated      bool    `json:"evaluated"`
+	BlackWinrate   float64 `json:"blackWinrate,omitempty"`
+	BlackScoreLead float64 `json:"blackScoreLead,omitempty"`
+	BestMove       string  `json:"bestMove,omitempty"`
+}
+
+// String returns the moves of the line, like "Q16 D4 R4"
+func (l OpeningLine) String() string {
+	return formatMoves(l.Moves)
+}
+
+// Openings groups the games by their first n moves, normalized by NormalizeOpening, and returns the lines
+// with how often each was played, the most played first. Games with fewer than n moves are left out, and
+// games on different board sizes are never in the same line.
+func Openings(games []katago.Position, n int) ([]OpeningLine, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("the number of moves must be positive, got %d", n)
+	}
+	lines := make(map[string]*OpeningLine)
+	total := 0
+	for i, g := range games {
+		if len(g.Moves) < n {
+			continue
+		}
+		moves, err := NormalizeOpening(g.Moves[:n], g.BoardXSize, g.BoardYSize)
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", i+1, err)
+		}
+		total++
+		key := fmt.Sprintf("%dx%d %s", g.BoardXSize, g.BoardYSize, formatMoves(moves))
+		line, ok := lines[key]
+		if !ok {
+			p := g
+			p.Moves = moves
+			line = &OpeningLine{Moves: moves, Position: p}
+			lines[key] = line
+		}
+		line.Games++
+	}
+	var sorted []OpeningLine
+	for _, line := range lines {
+		line.Frequency = float64(line.Games) / float64(total)
+		sorted = append(sorted, *line)
+	}
+	slices.SortFunc(sorted, func(a, b OpeningLine) int {
+		if c := cmp.Compare(b.Games, a.Games); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.String(), b.String())
+	})
+	return sorted, nil
+}
+
+// EvaluateOpenings analyzes the position after each of the lines with the given number of visits, and sets
+// the evaluation of the lines. All the positions are sent to KataGo at once.
+func EvaluateOpenings(k *katago.KataGo, lines []OpeningLine, visits int) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	requests := make([]katago.AnalysisRequest, len(lines))
+	for i, line := range lines {
+		requests[i] = line.Position.Request(fmt.Sprintf("opening-%d", i))
+		requests[i].MaxVisits = visits
+	}
+	responses, err := k.Analyze(requests)
+	if err != nil {
+		return fmt.Errorf("failed to evaluate the openings: %w", err)
+	}
+	if len(responses) != len(lines) {
+		return errors.New("expected one response for each opening")
+	}
+	for i, response := range responses {
+		if response.RootInfo.CurrentPlayer == "" {
+			response.RootInfo.CurrentPlayer = lines[i].Position.ToPlay()
+		}
+		line := &lines[i]
+		line.Evaluated = true
+		line.BlackWinrate = response.WinrateFor(katago.Black)
+		line.BlackScoreLead = response.ScoreFor(katago.Black)
+		if best, ok := response.BestMove(); ok {
+			line.BestMove = best.Move
+		}
+	}
+	return nil
+}
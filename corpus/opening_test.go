@@ -0,0 +1,52 @@
+package corpus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestOpenings(t *testing.T) {
+	games := []katago.Position{
+		newTestGame("", "", "", "E5", "C3", "G7").Position,
+		newTestGame("", "", "", "E5", "G3").Position,
+		newTestGame("", "", "", "C3", "E5").Position,
+		newTestGame("", "", "", "E5").Position,
+	}
+	lines, err := Openings(games, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %v", lines)
+	}
+	if lines[0].Games != 2 || lines[0].Frequency < 0.66 || lines[0].Frequency > 0.67 || lines[0].Moves[0].Vertex != "E5" {
+		t.Errorf("Expected E5 and a corner move in 2 of 3 games, got %+v", lines[0])
+	}
+	if len(lines[0].Position.Moves) != 2 || lines[0].Position.BoardXSize != 9 {
+		t.Errorf("Expected the position after the line, got %+v", lines[0].Position)
+	}
+	if _, err := Openings(games, 0); err == nil {
+		t.Error("Expected an error for no moves")
+	}
+
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	if err := EvaluateOpenings(k, lines, 10); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		if !line.Evaluated || line.BlackWinrate <= 0 || line.BestMove == "" {
+			t.Errorf("Expected an evaluation of %s, got %+v", line, line)
+		}
+	}
+	invalid := []OpeningLine{lines[0]}
+	invalid[0].Position.Komi = 7.25
+	if err := EvaluateOpenings(k, invalid, 10); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
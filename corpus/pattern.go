@@ -0,0 +1,282 @@
+package corpus
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// Continuations that are not a point of a pattern
+const (
+	// PassContinuation is a pass after the pattern
+	PassContinuation = "pass"
+	// TenukiContinuation is a move outside of the pattern
+	TenukiContinuation = "tenuki"
+)
+
+// Pattern is a local shape, like a joseki shape or a tesuji, that Find searches for in every rotation and
+// reflection. It is written as rows, where X is a stone of the player to move, O a stone of the opponent,
+// . an empty point, ? any point on the board and # a point outside of the board, for shapes at the edge.
+type Pattern struct {
+	rows     []string
+	variants []patternVariant
+}
+
+// patternVariant is a pattern turned by one or more symmetries that give the same shape, with the maps
+// from the points of the turned pattern back to the points of the pattern as it was written
+type patternVariant struct {
+	width, height int
+	cells         []byte
+	toOriginal    []map[board.Point]board.Point
+}
+
+// PatternMatch is a position where a pattern was found
+type PatternMatch struct {
+	// Game is the index of the game, and Turn the number of moves that were played before the position
+	Game int
+	Turn int
+	// Origin is the point on the board of the top left corner of the matched shape, as turned
+	Origin board.Point
+	// Continuation is the next move of the game, as a point of the pattern as it was written, like "b3"
+	// for the second column of the third row, or PassContinuation or TenukiContinuation. It is empty if
+	// the game ended with the position.
+	Continuation string
+}
+
+// ContinuationStats is how often a continuation of a pattern was played, and how KataGo evaluates it
+type ContinuationStats struct {
+	Continuation string
+	Count        int
+	// AveragePointsLost is the average number of points that the continuation lost
+	AveragePointsLost float64
+	// AverageWinrate is the average probability, from 0 to 1, that the player wins after the continuation
+	AverageWinrate float64
+}
+
+// ParsePattern parses a pattern from its rows, which must all have the same length
+func ParsePattern(rows ...string) (*Pattern, error) {
+	if len(rows) == 0 || rows[0] == "" {
+		return nil, fmt.Errorf("a pattern needs at least one point")
+	}
+	width, height := len(rows[0]), len(rows)
+	original := make([]byte, 0, width*height)
+	for y, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("row %d has %d points instead of %d", y+1, len(row), width)
+		}
+		for _, c := range []byte(row) {
+			if !strings.ContainsRune("XO.?#", rune(c)) {
+				return nil, fmt.Errorf("invalid point in pattern: %q", c)
+			}
+		}
+		original = append(original, row...)
+	}
+	p := &Pattern{rows: slices.Clone(rows)}
+	variants := make(map[string]int)
+	// A local shape can be found in every orientation, even on rectangular boards
+	for _, s := range board.Symmetries(1, 1) {
+		w, h := width, height
+		if s.Transposes() {
+			w, h = height, width
+		}
+		cells := make([]byte, w*h)
+		toOriginal := make(map[board.Point]board.Point)
+		for i, c := range original {
+			from := board.Point{X: i % width, Y: i / width}
+			to := s.Apply(from, w, h)
+			cells[to.Y*w+to.X] = c
+			toOriginal[to] = from
+		}
+		key := fmt.Sprintf("%dx%d %s", w, h, cells)
+		if i, ok := variants[key]; ok {
+			p.variants[i].toOriginal = append(p.variants[i].toOriginal, toOriginal)
+			continue
+		}
+		variants[key] = len(p.variants)
+		p.variants = append(p.variants, patternVariant{width: w, height: h, cells: cells, toOriginal: []map[board.Point]board.Point{toOriginal}})
+	}
+	return p, nil
+}
+
+// String returns the rows of the pattern, one for each line
+func (p *Pattern) String() string {
+	return strings.Join(p.rows, "\n")
+}
+
+// matches checks if the variant is found at the given origin, with the given color to move
+func (v patternVariant) matches(b *board.Board, origin board.Point, toPlay board.Color) bool {
+	for i, c := range v.cells {
+		point := board.Point{X: origin.X + i%v.width, Y: origin.Y + i/v.width}
+		if !b.Contains(point) {
+			if c != '#' {
+				return false
+			}
+			continue
+		}
+		stone := b.At(point)
+		switch c {
+		case '#':
+			return false
+		case 'X':
+			if stone != toPlay {
+				return false
+			}
+		case 'O':
+			if stone != toPlay.Opponent() {
+				return false
+			}
+		case '.':
+			if stone != board.Empty {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// continuation returns the name of the next move for a match of the variant at the given origin. Of the
+// points that the move can be in the pattern as it was written, the first one in the alphabet is used,
+// so that the same move in a symmetric pattern always gets the same name.
+func (v patternVariant) continuation(move, origin board.Point) string {
+	if move.IsPass() {
+		return PassContinuation
+	}
+	local := board.Point{X: move.X - origin.X, Y: move.Y - origin.Y}
+	if local.X < 0 || local.Y < 0 || local.X >= v.width || local.Y >= v.height {
+		return TenukiContinuation
+	}
+	var name string
+	for _, toOriginal := range v.toOriginal {
+		p := toOriginal[local]
+		if n := fmt.Sprintf("%c%d", 'a'+p.X, p.Y+1); name == "" || n < name {
+			name = n
+		}
+	}
+	return name
+}
+
+// Find replays the games and returns every position where the pattern is found, with the next move
+func (p *Pattern) Find(games []katago.Position) ([]PatternMatch, error) {
+	var matches []PatternMatch
+	for g, game := range games {
+		initial := game
+		initial.Moves = nil
+		b, err := initial.Board()
+		if err != nil {
+			return nil, fmt.Errorf("game %d: %w", g+1, err)
+		}
+		for turn := 0; turn <= len(game.Moves); turn++ {
+			toPlay := b.ToPlay()
+			next := board.Pass
+			if turn < len(game.Moves) {
+				move := game.Moves[turn]
+				if toPlay, err = board.ParseColor(string(move.Color)); err != nil {
+					return nil, fmt.Errorf("game %d: %w", g+1, err)
+				}
+				if next, err = b.ParseVertex(string(move.Vertex)); err != nil {
+					return nil, fmt.Errorf("game %d: %w", g+1, err)
+				}
+			}
+			for _, v := range p.variants {
+				for y := 1 - v.height; y < b.Height(); y++ {
+					for x := 1 - v.width; x < b.Width(); x++ {
+						origin := board.Point{X: x, Y: y}
+						if !v.matches(b, origin, toPlay) {
+							continue
+						}
+						match := PatternMatch{Game: g, Turn: turn, Origin: origin}
+						if turn < len(game.Moves) {
+							match.Continuation = v.continuation(next, origin)
+						}
+						matches = append(matches, match)
+					}
+				}
+			}
+			if turn < len(game.Moves) {
+				if err := b.Play(toPlay, next); err != nil {
+					return nil, fmt.Errorf("game %d, move %d at %s: %w", g+1, turn+1, game.Moves[turn].Vertex, err)
+				}
+			}
+		}
+	}
+	return matches, nil
+}
+
+// EvaluateContinuations analyzes the positions before and after the continuations of the matches, with the
+// given number of visits, and returns how often each continuation was played and how KataGo evaluates it,
+// the most played first. Matches at the end of a game are left out. Each game is sent to KataGo as one query.
+func EvaluateContinuations(k *katago.KataGo, games []katago.Position, matches []PatternMatch, visits int) ([]ContinuationStats, error) {
+	turns := make(map[int][]int)
+	for _, m := range matches {
+		if m.Continuation == "" {
+			continue
+		}
+		if m.Game < 0 || m.Game >= len(games) || m.Turn >= len(games[m.Game].Moves) {
+			return nil, fmt.Errorf("match at turn %d of game %d is not in the games", m.Turn, m.Game+1)
+		}
+		turns[m.Game] = append(turns[m.Game], m.Turn, m.Turn+1)
+	}
+	var requests []katago.AnalysisRequest
+	for g, analyze := range turns {
+		slices.Sort(analyze)
+		request := games[g].Request(fmt.Sprintf("pattern-%d", g))
+		request.MaxVisits = visits
+		request.AnalyzeTurns = slices.Compact(analyze)
+		requests = append(requests, request)
+	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	responses, err := k.Analyze(requests)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate the continuations: %w", err)
+	}
+	byTurn := make(map[string]katago.AnalysisResponse)
+	for _, response := range responses {
+		byTurn[fmt.Sprintf("%s %d", response.ID, response.TurnNumber)] = response
+	}
+	stats := make(map[string]*ContinuationStats)
+	for _, m := range matches {
+		if m.Continuation == "" {
+			continue
+		}
+		id := fmt.Sprintf("pattern-%d", m.Game)
+		before, ok := byTurn[fmt.Sprintf("%s %d", id, m.Turn)]
+		if !ok {
+			return nil, fmt.Errorf("no analysis of turn %d of game %d", m.Turn, m.Game+1)
+		}
+		after, ok := byTurn[fmt.Sprintf("%s %d", id, m.Turn+1)]
+		if !ok {
+			return nil, fmt.Errorf("no analysis of turn %d of game %d", m.Turn+1, m.Game+1)
+		}
+		if before.RootInfo.CurrentPlayer == "" {
+			before.RootInfo.CurrentPlayer = string(games[m.Game].Moves[m.Turn].Color)
+		}
+		delta := katago.Compare(before, after)
+		s, ok := stats[m.Continuation]
+		if !ok {
+			s = &ContinuationStats{Continuation: m.Continuation}
+			stats[m.Continuation] = s
+		}
+		s.Count++
+		s.AveragePointsLost += delta.PointsLost()
+		s.AverageWinrate += delta.WinrateAfter
+	}
+	var sorted []ContinuationStats
+	for _, s := range stats {
+		s.AveragePointsLost /= float64(s.Count)
+		s.AverageWinrate /= float64(s.Count)
+		sorted = append(sorted, *s)
+	}
+	slices.SortFunc(sorted, func(a, b ContinuationStats) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Continuation, b.Continuation)
+	})
+	return sorted, nil
+}
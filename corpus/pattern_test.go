@@ -0,0 +1,110 @@
+package corpus
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+func TestParsePattern(t *testing.T) {
+	for _, rows := range [][]string{nil, {""}, {"..", "."}, {".Z"}} {
+		if _, err := ParsePattern(rows...); err == nil {
+			t.Errorf("Expected an error for %q", rows)
+		}
+	}
+	p, err := ParsePattern("...", ".O.", "...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.variants) != 1 || len(p.variants[0].toOriginal) != 8 {
+		t.Errorf("Expected one variant for a symmetric pattern, got %d", len(p.variants))
+	}
+	if p.String() != "...\n.O.\n..." {
+		t.Errorf("Expected the rows of the pattern, got %q", p.String())
+	}
+	p, err = ParsePattern("##", "#X", "#.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.variants) != 8 {
+		t.Errorf("Expected 8 variants, got %d", len(p.variants))
+	}
+}
+
+func TestFind(t *testing.T) {
+	games := []katago.Position{
+		newTestGame("", "", "", "E5", "E4").Position,
+		newTestGame("", "", "", "E5", "F5").Position,
+		newTestGame("", "", "", "E5", "C3").Position,
+	}
+	p, err := ParsePattern("...", ".O.", "...")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, err := p.Find(games)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []PatternMatch{
+		{Game: 0, Turn: 1, Origin: board.Point{X: 3, Y: 3}, Continuation: "a2"},
+		{Game: 1, Turn: 1, Origin: board.Point{X: 3, Y: 3}, Continuation: "a2"},
+		{Game: 2, Turn: 1, Origin: board.Point{X: 3, Y: 3}, Continuation: TenukiContinuation},
+		{Game: 2, Turn: 2, Origin: board.Point{X: 1, Y: 5}},
+	}
+	if len(matches) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, matches)
+	}
+	for i, m := range matches {
+		if m != expected[i] {
+			t.Errorf("Expected %+v, got %+v", expected[i], m)
+		}
+	}
+
+	// A stone in the corner of the board
+	p, err = ParsePattern("#.", "#O", "##")
+	if err != nil {
+		t.Fatal(err)
+	}
+	corner := []katago.Position{newTestGame("", "", "", "A1", "E5").Position}
+	if matches, err = p.Find(corner); err != nil {
+		t.Fatal(err)
+	}
+	// The shape is found along both edges
+	if len(matches) != 2 || matches[0].Origin != (board.Point{X: -1, Y: 7}) || matches[1].Origin != (board.Point{X: -1, Y: 8}) {
+		t.Fatalf("Expected the stone in the corner twice, got %+v", matches)
+	}
+	for _, m := range matches {
+		if m.Turn != 1 || m.Continuation != TenukiContinuation {
+			t.Errorf("Expected a tenuki after the first move, got %+v", m)
+		}
+	}
+
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	stats, err := EvaluateContinuations(k, games, expected, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 2 || stats[0].Continuation != "a2" || stats[0].Count != 2 || stats[1].Continuation != TenukiContinuation {
+		t.Fatalf("Expected the attachment twice and a tenuki, got %+v", stats)
+	}
+	for _, s := range stats {
+		if s.AverageWinrate <= 0 || s.AverageWinrate >= 1 || s.AveragePointsLost < 0 {
+			t.Errorf("Expected an evaluation, got %+v", s)
+		}
+	}
+	invalid := []katago.Position{games[0]}
+	invalid[0].Komi = 7.25
+	if _, err := EvaluateContinuations(k, invalid, expected[:1], 10); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	occupied := []katago.Position{newTestGame("", "", "", "E5", "E5").Position}
+	if _, err := p.Find(occupied); !errors.Is(err, board.ErrOccupied) {
+		t.Errorf("Expected ErrOccupied, got %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+package corpus
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// DefaultOpeningMoves is how many moves identify the opening of a game in the statistics
+const DefaultOpeningMoves = 4
+
+// LocalDistance is the largest distance between a played move and the move that KataGo prefers, along the
+// rows or the columns, for a blunder to be local
+var LocalDistance = 2
+
+// BlunderKind is what kind of mistake a blunder was
+type BlunderKind int
+
+// Blunder kinds
+const (
+	// LocalBlunder is a blunder close to the move that KataGo prefers, like a misread fight or a wrong shape
+	LocalBlunder BlunderKind = iota
+	// DirectionBlunder is a blunder in another part of the board, like playing in the wrong area or tenuki
+	DirectionBlunder
+	// PassBlunder is passing, or not passing, when the other was much better
+	PassBlunder
+)
+
+// BlunderKinds lists all blunder kinds
+var BlunderKinds = []BlunderKind{LocalBlunder, DirectionBlunder, PassBlunder}
+
+// String returns the name of the blunder kind
+func (k BlunderKind) String() string {
+	switch k {
+	case LocalBlunder:
+		return "Local"
+	case DirectionBlunder:
+		return "Direction"
+	case PassBlunder:
+		return "Pass"
+	}
+	return fmt.Sprintf("BlunderKind(%d)", int(k))
+}
+
+// blunderKind returns the kind of a blunder, from where it was played compared to the move that KataGo prefers
+func blunderKind(move katago.GradedMove, width, height int) (BlunderKind, error) {
+	played, err := board.ParseVertex(move.Move, width, height)
+	if err != nil {
+		return 0, err
+	}
+	best, err := board.ParseVertex(move.BestMove, width, height)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case played.IsPass() || best.IsPass():
+		return PassBlunder, nil
+	case max(abs(played.X-best.X), abs(played.Y-best.Y)) <= LocalDistance:
+		return LocalBlunder, nil
+	}
+	return DirectionBlunder, nil
+}
+
+// abs returns the absolute value of an integer
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// RankAccuracy is the accuracy of the players of one rank
+type RankAccuracy struct {
+	Rank katago.Rank
+	// Players is the number of games that a player of this rank played in
+	Players           int
+	Moves             int
+	Accuracy          float64
+	AveragePointsLost float64
+}
+
+// OpeningWinrate is how often black won with one opening
+type OpeningWinrate struct {
+	// Opening is the first moves of the games, normalized by NormalizeOpening, like "Q16 D4 R4 D16"
+	Opening string
+	Games   int
+	// BlackWins and Draws count the games with a known result
+	BlackWins int
+	Draws     int
+	Decided   int
+}
+
+// BlackWinrate returns the fraction of the games with a known result that black won, where a draw counts as half
+func (o OpeningWinrate) BlackWinrate() float64 {
+	if o.Decided == 0 {
+		return 0
+	}
+	return (float64(o.BlackWins) + 0.5*float64(o.Draws)) / float64(o.Decided)
+}
+
+// BlunderCount is how many blunders of one kind were played in one phase of the game
+type BlunderCount struct {
+	Kind  BlunderKind
+	Phase katago.Phase
+	Count int
+}
+
+// blunderKey is the kind and the phase of a blunder
+type blunderKey struct {
+	kind  BlunderKind
+	phase katago.Phase
+}
+
+// Stats are the statistics of a collection of games. Games are added with Add.
+type Stats struct {
+	// OpeningMoves is how many moves identify the opening of a game
+	OpeningMoves int
+	games        int
+	ranks        map[katago.Rank]*katago.PlayerSummary
+	players      map[katago.Rank]int
+	openings     map[string]*OpeningWinrate
+	blunders     map[blunderKey]int
+}
+
+// NewStats returns empty statistics, where the openings are identified by DefaultOpeningMoves moves
+func NewStats() *Stats {
+	return &Stats{
+		OpeningMoves: DefaultOpeningMoves,
+		ranks:        make(map[katago.Rank]*katago.PlayerSummary),
+		players:      make(map[katago.Rank]int),
+		openings:     make(map[string]*OpeningWinrate),
+		blunders:     make(map[blunderKey]int),
+	}
+}
+
+// Games returns the number of games that were added
+func (s *Stats) Games() int {
+	return s.games
+}
+
+// Add adds a game to the statistics. Players with a rank that can not be parsed do not count for the
+// accuracy by rank, and games that are shorter than OpeningMoves do not count for the openings.
+func (s *Stats) Add(g Game) error {
+	p := g.Position
+	phases, err := p.Phases()
+	if err != nil {
+		return err
+	}
+	for color, summary := range katago.SummarizeGrades(g.Graded) {
+		rankString := g.BlackRank
+		if color == "W" {
+			rankString = g.WhiteRank
+		}
+		rank, err := katago.ParseRank(rankString)
+		if err != nil {
+			continue
+		}
+		total, ok := s.ranks[rank]
+		if !ok {
+			total = &katago.PlayerSummary{Counts: make(map[katago.Grade]int)}
+			s.ranks[rank] = total
+		}
+		total.Moves += summary.Moves
+		total.TotalPointsLost += summary.TotalPointsLost
+		for grade, count := range summary.Counts {
+			total.Counts[grade] += count
+		}
+		s.players[rank]++
+	}
+	if len(p.Moves) >= s.OpeningMoves && s.OpeningMoves > 0 {
+		moves, err := NormalizeOpening(p.Moves[:s.OpeningMoves], p.BoardXSize, p.BoardYSize)
+		if err != nil {
+			return err
+		}
+		key := formatMoves(moves)
+		o, ok := s.openings[key]
+		if !ok {
+			o = &OpeningWinrate{Opening: key}
+			s.openings[key] = o
+		}
+		o.Games++
+		if winner, ok := g.Winner(); ok {
+			o.Decided++
+			switch winner {
+			case "B":
+				o.BlackWins++
+			case "":
+				o.Draws++
+			}
+		}
+	}
+	for _, move := range g.Graded {
+		if move.Grade < katago.Blunder || move.Turn < 0 || move.Turn >= len(phases) {
+			continue
+		}
+		kind, err := blunderKind(move, p.BoardXSize, p.BoardYSize)
+		if err != nil {
+			return err
+		}
+		s.blunders[blunderKey{kind, phases[move.Turn]}]++
+	}
+	s.games++
+	return nil
+}
+
+// AccuracyByRank returns the accuracy and the average points lost of the players of each rank, from the
+// weakest rank to the strongest
+func (s *Stats) AccuracyByRank() []RankAccuracy {
+	var accuracies []RankAccuracy
+	for rank, summary := range s.ranks {
+		accuracies = append(accuracies, RankAccuracy{
+			Rank:              rank,
+			Players:           s.players[rank],
+			Moves:             summary.Moves,
+			Accuracy:          summary.Accuracy(),
+			AveragePointsLost: summary.AveragePointsLost(),
+		})
+	}
+	slices.SortFunc(accuracies, func(a, b RankAccuracy) int { return cmp.Compare(a.Rank, b.Rank) })
+	return accuracies
+}
+
+// WinrateByOpening returns how often black won with each opening, the most played opening first
+func (s *Stats) WinrateByOpening() []OpeningWinrate {
+	var openings []OpeningWinrate
+	for _, o := range s.openings {
+		openings = append(openings, *o)
+	}
+	slices.SortFunc(openings, func(a, b OpeningWinrate) int {
+		if c := cmp.Compare(b.Games, a.Games); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Opening, b.Opening)
+	})
+	return openings
+}
+
+// Blunders returns how many blunders of each kind were played in each phase of the game, the most common first
+func (s *Stats) Blunders() []BlunderCount {
+	var counts []BlunderCount
+	for key, count := range s.blunders {
+		counts = append(counts, BlunderCount{Kind: key.kind, Phase: key.phase, Count: count})
+	}
+	slices.SortFunc(counts, func(a, b BlunderCount) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(a.Phase, b.Phase); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Kind, b.Kind)
+	})
+	return counts
+}
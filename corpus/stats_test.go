@@ -0,0 +1,76 @@
+package corpus
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestGame(result, blackRank, whiteRank string, moves ...katago.Vertex) Game {
+	p := katago.NewPosition(9, 9)
+	for i, vertex := range moves {
+		color := katago.Black
+		if i%2 == 1 {
+			color = katago.White
+		}
+		p.Moves = append(p.Moves, katago.Move{Color: color, Vertex: vertex})
+	}
+	return Game{Position: p, Result: result, BlackRank: blackRank, WhiteRank: whiteRank}
+}
+
+func TestStats(t *testing.T) {
+	s := NewStats()
+	s.OpeningMoves = 2
+
+	a := newTestGame("B+2.5", "5k", "3k", "E5", "C3", "G7", "C7")
+	a.Graded = []katago.GradedMove{
+		{Turn: 0, Color: "B", Move: "E5", BestMove: "E5", Grade: katago.Excellent},
+		{Turn: 1, Color: "W", Move: "C3", BestMove: "C4", PointsLost: 7, Grade: katago.Blunder},
+		{Turn: 2, Color: "B", Move: "G7", BestMove: "C7", PointsLost: 8, Grade: katago.Blunder},
+		{Turn: 3, Color: "W", Move: "C7", BestMove: "C7", Grade: katago.Excellent},
+	}
+	// The same opening, rotated
+	b := newTestGame("W+R", "5k", "", "E5", "G3", "C7")
+	b.Graded = []katago.GradedMove{
+		{Turn: 0, Color: "B", Move: "E5", BestMove: "E5", PointsLost: 1, Grade: katago.Good},
+		{Turn: 2, Color: "B", Move: "C7", BestMove: "C6", PointsLost: 9, Grade: katago.Blunder},
+	}
+	c := newTestGame("?", "", "", "E5", "E3")
+	d := newTestGame("", "", "", "E5")
+	for _, g := range []Game{a, b, c, d} {
+		if err := s.Add(g); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s.Games() != 4 {
+		t.Errorf("Expected 4 games, got %d", s.Games())
+	}
+
+	ranks := s.AccuracyByRank()
+	if len(ranks) != 2 || ranks[0].Rank != katago.Kyu(5) || ranks[1].Rank != katago.Kyu(3) {
+		t.Fatalf("Expected 5k and 3k, got %+v", ranks)
+	}
+	if r := ranks[0]; r.Players != 2 || r.Moves != 4 || r.Accuracy != 50 || r.AveragePointsLost != 4.5 {
+		t.Errorf("Unexpected accuracy for 5k: %+v", r)
+	}
+
+	openings := s.WinrateByOpening()
+	if len(openings) != 2 || openings[0].Games != 2 || openings[0].Decided != 2 || openings[0].BlackWinrate() != 0.5 {
+		t.Fatalf("Expected one opening played twice, got %+v", openings)
+	}
+	if o := openings[1]; o.Games != 1 || o.Decided != 0 || o.BlackWinrate() != 0 {
+		t.Errorf("Expected an opening without a known result, got %+v", o)
+	}
+
+	blunders := s.Blunders()
+	if len(blunders) != 2 || blunders[0] != (BlunderCount{Kind: LocalBlunder, Phase: katago.Opening, Count: 2}) {
+		t.Fatalf("Expected 2 local blunders and 1 in the wrong direction, got %+v", blunders)
+	}
+	if blunders[1].Kind != DirectionBlunder || blunders[1].Count != 1 {
+		t.Errorf("Expected a blunder in the wrong direction, got %+v", blunders[1])
+	}
+
+	if err := s.Add(newTestGame("", "", "", "E5", "E5")); err == nil {
+		t.Error("Expected an error for an illegal game")
+	}
+}
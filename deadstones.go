@@ -0,0 +1,62 @@
+package katago
+
+import (
+	"github.com/xyproto/katago/board"
+)
+
+// DeadStoneThreshold is how strongly the opponent must be predicted to own a group before it is considered dead
+var DeadStoneThreshold = 0.5
+
+// DeadGroup is a chain of stones that KataGo considers to be dead
+type DeadGroup struct {
+	Color    board.Color
+	Points   []board.Point
+	Vertices []string
+	// Ownership is the average ownership of the stones, from the point of view of their own color (-1 to 1)
+	Ownership float64
+}
+
+// deadGroups finds the chains of stones that are owned by the opponent, according to the ownership map
+func deadGroups(b *board.Board, ownership *OwnershipMap, threshold float64) []DeadGroup {
+	var groups []DeadGroup
+	visited := make(map[board.Point]bool)
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			p := board.Point{X: x, Y: y}
+			c := b.At(p)
+			if c == board.Empty || visited[p] {
+				continue
+			}
+			points, _ := b.Group(p)
+			total := 0.0
+			for _, q := range points {
+				visited[q] = true
+				total += ownership.For(c, q.X, q.Y)
+			}
+			average := total / float64(len(points))
+			if average > -threshold {
+				continue
+			}
+			group := DeadGroup{Color: c, Points: points, Ownership: average}
+			for _, q := range points {
+				group.Vertices = append(group.Vertices, b.Vertex(q))
+			}
+			groups = append(groups, group)
+		}
+	}
+	return groups
+}
+
+// DeadStones returns the groups of stones that KataGo considers dead in the final position,
+// which is useful for resolving scoring disputes and for removing dead stones after both players have passed
+func (k *KataGo) DeadStones(p Position) ([]DeadGroup, error) {
+	b, err := p.Board()
+	if err != nil {
+		return nil, err
+	}
+	score, err := k.Score(p)
+	if err != nil {
+		return nil, err
+	}
+	return deadGroups(b, score.Ownership, DeadStoneThreshold), nil
+}
@@ -0,0 +1,61 @@
+package katago
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestDeadGroups(t *testing.T) {
+	b, err := board.FromPairs(5, 5, nil, [][2]string{{"B", "B4"}, {"W", "D2"}, {"B", "C4"}})
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	// Black owns the top, white owns the bottom, so the white stone at D2 lives and the black stones live too
+	values := make([]float64, 25)
+	for i := range values {
+		if i < 10 {
+			values[i] = 0.9
+		} else {
+			values[i] = -0.9
+		}
+	}
+	ownership, err := NewOwnershipMap(values, 5, 5, board.Black)
+	if err != nil {
+		t.Fatalf("Failed to create ownership map: %v", err)
+	}
+	if groups := deadGroups(b, ownership, 0.5); len(groups) != 0 {
+		t.Errorf("Expected no dead groups, got %v", groups)
+	}
+	// Now white owns everything, so the two connected black stones are dead
+	for i := range values {
+		values[i] = -0.9
+	}
+	ownership, _ = NewOwnershipMap(values, 5, 5, board.Black)
+	groups := deadGroups(b, ownership, 0.5)
+	if len(groups) != 1 || groups[0].Color != board.Black || len(groups[0].Vertices) != 2 {
+		t.Fatalf("Expected one dead black group of 2 stones, got %v", groups)
+	}
+}
+
+func TestKataGoDeadStones(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	p := Position{
+		Moves:      []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}, {"W", "F4"}},
+		Rules:      "chinese",
+		Komi:       7,
+		BoardXSize: 9,
+		BoardYSize: 9,
+	}
+	groups, err := katago.DeadStones(p)
+	if err != nil {
+		t.Fatalf("Failed to find dead stones: %v", err)
+	}
+	for _, group := range groups {
+		if len(group.Points) == 0 || group.Ownership > -DeadStoneThreshold {
+			t.Errorf("Unexpected dead group %v", group)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package katago
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Position returns the position at the given turn of the request, which is the position after that many moves
+func (r AnalysisRequest) Position(turn int) Position {
+	if turn < 0 || turn > len(r.Moves) {
+		turn = len(r.Moves)
+	}
+	return Position{
+		InitialStones:      r.InitialStones,
+		Moves:              r.Moves[:turn],
+		InitialPlayer:      r.InitialPlayer,
+		Rules:              r.Rules,
+		Komi:               r.Komi,
+		WhiteHandicapBonus: r.WhiteHandicapBonus,
+		BoardXSize:         r.BoardXSize,
+		BoardYSize:         r.BoardYSize,
+	}
+}
+
+// Hash returns a hash of the position, which combines the Zobrist hash of the board with the rules and komi.
+// Positions that are reached by different move orders have the same hash.
+func (p Position) Hash() (board.Hash, error) {
+	b, err := p.Board()
+	if err != nil {
+		return 0, err
+	}
+	return p.hashBoard(b)
+}
+
+// hashBoard combines the Zobrist hash of the given board with the rules and komi of the position
+func (p Position) hashBoard(b *board.Board) (board.Hash, error) {
+	rules, err := p.Rules.Detailed()
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, uint64(b.Hash()))
+	fmt.Fprintf(h, "%s %g %s", rules.Rules(), p.Komi, p.WhiteHandicapBonus)
+	return board.Hash(h.Sum64()), nil
+}
+
+// lastTurn returns the last turn that the request analyzes, which is after all the moves if no turns are given
+func (r AnalysisRequest) lastTurn() int {
+	if len(r.AnalyzeTurns) == 0 {
+		return len(r.Moves)
+	}
+	turn := r.AnalyzeTurns[0]
+	for _, t := range r.AnalyzeTurns[1:] {
+		turn = max(turn, t)
+	}
+	return turn
+}
+
+// Hash returns a hash of a request for a single turn, which is the same for all requests that
+// analyze the same position with the same settings, even if the moves were played in a different order
+func (r AnalysisRequest) Hash() (board.Hash, error) {
+	if len(r.AnalyzeTurns) > 1 {
+		return 0, fmt.Errorf("request %s analyzes %d turns", r.ID, len(r.AnalyzeTurns))
+	}
+	positionHash, err := r.Position(r.lastTurn()).Hash()
+	if err != nil {
+		return 0, err
+	}
+	return r.hashWith(positionHash)
+}
+
+// hashWith combines the hash of a position with the settings of the request that change the response
+func (r AnalysisRequest) hashWith(positionHash board.Hash) (board.Hash, error) {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, uint64(positionHash))
+	fmt.Fprintf(h, "%d %t %t %t %t %t", r.MaxVisits, r.IncludeOwnership, r.IncludePolicy, r.IncludePVVisits,
+		r.IncludeMovesOwnership, r.IncludeOwnershipStdev)
+	if len(r.OverrideSettings) > 0 {
+		// The keys of maps are sorted when marshalled, so equal settings give the same hash
+		settings, err := json.Marshal(r.OverrideSettings)
+		if err != nil {
+			return 0, err
+		}
+		h.Write(settings)
+	}
+	if len(r.AvoidMoves) > 0 || len(r.AllowMoves) > 0 {
+		restrictions, err := json.Marshal([][]MoveRestriction{r.AvoidMoves, r.AllowMoves})
+		if err != nil {
+			return 0, err
+		}
+		h.Write(restrictions)
+	}
+	return board.Hash(h.Sum64()), nil
+}
+
+// withModel combines a hash with the model file, since another network gives other responses for the same request
+func withModel(hash board.Hash, model string) board.Hash {
+	h := fnv.New64a()
+	binary.Write(h, binary.LittleEndian, uint64(hash))
+	h.Write([]byte(model))
+	return board.Hash(h.Sum64())
+}
+
+// deduplicate finds the requests that analyze the same position as an earlier request, also when it is
+// rotated or reflected, and returns a map from the index of each such request to the index of the first one,
+// together with the cache keys of the requests. Requests for more than one turn, and requests that can not
+// be replayed on a board, have no key and are never considered to be duplicates.
+func deduplicate(requests []AnalysisRequest) (map[int]int, map[int]cacheKey) {
+	duplicateOf := make(map[int]int)
+	keys := make(map[int]cacheKey)
+	first := make(map[board.Hash]int)
+	for i, request := range requests {
+		key, err := request.canonicalKey()
+		if err != nil {
+			continue
+		}
+		keys[i] = key
+		if j, ok := first[key.hash]; ok {
+			duplicateOf[i] = j
+			continue
+		}
+		first[key.hash] = i
+	}
+	return duplicateOf, keys
+}
@@ -0,0 +1,71 @@
+package katago
+
+import "testing"
+
+func TestPositionHashTransposition(t *testing.T) {
+	a := NewPosition(9, 9)
+	a.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}}
+	b := a
+	b.Moves = []Move{{"B", "G7"}, {"W", "C3"}, {"B", "E5"}}
+	ha, err := a.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash the position: %v", err)
+	}
+	hb, err := b.Hash()
+	if err != nil {
+		t.Fatalf("Failed to hash the position: %v", err)
+	}
+	if ha != hb {
+		t.Errorf("Expected transpositions to have the same hash, got %s and %s", ha, hb)
+	}
+	c := a
+	c.Komi = 5.5
+	if hc, _ := c.Hash(); hc == ha {
+		t.Errorf("Expected a different komi to give a different hash")
+	}
+}
+
+func TestDeduplicate(t *testing.T) {
+	a := NewPosition(9, 9)
+	a.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}}
+	b := a
+	b.Moves = []Move{{"B", "G7"}, {"W", "C3"}, {"B", "E5"}}
+	requests := []AnalysisRequest{a.Request("a"), b.Request("b"), a.Request("c"), NewPosition(9, 9).Request("d")}
+	requests[2].MaxVisits = 10
+	duplicateOf, hashes := deduplicate(requests)
+	if len(duplicateOf) != 1 || duplicateOf[1] != 0 {
+		t.Errorf("Expected only b to be a duplicate of a, got %v", duplicateOf)
+	}
+	if len(hashes) != len(requests) {
+		t.Errorf("Expected all requests to be hashed, got %v", hashes)
+	}
+	multi := a.Request("multi")
+	multi.AnalyzeTurns = []int{1, 2}
+	if _, err := multi.Hash(); err == nil {
+		t.Error("Expected an error when hashing a request for several turns")
+	}
+}
+
+func TestAnalyzeDuplicates(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	a := NewPosition(9, 9)
+	a.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}}
+	b := a
+	b.Moves = []Move{{"B", "G7"}, {"W", "C3"}, {"B", "E5"}}
+	requests := []AnalysisRequest{a.Request("first"), b.Request("second")}
+	for i := range requests {
+		requests[i].MaxVisits = 10
+	}
+	responses, err := katago.Analyze(requests)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(responses) != 2 || responses[0].ID != "first" || responses[1].ID != "second" {
+		t.Fatalf("Expected responses for both requests, got %v", responses)
+	}
+	if responses[1].TurnNumber != 3 || len(responses[1].MoveInfos) != len(responses[0].MoveInfos) {
+		t.Errorf("Expected the second response to be a copy of the first, got %v", responses[1])
+	}
+}
@@ -0,0 +1,54 @@
+package katago
+
+import (
+	"time"
+)
+
+// RequestDefaults are the values that are used for the fields that a request leaves empty
+type RequestDefaults struct {
+	Rules Rules
+	// Komi is used when the request has a komi of 0, so games without komi need a default komi of 0 too
+	Komi       float64
+	BoardXSize int
+	BoardYSize int
+	MaxVisits  int
+	// IncludeOwnership asks for the ownership in every request
+	IncludeOwnership bool
+	Timeout          time.Duration
+}
+
+// WithRequestDefaults makes every request use the given defaults for the fields that it leaves empty,
+// so that only what differs between the requests needs to be set
+func WithRequestDefaults(defaults RequestDefaults) Option {
+	return func(k *KataGo) {
+		k.defaults = &defaults
+	}
+}
+
+// apply fills in the empty fields of the request
+func (d *RequestDefaults) apply(r AnalysisRequest) AnalysisRequest {
+	if d == nil {
+		return r
+	}
+	if r.Rules == "" {
+		r.Rules = d.Rules
+	}
+	if r.Komi == 0 {
+		r.Komi = d.Komi
+	}
+	if r.BoardXSize == 0 && r.BoardYSize == 0 {
+		r.BoardXSize, r.BoardYSize = d.BoardXSize, d.BoardYSize
+	}
+	if r.MaxVisits == 0 {
+		r.MaxVisits = d.MaxVisits
+	}
+	r.IncludeOwnership = r.IncludeOwnership || d.IncludeOwnership
+	if r.Timeout == 0 {
+		r.Timeout = d.Timeout
+	}
+	if r.Moves == nil {
+		// KataGo requires the moves to be an array, even when empty
+		r.Moves = []Move{}
+	}
+	return r
+}
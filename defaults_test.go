@@ -0,0 +1,41 @@
+package katago
+
+import (
+	"testing"
+)
+
+func TestRequestDefaults(t *testing.T) {
+	d := &RequestDefaults{Rules: Japanese, Komi: 6.5, BoardXSize: 19, BoardYSize: 19, MaxVisits: 100, IncludeOwnership: true}
+	r := d.apply(AnalysisRequest{ID: "a", AnalyzeTurns: []int{0}})
+	if r.Rules != Japanese || r.Komi != 6.5 || r.BoardXSize != 19 || r.MaxVisits != 100 || !r.IncludeOwnership || r.Moves == nil {
+		t.Errorf("Expected the defaults to be used, got %+v", r)
+	}
+	r = d.apply(NewRequest9x9())
+	if r.Rules != DefaultRules || r.Komi != 7 || r.BoardXSize != 9 || r.BoardYSize != 9 || r.MaxVisits != 100 {
+		t.Errorf("Expected the fields of the request to be kept, got %+v", r)
+	}
+	var none *RequestDefaults
+	if r := none.apply(AnalysisRequest{ID: "b"}); r.Rules != "" || r.Moves != nil {
+		t.Errorf("Expected the request to be unchanged without defaults, got %+v", r)
+	}
+}
+
+func TestWithRequestDefaults(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithRequestDefaults(RequestDefaults{
+		Rules: Chinese, Komi: 7, BoardXSize: 9, BoardYSize: 9, MaxVisits: 20, IncludeOwnership: true,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	responses, err := k.Analyze([]AnalysisRequest{{ID: "defaults", Moves: []Move{{"B", "E5"}}, AnalyzeTurns: []int{1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses[0].Ownership) != 81 {
+		t.Errorf("Expected the ownership of a 9x9 board, got %d values", len(responses[0].Ownership))
+	}
+	if visits := responses[0].RootInfo.Visits; visits > 25 {
+		t.Errorf("Expected about 20 visits, got %d", visits)
+	}
+}
@@ -0,0 +1,62 @@
+package katago
+
+// MoveDelta is how much a move changed the evaluation of a game, from the point of view of the player who
+// made it, which is what mistake detection is built on
+type MoveDelta struct {
+	// Color is the player who made the move, which is the side to move in the position before it
+	Color Color
+	// WinrateBefore and WinrateAfter are the probabilities that the player wins, from 0 to 1
+	WinrateBefore float64
+	WinrateAfter  float64
+	// ScoreBefore and ScoreAfter are how many points the player is ahead, negative if behind
+	ScoreBefore float64
+	ScoreAfter  float64
+}
+
+// Compare returns how the move between two analyzed positions changed the winrate and the score. The before
+// response is for the position where the move was made, and the after response is for the position right after
+// it. The values of each response are for its side to move. If a response has no current player, the player
+// who made the move is taken to be black, or the opponent of the side to move after the move.
+func Compare(before, after AnalysisResponse) MoveDelta {
+	color := Color(before.RootInfo.CurrentPlayer)
+	switch {
+	case color != "":
+	case after.RootInfo.CurrentPlayer != "":
+		color = Color(after.RootInfo.CurrentPlayer).Opponent()
+	default:
+		color = Black
+	}
+	if after.RootInfo.CurrentPlayer == "" {
+		after.RootInfo.CurrentPlayer = string(color.Opponent())
+	}
+	if before.RootInfo.CurrentPlayer == "" {
+		before.RootInfo.CurrentPlayer = string(color)
+	}
+	return MoveDelta{
+		Color:         color,
+		WinrateBefore: before.WinrateFor(color),
+		WinrateAfter:  after.WinrateFor(color),
+		ScoreBefore:   before.ScoreFor(color),
+		ScoreAfter:    after.ScoreFor(color),
+	}
+}
+
+// WinrateDelta returns how much the move changed the winrate of the player, negative if it lost winrate
+func (d MoveDelta) WinrateDelta() float64 {
+	return d.WinrateAfter - d.WinrateBefore
+}
+
+// ScoreDelta returns how many points the move changed the score of the player, negative if it lost points
+func (d MoveDelta) ScoreDelta() float64 {
+	return d.ScoreAfter - d.ScoreBefore
+}
+
+// PointsLost returns how many points the move lost, or 0 if it did not lose any
+func (d MoveDelta) PointsLost() float64 {
+	return max(-d.ScoreDelta(), 0)
+}
+
+// Grade returns the grade of the move, from the points that it lost
+func (d MoveDelta) Grade(t GradeThresholds) Grade {
+	return t.Grade(d.PointsLost())
+}
@@ -0,0 +1,38 @@
+package katago
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	before := AnalysisResponse{RootInfo: RootInfo{CurrentPlayer: "B", Winrate: 0.6, ScoreLead: 2}}
+	after := AnalysisResponse{RootInfo: RootInfo{CurrentPlayer: "W", Winrate: 0.55, ScoreLead: 1.5}}
+	d := Compare(before, after)
+	if d.Color != Black {
+		t.Errorf("Expected the move to be made by black, got %s", d.Color)
+	}
+	if math.Abs(d.WinrateDelta()+0.15) > 1e-9 {
+		t.Errorf("Expected a winrate delta of -0.15, got %f", d.WinrateDelta())
+	}
+	if math.Abs(d.ScoreDelta()+3.5) > 1e-9 || math.Abs(d.PointsLost()-3.5) > 1e-9 {
+		t.Errorf("Expected 3.5 points lost, got a delta of %f", d.ScoreDelta())
+	}
+	if g := d.Grade(DefaultGradeThresholds); g != Mistake {
+		t.Errorf("Expected a mistake, got %s", g)
+	}
+
+	// Without current players, the move is taken to be made by black, and the values alternate sides
+	before.RootInfo.CurrentPlayer, after.RootInfo.CurrentPlayer = "", ""
+	if got := Compare(before, after); got != d {
+		t.Errorf("Expected %+v without current players, got %+v", d, got)
+	}
+
+	// A move by white that gains points
+	before = AnalysisResponse{RootInfo: RootInfo{Winrate: 0.4, ScoreLead: -1}}
+	after = AnalysisResponse{RootInfo: RootInfo{CurrentPlayer: "B", Winrate: 0.5, ScoreLead: 0}}
+	d = Compare(before, after)
+	if d.Color != White || d.ScoreBefore != -1 || d.ScoreAfter != 0 || d.PointsLost() != 0 {
+		t.Errorf("Expected white to gain a point, got %+v", d)
+	}
+}
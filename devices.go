@@ -0,0 +1,85 @@
+package katago
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Backend is a neural network backend of KataGo that runs on a GPU, an OpenCL device or the CPU
+type Backend string
+
+// Backends, named by the prefix of their settings in the config file
+const (
+	CUDA     Backend = "cuda"
+	TensorRT Backend = "trt"
+	OpenCL   Backend = "opencl"
+	Eigen    Backend = "eigen" // runs on the CPU, and has no devices
+)
+
+// Device is a GPU or OpenCL device that KataGo reported using
+type Device struct {
+	Backend Backend
+	// Thread is the neural network server thread that uses the device, or -1 if it was not reported
+	Thread int
+	// Index is the index of the device, or -1 if it was not reported
+	Index int
+	Name  string
+}
+
+// String returns a description of the device, like "cuda thread 0: NVIDIA GeForce RTX 3080"
+func (d Device) String() string {
+	if d.Thread < 0 {
+		return fmt.Sprintf("%s device %d: %s", d.Backend, d.Index, d.Name)
+	}
+	return fmt.Sprintf("%s thread %d: %s", d.Backend, d.Thread, d.Name)
+}
+
+var (
+	gpuLinePattern    = regexp.MustCompile(`^(Cuda|TensorRT) backend thread (\d+): Found GPU (.+?) memory`)
+	openCLLinePattern = regexp.MustCompile(`^Using OpenCL Device (\d+): (.+?)(?: \(|$)`)
+)
+
+// parseDevice recognizes the lines where KataGo reports the device that it selected
+func parseDevice(line string) (Device, bool) {
+	if m := gpuLinePattern.FindStringSubmatch(line); m != nil {
+		backend := CUDA
+		if m[1] == "TensorRT" {
+			backend = TensorRT
+		}
+		thread, _ := strconv.Atoi(m[2])
+		return Device{Backend: backend, Thread: thread, Index: -1, Name: m[3]}, true
+	}
+	if m := openCLLinePattern.FindStringSubmatch(line); m != nil {
+		index, _ := strconv.Atoi(m[1])
+		return Device{Backend: OpenCL, Thread: -1, Index: index, Name: m[2]}, true
+	}
+	return Device{}, false
+}
+
+// WithDevices pins KataGo to the given devices, with one neural network server thread for each device.
+// The same device can be given more than once to run several threads on it.
+func WithDevices(backend Backend, devices ...int) Option {
+	settings := map[string]string{"numNNServerThreadsPerModel": strconv.Itoa(len(devices))}
+	for thread, device := range devices {
+		settings[fmt.Sprintf("%sDeviceToUseThread%d", backend, thread)] = strconv.Itoa(device)
+	}
+	return WithOverrides(settings)
+}
+
+// WithEnv adds environment variables, like "CUDA_VISIBLE_DEVICES=1,2", to the environment of KataGo
+func WithEnv(vars ...string) Option {
+	return func(k *KataGo) {
+		k.env = append(k.env, vars...)
+	}
+}
+
+// Devices waits until KataGo is ready to handle requests, and returns the devices that it reported using.
+// The list is empty for the Eigen backend, which runs on the CPU.
+func (k *KataGo) Devices() []Device {
+	p := k.current()
+	<-p.started
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	return append([]Device(nil), p.devices...)
+}
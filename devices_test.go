@@ -0,0 +1,45 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDevice(t *testing.T) {
+	tests := []struct {
+		line     string
+		expected Device
+	}{
+		{"Cuda backend thread 1: Found GPU NVIDIA GeForce RTX 3080 memory 10485235712 compute capability major 8 minor 6", Device{CUDA, 1, -1, "NVIDIA GeForce RTX 3080"}},
+		{"TensorRT backend thread 0: Found GPU NVIDIA A100 memory 42314694656", Device{TensorRT, 0, -1, "NVIDIA A100"}},
+		{"Using OpenCL Device 2: AMD Radeon RX 6800 (Advanced Micro Devices, Inc.) OpenCL 2.0", Device{OpenCL, -1, 2, "AMD Radeon RX 6800"}},
+	}
+	for _, test := range tests {
+		device, ok := parseDevice(test.line)
+		if !ok || device != test.expected {
+			t.Errorf("Expected %v, got %v (%v)", test.expected, device, ok)
+		}
+	}
+	if _, ok := parseDevice("Loaded model model.bin.gz"); ok {
+		t.Errorf("Expected no device")
+	}
+}
+
+func TestWithDevices(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithDevices(CUDA, 3, 1), WithEnv("CUDA_VISIBLE_DEVICES=1,3"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	args := strings.Join(k.current().cmd.Args, " ")
+	if !strings.Contains(args, "-override-config cudaDeviceToUseThread0=3,cudaDeviceToUseThread1=1,numNNServerThreadsPerModel=2") {
+		t.Errorf("Unexpected arguments: %s", args)
+	}
+	devices := k.Devices()
+	if len(devices) != 1 || devices[0].Name != "NVIDIA Fake GPU 3" {
+		t.Errorf("Expected the engine to report device 3, got %v", devices)
+	}
+	if env := k.current().cmd.Env; len(env) == 0 || env[len(env)-1] != "CUDA_VISIBLE_DEVICES=1,3" {
+		t.Errorf("Expected CUDA_VISIBLE_DEVICES to be set")
+	}
+}
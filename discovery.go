@@ -0,0 +1,129 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors for when NewKataGo is not given a config file or a model, and none can be found
+var (
+	ErrConfigNotFound = errors.New("no KataGo analysis config found")
+	ErrModelNotFound  = errors.New("no KataGo model found")
+)
+
+// ConfigNames are the names of analysis config files that are looked for, in order
+var ConfigNames = []string{"analysis.cfg", "analysis_example.cfg", filepath.Join("configs", "analysis_example.cfg")}
+
+// ModelNames are the names of models that are looked for, in order. If none of them are found,
+// the most recently modified network in the directory is used.
+var ModelNames = []string{"default_model.bin.gz", "model.bin.gz", "default_model.txt.gz"}
+
+// searchDirs returns the directories where config files and models are looked for, in order: the current
+// directory, ~/.katago, the XDG config, data and cache directories, and the directories of the KataGo install
+func searchDirs(binary string) []string {
+	dirs := []string{"."}
+	home, _ := os.UserHomeDir()
+	if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".katago"))
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "katago"))
+	}
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		dirs = append(dirs, filepath.Join(dataHome, "katago"))
+	} else if home != "" {
+		dirs = append(dirs, filepath.Join(home, ".local", "share", "katago"))
+	}
+	// The models package downloads networks here
+	if dir, err := os.UserCacheDir(); err == nil {
+		dirs = append(dirs, filepath.Join(dir, "katago", "models"))
+	}
+	if binary != "" {
+		// KataGo releases have the files next to the binary, and packages like Homebrew have them in share/katago
+		dir := filepath.Dir(binary)
+		if resolved, err := filepath.EvalSymlinks(binary); err == nil {
+			dir = filepath.Dir(resolved)
+		}
+		dirs = append(dirs, dir, filepath.Join(filepath.Dir(dir), "share", "katago"))
+	}
+	return dirs
+}
+
+// findConfig returns the first config file in the directories
+func findConfig(dirs []string) (string, error) {
+	var tried []string
+	for _, dir := range dirs {
+		for _, name := range ConfigNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+			tried = append(tried, path)
+		}
+	}
+	return "", fmt.Errorf("%w: tried %s", ErrConfigNotFound, strings.Join(tried, ", "))
+}
+
+// isModel reports whether the name is a network file, and not a human SL model
+func isModel(name string) bool {
+	lower := strings.ToLower(name)
+	return (strings.HasSuffix(lower, ".bin.gz") || strings.HasSuffix(lower, ".txt.gz")) && !strings.Contains(lower, "human")
+}
+
+// findModel returns the first model in the directories, with the names in ModelNames first,
+// and otherwise the most recently modified network
+func findModel(dirs []string) (string, error) {
+	var tried []string
+	for _, dir := range dirs {
+		for _, name := range ModelNames {
+			path := filepath.Join(dir, name)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path, nil
+			}
+		}
+		entries, err := os.ReadDir(dir)
+		tried = append(tried, dir)
+		if err != nil {
+			continue
+		}
+		var newest string
+		var newestTime int64
+		for _, entry := range entries {
+			if entry.IsDir() || !isModel(entry.Name()) {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			if t := info.ModTime().UnixNano(); newest == "" || t > newestTime {
+				newest, newestTime = filepath.Join(dir, entry.Name()), t
+			}
+		}
+		if newest != "" {
+			return newest, nil
+		}
+	}
+	return "", fmt.Errorf("%w: looked for %s and other networks in %s", ErrModelNotFound, strings.Join(ModelNames, ", "), strings.Join(tried, ", "))
+}
+
+// installedBinary returns the path of the KataGo binary, or "" if it is not found
+func installedBinary() string {
+	binary, _ := FindBinary()
+	return binary
+}
+
+// FindConfig returns the path of an analysis config file, from the current directory, ~/.katago, the XDG directories
+// or the directory where KataGo is installed. NewKataGo uses it when the config file is "".
+func FindConfig() (string, error) {
+	return findConfig(searchDirs(installedBinary()))
+}
+
+// FindModel returns the path of a network, from the same directories as FindConfig and from the directory where the
+// models package downloads networks. NewKataGo uses it when the model file is "".
+func FindModel() (string, error) {
+	return findModel(searchDirs(installedBinary()))
+}
@@ -0,0 +1,65 @@
+package katago
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestFindConfigAndModel(t *testing.T) {
+	empty, dir := t.TempDir(), t.TempDir()
+	if _, err := findConfig([]string{empty}); !errors.Is(err, ErrConfigNotFound) {
+		t.Errorf("Expected ErrConfigNotFound, got %v", err)
+	}
+	if _, err := findModel([]string{empty}); !errors.Is(err, ErrModelNotFound) {
+		t.Errorf("Expected ErrModelNotFound, got %v", err)
+	}
+	for _, name := range []string{"analysis_example.cfg", "b18c384nbt-humanv0.bin.gz", "old.bin.gz", "new.bin.gz"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(filepath.Join(dir, "old.bin.gz"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findConfig([]string{empty, dir}); err != nil || path != filepath.Join(dir, "analysis_example.cfg") {
+		t.Errorf("Expected the config in %s, got %s (%v)", dir, path, err)
+	}
+	if path, err := findModel([]string{empty, dir}); err != nil || path != filepath.Join(dir, "new.bin.gz") {
+		t.Errorf("Expected the newest model, got %s (%v)", path, err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "model.bin.gz"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findModel([]string{dir}); err != nil || path != filepath.Join(dir, "model.bin.gz") {
+		t.Errorf("Expected model.bin.gz, got %s (%v)", path, err)
+	}
+}
+
+func TestSearchDirs(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	dirs := searchDirs("/opt/katago/bin/katago")
+	for _, expected := range []string{".", filepath.Join(home, ".katago"), filepath.Join(home, ".local", "share", "katago"), "/opt/katago/bin", "/opt/katago/share/katago"} {
+		if !slices.Contains(dirs, expected) {
+			t.Errorf("Expected %s in %v", expected, dirs)
+		}
+	}
+}
+
+func TestNewKataGoWithoutFiles(t *testing.T) {
+	// The config and the model in the current directory are found
+	k, err := NewKataGo("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	if _, err := k.Analyze([]AnalysisRequest{NewRequest9x9()}); err != nil {
+		t.Error(err)
+	}
+}
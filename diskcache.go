@@ -0,0 +1,265 @@
+package katago
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/xyproto/katago/board"
+)
+
+// DiskCacheSchema is the version of the file format used by DiskCache.
+// Files with a different version are discarded when opened, since the responses may not be compatible.
+const DiskCacheSchema = 3
+
+// diskCacheHeader is the first line of a cache file, with the model that the responses are from, if it is known
+type diskCacheHeader struct {
+	Schema int    `json:"schema"`
+	Model  string `json:"model,omitempty"`
+}
+
+// diskCacheRecord is one line of a cache file
+type diskCacheRecord struct {
+	Key      string           `json:"key"`
+	Response AnalysisResponse `json:"response"`
+}
+
+// diskCacheEntry is the location of a record in the cache file
+type diskCacheEntry struct {
+	key            board.Hash
+	offset, length int64
+}
+
+// DiskCache is a cache that persists the responses to a file, so that batch runs on overlapping games
+// can reuse earlier results after a restart. Records are appended to the file, and only their locations
+// are kept in memory. When there are more than maxEntries responses, the least recently used ones are evicted,
+// and the file is compacted when it holds more evicted or replaced records than live ones.
+// The file is JSON Lines instead of an embedded store like bbolt or SQLite, since the package only uses
+// the standard library, and appending records while keeping their offsets in memory is enough for a cache.
+type DiskCache struct {
+	mut        sync.Mutex
+	filename   string
+	model      string
+	file       *os.File
+	size       int64
+	records    int
+	maxEntries int
+	order      *list.List
+	entries    map[board.Hash]*list.Element
+}
+
+// OpenDiskCache opens or creates a cache file that holds up to maxEntries responses
+func OpenDiskCache(filename string, maxEntries int) (*DiskCache, error) {
+	return OpenModelDiskCache(filename, "", maxEntries)
+}
+
+// OpenModelDiskCache opens or creates a cache file for the responses of the given model, like from KataGo.Model.
+// A file that was written for another model is discarded when opened, since the responses of another network
+// are not reused after a restart.
+func OpenModelDiskCache(filename, model string, maxEntries int) (*DiskCache, error) {
+	c := &DiskCache{
+		filename:   filename,
+		model:      model,
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[board.Hash]*list.Element),
+	}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// open opens the cache file and reads the locations of the records
+func (c *DiskCache) open() error {
+	f, err := os.OpenFile(c.filename, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open cache file: %v", err)
+	}
+	c.file = f
+	c.order.Init()
+	c.entries = make(map[board.Hash]*list.Element)
+	c.size, c.records = 0, 0
+
+	r := bufio.NewReader(f)
+	header, err := r.ReadBytes('\n')
+	var h diskCacheHeader
+	if err != nil || json.Unmarshal(header, &h) != nil || h.Schema != DiskCacheSchema || h.Model != c.model {
+		// A new file, or one from another version of the package or another model
+		return c.reset()
+	}
+	offset := int64(len(header))
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			// A partial record is left behind if the process was stopped while writing
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read cache file: %v", err)
+		}
+		var record struct {
+			Key string `json:"key"`
+		}
+		key, parseErr := uint64(0), json.Unmarshal(line, &record)
+		if parseErr == nil {
+			key, parseErr = strconv.ParseUint(record.Key, 16, 64)
+		}
+		if parseErr == nil {
+			c.index(board.Hash(key), offset, int64(len(line)))
+		}
+		offset += int64(len(line))
+		c.records++
+	}
+	c.size = offset
+	if err := f.Truncate(c.size); err != nil {
+		return fmt.Errorf("failed to truncate cache file: %v", err)
+	}
+	c.evict()
+	return nil
+}
+
+// reset empties the cache file and writes the header
+func (c *DiskCache) reset() error {
+	if err := c.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate cache file: %v", err)
+	}
+	header, err := json.Marshal(diskCacheHeader{DiskCacheSchema, c.model})
+	if err != nil {
+		return err
+	}
+	header = append(header, '\n')
+	if _, err := c.file.WriteAt(header, 0); err != nil {
+		return fmt.Errorf("failed to write cache file: %v", err)
+	}
+	c.size = int64(len(header))
+	return nil
+}
+
+// index records the location of the latest record for the key, and marks it as the most recently used
+func (c *DiskCache) index(key board.Hash, offset, length int64) {
+	if e, ok := c.entries[key]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[key] = c.order.PushBack(&diskCacheEntry{key, offset, length})
+}
+
+// evict forgets the least recently used records until there are at most maxEntries
+func (c *DiskCache) evict() {
+	for c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		oldest := c.order.Front()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*diskCacheEntry).key)
+	}
+}
+
+// Get reads the cached response for the key from the file
+func (c *DiskCache) Get(key board.Hash) (AnalysisResponse, bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		return AnalysisResponse{}, false
+	}
+	entry := e.Value.(*diskCacheEntry)
+	line := make([]byte, entry.length)
+	if _, err := c.file.ReadAt(line, entry.offset); err != nil {
+		log.Printf("Failed to read from the cache file: %v", err)
+		return AnalysisResponse{}, false
+	}
+	var record diskCacheRecord
+	if err := json.Unmarshal(line, &record); err != nil {
+		log.Printf("Failed to read from the cache file: %v", err)
+		return AnalysisResponse{}, false
+	}
+	c.order.MoveToBack(e)
+	return record.Response, true
+}
+
+// Put appends the response to the file. Errors are logged, since a failing cache should not stop the analysis.
+func (c *DiskCache) Put(key board.Hash, response AnalysisResponse) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	line, err := json.Marshal(diskCacheRecord{Key: key.String(), Response: response})
+	if err != nil {
+		log.Printf("Failed to write to the cache file: %v", err)
+		return
+	}
+	line = append(line, '\n')
+	if _, err := c.file.WriteAt(line, c.size); err != nil {
+		log.Printf("Failed to write to the cache file: %v", err)
+		return
+	}
+	c.index(key, c.size, int64(len(line)))
+	c.size += int64(len(line))
+	c.records++
+	c.evict()
+	if c.records > 2*c.order.Len()+100 {
+		if err := c.compact(); err != nil {
+			log.Printf("Failed to compact the cache file: %v", err)
+		}
+	}
+}
+
+// compact rewrites the file with only the records that are still in use, in order from least to most recently used
+func (c *DiskCache) compact() error {
+	var buf bytes.Buffer
+	header, err := json.Marshal(diskCacheHeader{DiskCacheSchema, c.model})
+	if err != nil {
+		return err
+	}
+	buf.Write(header)
+	buf.WriteByte('\n')
+	for e := c.order.Front(); e != nil; e = e.Next() {
+		entry := e.Value.(*diskCacheEntry)
+		line := make([]byte, entry.length)
+		if _, err := c.file.ReadAt(line, entry.offset); err != nil {
+			return err
+		}
+		buf.Write(line)
+	}
+	tmp := c.filename + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	if err := c.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.filename); err != nil {
+		// The original file is still in place, so keep using it
+		os.Remove(tmp)
+		if openErr := c.open(); openErr != nil {
+			return fmt.Errorf("%v, and %v", err, openErr)
+		}
+		return err
+	}
+	return c.open()
+}
+
+// Len returns the number of cached responses
+func (c *DiskCache) Len() int {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.order.Len()
+}
+
+// Compact rewrites the cache file without the evicted and replaced records
+func (c *DiskCache) Compact() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.compact()
+}
+
+// Close closes the cache file
+func (c *DiskCache) Close() error {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.file.Close()
+}
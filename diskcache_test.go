@@ -0,0 +1,113 @@
+package katago
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDiskCacheReopen(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.jsonl")
+	c, err := OpenDiskCache(filename, 10)
+	if err != nil {
+		t.Fatalf("Failed to open the cache: %v", err)
+	}
+	c.Put(1, AnalysisResponse{ID: "one", RootInfo: RootInfo{Winrate: 0.6}})
+	c.Put(2, AnalysisResponse{ID: "two"})
+	c.Put(1, AnalysisResponse{ID: "one again"})
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err = OpenDiskCache(filename, 10)
+	if err != nil {
+		t.Fatalf("Failed to reopen the cache: %v", err)
+	}
+	defer c.Close()
+	if c.Len() != 2 {
+		t.Errorf("Expected 2 cached responses, got %d", c.Len())
+	}
+	if r, ok := c.Get(1); !ok || r.ID != "one again" {
+		t.Errorf("Expected the latest response for 1, got %v", r)
+	}
+	if err := c.Compact(); err != nil {
+		t.Fatalf("Failed to compact the cache: %v", err)
+	}
+	if r, ok := c.Get(2); !ok || r.ID != "two" {
+		t.Errorf("Expected the response for 2 after compacting, got %v", r)
+	}
+}
+
+func TestDiskCacheEviction(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.jsonl")
+	c, err := OpenDiskCache(filename, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.Put(1, AnalysisResponse{ID: "one"})
+	c.Put(2, AnalysisResponse{ID: "two"})
+	c.Get(1)
+	c.Put(3, AnalysisResponse{ID: "three"})
+	if _, ok := c.Get(2); ok {
+		t.Error("Expected 2 to be evicted")
+	}
+	if _, ok := c.Get(1); !ok {
+		t.Error("Expected 1 to still be cached")
+	}
+}
+
+func TestDiskCacheSchema(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.jsonl")
+	old := "{\"schema\":0}\n{\"key\":\"0000000000000001\",\"response\":{\"id\":\"old\"}}\n"
+	if err := os.WriteFile(filename, []byte(old), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := OpenDiskCache(filename, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Expected responses from another schema version to be discarded, got %d", c.Len())
+	}
+	// A partial record at the end of the file is ignored
+	c.Put(1, AnalysisResponse{ID: "new"})
+	c.Close()
+	f, _ := os.OpenFile(filename, os.O_APPEND|os.O_WRONLY, 0o644)
+	f.WriteString("{\"key\":\"00")
+	f.Close()
+	c, err = OpenDiskCache(filename, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if r, ok := c.Get(1); !ok || r.ID != "new" || c.Len() != 1 {
+		t.Errorf("Expected only the complete record, got %v and %d", r, c.Len())
+	}
+}
+
+func TestDiskCacheModel(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cache.jsonl")
+	c, err := OpenModelDiskCache(filename, "old.bin.gz", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Put(1, AnalysisResponse{ID: "old"})
+	c.Close()
+	c, err = OpenModelDiskCache(filename, "old.bin.gz", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Len() != 1 {
+		t.Errorf("Expected the response for the same model to be kept, got %d", c.Len())
+	}
+	c.Close()
+	c, err = OpenModelDiskCache(filename, "new.bin.gz", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if c.Len() != 0 {
+		t.Errorf("Expected the responses of another model to be discarded, got %d", c.Len())
+	}
+}
@@ -0,0 +1,479 @@
+package katago
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+)
+
+// ErrUnknownQuery is returned when terminating a query that is not running
+var ErrUnknownQuery = errors.New("no query with that ID is running")
+
+// ErrDuplicateID is returned when a request uses the ID of a query that is still running
+var ErrDuplicateID = errors.New("a query with that ID is already running")
+
+// header are the fields that every line from KataGo may have, which are used for routing the line
+type header struct {
+	ID             string `json:"id"`
+	Action         string `json:"action"`
+	IsDuringSearch bool   `json:"isDuringSearch"`
+	Error          string `json:"error"`
+	Warning        string `json:"warning"`
+	Field          string `json:"field"`
+	RootInfo       struct {
+		Visits int `json:"visits"`
+	} `json:"rootInfo"`
+}
+
+// RequestError is an error or a warning that KataGo reported for a query. Errors are sent instead of
+// the response, and unwrap to ErrBadRequest. Warnings, like for an unknown field, are sent before the response.
+type RequestError struct {
+	// ID is the ID of the query, which is empty if KataGo could not read it
+	ID string
+	// Field is the field of the request that the error is about, if any
+	Field   string
+	Message string
+	Warning bool
+}
+
+// Error describes the error, with the query and the field
+func (e *RequestError) Error() string {
+	kind := "error"
+	if e.Warning {
+		kind = "warning"
+	}
+	s := "KataGo " + kind
+	if e.ID != "" {
+		s += " for query " + e.ID
+	}
+	if e.Field != "" {
+		s += " in field " + e.Field
+	}
+	return s + ": " + e.Message
+}
+
+// Unwrap returns ErrBadRequest for errors, and nil for warnings
+func (e *RequestError) Unwrap() error {
+	if e.Warning {
+		return nil
+	}
+	return ErrBadRequest
+}
+
+// query is a registered ID that a response is expected for
+type query struct {
+	ch chan []byte
+	// sent is true when the request has been written, so that a terminate action can not overtake it,
+	// and proc is the KataGo process that it was written to
+	sent bool
+	proc *process
+	// reports receives the reports that are sent during the search, if anyone listens, and latest is the last report.
+	// The channel is closed when the query is answered or given up.
+	reports chan []byte
+	latest  []byte
+	// priority is the priority of the request, and preempted is set when a query with a higher priority stopped it
+	priority  int
+	preempted bool
+	// terminated is set when Terminate or TerminateAll stopped the search, so that the results are not cached
+	terminated bool
+	// remaining is the number of final responses that are still expected, one for each analyzed turn
+	remaining int
+	// sentAt is when the request was written, and visits are the visits of the final responses so far, for Stats
+	sentAt time.Time
+	visits int
+	// maxVisits is the MaxVisits of the request, and latestVisits are the visits of the last report, for ETA
+	maxVisits    int
+	latestVisits int
+}
+
+// register reserves the IDs, so that the lines that KataGo sends for them are delivered on the returned channels
+func (k *KataGo) register(ids ...string) ([]chan []byte, error) {
+	turns := make([]int, len(ids))
+	for i := range turns {
+		turns[i] = 1
+	}
+	return k.registerCounts(ids, turns)
+}
+
+// registerRequests reserves the IDs of the requests, which get one response for each analyzed turn
+func (k *KataGo) registerRequests(requests []AnalysisRequest) ([]chan []byte, error) {
+	ids := make([]string, len(requests))
+	turns := make([]int, len(requests))
+	for i, request := range requests {
+		ids[i], turns[i] = request.ID, request.turns()
+	}
+	return k.registerCounts(ids, turns)
+}
+
+// registerTurns reserves the ID for a request that analyzes several turns, and gets one response for each turn
+func (k *KataGo) registerTurns(id string, turns int) (chan []byte, error) {
+	channels, err := k.registerCounts([]string{id}, []int{turns})
+	if err != nil {
+		return nil, err
+	}
+	return channels[0], nil
+}
+
+// registerCounts reserves the IDs, where each ID gets the given number of responses
+func (k *KataGo) registerCounts(ids []string, turns []int) ([]chan []byte, error) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	seen := make(map[string]bool)
+	for _, id := range ids {
+		if _, ok := k.pending[id]; ok || seen[id] {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicateID, id)
+		}
+		seen[id] = true
+	}
+	channels := make([]chan []byte, len(ids))
+	for i, id := range ids {
+		// A request that is sent again gets its warnings again
+		delete(k.warnings, id)
+		// The read loop never blocks, since it delivers at most one line per expected response
+		channels[i] = make(chan []byte, turns[i])
+		k.pending[id] = &query{ch: channels[i], remaining: turns[i]}
+	}
+	return channels, nil
+}
+
+// turns returns the number of final responses that KataGo sends for the request, one for each analyzed turn
+func (r AnalysisRequest) turns() int {
+	return max(1, len(r.AnalyzeTurns))
+}
+
+// waitTurns returns the lines for each of the turns of a query that was sent to the process. KataGo sends
+// an error instead of all of the responses, so the lines end early with an error.
+func (k *KataGo) waitTurns(p *process, ch chan []byte, turns int) ([][]byte, error) {
+	lines := make([][]byte, 0, turns)
+	for range turns {
+		line, err := k.wait(p, ch)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+		if responseError(line) != nil {
+			break
+		}
+	}
+	return lines, nil
+}
+
+// unregister removes IDs that are no longer waited for
+func (k *KataGo) unregister(ids ...string) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	for _, id := range ids {
+		if q, ok := k.pending[id]; ok {
+			q.stopReports()
+		}
+		delete(k.pending, id)
+		delete(k.preemptedIDs, id)
+		delete(k.terminatedIDs, id)
+		delete(k.warnings, id)
+	}
+	k.finished.Broadcast()
+}
+
+// reportBuffer is how many reports during the search are kept for a listener that is busy
+const reportBuffer = 16
+
+// listen returns a channel with the reports during the search for the ID, which is closed when the query is
+// answered or unregistered. Reports are dropped when the listener falls behind, since the later ones replace them.
+func (k *KataGo) listen(id string) <-chan []byte {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	reports := make(chan []byte, reportBuffer)
+	q, ok := k.pending[id]
+	if !ok {
+		close(reports)
+		return reports
+	}
+	q.reports = reports
+	return reports
+}
+
+// stopReports closes the reports channel of the query, if anyone listens, and must be called with mut held
+func (q *query) stopReports() {
+	if q.reports != nil {
+		close(q.reports)
+		q.reports = nil
+	}
+}
+
+// markSent records that the request has been written to the process
+func (k *KataGo) markSent(request AnalysisRequest, p *process) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	if q, ok := k.pending[request.ID]; ok {
+		q.sent = true
+		q.proc = p
+		q.priority = request.Priority
+		q.maxVisits = request.MaxVisits
+		q.sentAt = time.Now()
+		k.stats.sent()
+	}
+}
+
+// isPending checks if the request for the ID has been sent, and a response is still expected
+func (k *KataGo) isPending(id string) bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	q, ok := k.pending[id]
+	return ok && q.sent
+}
+
+// send writes the request to the current KataGo process and marks it as sent, and returns the process.
+// With WithProgress, requests without a report interval get DefaultReportInterval.
+func (k *KataGo) send(request AnalysisRequest) (*process, error) {
+	if k.progress != nil && request.ReportDuringSearchEvery <= 0 {
+		request.ReportDuringSearchEvery = DefaultReportInterval
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %v", err)
+	}
+	k.swapMut.RLock()
+	defer k.swapMut.RUnlock()
+	p := k.current()
+	k.writeMut.Lock()
+	defer k.writeMut.Unlock()
+	// KataGo may answer before the write returns, so the request is marked as sent first. A terminate
+	// action can still not overtake it, since it is written with writeMut.
+	k.markSent(request, p)
+	if err := k.writeLine(p, data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// write sends one JSON line to the KataGo process
+func (k *KataGo) write(p *process, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+	k.writeMut.Lock()
+	defer k.writeMut.Unlock()
+	return k.writeLine(p, data)
+}
+
+// writeLine writes a line to the KataGo process, and must be called with writeMut held
+func (k *KataGo) writeLine(p *process, data []byte) error {
+	if _, err := fmt.Fprintf(p.stdin, "%s\n", data); err != nil {
+		if !p.running() {
+			return fmt.Errorf("failed to send request: %w: %v", ErrEngineCrashed, err)
+		}
+		return fmt.Errorf("failed to send request: %v", err)
+	}
+	return nil
+}
+
+// wait returns the line that is delivered on the channel, or an error if the KataGo process stops first
+func (k *KataGo) wait(p *process, ch chan []byte) ([]byte, error) {
+	select {
+	case line := <-ch:
+		return line, nil
+	case <-p.done:
+		// A line may have been delivered just before KataGo stopped
+		select {
+		case line := <-ch:
+			return line, nil
+		default:
+		}
+		if p.readErr != nil {
+			return nil, fmt.Errorf("%w: %v", ErrEngineCrashed, p.readErr)
+		}
+		return nil, ErrEngineCrashed
+	}
+}
+
+// deliver routes one line from KataGo
+func (k *KataGo) deliver(line []byte) {
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil {
+		log.Printf("Unexpected output from KataGo: %s", line)
+		return
+	}
+	if h.Warning != "" && h.Error == "" {
+		// The response follows the warning, and gets it attached
+		log.Printf("%v", &RequestError{ID: h.ID, Field: h.Field, Message: h.Warning, Warning: true})
+		k.mut.Lock()
+		if _, ok := k.pending[h.ID]; ok {
+			k.warnings[h.ID] = append(k.warnings[h.ID], Warning{Field: h.Field, Message: h.Warning})
+		}
+		k.mut.Unlock()
+		return
+	}
+	if h.Error != "" && h.ID == "" {
+		// KataGo could not read the ID, so the error can not be tied to a query
+		log.Printf("%v", &RequestError{Field: h.Field, Message: h.Error})
+		return
+	}
+	// The lines are never sent on a channel that is full, so that one slow caller can not hold up the others
+	k.mut.Lock()
+	q, ok := k.pending[h.ID]
+	if ok && h.IsDuringSearch {
+		q.latest = line
+		q.latestVisits = h.RootInfo.Visits
+		if q.reports != nil {
+			select {
+			case q.reports <- line:
+			default:
+			}
+		}
+		if k.progressReports != nil {
+			select {
+			case k.progressReports <- line:
+			default:
+			}
+		}
+	}
+	if ok && !h.IsDuringSearch {
+		q.remaining--
+		q.visits += h.RootInfo.Visits
+		q.latestVisits = 0
+	}
+	// An error ends the query, since KataGo does not send any more responses for it
+	if ok && !h.IsDuringSearch && (q.remaining <= 0 || h.Error != "") {
+		q.stopReports()
+		delete(k.pending, h.ID)
+		if q.sent {
+			k.stats.answered(time.Since(q.sentAt), q.visits, h.Error != "")
+		}
+		if q.preempted {
+			k.preemptedIDs[h.ID] = true
+		} else if q.terminated {
+			k.terminatedIDs[h.ID] = true
+		}
+		k.finished.Broadcast()
+	}
+	k.mut.Unlock()
+	if !ok {
+		log.Printf("Received a response for an unknown query: %s", line)
+		return
+	}
+	if h.IsDuringSearch {
+		return
+	}
+	// The channel has room for one line for each expected response, so this does not block
+	select {
+	case q.ch <- line:
+	default:
+		log.Printf("Received more responses than expected for query %s: %s", h.ID, line)
+	}
+}
+
+// takeTerminated checks if the response for the ID came from a search that was terminated, and clears the flag
+func (k *KataGo) takeTerminated(id string) bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	terminated := k.terminatedIDs[id]
+	delete(k.terminatedIDs, id)
+	return terminated
+}
+
+// warningsFor returns the warnings that KataGo sent for a query
+func (k *KataGo) warningsFor(id string) []Warning {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	return slices.Clone(k.warnings[id])
+}
+
+// responseError returns the error that KataGo reported instead of a response, if any
+func responseError(line []byte) error {
+	var h header
+	if err := json.Unmarshal(line, &h); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	if h.Error == "" {
+		return nil
+	}
+	return &RequestError{ID: h.ID, Field: h.Field, Message: h.Error}
+}
+
+// action sends an action, like query_version, to the current KataGo process, and waits for KataGo to acknowledge it
+func (k *KataGo) action(fields map[string]any) ([]byte, error) {
+	return k.actionOn(k.current(), fields)
+}
+
+// actionOn sends an action to the KataGo process, and waits for KataGo to acknowledge it
+func (k *KataGo) actionOn(p *process, fields map[string]any) ([]byte, error) {
+	id := k.newID(fields["action"].(string))
+	fields["id"] = id
+	channels, err := k.register(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.write(p, fields); err != nil {
+		k.unregister(id)
+		return nil, err
+	}
+	return k.wait(p, channels[0])
+}
+
+// Terminate stops the search of a running query. KataGo still sends a response for the query,
+// with the results found so far, which is returned by the call to Analyze that sent the query.
+func (k *KataGo) Terminate(id string) error {
+	k.mut.Lock()
+	q, ok := k.pending[id]
+	var p *process
+	if ok && q.sent {
+		p = q.proc
+		q.terminated = true
+	}
+	k.mut.Unlock()
+	if p == nil {
+		return fmt.Errorf("%w: %s", ErrUnknownQuery, id)
+	}
+	// The query may have been sent to a process that is being replaced by SwapModel
+	_, err := k.actionOn(p, map[string]any{"action": "terminate", "terminateId": id})
+	return err
+}
+
+// TerminateAll stops the search of all running queries. Versions of KataGo without the terminate_all action
+// get a terminate action for each query instead.
+func (k *KataGo) TerminateAll() error {
+	if k.Supports(CapabilityTerminateAll) {
+		// Every process that has running queries is told, in case SwapModel is replacing one of them
+		procs := []*process{k.current()}
+		k.mut.Lock()
+		for _, q := range k.pending {
+			if !q.sent {
+				continue
+			}
+			q.terminated = true
+			if !slices.Contains(procs, q.proc) {
+				procs = append(procs, q.proc)
+			}
+		}
+		k.mut.Unlock()
+		for _, p := range procs {
+			if _, err := k.actionOn(p, map[string]any{"action": "terminate_all"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	k.mut.Lock()
+	var ids []string
+	for id, q := range k.pending {
+		if q.sent {
+			ids = append(ids, id)
+		}
+	}
+	k.mut.Unlock()
+	for _, id := range ids {
+		if err := k.Terminate(id); err != nil && !errors.Is(err, ErrUnknownQuery) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Running checks if the KataGo process is still running and answering
+func (k *KataGo) Running() bool {
+	return k.current().running()
+}
@@ -0,0 +1,159 @@
+package katago
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeConcurrent(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			p := NewPosition(9, 9)
+			p.Moves = []Move{{"B", "E5"}, {"W", Vertex(fmt.Sprintf("A%d", i+1))}}
+			request := p.Request(fmt.Sprintf("concurrent-%d", i))
+			request.MaxVisits = 20
+			responses, err := katago.Analyze([]AnalysisRequest{request})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if responses[0].ID != request.ID {
+				errs <- fmt.Errorf("expected a response for %s, got %s", request.ID, responses[0].ID)
+			}
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+func TestTerminate(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.ID = "long"
+	// Long enough that the search would take several seconds
+	request.MaxVisits = 100000
+	done := make(chan error, 1)
+	start := time.Now()
+	go func() {
+		_, err := katago.Analyze([]AnalysisRequest{request})
+		done <- err
+	}()
+	for !katago.isPending("long") {
+		time.Sleep(time.Millisecond)
+	}
+	if _, err := katago.Analyze([]AnalysisRequest{request}); !errors.Is(err, ErrDuplicateID) {
+		t.Errorf("Expected ErrDuplicateID, got %v", err)
+	}
+	if err := katago.Terminate("long"); err != nil {
+		t.Fatalf("Failed to terminate the query: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected the terminated query to return, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("Expected the query to stop early, it took %v", elapsed)
+	}
+	if err := katago.Terminate("long"); !errors.Is(err, ErrUnknownQuery) {
+		t.Errorf("Expected ErrUnknownQuery, got %v", err)
+	}
+}
+
+func TestRequestError(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	// The board is too large for this build of KataGo, but not for Validate
+	defer func(size int) { MaxBoardSize = size }(MaxBoardSize)
+	MaxBoardSize = 25
+	request := NewRequest9x9()
+	request.ID = "too-large"
+	request.BoardXSize, request.BoardYSize = 21, 21
+	_, err := katago.Analyze([]AnalysisRequest{request})
+	var requestErr *RequestError
+	if !errors.As(err, &requestErr) {
+		t.Fatalf("Expected a *RequestError, got %v", err)
+	}
+	if requestErr.ID != "too-large" || requestErr.Field != "boardXSize" || requestErr.Warning {
+		t.Errorf("Expected an error for the board size of too-large, got %+v", requestErr)
+	}
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
+
+func TestWarningIsNotAResponse(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	channels, err := katago.register("unknown-field")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer katago.unregister("unknown-field")
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	data, _ := json.Marshal(request)
+	var fields map[string]any
+	json.Unmarshal(data, &fields)
+	fields["id"] = "unknown-field"
+	fields["unknownField"] = true
+	if err := katago.write(katago.current(), fields); err != nil {
+		t.Fatal(err)
+	}
+	line, err := katago.wait(katago.current(), channels[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response AnalysisResponse
+	if err := json.Unmarshal(line, &response); err != nil || len(response.MoveInfos) == 0 {
+		t.Errorf("Expected the response after the warning, got %s", line)
+	}
+	warnings := katago.warningsFor("unknown-field")
+	if len(warnings) != 1 || warnings[0].Field != "unknownField" || warnings[0].Message == "" {
+		t.Errorf("Expected a warning about unknownField, got %v", warnings)
+	}
+}
+
+func TestMultipleTurnsDoNotLeak(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}}
+	request := p.Request("turns")
+	request.MaxVisits = 10
+	request.AnalyzeTurns = []int{3, 0, 1}
+	responses, err := katago.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 || responses[0].TurnNumber != 0 || responses[1].TurnNumber != 1 || responses[2].TurnNumber != 3 {
+		t.Fatalf("Expected the responses for turns 0, 1 and 3, got %d responses", len(responses))
+	}
+	// The next query only gets its own response
+	request = p.Request("after")
+	request.MaxVisits = 10
+	responses, err = katago.Analyze([]AnalysisRequest{request})
+	if err != nil || len(responses) != 1 || responses[0].ID != "after" {
+		t.Errorf("Expected one response for after, got %v (%v)", responses, err)
+	}
+	if _, err := katago.AnalyzeStream(AnalysisRequest{ID: "stream", AnalyzeTurns: []int{0, 1}}, func(AnalysisResponse) {}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest for streaming several turns, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package katago
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// EndgameMove is a move of the endgame with how many points it is worth, for teaching which moves are the
+// biggest. The scores are how many points the player to move is ahead.
+type EndgameMove struct {
+	Move string
+	// Value is the difference between the score after the player to move plays the move, and the score after
+	// the opponent plays there first, which is how much the move is worth with deiri counting
+	Value float64
+	// ScorePlayed is the score after the player to move plays the move
+	ScorePlayed float64
+	// ScoreOpponent is the score after the player to move passes, and the opponent plays the move
+	ScoreOpponent float64
+}
+
+// MiaiValue returns half of the value of the move, which is how most Go books count endgame moves
+func (m EndgameMove) MiaiValue() float64 {
+	return m.Value / 2
+}
+
+// String returns the move and its value, like "C1: 6.2 points"
+func (m EndgameMove) String() string {
+	return fmt.Sprintf("%s: %.1f points", m.Move, m.Value)
+}
+
+// EndgameMoves returns the biggest remaining moves of a late-game position, the biggest first. The candidates
+// are the n best moves of each player, and each candidate is valued by analyzing the position after the
+// player to move plays it, and after the opponent plays it first, with the given number of visits. Moves that
+// only one of the players can play, like retaking a ko, are left out. The values are only meaningful in the
+// endgame, when the moves are independent of each other.
+func (k *KataGo) EndgameMoves(p Position, n, visits int) ([]EndgameMove, error) {
+	if n <= 0 {
+		return nil, fmt.Errorf("%w: the number of candidates must be positive, got %d", ErrBadRequest, n)
+	}
+	b, err := p.Board()
+	if err != nil {
+		return nil, err
+	}
+	player := Color(p.ToPlay())
+	passed := p
+	passed.Moves = append(slices.Clone(p.Moves), Move{player, "pass"})
+	positions := []Position{p, passed}
+	responses, err := k.analyzePositions(positions, visits)
+	if err != nil {
+		return nil, err
+	}
+
+	// The candidates of both players, since the biggest move can be one that the opponent wants to play
+	var candidates []string
+	for _, response := range responses {
+		for _, info := range response.TopMoves(n) {
+			if strings.EqualFold(info.Move, "pass") || slices.Contains(candidates, info.Move) {
+				continue
+			}
+			point, err := b.ParseVertex(info.Move)
+			if err != nil {
+				return nil, err
+			}
+			if !b.IsLegal(board.Black, point) || !b.IsLegal(board.White, point) {
+				continue
+			}
+			candidates = append(candidates, info.Move)
+		}
+	}
+	positions = positions[:0]
+	for _, move := range candidates {
+		played, opponent := p, passed
+		played.Moves = append(slices.Clone(p.Moves), Move{player, Vertex(move)})
+		opponent.Moves = append(slices.Clone(passed.Moves), Move{player.Opponent(), Vertex(move)})
+		positions = append(positions, played, opponent)
+	}
+	if responses, err = k.analyzePositions(positions, visits); err != nil {
+		return nil, err
+	}
+	moves := make([]EndgameMove, len(candidates))
+	for i, move := range candidates {
+		m := EndgameMove{
+			Move:          move,
+			ScorePlayed:   responses[2*i].ScoreFor(player),
+			ScoreOpponent: responses[2*i+1].ScoreFor(player),
+		}
+		m.Value = m.ScorePlayed - m.ScoreOpponent
+		moves[i] = m
+	}
+	slices.SortStableFunc(moves, func(a, b EndgameMove) int {
+		if c := cmp.Compare(b.Value, a.Value); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Move, b.Move)
+	})
+	return moves, nil
+}
+
+// analyzePositions analyzes the positions at once, and returns the responses in the same order, with the
+// current player filled in
+func (k *KataGo) analyzePositions(positions []Position, visits int) ([]AnalysisResponse, error) {
+	if len(positions) == 0 {
+		return nil, nil
+	}
+	requests := make([]AnalysisRequest, len(positions))
+	for i, position := range positions {
+		requests[i] = position.Request(k.newID("endgame"))
+		requests[i].MaxVisits = visits
+	}
+	responses, err := k.Analyze(requests)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) != len(positions) {
+		return nil, errors.New("expected one response for each position")
+	}
+	for i := range responses {
+		if responses[i].RootInfo.CurrentPlayer == "" {
+			responses[i].RootInfo.CurrentPlayer = positions[i].ToPlay()
+		}
+	}
+	return responses, nil
+}
@@ -0,0 +1,43 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEndgameMoves(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{Black, "E5"}, {White, "C3"}, {Black, "G7"}}
+	moves, err := k.EndgameMoves(p, 3, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) < 3 || len(moves) > 6 {
+		t.Fatalf("Expected the 3 best moves of each player, got %v", moves)
+	}
+	seen := make(map[string]bool)
+	for i, m := range moves {
+		if seen[m.Move] || m.Move == "pass" {
+			t.Errorf("Expected each candidate once and no passes, got %v", moves)
+		}
+		seen[m.Move] = true
+		if m.Value != m.ScorePlayed-m.ScoreOpponent || m.MiaiValue() != m.Value/2 {
+			t.Errorf("Expected the value to be the difference of the scores, got %+v", m)
+		}
+		if i > 0 && m.Value > moves[i-1].Value {
+			t.Errorf("Expected the biggest moves first, got %v", moves)
+		}
+	}
+	if _, err := k.EndgameMoves(p, 0, 20); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest without candidates, got %v", err)
+	}
+}
+
+func TestEndgameMoveString(t *testing.T) {
+	m := EndgameMove{Move: "C1", Value: 6.24}
+	if s := m.String(); s != "C1: 6.2 points" {
+		t.Errorf("Expected C1: 6.2 points, got %q", s)
+	}
+}
@@ -0,0 +1,161 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// PositionComparison holds the evaluations of one position by two engines
+type PositionComparison struct {
+	Index    int
+	Position Position
+	A, B     AnalysisResponse
+	// BestA and BestB are the moves that each engine prefers
+	BestA, BestB string
+	// WinrateDiff and ScoreLeadDiff are the values of B minus the values of A, for the player to move
+	WinrateDiff   float64
+	ScoreLeadDiff float64
+}
+
+// SameBestMove checks if both engines prefer the same move
+func (c PositionComparison) SameBestMove() bool {
+	return c.BestA == c.BestB
+}
+
+// EngineComparison is the result of CompareEngines
+type EngineComparison struct {
+	Positions []PositionComparison
+	// Durations are the time each engine took to analyze all the positions
+	DurationA, DurationB time.Duration
+	// Visits are the total number of visits for each engine
+	VisitsA, VisitsB int
+}
+
+// AgreementRate returns the fraction (0 to 1) of positions where both engines prefer the same move
+func (c *EngineComparison) AgreementRate() float64 {
+	if len(c.Positions) == 0 {
+		return 0
+	}
+	same := 0
+	for _, p := range c.Positions {
+		if p.SameBestMove() {
+			same++
+		}
+	}
+	return float64(same) / float64(len(c.Positions))
+}
+
+// MeanAbsWinrateDiff returns the average difference in winrate between the engines
+func (c *EngineComparison) MeanAbsWinrateDiff() float64 {
+	if len(c.Positions) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, p := range c.Positions {
+		total += math.Abs(p.WinrateDiff)
+	}
+	return total / float64(len(c.Positions))
+}
+
+// MeanAbsScoreLeadDiff returns the average difference in score lead between the engines
+func (c *EngineComparison) MeanAbsScoreLeadDiff() float64 {
+	if len(c.Positions) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, p := range c.Positions {
+		total += math.Abs(p.ScoreLeadDiff)
+	}
+	return total / float64(len(c.Positions))
+}
+
+// VisitsPerSecond returns the speed of each engine
+func (c *EngineComparison) VisitsPerSecond() (float64, float64) {
+	speed := func(visits int, d time.Duration) float64 {
+		if d <= 0 {
+			return 0
+		}
+		return float64(visits) / d.Seconds()
+	}
+	return speed(c.VisitsA, c.DurationA), speed(c.VisitsB, c.DurationB)
+}
+
+// Disagreements returns the positions where the engines prefer different moves, or where the winrates differ by
+// at least winrateThreshold (0 to 1), with the largest winrate differences first
+func (c *EngineComparison) Disagreements(winrateThreshold float64) []PositionComparison {
+	var disagreements []PositionComparison
+	for _, p := range c.Positions {
+		if !p.SameBestMove() || math.Abs(p.WinrateDiff) >= winrateThreshold {
+			disagreements = append(disagreements, p)
+		}
+	}
+	sort.SliceStable(disagreements, func(i, j int) bool {
+		return math.Abs(disagreements[i].WinrateDiff) > math.Abs(disagreements[j].WinrateDiff)
+	})
+	return disagreements
+}
+
+// String returns a summary of the comparison
+func (c *EngineComparison) String() string {
+	speedA, speedB := c.VisitsPerSecond()
+	return fmt.Sprintf("%d positions: same best move in %.1f%%, winrate difference %.1f%%, score lead difference %.2f, %.0f vs %.0f visits/s",
+		len(c.Positions), 100*c.AgreementRate(), 100*c.MeanAbsWinrateDiff(), c.MeanAbsScoreLeadDiff(), speedA, speedB)
+}
+
+// analyzeAll analyzes the positions with the given number of visits, and returns the responses and the time it took
+func (k *KataGo) analyzeAll(positions []Position, visits int, prefix string) ([]AnalysisResponse, time.Duration, error) {
+	requests := make([]AnalysisRequest, len(positions))
+	for i, p := range positions {
+		requests[i] = p.Request(k.newID(prefix))
+		requests[i].MaxVisits = visits
+	}
+	start := time.Now()
+	responses, err := k.Analyze(requests)
+	duration := time.Since(start)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(responses) != len(positions) {
+		return nil, 0, fmt.Errorf("expected %d responses, got %d", len(positions), len(responses))
+	}
+	return responses, duration, nil
+}
+
+// CompareEngines analyzes the same positions with two engines, which may use different models or settings,
+// and compares their evaluations, their preferred moves and their speed. This helps with deciding whether to
+// upgrade to a new network. The engines run one after the other, so that the timings are not affected.
+func CompareEngines(a, b *KataGo, positions []Position, visits int) (*EngineComparison, error) {
+	if len(positions) == 0 {
+		return nil, errors.New("no positions to compare")
+	}
+	responsesA, durationA, err := a.analyzeAll(positions, visits, "compare-a")
+	if err != nil {
+		return nil, fmt.Errorf("engine A: %w", err)
+	}
+	responsesB, durationB, err := b.analyzeAll(positions, visits, "compare-b")
+	if err != nil {
+		return nil, fmt.Errorf("engine B: %w", err)
+	}
+	c := &EngineComparison{DurationA: durationA, DurationB: durationB}
+	for i, p := range positions {
+		ra, rb := responsesA[i], responsesB[i]
+		c.VisitsA += ra.RootInfo.Visits
+		c.VisitsB += rb.RootInfo.Visits
+		bestA, _ := ra.BestMove()
+		bestB, _ := rb.BestMove()
+		c.Positions = append(c.Positions, PositionComparison{
+			Index:         i,
+			Position:      p,
+			A:             ra,
+			B:             rb,
+			BestA:         bestA.Move,
+			BestB:         bestB.Move,
+			WinrateDiff:   rb.RootInfo.Winrate - ra.RootInfo.Winrate,
+			ScoreLeadDiff: rb.RootInfo.ScoreLead - ra.RootInfo.ScoreLead,
+		})
+	}
+	return c, nil
+}
@@ -0,0 +1,68 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCompareEngines(t *testing.T) {
+	a := initKataGo(t)
+	defer cleanupKataGo(t, a)
+	b := initKataGo(t)
+	defer cleanupKataGo(t, b)
+	var positions []Position
+	for _, moves := range [][]Move{{}, {{"B", "E5"}}, {{"B", "E5"}, {"W", "C3"}}} {
+		p := NewPosition(9, 9)
+		p.Moves = moves
+		positions = append(positions, p)
+	}
+	c, err := CompareEngines(a, b, positions, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Positions) != 3 || c.VisitsA != 60 || c.VisitsB != 60 {
+		t.Fatalf("Unexpected comparison: %v", c)
+	}
+	// The same engine and model give the same results
+	if c.AgreementRate() != 1 || c.MeanAbsWinrateDiff() != 0 || len(c.Disagreements(0.01)) != 0 {
+		t.Errorf("Expected the engines to agree, got %v", c)
+	}
+	if speedA, speedB := c.VisitsPerSecond(); speedA <= 0 || speedB <= 0 {
+		t.Errorf("Expected positive speeds, got %v and %v", speedA, speedB)
+	}
+}
+
+func TestDisagreements(t *testing.T) {
+	c := &EngineComparison{
+		Positions: []PositionComparison{
+			{Index: 0, BestA: "D4", BestB: "D4", WinrateDiff: 0.01},
+			{Index: 1, BestA: "D4", BestB: "Q16", WinrateDiff: 0.02},
+			{Index: 2, BestA: "C3", BestB: "C3", WinrateDiff: -0.2},
+		},
+		VisitsA:   100,
+		DurationA: time.Second,
+	}
+	disagreements := c.Disagreements(0.1)
+	if len(disagreements) != 2 || disagreements[0].Index != 2 || disagreements[1].Index != 1 {
+		t.Errorf("Unexpected disagreements: %v", disagreements)
+	}
+	if rate := c.AgreementRate(); rate != 2.0/3 {
+		t.Errorf("Expected an agreement rate of 2/3, got %v", rate)
+	}
+	if speedA, speedB := c.VisitsPerSecond(); speedA != 100 || speedB != 0 {
+		t.Errorf("Unexpected speeds: %v and %v", speedA, speedB)
+	}
+}
+
+func TestCompareEnginesError(t *testing.T) {
+	a := initKataGo(t)
+	defer cleanupKataGo(t, a)
+	b := initKataGo(t)
+	defer cleanupKataGo(t, b)
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{"B", "Z99"}}
+	if _, err := CompareEngines(a, b, []Position{p}, 20); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
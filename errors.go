@@ -0,0 +1,18 @@
+package katago
+
+import "errors"
+
+// Errors for the ways that talking to KataGo can fail, which can be checked with errors.Is.
+// They are returned wrapped, with the details, like the ID of the query.
+var (
+	// ErrEngineNotStarted is returned when the KataGo process can not be started
+	ErrEngineNotStarted = errors.New("KataGo could not be started")
+	// ErrEngineCrashed is returned when KataGo stops while a response is expected, or can not be written to
+	ErrEngineCrashed = errors.New("KataGo has stopped")
+	// ErrQueryTerminated is returned when a query is terminated before KataGo has found anything
+	ErrQueryTerminated = errors.New("the query was terminated")
+	// ErrQueryTimeout is returned when a request times out before KataGo has found anything
+	ErrQueryTimeout = errors.New("the analysis timed out")
+	// ErrBadRequest is returned when a request is invalid, or when KataGo rejects it
+	ErrBadRequest = errors.New("invalid request")
+)
@@ -0,0 +1,47 @@
+package katago
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrEngineNotStarted(t *testing.T) {
+	_, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithBinary(filepath.Join(t.TempDir(), "katago")))
+	if !errors.Is(err, ErrEngineNotStarted) {
+		t.Errorf("Expected ErrEngineNotStarted, got %v", err)
+	}
+}
+
+func TestErrBadRequest(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.Rules = "unknown"
+	if _, err := katago.Analyze([]AnalysisRequest{request}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	if _, err := katago.AnalyzeStream(request, func(AnalysisResponse) {}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	if err := responseError([]byte(`{"id":"x","error":"Could not parse","field":"moves"}`)); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
+
+func TestErrEngineCrashed(t *testing.T) {
+	katago := initKataGo(t)
+	if err := katago.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := katago.Analyze([]AnalysisRequest{NewRequest9x9()}); !errors.Is(err, ErrEngineCrashed) {
+		t.Errorf("Expected ErrEngineCrashed, got %v", err)
+	}
+}
+
+func TestErrTimeoutAlias(t *testing.T) {
+	if !errors.Is(ErrTimeout, ErrQueryTimeout) {
+		t.Error("Expected ErrTimeout to be ErrQueryTimeout")
+	}
+}
@@ -0,0 +1,44 @@
+package katago
+
+// Exploration holds the settings that decide how broadly KataGo searches at the root of the tree,
+// and how willing it is to choose moves other than the best one
+type Exploration struct {
+	// WideRootNoise makes the search look at more moves at the root. The analysis engine uses 0.04 by default,
+	// 0 gives the narrowest search and values up to about 0.1 give a wider search.
+	WideRootNoise float64
+	// RootPolicyTemperature flattens the policy at the root when it is above 1, so that less likely moves are searched
+	RootPolicyTemperature float64
+	// ChosenMoveTemperature is used when choosing a move from the search results, where 0 always picks the best one
+	ChosenMoveTemperature float64
+}
+
+// Exploration presets
+var (
+	// ExplorationNarrow searches the moves that the network likes, which gives the strongest play per visit
+	ExplorationNarrow = Exploration{WideRootNoise: 0, RootPolicyTemperature: 1, ChosenMoveTemperature: 0}
+	// ExplorationWide searches many more candidate moves, which gives a more humanlike spread of
+	// suggestions and better evaluations of moves that the network does not like
+	ExplorationWide = Exploration{WideRootNoise: 0.08, RootPolicyTemperature: 1.5, ChosenMoveTemperature: 0.5}
+)
+
+// SetWideRootNoise sets the wideRootNoise setting for this request
+func (r *AnalysisRequest) SetWideRootNoise(noise float64) {
+	r.SetOverride("wideRootNoise", noise)
+}
+
+// SetRootPolicyTemperature sets the rootPolicyTemperature setting for this request
+func (r *AnalysisRequest) SetRootPolicyTemperature(temperature float64) {
+	r.SetOverride("rootPolicyTemperature", temperature)
+}
+
+// SetChosenMoveTemperature sets the chosenMoveTemperature setting for this request
+func (r *AnalysisRequest) SetChosenMoveTemperature(temperature float64) {
+	r.SetOverride("chosenMoveTemperature", temperature)
+}
+
+// SetExploration sets all of the exploration settings for this request, like ExplorationWide
+func (r *AnalysisRequest) SetExploration(e Exploration) {
+	r.SetWideRootNoise(e.WideRootNoise)
+	r.SetRootPolicyTemperature(e.RootPolicyTemperature)
+	r.SetChosenMoveTemperature(e.ChosenMoveTemperature)
+}
@@ -0,0 +1,27 @@
+package katago
+
+import (
+	"testing"
+)
+
+func TestSetExploration(t *testing.T) {
+	request := NewRequest9x9()
+	request.SetExploration(ExplorationWide)
+	if request.OverrideSettings["wideRootNoise"] != 0.08 || request.OverrideSettings["rootPolicyTemperature"] != 1.5 {
+		t.Errorf("Expected the wide exploration settings, got %v", request.OverrideSettings)
+	}
+	request.SetExploration(ExplorationNarrow)
+	if request.OverrideSettings["wideRootNoise"] != 0.0 || request.OverrideSettings["chosenMoveTemperature"] != 0.0 {
+		t.Errorf("Expected the narrow exploration settings, got %v", request.OverrideSettings)
+	}
+	if len(request.OverrideSettings) != 3 {
+		t.Errorf("Expected 3 override settings, got %d", len(request.OverrideSettings))
+	}
+
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request.MaxVisits = 20
+	if _, err := k.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Errorf("Expected the request to be accepted, got %v", err)
+	}
+}
@@ -0,0 +1,183 @@
+// Package fetch downloads SGF game records from game servers and archives, given the URL of a game or
+// a reference like "ogs:12345", and reads the players, ranks, result and date of the game in the same way
+// for every source, so that games can be reviewed straight from the web
+package fetch
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/sgf"
+)
+
+// ErrUnsupported is returned when none of the fetchers can download a game
+var ErrUnsupported = errors.New("unsupported game source")
+
+// Fetcher downloads games from one source
+type Fetcher interface {
+	// Name is the name of the source, like "ogs"
+	Name() string
+	// Match reports whether the fetcher can download the game with the given URL or reference
+	Match(ref string) bool
+	// Fetch downloads the SGF of the game
+	Fetch(ctx context.Context, client *http.Client, ref string) (string, error)
+}
+
+// Info is the information about a game from the root node of its SGF, in the same form for every source
+type Info struct {
+	Black     string
+	White     string
+	BlackRank string
+	WhiteRank string
+	// Result is like "B+R", "W+3.5", "Draw" or "Void", or empty if the result is not known
+	Result string
+	// Date is the first date of the game, like "2024-01-31", or as much of it as is known
+	Date  string
+	Event string
+	Place string
+}
+
+// Game is a game that was downloaded from a source
+type Game struct {
+	// Source is the name of the fetcher that downloaded the game, and Ref is the URL or reference that was given
+	Source string
+	Ref    string
+	SGF    string
+	Root   *sgf.Node
+	Info   Info
+	// Position is the position at the end of the main line, and is only set by Load and LoadGame
+	Position katago.Position
+}
+
+// Downloader downloads games with the first fetcher that matches
+type Downloader struct {
+	Client   *http.Client
+	Fetchers []Fetcher
+}
+
+// NewDownloader returns a downloader with the fetchers for OGS, KGS, GoKifu and links to SGF files
+func NewDownloader() *Downloader {
+	return &Downloader{
+		Client:   http.DefaultClient,
+		Fetchers: []Fetcher{NewOGS(), NewKGS(), NewGoKifu(), URL{}},
+	}
+}
+
+// Register adds a fetcher, which is tried before the other fetchers
+func (d *Downloader) Register(f Fetcher) {
+	d.Fetchers = append([]Fetcher{f}, d.Fetchers...)
+}
+
+// Fetch downloads and parses a game
+func (d *Downloader) Fetch(ctx context.Context, ref string) (*Game, error) {
+	ref = strings.TrimSpace(ref)
+	for _, f := range d.Fetchers {
+		if !f.Match(ref) {
+			continue
+		}
+		data, err := f.Fetch(ctx, d.Client, ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name(), err)
+		}
+		return parse(f.Name(), ref, data)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupported, ref)
+}
+
+// parse parses a downloaded game
+func parse(source, ref, data string) (*Game, error) {
+	root, err := sgf.ParseGame(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	return &Game{Source: source, Ref: ref, SGF: data, Root: root, Info: ReadInfo(root)}, nil
+}
+
+var (
+	rankPattern   = regexp.MustCompile(`(?i)^(\d+)\s*(k|kyu|d|dan|p|pro)\b`)
+	resultPattern = regexp.MustCompile(`(?i)^(b|w|black|white)\s*\+\s*(.*)$`)
+	datePattern   = regexp.MustCompile(`^(\d{4})(?:-(\d{1,2})(?:-(\d{1,2}))?)?`)
+)
+
+// normalizeRank converts ranks like "5 dan", "3K" or "9p" to "5d", "3k" and "9p". Unknown ranks are returned as they are.
+func normalizeRank(s string) string {
+	s = strings.TrimSpace(s)
+	m := rankPattern.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	return m[1] + strings.ToLower(m[2][:1])
+}
+
+// normalizeResult converts results like "Black+Resign", "W+3.5" or "0" to "B+R", "W+3.5" and "Draw"
+func normalizeResult(s string) string {
+	s = strings.TrimSpace(s)
+	switch strings.ToLower(s) {
+	case "0", "draw", "jigo":
+		return "Draw"
+	case "":
+		return ""
+	case "void", "?":
+		return "Void"
+	}
+	m := resultPattern.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	winner := strings.ToUpper(m[1][:1])
+	switch how := strings.ToLower(strings.TrimSpace(m[2])); how {
+	case "r", "res", "resign", "resignation":
+		return winner + "+R"
+	case "t", "time", "timeout":
+		return winner + "+T"
+	case "f", "forfeit":
+		return winner + "+F"
+	case "":
+		return winner + "+"
+	default:
+		if _, err := strconv.ParseFloat(how, 64); err == nil {
+			return winner + "+" + how
+		}
+		return winner + "+" + strings.TrimSpace(m[2])
+	}
+}
+
+// normalizeDate returns the first date of a DT property, like "2024-01-31" from "2024-01-31,02-01"
+func normalizeDate(s string) string {
+	s = strings.ReplaceAll(strings.TrimSpace(s), "/", "-")
+	m := datePattern.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	date := m[1]
+	for _, part := range m[2:] {
+		if part == "" {
+			break
+		}
+		if len(part) == 1 {
+			part = "0" + part
+		}
+		date += "-" + part
+	}
+	return date
+}
+
+// ReadInfo returns the information about a game from the root node of its SGF
+func ReadInfo(root *sgf.Node) Info {
+	return Info{
+		Black:     strings.TrimSpace(root.Get("PB")),
+		White:     strings.TrimSpace(root.Get("PW")),
+		BlackRank: normalizeRank(root.Get("BR")),
+		WhiteRank: normalizeRank(root.Get("WR")),
+		Result:    normalizeResult(root.Get("RE")),
+		Date:      normalizeDate(root.Get("DT")),
+		Event:     strings.TrimSpace(root.Get("EV")),
+		Place:     strings.TrimSpace(root.Get("PC")),
+	}
+}
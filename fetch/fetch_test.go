@@ -0,0 +1,82 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xyproto/katago/sgf"
+)
+
+const gameSGF = "(;GM[1]SZ[19]PB[Alice]PW[Bob]BR[3 dan]WR[2D]RE[Black+Resign]DT[2024-01-31,2024-02-01];B[pd];W[dp])"
+
+func TestReadInfo(t *testing.T) {
+	root, err := sgf.ParseGame(gameSGF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := ReadInfo(root)
+	expected := Info{Black: "Alice", White: "Bob", BlackRank: "3d", WhiteRank: "2d", Result: "B+R", Date: "2024-01-31"}
+	if info != expected {
+		t.Errorf("Expected %+v, got %+v", expected, info)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for s, expected := range map[string]string{
+		"W+3.5": "W+3.5", "white + time": "W+T", "0": "Draw", "Jigo": "Draw", "?": "Void", "": "",
+	} {
+		if result := normalizeResult(s); result != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, s, result)
+		}
+	}
+	for s, expected := range map[string]string{"5 kyu": "5k", "9p": "9p", "1d?": "1d", "unknown": "unknown"} {
+		if rank := normalizeRank(s); rank != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, s, rank)
+		}
+	}
+	for s, expected := range map[string]string{"2024/1/5": "2024-01-05", "2024": "2024", "1998-07": "1998-07"} {
+		if date := normalizeDate(s); date != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, s, date)
+		}
+	}
+}
+
+type staticFetcher struct{}
+
+func (staticFetcher) Name() string          { return "static" }
+func (staticFetcher) Match(ref string) bool { return ref == "static:game" }
+func (staticFetcher) Fetch(ctx context.Context, client *http.Client, ref string) (string, error) {
+	return gameSGF, nil
+}
+
+func TestDownloader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/games/game.sgf" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(gameSGF))
+	}))
+	defer ts.Close()
+	d := NewDownloader()
+	g, err := d.Fetch(context.Background(), ts.URL+"/games/game.sgf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Source != "url" || g.Info.Black != "Alice" || len(g.Root.MainLine()) != 3 {
+		t.Errorf("Unexpected game: %+v", g)
+	}
+	if _, err := d.Fetch(context.Background(), ts.URL+"/games/missing.sgf"); err == nil {
+		t.Errorf("Expected an error for a missing game")
+	}
+	if _, err := d.Fetch(context.Background(), "static:game"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got %v", err)
+	}
+	d.Register(staticFetcher{})
+	if g, err := d.Fetch(context.Background(), "static:game"); err != nil || g.Source != "static" {
+		t.Errorf("Expected a game from the registered fetcher, got %v", err)
+	}
+}
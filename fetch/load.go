@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/xyproto/katago/sgf"
+)
+
+// Detect returns the name of the fetcher that downloads ref, or "" if there is none
+func (d *Downloader) Detect(ref string) string {
+	ref = strings.TrimSpace(ref)
+	for _, f := range d.Fetchers {
+		if f.Match(ref) {
+			return f.Name()
+		}
+	}
+	return ""
+}
+
+// looksLikeSGF reports whether data starts like an SGF file
+func looksLikeSGF(data string) bool {
+	data = strings.TrimPrefix(strings.TrimSpace(data), "\ufeff")
+	return strings.HasPrefix(data, "(") && strings.HasPrefix(strings.TrimSpace(data[1:]), ";")
+}
+
+// fetchAny downloads a game like Fetch, but also downloads links that no fetcher matches,
+// and uses them if they contain an SGF file
+func (d *Downloader) fetchAny(ctx context.Context, ref string) (*Game, error) {
+	u, err := url.Parse(ref)
+	if d.Detect(ref) != "" || err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return d.Fetch(ctx, ref)
+	}
+	data, err := get(ctx, d.Client, ref)
+	if err != nil {
+		return nil, err
+	}
+	if !looksLikeSGF(data) {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupported, ref)
+	}
+	return parse(URL{}.Name(), ref, data)
+}
+
+// Load downloads a game like Fetch, and also returns the position at the end of the game, so that it can be
+// analyzed right away. Links that no fetcher matches, like links to SGF files without an ".sgf" extension,
+// are downloaded and used if they contain an SGF file.
+func (d *Downloader) Load(ctx context.Context, ref string) (*Game, error) {
+	ref = strings.TrimSpace(ref)
+	g, err := d.fetchAny(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	if g.Position, err = sgf.Position(g.Root); err != nil {
+		return nil, fmt.Errorf("%s: %v", g.Source, err)
+	}
+	// Check that the moves are legal, so that the position can be analyzed
+	if _, err := g.Position.Board(); err != nil {
+		return nil, fmt.Errorf("%s: %v", g.Source, err)
+	}
+	return g, nil
+}
+
+// LoadGame downloads a game from OGS, KGS, GoKifu or a link to an SGF file, detecting the site from the URL,
+// and returns it with the position at the end of the game
+func LoadGame(ctx context.Context, ref string) (*Game, error) {
+	return NewDownloader().Load(ctx, ref)
+}
@@ -0,0 +1,45 @@
+package fetch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/raw/abc", "/game.sgf":
+			w.Write([]byte(gameSGF))
+		case "/illegal.sgf":
+			w.Write([]byte("(;SZ[9];B[ee];W[ee])"))
+		default:
+			w.Write([]byte("<html></html>"))
+		}
+	}))
+	defer ts.Close()
+	d := NewDownloader()
+	if source := d.Detect("https://online-go.com/game/view/1"); source != "ogs" {
+		t.Errorf("Expected ogs, got %q", source)
+	}
+	if source := d.Detect(ts.URL + "/raw/abc"); source != "" {
+		t.Errorf("Expected no source, got %q", source)
+	}
+	for _, ref := range []string{ts.URL + "/game.sgf", ts.URL + "/raw/abc"} {
+		g, err := d.Load(context.Background(), ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(g.Position.Moves) != 2 || g.Position.BoardXSize != 19 {
+			t.Errorf("Expected a 19x19 position with 2 moves, got %+v", g.Position)
+		}
+	}
+	if _, err := d.Load(context.Background(), ts.URL+"/page"); !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported for a page without an SGF file, got %v", err)
+	}
+	if _, err := d.Load(context.Background(), ts.URL+"/illegal.sgf"); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
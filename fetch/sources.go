@@ -0,0 +1,209 @@
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago/ogs"
+)
+
+// get downloads a URL
+func get(ctx context.Context, client *http.Client, u string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to get %s: %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	return string(data), err
+}
+
+// sameHost reports whether ref is a URL on the same host as base
+func sameHost(ref, base string) bool {
+	u, err := url.Parse(ref)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	b, err := url.Parse(base)
+	return err == nil && strings.EqualFold(strings.TrimPrefix(u.Host, "www."), strings.TrimPrefix(b.Host, "www."))
+}
+
+// OGS downloads games from online-go.com, given the URL of a game or a reference like "ogs:12345"
+type OGS struct {
+	BaseURL string
+}
+
+// NewOGS returns a fetcher for online-go.com
+func NewOGS() OGS {
+	return OGS{BaseURL: ogs.DefaultBaseURL}
+}
+
+// Name returns "ogs"
+func (f OGS) Name() string {
+	return "ogs"
+}
+
+// id returns the ID of the game
+func (f OGS) id(ref string) (int, bool) {
+	if s, ok := strings.CutPrefix(ref, "ogs:"); ok {
+		id, err := strconv.Atoi(s)
+		return id, err == nil
+	}
+	if !sameHost(ref, f.BaseURL) {
+		return 0, false
+	}
+	id, err := ogs.ParseGameID(ref)
+	return id, err == nil
+}
+
+// Match reports whether ref is an OGS game
+func (f OGS) Match(ref string) bool {
+	_, ok := f.id(ref)
+	return ok
+}
+
+// Fetch downloads the SGF of the game from the OGS API
+func (f OGS) Fetch(ctx context.Context, client *http.Client, ref string) (string, error) {
+	id, ok := f.id(ref)
+	if !ok {
+		return "", fmt.Errorf("not an OGS game: %q", ref)
+	}
+	c := &ogs.Client{HTTPClient: client, BaseURL: f.BaseURL}
+	return c.SGF(ctx, id)
+}
+
+// KGS downloads games from the KGS archives, given the URL of an SGF file on files.gokgs.com or a reference like
+// "kgs:2024/1/31/white-black", which is the date and the names of the players, with "-2" and so on after the names
+// for later games on the same day
+type KGS struct {
+	BaseURL string
+}
+
+// NewKGS returns a fetcher for the KGS archives
+func NewKGS() KGS {
+	return KGS{BaseURL: "https://files.gokgs.com/games/"}
+}
+
+// Name returns "kgs"
+func (f KGS) Name() string {
+	return "kgs"
+}
+
+var kgsPattern = regexp.MustCompile(`^(\d{4})/(\d{1,2})/(\d{1,2})/([^/]+)$`)
+
+// url returns the address of the SGF file
+func (f KGS) url(ref string) (string, bool) {
+	if s, ok := strings.CutPrefix(ref, "kgs:"); ok {
+		m := kgsPattern.FindStringSubmatch(strings.TrimSuffix(s, ".sgf"))
+		if m == nil {
+			return "", false
+		}
+		// The archives do not have leading zeros in the month and day
+		month, _ := strconv.Atoi(m[2])
+		day, _ := strconv.Atoi(m[3])
+		return fmt.Sprintf("%s/%s/%d/%d/%s.sgf", strings.TrimSuffix(f.BaseURL, "/"), m[1], month, day, m[4]), true
+	}
+	if sameHost(ref, f.BaseURL) && strings.HasSuffix(strings.ToLower(ref), ".sgf") {
+		return ref, true
+	}
+	return "", false
+}
+
+// Match reports whether ref is a game in the KGS archives
+func (f KGS) Match(ref string) bool {
+	_, ok := f.url(ref)
+	return ok
+}
+
+// Fetch downloads the SGF of the game
+func (f KGS) Fetch(ctx context.Context, client *http.Client, ref string) (string, error) {
+	u, ok := f.url(ref)
+	if !ok {
+		return "", fmt.Errorf("not a KGS game: %q", ref)
+	}
+	return get(ctx, client, u)
+}
+
+// GoKifu downloads professional games from gokifu.com, given the URL of the page of a game, like
+// "https://gokifu.com/s/2k3m-gokifu-20240131-Shin_Jinseo-Ke_Jie.html", or a reference like "gokifu:2k3m"
+type GoKifu struct {
+	BaseURL string
+}
+
+// NewGoKifu returns a fetcher for gokifu.com
+func NewGoKifu() GoKifu {
+	return GoKifu{BaseURL: "https://gokifu.com"}
+}
+
+// Name returns "gokifu"
+func (f GoKifu) Name() string {
+	return "gokifu"
+}
+
+var gokifuPattern = regexp.MustCompile(`/(?:s|f)/([0-9a-z]+)(?:-[^/]*)?(?:\.html|\.sgf|\.gokifu)?$`)
+
+// id returns the ID of the game
+func (f GoKifu) id(ref string) (string, bool) {
+	if s, ok := strings.CutPrefix(ref, "gokifu:"); ok {
+		return s, s != "" && !strings.Contains(s, "/")
+	}
+	if !sameHost(ref, f.BaseURL) {
+		return "", false
+	}
+	u, err := url.Parse(ref)
+	if err != nil {
+		return "", false
+	}
+	m := gokifuPattern.FindStringSubmatch(u.Path)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// Match reports whether ref is a game on GoKifu
+func (f GoKifu) Match(ref string) bool {
+	_, ok := f.id(ref)
+	return ok
+}
+
+// Fetch downloads the SGF of the game
+func (f GoKifu) Fetch(ctx context.Context, client *http.Client, ref string) (string, error) {
+	id, ok := f.id(ref)
+	if !ok {
+		return "", fmt.Errorf("not a GoKifu game: %q", ref)
+	}
+	return get(ctx, client, fmt.Sprintf("%s/f/%s.sgf", strings.TrimSuffix(f.BaseURL, "/"), id))
+}
+
+// URL downloads SGF files from any HTTP or HTTPS URL that ends with ".sgf"
+type URL struct{}
+
+// Name returns "url"
+func (URL) Name() string {
+	return "url"
+}
+
+// Match reports whether ref is a link to an SGF file
+func (URL) Match(ref string) bool {
+	u, err := url.Parse(ref)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && strings.HasSuffix(strings.ToLower(u.Path), ".sgf")
+}
+
+// Fetch downloads the SGF file
+func (URL) Fetch(ctx context.Context, client *http.Client, ref string) (string, error) {
+	return get(ctx, client, ref)
+}
@@ -0,0 +1,69 @@
+package fetch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newSourceServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	for _, path := range []string{"/api/v1/games/123/sgf", "/games/2024/1/5/bob-alice.sgf", "/f/2k3m.sgf"} {
+		mux.HandleFunc("GET "+path, func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(gameSGF))
+		})
+	}
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestMatch(t *testing.T) {
+	for _, tc := range []struct {
+		f   Fetcher
+		ref string
+		ok  bool
+	}{
+		{NewOGS(), "https://online-go.com/game/12345", true},
+		{NewOGS(), "ogs:12345", true},
+		{NewOGS(), "https://example.com/game/12345", false},
+		{NewKGS(), "kgs:2024/01/05/bob-alice", true},
+		{NewKGS(), "https://files.gokgs.com/games/2024/1/5/bob-alice.sgf", true},
+		{NewKGS(), "kgs:bob-alice", false},
+		{NewGoKifu(), "https://gokifu.com/s/2k3m-gokifu-20240131-Shin_Jinseo-Ke_Jie.html", true},
+		{NewGoKifu(), "gokifu:2k3m", true},
+		{NewGoKifu(), "https://gokifu.com/", false},
+		{URL{}, "https://example.com/game.SGF", true},
+		{URL{}, "file:///game.sgf", false},
+	} {
+		if ok := tc.f.Match(tc.ref); ok != tc.ok {
+			t.Errorf("Expected %s to match %q: %v, got %v", tc.f.Name(), tc.ref, tc.ok, ok)
+		}
+	}
+}
+
+func TestSources(t *testing.T) {
+	ts := newSourceServer(t)
+	d := &Downloader{
+		Client:   ts.Client(),
+		Fetchers: []Fetcher{OGS{BaseURL: ts.URL}, KGS{BaseURL: ts.URL + "/games/"}, GoKifu{BaseURL: ts.URL}},
+	}
+	for ref, source := range map[string]string{
+		"ogs:123":                  "ogs",
+		ts.URL + "/game/123":       "ogs",
+		"kgs:2024/01/05/bob-alice": "kgs",
+		"gokifu:2k3m":              "gokifu",
+		ts.URL + "/s/2k3m-gokifu-20240131-Shin_Jinseo-Ke_Jie.html": "gokifu",
+	} {
+		g, err := d.Fetch(context.Background(), ref)
+		if err != nil {
+			t.Errorf("Failed to fetch %q: %v", ref, err)
+			continue
+		}
+		if g.Source != source || g.Info.White != "Bob" {
+			t.Errorf("Expected a game from %s, got %+v", source, g)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package katago
+
+import (
+	"fmt"
+	"log"
+)
+
+// GameChunkSize is how many turns AnalyzeGame puts in each query. KataGo searches the turns of a query
+// in parallel, and the queries too, so smaller chunks mostly help with spreading a long game over
+// the search threads while keeping each request line short.
+var GameChunkSize = 50
+
+// AnalyzeGame analyzes every turn of the moves in the request, from the empty board or the initial stones
+// up to the final position, and returns the responses indexed by turn number. The AnalyzeTurns of the
+// request are ignored, and the turns are split over queries of GameChunkSize turns each. KataGo sends
+// one response for each analyzed turn, and they are collected before returning.
+func (k *KataGo) AnalyzeGame(request AnalysisRequest) ([]AnalysisResponse, error) {
+	request = k.defaults.apply(request)
+	request.AnalyzeTurns = nil
+	if err := request.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	turns := len(request.Moves) + 1
+	chunkSize := max(1, GameChunkSize)
+	var chunks []AnalysisRequest
+	for start := 0; start < turns; start += chunkSize {
+		chunk := request
+		chunk.ID = fmt.Sprintf("%s-%d", request.ID, start)
+		chunk.AnalyzeTurns = nil
+		for turn := start; turn < min(turns, start+chunkSize); turn++ {
+			chunk.AnalyzeTurns = append(chunk.AnalyzeTurns, turn)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if err := k.queue.acquire(len(chunks)); err != nil {
+		return nil, err
+	}
+	defer k.queue.release(len(chunks))
+	if k.clearCacheEachGame {
+		if err := k.ClearCache(); err != nil {
+			return nil, err
+		}
+	}
+	visits := k.limiter.acquire(chunks)
+	defer k.limiter.release(visits)
+	channels := make([]chan []byte, len(chunks))
+	for i, chunk := range chunks {
+		ch, err := k.registerTurns(chunk.ID, len(chunk.AnalyzeTurns))
+		if err != nil {
+			return nil, err
+		}
+		defer k.unregister(chunk.ID)
+		channels[i] = ch
+	}
+	procs := make([]*process, len(chunks))
+	timeouts := make([]func() bool, len(chunks))
+	for i, chunk := range chunks {
+		log.Printf("Sending request: %v", chunk)
+		p, err := k.send(chunk)
+		if err != nil {
+			return nil, err
+		}
+		procs[i] = p
+		timeouts[i] = k.terminateAfter(chunk)
+		defer timeouts[i]()
+	}
+	if k.preemption {
+		k.preempt(chunks)
+	}
+
+	// The chunks are collected like the requests of Analyze, so that preempted chunks are sent again,
+	// and chunks that were stopped without results fail
+	responses := make([]AnalysisResponse, turns)
+	for i, chunk := range chunks {
+		chunkResponses, _, err := k.collect(chunk, procs[i], channels[i], timeouts[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, response := range chunkResponses {
+			if response.TurnNumber < 0 || response.TurnNumber >= turns {
+				return nil, fmt.Errorf("request %s: unexpected turn number %d", chunk.ID, response.TurnNumber)
+			}
+			response.ID = request.ID
+			responses[response.TurnNumber] = response
+		}
+	}
+	return responses, nil
+}
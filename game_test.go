@@ -0,0 +1,96 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeGame(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	defer func(size int) { GameChunkSize = size }(GameChunkSize)
+	GameChunkSize = 2
+
+	request := NewRequest9x9()
+	request.Moves = []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}, {"W", "C7"}, {"B", "G3"}}
+	request.MaxVisits = 20
+	responses, err := k.AnalyzeGame(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != len(request.Moves)+1 {
+		t.Fatalf("Expected %d responses, got %d", len(request.Moves)+1, len(responses))
+	}
+	for turn, response := range responses {
+		if response.TurnNumber != turn || response.ID != request.ID {
+			t.Errorf("Expected turn %d of %s, got turn %d of %s", turn, request.ID, response.TurnNumber, response.ID)
+		}
+		if len(response.MoveInfos) == 0 {
+			t.Errorf("Expected moves for turn %d", turn)
+		}
+	}
+	if responses[1].RootInfo.CurrentPlayer != "W" {
+		t.Errorf("Expected white to play at turn 1, got %s", responses[1].RootInfo.CurrentPlayer)
+	}
+
+	// The engine can still be used afterwards, since no responses were left over
+	if _, err := k.Analyze([]AnalysisRequest{NewRequest9x9()}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAnalyzeGameError(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.Moves = []Move{{"B", "Z9"}}
+	if _, err := k.AnalyzeGame(request); err == nil {
+		t.Errorf("Expected an error for an invalid move")
+	}
+}
+
+func TestAnalyzeGamePreempted(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithPreemption())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	game := NewRequest9x9()
+	game.Moves = []Move{{"B", "E5"}, {"W", "C3"}}
+	game.MaxVisits = 4000 // 0.2 seconds for each of the 3 turns
+	type result struct {
+		responses []AnalysisResponse
+		err       error
+	}
+	done := make(chan result)
+	go func() {
+		responses, err := k.AnalyzeGame(game)
+		done <- result{responses, err}
+	}()
+	chunk := game.ID + "-0"
+	for !k.isPending(chunk) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	interactive := NewRequest9x9()
+	interactive.MaxVisits = 2000
+	interactive.Priority = 10
+	if _, err := k.Analyze([]AnalysisRequest{interactive}); err != nil {
+		t.Fatal(err)
+	}
+	// The chunk was stopped in its first turn, and is searched again from the start
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		for turn, response := range r.responses {
+			if response.NoResults || response.RootInfo.Visits != game.MaxVisits {
+				t.Errorf("Expected the full search of turn %d, got %d visits", turn, response.RootInfo.Visits)
+			}
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the responses for the game")
+	}
+}
@@ -0,0 +1,181 @@
+package katago
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Grade is the quality of a played move, based on how many points were lost compared to the best move
+type Grade int
+
+// Move grades, from best to worst
+const (
+	Excellent Grade = iota
+	Good
+	Inaccuracy
+	Mistake
+	Blunder
+)
+
+// Grades lists all move grades, from best to worst
+var Grades = []Grade{Excellent, Good, Inaccuracy, Mistake, Blunder}
+
+// String returns the name of the grade
+func (g Grade) String() string {
+	switch g {
+	case Excellent:
+		return "Excellent"
+	case Good:
+		return "Good"
+	case Inaccuracy:
+		return "Inaccuracy"
+	case Mistake:
+		return "Mistake"
+	case Blunder:
+		return "Blunder"
+	}
+	return fmt.Sprintf("Grade(%d)", int(g))
+}
+
+// Letter returns a letter grade, from "A" (Excellent) to "E" (Blunder)
+func (g Grade) Letter() string {
+	if g < Excellent || g > Blunder {
+		return "?"
+	}
+	return string(rune('A' + int(g)))
+}
+
+// GradeThresholds holds the minimum number of points lost for a move to receive each grade
+type GradeThresholds struct {
+	Good       float64
+	Inaccuracy float64
+	Mistake    float64
+	Blunder    float64
+}
+
+// DefaultGradeThresholds are the thresholds used when grading moves, in points lost
+var DefaultGradeThresholds = GradeThresholds{
+	Good:       0.5,
+	Inaccuracy: 1.5,
+	Mistake:    3.0,
+	Blunder:    6.0,
+}
+
+// Grade returns the grade for a move that lost the given number of points
+func (t GradeThresholds) Grade(pointsLost float64) Grade {
+	switch {
+	case pointsLost >= t.Blunder:
+		return Blunder
+	case pointsLost >= t.Mistake:
+		return Mistake
+	case pointsLost >= t.Inaccuracy:
+		return Inaccuracy
+	case pointsLost >= t.Good:
+		return Good
+	}
+	return Excellent
+}
+
+// GradedMove is a played move together with its evaluation
+type GradedMove struct {
+	Turn       int
+	Color      string
+	Move       string
+	BestMove   string
+	PointsLost float64
+	Grade      Grade
+}
+
+// PlayerSummary holds the grade statistics for one player
+type PlayerSummary struct {
+	Color           string
+	Moves           int
+	Counts          map[Grade]int
+	TotalPointsLost float64
+}
+
+// Percentage returns the percentage (0 to 100) of the player's moves that received the given grade
+func (s *PlayerSummary) Percentage(g Grade) float64 {
+	if s.Moves == 0 {
+		return 0
+	}
+	return 100 * float64(s.Counts[g]) / float64(s.Moves)
+}
+
+// AveragePointsLost returns the average number of points lost per move
+func (s *PlayerSummary) AveragePointsLost() float64 {
+	if s.Moves == 0 {
+		return 0
+	}
+	return s.TotalPointsLost / float64(s.Moves)
+}
+
+// Accuracy returns the percentage (0 to 100) of the player's moves that were graded Excellent or Good
+func (s *PlayerSummary) Accuracy() float64 {
+	return s.Percentage(Excellent) + s.Percentage(Good)
+}
+
+// GradeMoves grades each of the played moves, given analysis responses for the turns before and after them.
+// The responses are matched to the moves by their turn number, and the scores are expected to be reported
+// from the side to move (reportAnalysisWinratesAs = SIDETOMOVE, as in analysis_example.cfg).
+// Moves that can not be evaluated, because the turn was not analyzed, are left out.
+func GradeMoves(moves []Move, responses []AnalysisResponse, thresholds GradeThresholds) ([]GradedMove, error) {
+	turns := make(map[int]AnalysisResponse, len(responses))
+	for _, response := range responses {
+		turns[response.TurnNumber] = response
+	}
+	var graded []GradedMove
+	for i, move := range moves {
+		response, ok := turns[i]
+		if !ok {
+			continue
+		}
+		best, ok := response.BestMove()
+		if !ok {
+			return nil, fmt.Errorf("no move infos for turn %d", i)
+		}
+		playedLead, found := 0.0, false
+		for _, moveInfo := range response.MoveInfos {
+			if strings.EqualFold(moveInfo.Move, string(move.Vertex)) {
+				playedLead, found = moveInfo.ScoreLead, true
+				break
+			}
+		}
+		if !found {
+			next, ok := turns[i+1]
+			if !ok {
+				continue
+			}
+			playedLead = Compare(response, next).ScoreAfter
+		}
+		pointsLost := best.ScoreLead - playedLead
+		if pointsLost < 0 {
+			pointsLost = 0
+		}
+		graded = append(graded, GradedMove{
+			Turn:       i,
+			Color:      strings.ToUpper(string(move.Color)),
+			Move:       string(move.Vertex),
+			BestMove:   best.Move,
+			PointsLost: pointsLost,
+			Grade:      thresholds.Grade(pointsLost),
+		})
+	}
+	return graded, nil
+}
+
+// SummarizeGrades returns per-player grade statistics, keyed by color ("B" or "W")
+func SummarizeGrades(graded []GradedMove) map[string]*PlayerSummary {
+	summaries := make(map[string]*PlayerSummary)
+	for _, move := range graded {
+		summary, ok := summaries[move.Color]
+		if !ok {
+			summary = &PlayerSummary{Color: move.Color, Counts: make(map[Grade]int)}
+			summaries[move.Color] = summary
+		}
+		summary.Moves++
+		summary.Counts[move.Grade]++
+		summary.TotalPointsLost += move.PointsLost
+	}
+	return summaries
+}
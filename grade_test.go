@@ -0,0 +1,98 @@
+package katago
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGradeThresholds(t *testing.T) {
+	cases := []struct {
+		pointsLost float64
+		expected   Grade
+	}{
+		{0, Excellent},
+		{0.4, Excellent},
+		{0.5, Good},
+		{2, Inaccuracy},
+		{4.5, Mistake},
+		{12, Blunder},
+	}
+	for _, c := range cases {
+		if g := DefaultGradeThresholds.Grade(c.pointsLost); g != c.expected {
+			t.Errorf("Expected %v for %.1f points lost, got %v", c.expected, c.pointsLost, g)
+		}
+	}
+	if Blunder.Letter() != "E" || Excellent.Letter() != "A" {
+		t.Errorf("Expected letter grades A and E, got %s and %s", Excellent.Letter(), Blunder.Letter())
+	}
+}
+
+func TestGradeMoves(t *testing.T) {
+	moves := []Move{{"B", "Q16"}, {"W", "D4"}, {"B", "K10"}}
+	responses := []AnalysisResponse{
+		{
+			TurnNumber: 0,
+			MoveInfos: []MoveInfoExt{
+				{Move: "D16", Order: 1, ScoreLead: 0.5},
+				{Move: "Q16", Order: 0, ScoreLead: 0.8},
+			},
+		},
+		{
+			TurnNumber: 1,
+			MoveInfos: []MoveInfoExt{
+				{Move: "D4", Order: 0, ScoreLead: -0.8},
+			},
+		},
+		{
+			TurnNumber: 2,
+			MoveInfos: []MoveInfoExt{
+				{Move: "D16", Order: 0, ScoreLead: 1.0},
+			},
+		},
+		{
+			TurnNumber: 3,
+			RootInfo:   RootInfo{ScoreLead: 4.0},
+		},
+	}
+	graded, err := GradeMoves(moves, responses, DefaultGradeThresholds)
+	if err != nil {
+		t.Fatalf("Failed to grade moves: %v", err)
+	}
+	if len(graded) != 3 {
+		t.Fatalf("Expected 3 graded moves, got %d", len(graded))
+	}
+	if graded[0].Grade != Excellent {
+		t.Errorf("Expected the best move to be Excellent, got %v", graded[0].Grade)
+	}
+	// K10 was not a candidate, so the next position is used: 1.0 - (-4.0) = 5.0 points lost
+	if math.Abs(graded[2].PointsLost-5.0) > 1e-9 || graded[2].Grade != Mistake {
+		t.Errorf("Expected K10 to lose 5 points and be a Mistake, got %.2f (%v)", graded[2].PointsLost, graded[2].Grade)
+	}
+
+	summaries := SummarizeGrades(graded)
+	black := summaries["B"]
+	if black == nil || black.Moves != 2 {
+		t.Fatalf("Expected a summary for 2 black moves, got %v", black)
+	}
+	if p := black.Percentage(Mistake); p != 50 {
+		t.Errorf("Expected 50%% mistakes for black, got %.1f", p)
+	}
+	if avg := black.AveragePointsLost(); math.Abs(avg-2.5) > 1e-9 {
+		t.Errorf("Expected 2.5 average points lost for black, got %.2f", avg)
+	}
+}
+
+func TestAccuracy(t *testing.T) {
+	summary := SummarizeGrades([]GradedMove{
+		{Color: "B", Grade: Excellent},
+		{Color: "B", Grade: Good},
+		{Color: "B", Grade: Mistake},
+		{Color: "B", Grade: Blunder},
+	})["B"]
+	if accuracy := summary.Accuracy(); accuracy != 50 {
+		t.Errorf("Expected an accuracy of 50%%, got %v", accuracy)
+	}
+	if accuracy := (&PlayerSummary{}).Accuracy(); accuracy != 0 {
+		t.Errorf("Expected an accuracy of 0%% without moves, got %v", accuracy)
+	}
+}
@@ -0,0 +1,111 @@
+package grpc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+)
+
+// Client calls the Analysis service of a gRPC server over HTTP/2
+type Client struct {
+	// BaseURL is the address of the server, like "https://analysis.example.com:8443"
+	BaseURL string
+	// HTTPClient must support HTTP/2, which the default client does over TLS
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the server at the given base URL
+func NewClient(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient}
+}
+
+// call sends one message to the method, and calls fn with each message in the response
+func (c *Client) call(ctx context.Context, method string, message []byte, fn func([]byte) error) error {
+	var body bytes.Buffer
+	writeMessage(&body, message)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/"+ServiceName+"/"+method, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return &Error{Code: Unavailable, Message: resp.Status}
+	}
+	for {
+		data, err := readMessage(resp.Body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+	// The status is in the trailers, or in the headers if there was no response message
+	status := resp.Trailer.Get("Grpc-Status")
+	statusMessage := resp.Trailer.Get("Grpc-Message")
+	if status == "" {
+		status, statusMessage = resp.Header.Get("Grpc-Status"), resp.Header.Get("Grpc-Message")
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return &Error{Code: Internal, Message: "missing gRPC status"}
+	}
+	if Code(code) != OK {
+		text, err := url.PathUnescape(statusMessage)
+		if err != nil {
+			text = statusMessage
+		}
+		return &Error{Code: Code(code), Message: text}
+	}
+	return nil
+}
+
+// Analyze returns the final result for the request
+func (c *Client) Analyze(ctx context.Context, request katago.AnalysisRequest) (katago.AnalysisResponse, error) {
+	var response katago.AnalysisResponse
+	err := c.call(ctx, "Analyze", encodeRequest(request), func(data []byte) error {
+		var err error
+		response, err = decodeResponse(data)
+		return err
+	})
+	return response, err
+}
+
+// AnalyzeStream calls interim with the results during the search, and returns the final result
+func (c *Client) AnalyzeStream(ctx context.Context, request katago.AnalysisRequest, interim func(katago.AnalysisResponse)) (katago.AnalysisResponse, error) {
+	var final katago.AnalysisResponse
+	err := c.call(ctx, "AnalyzeStream", encodeRequest(request), func(data []byte) error {
+		response, err := decodeResponse(data)
+		if err != nil {
+			return err
+		}
+		if response.IsDuringSearch {
+			interim(response)
+		} else {
+			final = response
+		}
+		return nil
+	})
+	return final, err
+}
+
+// Terminate stops the search of a running query
+func (c *Client) Terminate(ctx context.Context, id string) error {
+	return c.call(ctx, "Terminate", encodeTerminate(id, false), func([]byte) error { return nil })
+}
@@ -0,0 +1,383 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/xyproto/katago"
+)
+
+// Protocol buffer wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// errTruncated is returned when a message ends in the middle of a field
+var errTruncated = errors.New("truncated protocol buffer message")
+
+// encoder writes the fields of a protocol buffer message. Fields with zero values are left out, as in proto3.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) tag(field, wire int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wire))
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(b)))
+	e.buf = append(e.buf, b...)
+}
+
+func (e *encoder) string(field int, s string) {
+	if s != "" {
+		e.bytes(field, []byte(s))
+	}
+}
+
+func (e *encoder) int32(field, v int) {
+	if v != 0 {
+		e.tag(field, wireVarint)
+		// Negative numbers are sign extended to 64 bits
+		e.buf = binary.AppendUvarint(e.buf, uint64(int64(v)))
+	}
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.tag(field, wireVarint)
+		e.buf = append(e.buf, 1)
+	}
+}
+
+func (e *encoder) double(field int, v float64) {
+	if v != 0 {
+		e.tag(field, wireFixed64)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+	}
+}
+
+func (e *encoder) packedInt32(field int, values []int) {
+	if len(values) == 0 {
+		return
+	}
+	var packed []byte
+	for _, v := range values {
+		packed = binary.AppendUvarint(packed, uint64(int64(v)))
+	}
+	e.bytes(field, packed)
+}
+
+func (e *encoder) packedDouble(field int, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	packed := make([]byte, 0, 8*len(values))
+	for _, v := range values {
+		packed = binary.LittleEndian.AppendUint64(packed, math.Float64bits(v))
+	}
+	e.bytes(field, packed)
+}
+
+// decodeFields calls fn for each field of a message. For varint and fixed fields, v holds the value,
+// and for length delimited fields, b holds the data.
+func decodeFields(data []byte, fn func(field, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+		field, wire := int(key>>3), int(key&7)
+		var v uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireFixed64:
+			if len(data) < 8 {
+				return errTruncated
+			}
+			v, data = binary.LittleEndian.Uint64(data), data[8:]
+		case wireFixed32:
+			if len(data) < 4 {
+				return errTruncated
+			}
+			v, data = uint64(binary.LittleEndian.Uint32(data)), data[4:]
+		case wireBytes:
+			length, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < length {
+				return errTruncated
+			}
+			b, data = data[n:n+int(length)], data[n+int(length):]
+		default:
+			return fmt.Errorf("unsupported protocol buffer wire type %d", wire)
+		}
+		if err := fn(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeInt32s decodes a repeated int32 field, which may be packed or not
+func decodeInt32s(values []int, wire int, v uint64, b []byte) ([]int, error) {
+	if wire != wireBytes {
+		return append(values, int(int32(v))), nil
+	}
+	for len(b) > 0 {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			return nil, errTruncated
+		}
+		values, b = append(values, int(int32(x))), b[n:]
+	}
+	return values, nil
+}
+
+// decodeDoubles decodes a repeated double field, which may be packed or not
+func decodeDoubles(values []float64, wire int, v uint64, b []byte) ([]float64, error) {
+	if wire != wireBytes {
+		return append(values, math.Float64frombits(v)), nil
+	}
+	if len(b)%8 != 0 {
+		return nil, errTruncated
+	}
+	for ; len(b) > 0; b = b[8:] {
+		values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(b)))
+	}
+	return values, nil
+}
+
+func encodeMove(move katago.Move) []byte {
+	var e encoder
+	e.string(1, string(move.Color))
+	e.string(2, string(move.Vertex))
+	return e.buf
+}
+
+func decodeMove(data []byte) (katago.Move, error) {
+	var move katago.Move
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			move.Color = katago.Color(b)
+		case 2:
+			move.Vertex = katago.Vertex(b)
+		}
+		return nil
+	})
+	return move, err
+}
+
+// encodeRequest encodes an AnalysisRequest message
+func encodeRequest(r katago.AnalysisRequest) []byte {
+	var e encoder
+	e.string(1, r.ID)
+	for _, stone := range r.InitialStones {
+		e.bytes(2, encodeMove(stone))
+	}
+	for _, move := range r.Moves {
+		e.bytes(3, encodeMove(move))
+	}
+	e.string(4, r.InitialPlayer)
+	e.string(5, string(r.Rules))
+	e.double(6, r.Komi)
+	e.string(7, r.WhiteHandicapBonus)
+	e.int32(8, r.BoardXSize)
+	e.int32(9, r.BoardYSize)
+	e.int32(10, r.MaxVisits)
+	e.packedInt32(11, r.AnalyzeTurns)
+	e.bool(12, r.IncludeOwnership)
+	e.bool(13, r.IncludePolicy)
+	e.double(14, r.ReportDuringSearchEvery)
+	return e.buf
+}
+
+// decodeRequest decodes an AnalysisRequest message
+func decodeRequest(data []byte) (katago.AnalysisRequest, error) {
+	r := katago.AnalysisRequest{Moves: []katago.Move{}}
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		var err error
+		switch field {
+		case 1:
+			r.ID = string(b)
+		case 2, 3:
+			move, err := decodeMove(b)
+			if err != nil {
+				return err
+			}
+			if field == 2 {
+				r.InitialStones = append(r.InitialStones, move)
+			} else {
+				r.Moves = append(r.Moves, move)
+			}
+		case 4:
+			r.InitialPlayer = string(b)
+		case 5:
+			r.Rules = katago.Rules(b)
+		case 6:
+			r.Komi = math.Float64frombits(v)
+		case 7:
+			r.WhiteHandicapBonus = string(b)
+		case 8:
+			r.BoardXSize = int(int32(v))
+		case 9:
+			r.BoardYSize = int(int32(v))
+		case 10:
+			r.MaxVisits = int(int32(v))
+		case 11:
+			r.AnalyzeTurns, err = decodeInt32s(r.AnalyzeTurns, wire, v, b)
+		case 12:
+			r.IncludeOwnership = v != 0
+		case 13:
+			r.IncludePolicy = v != 0
+		case 14:
+			r.ReportDuringSearchEvery = math.Float64frombits(v)
+		}
+		return err
+	})
+	return r, err
+}
+
+func encodeMoveInfo(info katago.MoveInfoExt) []byte {
+	var e encoder
+	e.string(1, info.Move)
+	e.int32(2, info.Visits)
+	e.double(3, info.Winrate)
+	e.double(4, info.ScoreLead)
+	e.double(5, info.Prior)
+	e.int32(6, info.Order)
+	for _, move := range info.PV {
+		e.string(7, move)
+	}
+	return e.buf
+}
+
+func decodeMoveInfo(data []byte) (katago.MoveInfoExt, error) {
+	var info katago.MoveInfoExt
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			info.Move = string(b)
+		case 2:
+			info.Visits = int(int32(v))
+		case 3:
+			info.Winrate = math.Float64frombits(v)
+		case 4:
+			info.ScoreLead = math.Float64frombits(v)
+		case 5:
+			info.Prior = math.Float64frombits(v)
+		case 6:
+			info.Order = int(int32(v))
+		case 7:
+			info.PV = append(info.PV, string(b))
+		}
+		return nil
+	})
+	return info, err
+}
+
+func encodeRootInfo(root katago.RootInfo) []byte {
+	var e encoder
+	e.double(1, root.Winrate)
+	e.double(2, root.ScoreLead)
+	e.int32(3, root.Visits)
+	e.string(4, root.CurrentPlayer)
+	return e.buf
+}
+
+func decodeRootInfo(data []byte) (katago.RootInfo, error) {
+	var root katago.RootInfo
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			root.Winrate = math.Float64frombits(v)
+		case 2:
+			root.ScoreLead = math.Float64frombits(v)
+		case 3:
+			root.Visits = int(int32(v))
+		case 4:
+			root.CurrentPlayer = string(b)
+		}
+		return nil
+	})
+	return root, err
+}
+
+// encodeResponse encodes an AnalysisResponse message
+func encodeResponse(r katago.AnalysisResponse) []byte {
+	var e encoder
+	e.string(1, r.ID)
+	e.int32(2, r.TurnNumber)
+	for _, info := range r.MoveInfos {
+		e.bytes(3, encodeMoveInfo(info))
+	}
+	e.bytes(4, encodeRootInfo(r.RootInfo))
+	e.packedDouble(5, r.Ownership)
+	e.packedDouble(6, r.Policy)
+	e.bool(7, r.IsDuringSearch)
+	return e.buf
+}
+
+// decodeResponse decodes an AnalysisResponse message
+func decodeResponse(data []byte) (katago.AnalysisResponse, error) {
+	var r katago.AnalysisResponse
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		var err error
+		switch field {
+		case 1:
+			r.ID = string(b)
+		case 2:
+			r.TurnNumber = int(int32(v))
+		case 3:
+			var info katago.MoveInfoExt
+			info, err = decodeMoveInfo(b)
+			r.MoveInfos = append(r.MoveInfos, info)
+		case 4:
+			r.RootInfo, err = decodeRootInfo(b)
+		case 5:
+			r.Ownership, err = decodeDoubles(r.Ownership, wire, v, b)
+		case 6:
+			r.Policy, err = decodeDoubles(r.Policy, wire, v, b)
+		case 7:
+			r.IsDuringSearch = v != 0
+		}
+		return err
+	})
+	return r, err
+}
+
+// encodeTerminate encodes a TerminateRequest or TerminateResponse message
+func encodeTerminate(id string, terminated bool) []byte {
+	var e encoder
+	e.string(1, id)
+	e.bool(2, terminated)
+	return e.buf
+}
+
+// decodeTerminate decodes a TerminateRequest or TerminateResponse message
+func decodeTerminate(data []byte) (string, bool, error) {
+	var id string
+	var terminated bool
+	err := decodeFields(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			id = string(b)
+		case 2:
+			terminated = v != 0
+		}
+		return nil
+	})
+	return id, terminated, err
+}
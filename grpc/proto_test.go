@@ -0,0 +1,67 @@
+package grpc
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestRequestRoundTrip(t *testing.T) {
+	request := katago.AnalysisRequest{
+		ID:                      "q1",
+		InitialStones:           []katago.Move{{Color: "B", Vertex: "D4"}},
+		Moves:                   []katago.Move{{Color: "W", Vertex: "Q16"}, {Color: "B", Vertex: "pass"}},
+		InitialPlayer:           "W",
+		Rules:                   katago.Japanese,
+		Komi:                    -6.5,
+		BoardXSize:              19,
+		BoardYSize:              19,
+		MaxVisits:               500,
+		AnalyzeTurns:            []int{0, 2},
+		IncludeOwnership:        true,
+		ReportDuringSearchEvery: 0.25,
+	}
+	decoded, err := decodeRequest(encodeRequest(request))
+	if err != nil {
+		t.Fatalf("Failed to decode the request: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, request) {
+		t.Errorf("Expected %+v, got %+v", request, decoded)
+	}
+}
+
+func TestResponseRoundTrip(t *testing.T) {
+	response := katago.AnalysisResponse{
+		ID:         "q1",
+		TurnNumber: 2,
+		MoveInfos: []katago.MoveInfoExt{
+			{Move: "Q16", Visits: 300, Winrate: 0.55, ScoreLead: 1.5, Prior: 0.3, Order: 0, PV: []string{"Q16", "D4"}},
+			{Move: "D4", Visits: 100, Winrate: 0.5, ScoreLead: -0.5, Prior: 0.2, Order: 1, PV: []string{"D4"}},
+		},
+		RootInfo:       katago.RootInfo{Winrate: 0.54, ScoreLead: 1.2, Visits: 400, CurrentPlayer: "B"},
+		Ownership:      []float64{0.5, -0.25, 0},
+		IsDuringSearch: true,
+	}
+	decoded, err := decodeResponse(encodeResponse(response))
+	if err != nil {
+		t.Fatalf("Failed to decode the response: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, response) {
+		t.Errorf("Expected %+v, got %+v", response, decoded)
+	}
+	if _, err := decodeResponse([]byte{0x0a, 0x05, 'a'}); err == nil {
+		t.Error("Expected an error for a truncated message")
+	}
+}
+
+func TestUnpackedInt32s(t *testing.T) {
+	// analyze_turns sent as two separate varint fields, which decoders must also accept
+	request, err := decodeRequest([]byte{11<<3 | wireVarint, 3, 11<<3 | wireVarint, 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(request.AnalyzeTurns, []int{3, 5}) {
+		t.Errorf("Expected [3 5], got %v", request.AnalyzeTurns)
+	}
+}
@@ -0,0 +1,249 @@
+// Package grpc serves a KataGo analysis engine with the gRPC protocol, as described by katago.proto,
+// for deployments where the overhead of REST and JSON matters. The protocol buffer encoding and the gRPC
+// framing are implemented with the standard library, so that no generated code or dependencies are needed.
+// gRPC uses HTTP/2, so the server must be served over TLS, or behind a proxy that speaks HTTP/2.
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+)
+
+// ServiceName is the full name of the service in katago.proto
+const ServiceName = "katago.Analysis"
+
+// MaxMessageSize is the largest message that is accepted, in bytes
+var MaxMessageSize = 4 << 20
+
+// Code is a gRPC status code
+type Code int
+
+// The gRPC status codes that are used by the server
+const (
+	OK               Code = 0
+	InvalidArgument  Code = 3
+	DeadlineExceeded Code = 4
+	NotFound         Code = 5
+	AlreadyExists    Code = 6
+	Unimplemented    Code = 12
+	Internal         Code = 13
+	Unavailable      Code = 14
+)
+
+// Error is an error with a gRPC status code, as returned by the client
+type Error struct {
+	Code    Code
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gRPC error %d: %s", e.Code, e.Message)
+}
+
+// Server implements the Analysis service of katago.proto as an http.Handler
+type Server struct {
+	katago *katago.KataGo
+}
+
+// NewServer creates a gRPC server for the given engine
+func NewServer(k *katago.KataGo) *Server {
+	return &Server{katago: k}
+}
+
+// ListenAndServeTLS serves the engine with gRPC on the given address
+func ListenAndServeTLS(addr, certFile, keyFile string, k *katago.KataGo) error {
+	return http.ListenAndServeTLS(addr, certFile, keyFile, NewServer(k))
+}
+
+// readMessage reads one length-prefixed gRPC message
+func readMessage(r io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if int64(length) > int64(MaxMessageSize) {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(r, message); err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// writeMessage writes one length-prefixed gRPC message
+func writeMessage(w io.Writer, message []byte) error {
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	if _, err := w.Write(append(frame, message...)); err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// encodeStatusMessage percent-encodes a status message, as required for the grpc-message trailer
+func encodeStatusMessage(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; c < ' ' || c > '~' || c == '%' {
+			fmt.Fprintf(&sb, "%%%02X", c)
+		} else {
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// setStatus sets the trailers with the status of the call
+func setStatus(w http.ResponseWriter, code Code, message string) {
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(int(code)))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeStatusMessage(message))
+	}
+}
+
+// codeFor returns the status code for an error from the engine
+func codeFor(err error) Code {
+	switch {
+	case errors.Is(err, katago.ErrDuplicateID):
+		return AlreadyExists
+	case errors.Is(err, katago.ErrUnknownQuery):
+		return NotFound
+	case errors.Is(err, katago.ErrBadRequest):
+		return InvalidArgument
+	case errors.Is(err, katago.ErrQueryTimeout):
+		return DeadlineExceeded
+	case errors.Is(err, katago.ErrEngineCrashed):
+		return Unavailable
+	}
+	return Internal
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests must be POST requests with the application/grpc content type", http.StatusUnsupportedMediaType)
+		return
+	}
+	w.Header().Set("Content-Type", "application/grpc")
+	method, ok := strings.CutPrefix(r.URL.Path, "/"+ServiceName+"/")
+	if !ok {
+		setStatus(w, Unimplemented, "unknown service")
+		return
+	}
+	if !s.katago.Running() {
+		setStatus(w, Unavailable, "KataGo is not running")
+		return
+	}
+	message, err := readMessage(r.Body)
+	if err != nil {
+		setStatus(w, InvalidArgument, err.Error())
+		return
+	}
+	switch method {
+	case "Analyze":
+		s.analyze(w, message)
+	case "AnalyzeStream":
+		s.analyzeStream(w, message)
+	case "Terminate":
+		s.terminate(w, message)
+	default:
+		setStatus(w, Unimplemented, "unknown method "+method)
+	}
+}
+
+// decodeAndCheck decodes and validates an analysis request
+func decodeAndCheck(message []byte) (katago.AnalysisRequest, error) {
+	request, err := decodeRequest(message)
+	if err != nil {
+		return request, err
+	}
+	if request.ID == "" {
+		return request, errors.New("the request needs an ID")
+	}
+	if len(request.AnalyzeTurns) > 1 {
+		// Each call returns the response for one turn
+		return request, fmt.Errorf("request %s analyzes %d turns, but only one turn can be analyzed per call", request.ID, len(request.AnalyzeTurns))
+	}
+	return request, request.Rules.Validate()
+}
+
+func (s *Server) analyze(w http.ResponseWriter, message []byte) {
+	request, err := decodeAndCheck(message)
+	if err != nil {
+		setStatus(w, InvalidArgument, err.Error())
+		return
+	}
+	responses, err := s.katago.Analyze([]katago.AnalysisRequest{request})
+	if err != nil {
+		setStatus(w, codeFor(err), err.Error())
+		return
+	}
+	if err := writeMessage(w, encodeResponse(responses[0])); err != nil {
+		return
+	}
+	setStatus(w, OK, "")
+}
+
+func (s *Server) analyzeStream(w http.ResponseWriter, message []byte) {
+	request, err := decodeAndCheck(message)
+	if err != nil {
+		setStatus(w, InvalidArgument, err.Error())
+		return
+	}
+	// The reports are written from the goroutine that reads from KataGo, so they are passed through a channel
+	interim := make(chan katago.AnalysisResponse, 8)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for response := range interim {
+			writeMessage(w, encodeResponse(response))
+		}
+	}()
+	response, err := s.katago.AnalyzeStream(request, func(response katago.AnalysisResponse) {
+		select {
+		case interim <- response:
+		default:
+		}
+	})
+	close(interim)
+	<-done
+	if err != nil {
+		setStatus(w, codeFor(err), err.Error())
+		return
+	}
+	if err := writeMessage(w, encodeResponse(response)); err != nil {
+		return
+	}
+	setStatus(w, OK, "")
+}
+
+func (s *Server) terminate(w http.ResponseWriter, message []byte) {
+	id, _, err := decodeTerminate(message)
+	if err != nil {
+		setStatus(w, InvalidArgument, err.Error())
+		return
+	}
+	if err := s.katago.Terminate(id); err != nil {
+		setStatus(w, codeFor(err), err.Error())
+		return
+	}
+	if err := writeMessage(w, encodeTerminate(id, true)); err != nil {
+		return
+	}
+	setStatus(w, OK, "")
+}
@@ -0,0 +1,79 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestClient(t *testing.T) *Client {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	ts := httptest.NewUnstartedServer(NewServer(k))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	t.Cleanup(func() {
+		ts.Close()
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	c := NewClient(ts.URL)
+	c.HTTPClient = ts.Client()
+	return c
+}
+
+func TestAnalyze(t *testing.T) {
+	c := newTestClient(t)
+	request := katago.NewRequest9x9()
+	request.MaxVisits = 20
+	response, err := c.Analyze(context.Background(), request)
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if response.ID != request.ID || len(response.MoveInfos) == 0 || response.RootInfo.Visits != 20 {
+		t.Errorf("Unexpected response: %+v", response)
+	}
+}
+
+func TestAnalyzeStream(t *testing.T) {
+	c := newTestClient(t)
+	request := katago.NewRequest9x9()
+	request.MaxVisits = 10000
+	request.ReportDuringSearchEvery = 0.1
+	reports := 0
+	response, err := c.AnalyzeStream(context.Background(), request, func(katago.AnalysisResponse) {
+		reports++
+	})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if reports == 0 || response.IsDuringSearch || response.RootInfo.Visits != 10000 {
+		t.Errorf("Expected reports followed by the final response, got %d reports and %+v", reports, response)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c := newTestClient(t)
+	request := katago.NewRequest9x9()
+	request.Rules = "chess"
+	var e *Error
+	if _, err := c.Analyze(context.Background(), request); !errors.As(err, &e) || e.Code != InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+	request = katago.NewRequest9x9()
+	request.Moves = []katago.Move{{Color: katago.Black, Vertex: "E5"}}
+	request.AnalyzeTurns = []int{0, 1}
+	if _, err := c.Analyze(context.Background(), request); !errors.As(err, &e) || e.Code != InvalidArgument {
+		t.Errorf("Expected InvalidArgument for several turns, got %v", err)
+	}
+	if err := c.Terminate(context.Background(), "missing"); !errors.As(err, &e) || e.Code != NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
@@ -0,0 +1,425 @@
+// Package gtp makes a KataGo analysis engine usable as a GTP engine, so that GUIs like Sabaki, q5Go and
+// Lizzie can play against it and show its analysis. The position is kept by the engine, and each genmove
+// and kata-analyze command sends a query to the analysis engine.
+//
+// The supported commands are protocol_version, name, version, known_command, list_commands, quit,
+// boardsize, rectangular_boardsize, clear_board, komi, kata-get-rules, kata-set-rules, play, genmove,
+// undo, showboard, final_score, fixed_handicap, place_free_handicap, set_free_handicap and kata-analyze.
+package gtp
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// AnalyzeVisits is the maximum number of visits for kata-analyze, which runs until the next command
+var AnalyzeVisits = 1000000
+
+// commands are the supported commands, in the order of list_commands
+var commands = []string{
+	"protocol_version", "name", "version", "known_command", "list_commands", "quit",
+	"boardsize", "rectangular_boardsize", "clear_board", "komi", "kata-get-rules", "kata-set-rules",
+	"play", "genmove", "undo", "showboard", "final_score",
+	"fixed_handicap", "place_free_handicap", "set_free_handicap", "kata-analyze",
+}
+
+// errQuit is returned by the quit command, to stop serving
+var errQuit = errors.New("quit")
+
+// Engine is a GTP engine that uses a KataGo analysis engine
+type Engine struct {
+	katago *katago.KataGo
+	// Name and Version are reported by the name and version commands
+	Name    string
+	Version string
+	// Visits is the number of visits for genmove, or 0 for the default of the analysis engine
+	Visits int
+	// ResignThreshold makes genmove resign when the winrate of the best move is below it, or never if it is 0
+	ResignThreshold float64
+
+	position katago.Position
+	board    *board.Board
+	nextID   atomic.Uint64
+
+	// writeMut keeps the output of kata-analyze from being mixed with the responses to commands
+	writeMut sync.Mutex
+	out      *bufio.Writer
+	// analysis is the running kata-analyze command, if any
+	analysis *analysis
+}
+
+// analysis is a running kata-analyze command
+type analysis struct {
+	queryID string
+	done    chan struct{}
+}
+
+// NewEngine creates a GTP engine with an empty 19x19 board
+func NewEngine(k *katago.KataGo) *Engine {
+	e := &Engine{katago: k, Name: "KataGo", Version: "1.0"}
+	e.reset(19, 19)
+	return e
+}
+
+// ServeStdio reads commands from stdin and writes the responses to stdout, until stdin is closed or quit is sent
+func ServeStdio(k *katago.KataGo) error {
+	return NewEngine(k).Serve(os.Stdin, os.Stdout)
+}
+
+// reset clears the board and sets its size, while keeping the rules and the komi
+func (e *Engine) reset(width, height int) error {
+	p := katago.NewPosition(width, height)
+	if e.board != nil {
+		p.Rules, p.Komi = e.position.Rules, e.position.Komi
+	}
+	b, err := p.Board()
+	if err != nil {
+		return err
+	}
+	e.position, e.board = p, b
+	return nil
+}
+
+// queryID returns a new ID for a query to the analysis engine
+func (e *Engine) queryID() string {
+	return fmt.Sprintf("gtp-%d", e.nextID.Add(1))
+}
+
+// write writes to the output and flushes it
+func (e *Engine) write(s string) {
+	e.writeMut.Lock()
+	defer e.writeMut.Unlock()
+	e.out.WriteString(s)
+	e.out.Flush()
+}
+
+// Serve reads commands from r and writes the responses to w, until r is closed or quit is sent
+func (e *Engine) Serve(r io.Reader, w io.Writer) error {
+	e.out = bufio.NewWriter(w)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(strings.ReplaceAll(line, "\t", " "))
+		if len(fields) == 0 {
+			continue
+		}
+		// Any command stops the analysis that is running
+		e.stopAnalysis()
+		id := ""
+		if _, err := strconv.Atoi(fields[0]); err == nil {
+			id, fields = fields[0], fields[1:]
+			if len(fields) == 0 {
+				continue
+			}
+		}
+		if fields[0] == "kata-analyze" {
+			if err := e.startAnalysis(id, fields[1:]); err != nil {
+				e.write("?" + id + " " + err.Error() + "\n\n")
+			}
+			continue
+		}
+		result, err := e.handle(fields[0], fields[1:])
+		switch {
+		case errors.Is(err, errQuit):
+			e.write("=" + id + "\n\n")
+			return nil
+		case err != nil:
+			e.write("?" + id + " " + err.Error() + "\n\n")
+		case result == "":
+			e.write("=" + id + "\n\n")
+		default:
+			e.write("=" + id + " " + result + "\n\n")
+		}
+	}
+	e.stopAnalysis()
+	return scanner.Err()
+}
+
+// handle runs one command and returns the result
+func (e *Engine) handle(command string, args []string) (string, error) {
+	switch command {
+	case "protocol_version":
+		return "2", nil
+	case "name":
+		return e.Name, nil
+	case "version":
+		return e.Version, nil
+	case "known_command":
+		if len(args) != 1 {
+			return "", errors.New("expected a command")
+		}
+		for _, c := range commands {
+			if c == args[0] {
+				return "true", nil
+			}
+		}
+		return "false", nil
+	case "list_commands":
+		return strings.Join(commands, "\n"), nil
+	case "quit":
+		return "", errQuit
+	case "boardsize", "rectangular_boardsize":
+		sizes, err := parseInts(args)
+		if err != nil || len(sizes) < 1 || len(sizes) > 2 || (command == "rectangular_boardsize") != (len(sizes) == 2) {
+			return "", errors.New("unacceptable size")
+		}
+		width, height := sizes[0], sizes[len(sizes)-1]
+		if err := e.reset(width, height); err != nil {
+			return "", errors.New("unacceptable size")
+		}
+		return "", nil
+	case "clear_board":
+		return "", e.reset(e.position.BoardXSize, e.position.BoardYSize)
+	case "komi":
+		if len(args) != 1 {
+			return "", errors.New("expected a komi")
+		}
+		komi, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid komi: %s", args[0])
+		}
+		e.position.Komi = komi
+		return "", nil
+	case "kata-get-rules":
+		return string(e.position.Rules), nil
+	case "kata-set-rules":
+		if len(args) != 1 {
+			return "", errors.New("expected rules")
+		}
+		p := e.position
+		p.Rules = katago.Rules(args[0])
+		// Replaying the position checks the rules, and the moves with the new ko rule
+		b, err := p.Board()
+		if err != nil {
+			return "", err
+		}
+		e.position, e.board = p, b
+		return "", nil
+	case "play":
+		if len(args) != 2 {
+			return "", errors.New("expected a color and a vertex")
+		}
+		return "", e.play(args[0], args[1])
+	case "genmove":
+		if len(args) != 1 {
+			return "", errors.New("expected a color")
+		}
+		return e.genmove(args[0])
+	case "undo":
+		if err := e.board.Undo(); err != nil {
+			return "", errors.New("cannot undo")
+		}
+		e.position.Moves = e.position.Moves[:len(e.position.Moves)-1]
+		return "", nil
+	case "showboard":
+		return "\n" + strings.TrimRight(e.board.String(), "\n"), nil
+	case "final_score":
+		score, err := e.katago.Score(e.position)
+		if err != nil {
+			return "", err
+		}
+		return score.Result, nil
+	case "fixed_handicap", "place_free_handicap":
+		return e.handicap(args)
+	case "set_free_handicap":
+		return "", e.setHandicap(args)
+	}
+	return "", errors.New("unknown command")
+}
+
+// parseInts parses integer arguments
+func parseInts(args []string) ([]int, error) {
+	values := make([]int, len(args))
+	for i, arg := range args {
+		v, err := strconv.Atoi(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = v
+	}
+	return values, nil
+}
+
+// play plays a move for the given color, which does not have to be the player to move
+func (e *Engine) play(color, vertex string) error {
+	c, err := board.ParseColor(color)
+	if err != nil {
+		return errors.New("invalid color")
+	}
+	p, err := e.board.ParseVertex(vertex)
+	if err != nil {
+		return errors.New("invalid vertex")
+	}
+	if err := e.board.Play(c, p); err != nil {
+		return errors.New("illegal move")
+	}
+	e.position.Moves = append(e.position.Moves, katago.Move{Color: katago.Color(c.String()), Vertex: katago.Vertex(e.board.Vertex(p))})
+	return nil
+}
+
+// toPlay makes the given color the player to move in the position. This is only possible before
+// the first move, since KataGo lets the players alternate after that.
+func (e *Engine) toPlay(color string) error {
+	c, err := board.ParseColor(color)
+	if err != nil {
+		return errors.New("invalid color")
+	}
+	if e.position.ToPlay() == c.String() {
+		return nil
+	}
+	if len(e.position.Moves) > 0 {
+		return fmt.Errorf("%s played the last move", c)
+	}
+	e.position.InitialPlayer = c.String()
+	e.board.SetToPlay(c)
+	return nil
+}
+
+// genmove lets KataGo choose a move for the given color, and plays it
+func (e *Engine) genmove(color string) (string, error) {
+	if err := e.toPlay(color); err != nil {
+		return "", err
+	}
+	request := e.position.Request(e.queryID())
+	request.MaxVisits = e.Visits
+	responses, err := e.katago.Analyze([]katago.AnalysisRequest{request})
+	if err != nil {
+		return "", err
+	}
+	if len(responses[0].MoveInfos) == 0 {
+		return "", errors.New("no moves were analyzed")
+	}
+	best := responses[0].MoveInfos[0]
+	if e.ResignThreshold > 0 && best.Winrate < e.ResignThreshold {
+		return "resign", nil
+	}
+	if err := e.play(color, best.Move); err != nil {
+		return "", fmt.Errorf("KataGo chose %s: %v", best.Move, err)
+	}
+	return string(e.position.Moves[len(e.position.Moves)-1].Vertex), nil
+}
+
+// handicap places the handicap stones on an empty board, and returns their vertices
+func (e *Engine) handicap(args []string) (string, error) {
+	stones, err := parseInts(args)
+	if err != nil || len(stones) != 1 {
+		return "", errors.New("expected the number of stones")
+	}
+	if len(e.position.Moves) > 0 || len(e.position.InitialStones) > 0 {
+		return "", errors.New("board not empty")
+	}
+	p, err := katago.HandicapPosition(e.position.BoardXSize, e.position.BoardYSize, stones[0], e.position.Rules)
+	if err != nil {
+		return "", err
+	}
+	vertices := make([]string, len(p.InitialStones))
+	for i, stone := range p.InitialStones {
+		vertices[i] = string(stone.Vertex)
+	}
+	return strings.Join(vertices, " "), e.setHandicap(vertices)
+}
+
+// setHandicap places black stones at the given vertices on an empty board, and lets white play first
+func (e *Engine) setHandicap(vertices []string) error {
+	if len(vertices) < 2 {
+		return errors.New("expected at least two vertices")
+	}
+	if len(e.position.Moves) > 0 || len(e.position.InitialStones) > 0 {
+		return errors.New("board not empty")
+	}
+	p := e.position
+	for _, vertex := range vertices {
+		p.InitialStones = append(p.InitialStones, katago.Move{Color: katago.Black, Vertex: katago.Vertex(strings.ToUpper(vertex))})
+	}
+	p.InitialPlayer = "W"
+	b, err := p.Board()
+	if err != nil {
+		return errors.New("bad vertex list")
+	}
+	e.position, e.board = p, b
+	return nil
+}
+
+// startAnalysis starts a kata-analyze command, with the arguments [color] [interval] [ownership true].
+// The interval is in centiseconds, as for Leela Zero.
+func (e *Engine) startAnalysis(id string, args []string) error {
+	if len(args) > 0 {
+		if _, err := board.ParseColor(args[0]); err == nil {
+			if err := e.toPlay(args[0]); err != nil {
+				return err
+			}
+			args = args[1:]
+		}
+	}
+	interval := 1.0
+	ownership := false
+	for i := 0; i < len(args); i++ {
+		key := args[i]
+		if v, err := strconv.Atoi(key); err == nil {
+			interval = float64(v) / 100
+			continue
+		}
+		if i+1 >= len(args) {
+			return fmt.Errorf("expected a value for %s", key)
+		}
+		value := args[i+1]
+		i++
+		switch key {
+		case "interval":
+			v, err := strconv.Atoi(value)
+			if err != nil {
+				return errors.New("invalid interval")
+			}
+			interval = float64(v) / 100
+		case "ownership":
+			ownership = value == "true"
+		}
+	}
+	request := e.position.Request(e.queryID())
+	request.MaxVisits = AnalyzeVisits
+	request.ReportDuringSearchEvery = max(interval, 0.01)
+	request.IncludeOwnership = ownership
+	a := &analysis{queryID: request.ID, done: make(chan struct{})}
+	e.analysis = a
+	e.write("=" + id + "\n")
+	go func() {
+		defer close(a.done)
+		response, err := e.katago.AnalyzeStream(request, func(interim katago.AnalysisResponse) {
+			e.write(interim.KataAnalyze(ownership) + "\n")
+		})
+		if err == nil && len(response.MoveInfos) > 0 {
+			e.write(response.KataAnalyze(ownership) + "\n")
+		}
+	}()
+	return nil
+}
+
+// stopAnalysis stops the running kata-analyze command, and ends its output with an empty line
+func (e *Engine) stopAnalysis() {
+	a := e.analysis
+	if a == nil {
+		return
+	}
+	e.analysis = nil
+	// The query may not have been sent yet, or be done already
+	for {
+		e.katago.Terminate(a.queryID)
+		select {
+		case <-a.done:
+			e.write("\n")
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package gtp
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	e := NewEngine(k)
+	e.Visits = 20
+	return e
+}
+
+// serve runs the engine on the given commands and returns the responses, without the empty lines between them
+func serve(t *testing.T, e *Engine, input string) []string {
+	t.Helper()
+	var out strings.Builder
+	if err := e.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	var responses []string
+	for _, response := range strings.Split(out.String(), "\n\n") {
+		if response != "" {
+			responses = append(responses, response)
+		}
+	}
+	return responses
+}
+
+func TestCommands(t *testing.T) {
+	e := newTestEngine(t)
+	responses := serve(t, e, "1 protocol_version\nboardsize 9\nkomi 6.5\n# a comment\nplay B E5\nplay W E5\n2 known_command genmove\nfoo\nquit\nname\n")
+	expected := []string{"=1 2", "=", "=", "=", "? illegal move", "=2 true", "? unknown command", "="}
+	if len(responses) != len(expected) {
+		t.Fatalf("Expected %d responses, got %q", len(expected), responses)
+	}
+	for i := range expected {
+		if responses[i] != expected[i] {
+			t.Errorf("Expected %q, got %q", expected[i], responses[i])
+		}
+	}
+	if e.position.Komi != 6.5 || e.position.BoardXSize != 9 || len(e.position.Moves) != 1 {
+		t.Errorf("Unexpected position: %+v", e.position)
+	}
+}
+
+func TestGenmove(t *testing.T) {
+	e := newTestEngine(t)
+	responses := serve(t, e, "boardsize 9\nplay B E5\ngenmove W\ngenmove W\nundo\nundo\nundo\n")
+	if !strings.HasPrefix(responses[2], "= ") || len(e.position.Moves) != 0 {
+		t.Errorf("Expected a move from genmove, got %q", responses[2])
+	}
+	if !strings.HasPrefix(responses[3], "?") {
+		t.Errorf("Expected an error for white playing twice, got %q", responses[3])
+	}
+	if responses[6] != "? cannot undo" {
+		t.Errorf("Expected the third undo to fail, got %q", responses[6])
+	}
+}
+
+func TestHandicap(t *testing.T) {
+	e := newTestEngine(t)
+	responses := serve(t, e, "fixed_handicap 4\ngenmove W\n")
+	if len(strings.Fields(responses[0])) != 5 {
+		t.Errorf("Expected 4 handicap stones, got %q", responses[0])
+	}
+	if len(e.position.Moves) != 1 || string(e.position.Moves[0].Color) != "W" {
+		t.Errorf("Expected white to move first, got %v", e.position.Moves)
+	}
+}
+
+func TestKataAnalyze(t *testing.T) {
+	e := newTestEngine(t)
+	r, w := io.Pipe()
+	var out strings.Builder
+	done := make(chan error)
+	go func() {
+		done <- e.Serve(r, &out)
+	}()
+	io.WriteString(w, "boardsize 9\n5 kata-analyze B 5 ownership true\n")
+	time.Sleep(200 * time.Millisecond)
+	io.WriteString(w, "6 name\n")
+	w.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out.String(), "\n")
+	if lines[2] != "=5" || !strings.HasPrefix(lines[3], "info move ") || !strings.Contains(lines[3], " ownership ") {
+		t.Errorf("Expected analysis lines, got %q", lines[:4])
+	}
+	if !strings.Contains(out.String(), "\n\n=6 KataGo\n\n") {
+		t.Errorf("Expected the analysis to end with an empty line before the next response, got %q", out.String())
+	}
+}
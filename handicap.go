@@ -0,0 +1,34 @@
+package katago
+
+import (
+	"github.com/xyproto/katago/board"
+)
+
+// HandicapKomi is the komi that is normally used in handicap games
+const HandicapKomi = 0.5
+
+// HandicapPosition returns a position with the given number of fixed handicap stones as initial stones,
+// with white to play, the usual handicap komi and the white handicap bonus that matches the rules
+func HandicapPosition(width, height, stones int, rules Rules) (Position, error) {
+	detailed, err := rules.Detailed()
+	if err != nil {
+		return Position{}, err
+	}
+	points, err := board.HandicapPoints(width, height, stones)
+	if err != nil {
+		return Position{}, err
+	}
+	initialStones := make([]Move, 0, len(points))
+	for _, p := range points {
+		initialStones = append(initialStones, Move{Black, Vertex(board.Vertex(p, height))})
+	}
+	return Position{
+		InitialStones:      initialStones,
+		InitialPlayer:      "W",
+		Rules:              rules,
+		Komi:               HandicapKomi,
+		WhiteHandicapBonus: detailed.WhiteHandicapBonus,
+		BoardXSize:         width,
+		BoardYSize:         height,
+	}, nil
+}
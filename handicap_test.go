@@ -0,0 +1,25 @@
+package katago
+
+import "testing"
+
+func TestHandicapPosition(t *testing.T) {
+	p, err := HandicapPosition(19, 19, 4, "chinese")
+	if err != nil {
+		t.Fatalf("Failed to create handicap position: %v", err)
+	}
+	if len(p.InitialStones) != 4 || p.InitialStones[0] != (Move{"B", "Q16"}) {
+		t.Errorf("Expected 4 handicap stones starting with Q16, got %v", p.InitialStones)
+	}
+	if p.ToPlay() != "W" || p.Komi != HandicapKomi || p.WhiteHandicapBonus != "N" {
+		t.Errorf("Expected white to play with komi 0.5 and bonus N, got %s %.1f %s", p.ToPlay(), p.Komi, p.WhiteHandicapBonus)
+	}
+	if p, _ := HandicapPosition(19, 19, 2, "japanese"); p.WhiteHandicapBonus != "0" {
+		t.Errorf("Expected no handicap bonus with japanese rules, got %s", p.WhiteHandicapBonus)
+	}
+	if p, _ := HandicapPosition(19, 19, 2, "aga"); p.WhiteHandicapBonus != "N-1" {
+		t.Errorf("Expected N-1 handicap bonus with aga rules, got %s", p.WhiteHandicapBonus)
+	}
+	if _, err := HandicapPosition(9, 9, 10, "chinese"); err == nil {
+		t.Errorf("Expected an error for 10 handicap stones")
+	}
+}
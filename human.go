@@ -0,0 +1,178 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// WithHumanModel loads the KataGo human SL model, like "b18c384nbt-humanv0.bin.gz", next to the main model.
+// Requests can then select a rank with SetHumanSLProfile, and the responses include HumanPrior for each
+// move, and HumanPolicy when IncludePolicy is set.
+func WithHumanModel(modelFile string) Option {
+	return func(k *KataGo) {
+		k.humanModel = modelFile
+	}
+}
+
+// SetOverride sets one of the override settings of the request
+func (r *AnalysisRequest) SetOverride(key string, value any) {
+	if r.OverrideSettings == nil {
+		r.OverrideSettings = make(map[string]any)
+	}
+	r.OverrideSettings[key] = value
+}
+
+// SetHumanSLProfile selects the profile of the human SL model for this request, like "rank_5k",
+// "preaz_3d" or "proyear_1990". Rank.HumanSLProfile returns the profile for a rank.
+func (r *AnalysisRequest) SetHumanSLProfile(profile string) {
+	r.SetOverride("humanSLProfile", profile)
+}
+
+// policyAt returns the policy value of a move, where the values are in row order from the top left,
+// followed by the value for passing
+func policyAt(policy []float64, vertex string, width, height int) (float64, error) {
+	if len(policy) != width*height+1 {
+		return 0, fmt.Errorf("expected %d policy values, got %d", width*height+1, len(policy))
+	}
+	if strings.EqualFold(vertex, "pass") {
+		return policy[width*height], nil
+	}
+	p, err := board.ParseVertex(vertex, width, height)
+	if err != nil {
+		return 0, err
+	}
+	return policy[p.Y*width+p.X], nil
+}
+
+// PolicyAt returns the probability that the neural network gives to a move, from the policy of the response.
+// Illegal moves have a negative value.
+func (r AnalysisResponse) PolicyAt(vertex string, width, height int) (float64, error) {
+	return policyAt(r.Policy, vertex, width, height)
+}
+
+// HumanPolicyAt returns the probability that a human player of the selected profile plays the move,
+// from the human policy of the response
+func (r AnalysisResponse) HumanPolicyAt(vertex string, width, height int) (float64, error) {
+	return policyAt(r.HumanPolicy, vertex, width, height)
+}
+
+// ErrNoHumanPolicy is returned when a response has no human policy, because the human SL model is not loaded
+var ErrNoHumanPolicy = errors.New("no human policy in the response, load the human SL model with WithHumanModel")
+
+// Settings for SuggestHumanMove
+var (
+	// HumanSuggestionVisits is the number of visits used to evaluate the position
+	HumanSuggestionVisits = 400
+	// HumanSuggestionCount is the largest number of human moves that are suggested
+	HumanSuggestionCount = 5
+	// HumanSuggestionMinProbability is the smallest human policy for a move to be suggested
+	HumanSuggestionMinProbability = 0.02
+)
+
+// HumanMove is a move that a human player could play, with its probability from the human SL model
+type HumanMove struct {
+	Move        string
+	Probability float64
+	// Evaluated is true if KataGo searched the move, and then the winrate and score lead are for the player
+	// to move, and PointsLost is compared to the best move
+	Evaluated  bool
+	Winrate    float64
+	ScoreLead  float64
+	PointsLost float64
+}
+
+// HumanSuggestion holds the moves that a player of a given rank would likely play, and the engine's best move
+type HumanSuggestion struct {
+	Rank Rank
+	// Moves are sorted by probability, from the most likely move
+	Moves []HumanMove
+	Best  MoveInfoExt
+}
+
+// Sample picks one of the moves, at random, in proportion to their probabilities.
+// This is useful for bots that should play like a human of the rank.
+func (s *HumanSuggestion) Sample(rng *rand.Rand) (HumanMove, bool) {
+	total := 0.0
+	for _, m := range s.Moves {
+		total += m.Probability
+	}
+	if total <= 0 {
+		return HumanMove{}, false
+	}
+	x := rng.Float64() * total
+	for _, m := range s.Moves {
+		x -= m.Probability
+		if x < 0 {
+			return m, true
+		}
+	}
+	return s.Moves[len(s.Moves)-1], true
+}
+
+// humanMoves returns the most likely moves from a human policy, with the evaluation of the moves that were searched
+func humanMoves(response AnalysisResponse, width, height int) ([]HumanMove, error) {
+	if len(response.HumanPolicy) == 0 {
+		return nil, ErrNoHumanPolicy
+	}
+	if len(response.HumanPolicy) != width*height+1 {
+		return nil, fmt.Errorf("expected %d human policy values, got %d", width*height+1, len(response.HumanPolicy))
+	}
+	best, hasBest := response.BestMove()
+	var moves []HumanMove
+	for i, probability := range response.HumanPolicy {
+		if probability < HumanSuggestionMinProbability {
+			continue
+		}
+		vertex := "pass"
+		if i < width*height {
+			vertex = board.Vertex(board.Point{X: i % width, Y: i / width}, height)
+		}
+		m := HumanMove{Move: vertex, Probability: probability}
+		for _, info := range response.MoveInfos {
+			if strings.EqualFold(info.Move, vertex) {
+				m.Evaluated, m.Winrate, m.ScoreLead = true, info.Winrate, info.ScoreLead
+				if hasBest {
+					m.PointsLost = max(0, best.ScoreLead-info.ScoreLead)
+				}
+				break
+			}
+		}
+		moves = append(moves, m)
+	}
+	sort.SliceStable(moves, func(i, j int) bool { return moves[i].Probability > moves[j].Probability })
+	if len(moves) > HumanSuggestionCount {
+		moves = moves[:HumanSuggestionCount]
+	}
+	return moves, nil
+}
+
+// SuggestHumanMove returns the moves that a player of the given rank would plausibly play in the position,
+// according to the human SL model, together with the move that KataGo prefers. This is useful for teaching
+// tools, and for bots that play at a realistic strength. The human SL model must be loaded with WithHumanModel.
+func (k *KataGo) SuggestHumanMove(p Position, rank Rank) (*HumanSuggestion, error) {
+	if err := k.require(CapabilityHumanModel); err != nil {
+		return nil, err
+	}
+	request := p.Request(k.newID("human"))
+	request.MaxVisits = HumanSuggestionVisits
+	request.IncludePolicy = true
+	request.SetHumanSLProfile(rank.HumanSLProfile())
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, errors.New("no response when suggesting a human move")
+	}
+	moves, err := humanMoves(responses[0], p.BoardXSize, p.BoardYSize)
+	if err != nil {
+		return nil, err
+	}
+	best, _ := responses[0].BestMove()
+	return &HumanSuggestion{Rank: rank, Moves: moves, Best: best}, nil
+}
@@ -0,0 +1,125 @@
+package katago
+
+import (
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestHumanModel(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithHumanModel("model.bin.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	if args := strings.Join(k.current().cmd.Args, " "); !strings.Contains(args, "-human-model model.bin.gz") {
+		t.Errorf("Expected -human-model in the arguments, got %s", args)
+	}
+	request := NewRequest9x9()
+	request.Moves = []Move{{"B", "E5"}}
+	request.AnalyzeTurns = []int{1}
+	request.MaxVisits = 10
+	request.IncludePolicy = true
+	request.SetHumanSLProfile(Kyu(5).HumanSLProfile())
+	if request.OverrideSettings["humanSLProfile"] != "rank_5k" {
+		t.Errorf("Expected the rank_5k profile, got %v", request.OverrideSettings)
+	}
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := responses[0]
+	if len(response.HumanPolicy) != 82 || response.MoveInfos[0].HumanPrior <= 0 {
+		t.Errorf("Expected a human policy and human priors, got %d values and %v", len(response.HumanPolicy), response.MoveInfos[0].HumanPrior)
+	}
+	if p, err := response.HumanPolicyAt("E5", 9, 9); err != nil || p >= 0 {
+		t.Errorf("Expected a negative value for an occupied point, got %v (%v)", p, err)
+	}
+	if p, err := response.PolicyAt(response.MoveInfos[0].Move, 9, 9); err != nil || p <= 0 {
+		t.Errorf("Expected a positive policy for the best move, got %v (%v)", p, err)
+	}
+}
+
+func TestPolicyAt(t *testing.T) {
+	policy := []float64{0.1, 0.2, 0.3, 0.15, 0.25}
+	for vertex, expected := range map[string]float64{"A2": 0.1, "B2": 0.2, "A1": 0.3, "pass": 0.25} {
+		if p, err := policyAt(policy, vertex, 2, 2); err != nil || p != expected {
+			t.Errorf("Expected %v for %s, got %v (%v)", expected, vertex, p, err)
+		}
+	}
+	if _, err := policyAt(policy[:4], "A1", 2, 2); err == nil {
+		t.Errorf("Expected an error for a policy of the wrong size")
+	}
+}
+
+func TestHashOverrideSettings(t *testing.T) {
+	a := NewRequest9x9()
+	b := a
+	a.SetHumanSLProfile("rank_5k")
+	b.SetHumanSLProfile("rank_3d")
+	ha, err := a.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb, err := b.Hash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ha == hb {
+		t.Errorf("Expected requests with different profiles to have different hashes")
+	}
+}
+
+func TestSuggestHumanMove(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithHumanModel("model.bin.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{"B", "E5"}, {"W", "C3"}}
+	suggestion, err := k.SuggestHumanMove(p, Kyu(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if suggestion.Best.Move == "" || len(suggestion.Moves) == 0 || len(suggestion.Moves) > HumanSuggestionCount {
+		t.Fatalf("Unexpected suggestion: %+v", suggestion)
+	}
+	for i, m := range suggestion.Moves {
+		if m.Move == "E5" || m.Move == "C3" {
+			t.Errorf("Expected only empty points, got %s", m.Move)
+		}
+		if i > 0 && m.Probability > suggestion.Moves[i-1].Probability {
+			t.Errorf("Expected the moves to be sorted by probability")
+		}
+	}
+	if m, ok := suggestion.Sample(rand.New(rand.NewSource(1))); !ok || m.Move == "" {
+		t.Errorf("Expected a sampled move")
+	}
+}
+
+func TestSuggestHumanMoveWithoutModel(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	if _, err := k.SuggestHumanMove(NewPosition(9, 9), Dan(1)); !errors.Is(err, ErrNoHumanPolicy) {
+		t.Errorf("Expected ErrNoHumanPolicy, got %v", err)
+	}
+}
+
+func TestHumanMoves(t *testing.T) {
+	response := AnalysisResponse{
+		HumanPolicy: []float64{0.5, -1, 0.01, 0.3, 0.19},
+		MoveInfos:   []MoveInfoExt{{Move: "A1", ScoreLead: 2, Order: 0}, {Move: "A2", ScoreLead: 0.5, Order: 1}},
+	}
+	moves, err := humanMoves(response, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 3 || moves[0].Move != "A2" || moves[1].Move != "B1" || moves[2].Move != "pass" {
+		t.Fatalf("Unexpected moves: %+v", moves)
+	}
+	if !moves[0].Evaluated || moves[0].PointsLost != 1.5 || moves[1].Evaluated {
+		t.Errorf("Unexpected evaluations: %+v", moves)
+	}
+}
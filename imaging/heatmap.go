@@ -0,0 +1,99 @@
+// Package imaging renders Go positions with analysis overlays as PNG and SVG images
+package imaging
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// Heatmap holds a value for each point of the board, in row-major order starting at the top left corner.
+// Signed heatmaps have values from -1 (white) to 1 (black) and are drawn as shades of gray,
+// while unsigned heatmaps have values from 0 to 1 and are drawn in warm colors.
+type Heatmap struct {
+	Width, Height int
+	Values        []float64
+	Signed        bool
+}
+
+// At returns the heatmap value at (x, y)
+func (h *Heatmap) At(x, y int) float64 {
+	if h == nil || x < 0 || y < 0 || x >= h.Width || y >= h.Height {
+		return 0
+	}
+	return h.Values[y*h.Width+x]
+}
+
+// normalize scales non-negative values so that the largest one becomes 1
+func normalize(values []float64) []float64 {
+	max := 0.0
+	for _, v := range values {
+		max = math.Max(max, v)
+	}
+	normalized := make([]float64, len(values))
+	if max <= 0 {
+		return normalized
+	}
+	for i, v := range values {
+		if v > 0 {
+			normalized[i] = v / max
+		}
+	}
+	return normalized
+}
+
+// PolicyHeatmap creates a heatmap from the raw policy of a response (IncludePolicy must be set in the request).
+// The values are scaled so that the most likely move has the value 1. Illegal moves and passing are left out.
+func PolicyHeatmap(response katago.AnalysisResponse, width, height int) (*Heatmap, error) {
+	if len(response.Policy) < width*height {
+		return nil, fmt.Errorf("response %s has no policy for a %dx%d board", response.ID, width, height)
+	}
+	return &Heatmap{Width: width, Height: height, Values: normalize(response.Policy[:width*height])}, nil
+}
+
+// OwnershipHeatmap creates a signed heatmap from an ownership map
+func OwnershipHeatmap(ownership *katago.OwnershipMap) *Heatmap {
+	h := &Heatmap{Width: ownership.Width(), Height: ownership.Height(), Signed: true}
+	h.Values = make([]float64, h.Width*h.Height)
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
+			h.Values[y*h.Width+x] = ownership.At(x, y)
+		}
+	}
+	return h
+}
+
+// UncertaintyHeatmap creates a heatmap from the standard deviation of the ownership of a response
+// (IncludeOwnershipStdev must be set in the request). The values are not scaled, so settled points stay cold.
+func UncertaintyHeatmap(response katago.AnalysisResponse, width, height int) (*Heatmap, error) {
+	stdev, err := response.OwnershipStdevMap(width, height)
+	if err != nil {
+		return nil, err
+	}
+	h := &Heatmap{Width: width, Height: height, Values: make([]float64, width*height)}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			h.Values[y*width+x] = min(1, stdev.At(x, y))
+		}
+	}
+	return h, nil
+}
+
+// VisitsHeatmap creates a heatmap from the number of visits of each candidate move in a response.
+// The values are scaled so that the most visited move has the value 1.
+func VisitsHeatmap(response katago.AnalysisResponse, width, height int) (*Heatmap, error) {
+	visits := make([]float64, width*height)
+	for _, moveInfo := range response.MoveInfos {
+		if moveInfo.Move == "pass" {
+			continue
+		}
+		p, err := board.ParseVertex(moveInfo.Move, width, height)
+		if err != nil {
+			return nil, err
+		}
+		visits[p.Y*width+p.X] = float64(moveInfo.Visits)
+	}
+	return &Heatmap{Width: width, Height: height, Values: normalize(visits)}, nil
+}
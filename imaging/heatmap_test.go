@@ -0,0 +1,68 @@
+package imaging
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+func TestVisitsHeatmap(t *testing.T) {
+	response := katago.AnalysisResponse{
+		ID: "test",
+		MoveInfos: []katago.MoveInfoExt{
+			{Move: "C3", Visits: 200},
+			{Move: "D4", Visits: 50},
+			{Move: "pass", Visits: 10},
+		},
+	}
+	h, err := VisitsHeatmap(response, 9, 9)
+	if err != nil {
+		t.Fatalf("Failed to create visits heatmap: %v", err)
+	}
+	if h.At(2, 6) != 1 || h.At(3, 5) != 0.25 {
+		t.Errorf("Expected C3 to be 1 and D4 to be 0.25, got %f and %f", h.At(2, 6), h.At(3, 5))
+	}
+	if _, err := VisitsHeatmap(katago.AnalysisResponse{MoveInfos: []katago.MoveInfoExt{{Move: "Z99"}}}, 9, 9); err == nil {
+		t.Errorf("Expected an error for an invalid move")
+	}
+}
+
+func TestPolicyAndOwnershipHeatmaps(t *testing.T) {
+	policy := make([]float64, 4*4+1)
+	policy[5] = 0.4
+	policy[6] = 0.1
+	policy[0] = -1
+	h, err := PolicyHeatmap(katago.AnalysisResponse{Policy: policy}, 4, 4)
+	if err != nil {
+		t.Fatalf("Failed to create policy heatmap: %v", err)
+	}
+	if h.At(1, 1) != 1 || h.At(0, 0) != 0 {
+		t.Errorf("Expected the most likely move to be 1 and illegal moves to be 0")
+	}
+	if _, err := PolicyHeatmap(katago.AnalysisResponse{}, 4, 4); err == nil {
+		t.Errorf("Expected an error for a response without a policy")
+	}
+
+	ownership, err := katago.NewOwnershipMap([]float64{1, -1, 0, 0.5}, 2, 2, board.Black)
+	if err != nil {
+		t.Fatalf("Failed to create ownership map: %v", err)
+	}
+	oh := OwnershipHeatmap(ownership)
+	if !oh.Signed || oh.At(1, 0) != -1 {
+		t.Errorf("Expected a signed heatmap with -1 at (1, 0)")
+	}
+}
+
+func TestUncertaintyHeatmap(t *testing.T) {
+	h, err := UncertaintyHeatmap(katago.AnalysisResponse{OwnershipStdev: []float64{0, 0.5, 0.1, 0.2}}, 2, 2)
+	if err != nil {
+		t.Fatalf("Failed to create uncertainty heatmap: %v", err)
+	}
+	if h.Signed || h.At(1, 0) != 0.5 || h.At(0, 0) != 0 {
+		t.Errorf("Expected an unsigned heatmap with 0.5 at (1, 0), got %v", h.Values)
+	}
+	if _, err := UncertaintyHeatmap(katago.AnalysisResponse{}, 2, 2); err == nil {
+		t.Errorf("Expected an error for a response without the ownership stdev")
+	}
+}
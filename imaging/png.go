@@ -0,0 +1,190 @@
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// Colors used when drawing the board
+var (
+	BoardColor = color.RGBA{220, 179, 92, 255}
+	LineColor  = color.RGBA{40, 30, 20, 255}
+	BlackStone = color.RGBA{20, 20, 20, 255}
+	WhiteStone = color.RGBA{245, 245, 240, 255}
+)
+
+// Options for drawing a board
+type Options struct {
+	// CellSize is the distance between two lines, in pixels. The default is 32.
+	CellSize int
+	// Heatmap is an optional overlay, like policy, ownership or visits
+	Heatmap *Heatmap
+
+	// Variation is a sequence of moves that is played on top of the position.
+	// The stones are numbered in SVG images.
+	Variation []board.Move
+
+	// The following options need text and are only used for SVG images
+
+	// Candidates are candidate moves from an analysis, drawn with their winrate and visits
+	Candidates []katago.MoveInfoExt
+	// Labels are extra texts drawn on top of the board
+	Labels []Label
+	// Caption is a text drawn below the board, like the winrate of the variation
+	Caption string
+}
+
+// Label is a text drawn at a point on the board
+type Label struct {
+	Point board.Point
+	Text  string
+}
+
+// DefaultCellSize is the distance between two lines, in pixels, when no cell size is given
+const DefaultCellSize = 32
+
+func (o Options) cellSize() int {
+	if o.CellSize <= 0 {
+		return DefaultCellSize
+	}
+	return o.CellSize
+}
+
+// canvas is an image together with the geometry of the board drawn on it
+type canvas struct {
+	img    *image.RGBA
+	cell   int
+	margin int
+}
+
+// center returns the pixel position of an intersection
+func (c *canvas) center(x, y int) (float64, float64) {
+	return float64(c.margin + x*c.cell), float64(c.margin + y*c.cell)
+}
+
+// blend mixes a color into a pixel, with the given opacity from 0 to 1
+func (c *canvas) blend(x, y int, col color.RGBA, alpha float64) {
+	if !(image.Point{x, y}.In(c.img.Rect)) || alpha <= 0 {
+		return
+	}
+	alpha = math.Min(alpha, 1)
+	old := c.img.RGBAAt(x, y)
+	mix := func(a, b uint8) uint8 {
+		return uint8(math.Round(float64(a)*(1-alpha) + float64(b)*alpha))
+	}
+	c.img.SetRGBA(x, y, color.RGBA{mix(old.R, col.R), mix(old.G, col.G), mix(old.B, col.B), 255})
+}
+
+// fillRect fills a rectangle with a color, with the given opacity
+func (c *canvas) fillRect(x0, y0, x1, y1 int, col color.RGBA, alpha float64) {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			c.blend(x, y, col, alpha)
+		}
+	}
+}
+
+// fillCircle draws an anti-aliased filled circle
+func (c *canvas) fillCircle(cx, cy, r float64, col color.RGBA, alpha float64) {
+	for y := int(cy - r - 1); y <= int(cy+r+1); y++ {
+		for x := int(cx - r - 1); x <= int(cx+r+1); x++ {
+			d := math.Hypot(float64(x)-cx, float64(y)-cy)
+			// Pixels on the edge are partially covered
+			coverage := math.Max(0, math.Min(1, r-d+0.5))
+			c.blend(x, y, col, alpha*coverage)
+		}
+	}
+}
+
+// heatColor returns the overlay color and opacity for a heatmap value
+func heatColor(v float64, signed bool) (color.RGBA, float64) {
+	if signed {
+		if v > 0 {
+			return BlackStone, 0.6 * v
+		}
+		return WhiteStone, -0.8 * v
+	}
+	// From yellow for low values to red for high values
+	return color.RGBA{255, uint8(220 * (1 - v)), 0, 255}, 0.25 + 0.5*v
+}
+
+// Render draws the board with its stones and an optional heatmap overlay
+func Render(b *board.Board, opts Options) (*image.RGBA, error) {
+	if h := opts.Heatmap; h != nil && (h.Width != b.Width() || h.Height != b.Height() || len(h.Values) != h.Width*h.Height) {
+		return nil, fmt.Errorf("heatmap does not match the %dx%d board", b.Width(), b.Height())
+	}
+	position, _, err := playVariation(b, opts.Variation)
+	if err != nil {
+		return nil, err
+	}
+	cell := opts.cellSize()
+	c := &canvas{cell: cell, margin: cell}
+	w := (b.Width()-1)*cell + 2*c.margin
+	h := (b.Height()-1)*cell + 2*c.margin
+	c.img = image.NewRGBA(image.Rect(0, 0, w, h))
+	c.fillRect(0, 0, w, h, BoardColor, 1)
+
+	// Grid lines
+	for x := 0; x < b.Width(); x++ {
+		px, top := c.center(x, 0)
+		_, bottom := c.center(x, b.Height()-1)
+		c.fillRect(int(px), int(top), int(px)+1, int(bottom)+1, LineColor, 1)
+	}
+	for y := 0; y < b.Height(); y++ {
+		left, py := c.center(0, y)
+		right, _ := c.center(b.Width()-1, y)
+		c.fillRect(int(left), int(py), int(right)+1, int(py)+1, LineColor, 1)
+	}
+	for _, p := range board.StarPoints(b.Width(), b.Height()) {
+		cx, cy := c.center(p.X, p.Y)
+		c.fillCircle(cx+0.5, cy+0.5, float64(cell)/10, LineColor, 1)
+	}
+
+	// Heatmap squares, below the stones
+	if opts.Heatmap != nil {
+		for y := 0; y < b.Height(); y++ {
+			for x := 0; x < b.Width(); x++ {
+				v := opts.Heatmap.At(x, y)
+				if v == 0 {
+					continue
+				}
+				col, alpha := heatColor(v, opts.Heatmap.Signed)
+				cx, cy := c.center(x, y)
+				half := cell / 2
+				c.fillRect(int(cx)-half+1, int(cy)-half+1, int(cx)+half, int(cy)+half, col, alpha)
+			}
+		}
+	}
+
+	// Stones
+	radius := float64(cell) * 0.47
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			cx, cy := c.center(x, y)
+			switch position.At(board.Point{X: x, Y: y}) {
+			case board.Black:
+				c.fillCircle(cx+0.5, cy+0.5, radius, BlackStone, 1)
+			case board.White:
+				c.fillCircle(cx+0.5, cy+0.5, radius, LineColor, 1)
+				c.fillCircle(cx+0.5, cy+0.5, radius-1, WhiteStone, 1)
+			}
+		}
+	}
+	return c.img, nil
+}
+
+// WritePNG draws the board and writes it as a PNG image
+func WritePNG(w io.Writer, b *board.Board, opts Options) error {
+	img, err := Render(b, opts)
+	if err != nil {
+		return err
+	}
+	return png.Encode(w, img)
+}
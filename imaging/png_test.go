@@ -0,0 +1,54 @@
+package imaging
+
+import (
+	"bytes"
+	"image/png"
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestRender(t *testing.T) {
+	b, err := board.New(9, 9)
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	b.Play(board.Black, board.Point{X: 2, Y: 2})
+	b.Play(board.White, board.Point{X: 6, Y: 6})
+	heatmap := &Heatmap{Width: 9, Height: 9, Values: make([]float64, 81)}
+	heatmap.Values[4*9+4] = 1
+	img, err := Render(b, Options{CellSize: 20, Heatmap: heatmap})
+	if err != nil {
+		t.Fatalf("Failed to render board: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X != 8*20+2*20 || size.Y != 8*20+2*20 {
+		t.Errorf("Unexpected image size %v", size)
+	}
+	if c := img.RGBAAt(20+2*20+3, 20+2*20+3); c.R > 60 {
+		t.Errorf("Expected a dark pixel on the black stone, got %v", c)
+	}
+	if c := img.RGBAAt(20+6*20+3, 20+6*20+3); c.R < 200 {
+		t.Errorf("Expected a light pixel on the white stone, got %v", c)
+	}
+	if c := img.RGBAAt(20+4*20+5, 20+4*20+5); c == BoardColor {
+		t.Errorf("Expected the heatmap to change the color at the center")
+	}
+	if _, err := Render(b, Options{Heatmap: &Heatmap{Width: 3, Height: 3}}); err == nil {
+		t.Errorf("Expected an error for a heatmap of the wrong size")
+	}
+}
+
+func TestWritePNG(t *testing.T) {
+	b, _ := board.New(13, 9)
+	var buf bytes.Buffer
+	if err := WritePNG(&buf, b, Options{}); err != nil {
+		t.Fatalf("Failed to write PNG: %v", err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size.X <= size.Y {
+		t.Errorf("Expected a wide image for a 13x9 board, got %v", size)
+	}
+}
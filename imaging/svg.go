@@ -0,0 +1,155 @@
+package imaging
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+	"io"
+
+	"github.com/xyproto/katago/board"
+)
+
+// svgColor returns a color in the #rrggbb format
+func svgColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// playVariation plays the variation on a copy of the board, and returns the move number of each stone
+func playVariation(b *board.Board, variation []board.Move) (*board.Board, map[board.Point]int, error) {
+	result := b.Clone()
+	numbers := make(map[board.Point]int)
+	for i, move := range variation {
+		if err := result.Play(move.Color, move.Point); err != nil {
+			return nil, nil, fmt.Errorf("variation move %d at %s: %w", i+1, b.Vertex(move.Point), err)
+		}
+		if !move.Point.IsPass() {
+			numbers[move.Point] = i + 1
+		}
+	}
+	// Numbers of captured stones are not shown
+	for p := range numbers {
+		if result.At(p) == board.Empty {
+			delete(numbers, p)
+		}
+	}
+	return result, numbers, nil
+}
+
+// WriteSVG draws the board as an SVG image. In addition to the stones and the heatmap, variations are drawn with
+// numbered stones, candidate moves are drawn with their winrate and visits, and labels and a caption are added.
+func WriteSVG(w io.Writer, b *board.Board, opts Options) error {
+	if h := opts.Heatmap; h != nil && (h.Width != b.Width() || h.Height != b.Height() || len(h.Values) != h.Width*h.Height) {
+		return fmt.Errorf("heatmap does not match the %dx%d board", b.Width(), b.Height())
+	}
+	position, numbers, err := playVariation(b, opts.Variation)
+	if err != nil {
+		return err
+	}
+	cell := opts.cellSize()
+	margin := cell
+	width := (b.Width()-1)*cell + 2*margin
+	height := (b.Height()-1)*cell + 2*margin
+	if opts.Caption != "" {
+		height += cell
+	}
+	center := func(p board.Point) (int, int) {
+		return margin + p.X*cell, margin + p.Y*cell
+	}
+	fontSize := float64(cell) * 0.4
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="%s"/>`+"\n", width, height, svgColor(BoardColor))
+
+	// Grid lines and star points
+	for x := 0; x < b.Width(); x++ {
+		x0, y0 := center(board.Point{X: x, Y: 0})
+		_, y1 := center(board.Point{X: x, Y: b.Height() - 1})
+		fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", x0, y0, x0, y1, svgColor(LineColor))
+	}
+	for y := 0; y < b.Height(); y++ {
+		x0, y0 := center(board.Point{X: 0, Y: y})
+		x1, _ := center(board.Point{X: b.Width() - 1, Y: y})
+		fmt.Fprintf(bw, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s"/>`+"\n", x0, y0, x1, y0, svgColor(LineColor))
+	}
+	for _, p := range board.StarPoints(b.Width(), b.Height()) {
+		cx, cy := center(p)
+		fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%.1f" fill="%s"/>`+"\n", cx, cy, float64(cell)/10, svgColor(LineColor))
+	}
+
+	// Heatmap squares
+	if opts.Heatmap != nil {
+		for y := 0; y < b.Height(); y++ {
+			for x := 0; x < b.Width(); x++ {
+				v := opts.Heatmap.At(x, y)
+				if v == 0 {
+					continue
+				}
+				col, alpha := heatColor(v, opts.Heatmap.Signed)
+				cx, cy := center(board.Point{X: x, Y: y})
+				fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" fill-opacity="%.2f"/>`+"\n", cx-cell/2, cy-cell/2, cell, cell, svgColor(col), alpha)
+			}
+		}
+	}
+
+	// Stones, with move numbers for the variation
+	radius := float64(cell) * 0.47
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			p := board.Point{X: x, Y: y}
+			stone := position.At(p)
+			if stone == board.Empty {
+				continue
+			}
+			fill, textColor := BlackStone, WhiteStone
+			if stone == board.White {
+				fill, textColor = WhiteStone, BlackStone
+			}
+			cx, cy := center(p)
+			fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%.1f" fill="%s" stroke="%s"/>`+"\n", cx, cy, radius, svgColor(fill), svgColor(LineColor))
+			if n, ok := numbers[p]; ok {
+				writeText(bw, cx, cy, fontSize, textColor, fmt.Sprint(n))
+			}
+		}
+	}
+
+	// Candidate moves, with the winrate and the number of visits
+	for i, moveInfo := range opts.Candidates {
+		p, err := position.ParseVertex(moveInfo.Move)
+		if err != nil {
+			return err
+		}
+		if p.IsPass() || position.At(p) != board.Empty {
+			continue
+		}
+		fill := color.RGBA{120, 200, 255, 255}
+		if i == 0 {
+			fill = color.RGBA{60, 200, 90, 255}
+		}
+		cx, cy := center(p)
+		fmt.Fprintf(bw, `<circle cx="%d" cy="%d" r="%.1f" fill="%s" fill-opacity="0.85"/>`+"\n", cx, cy, radius, svgColor(fill))
+		writeText(bw, cx, cy-int(fontSize*0.45), fontSize*0.8, BlackStone, fmt.Sprintf("%.1f", 100*moveInfo.Winrate))
+		writeText(bw, cx, cy+int(fontSize*0.55), fontSize*0.65, BlackStone, fmt.Sprint(moveInfo.Visits))
+	}
+
+	for _, label := range opts.Labels {
+		cx, cy := center(label.Point)
+		textColor := BlackStone
+		if position.At(label.Point) == board.Black {
+			textColor = WhiteStone
+		}
+		writeText(bw, cx, cy, fontSize, textColor, label.Text)
+	}
+
+	if opts.Caption != "" {
+		writeText(bw, width/2, height-cell, fontSize, BlackStone, opts.Caption)
+	}
+	fmt.Fprintln(bw, "</svg>")
+	return bw.Flush()
+}
+
+// writeText writes a text element centered at the given position
+func writeText(w io.Writer, x, y int, size float64, c color.RGBA, text string) {
+	fmt.Fprintf(w, `<text x="%d" y="%d" font-size="%.1f" fill="%s" text-anchor="middle" dominant-baseline="central">%s</text>`+"\n", x, y, size, svgColor(c), html.EscapeString(text))
+}
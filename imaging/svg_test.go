@@ -0,0 +1,47 @@
+package imaging
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+func TestWriteSVG(t *testing.T) {
+	b, err := board.New(9, 9)
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	b.Play(board.Black, board.Point{X: 2, Y: 2})
+	opts := Options{
+		Variation: []board.Move{
+			{Color: board.White, Point: board.Point{X: 6, Y: 6}},
+			{Color: board.Black, Point: board.Point{X: 6, Y: 2}},
+		},
+		Candidates: []katago.MoveInfoExt{{Move: "E5", Winrate: 0.523, Visits: 120}},
+		Labels:     []Label{{Point: board.Point{X: 0, Y: 0}, Text: "A"}},
+		Caption:    "B+2.5 <after 2 moves>",
+	}
+	var buf bytes.Buffer
+	if err := WriteSVG(&buf, b, opts); err != nil {
+		t.Fatalf("Failed to write SVG: %v", err)
+	}
+	svg := buf.String()
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(strings.TrimSpace(svg), "</svg>") {
+		t.Errorf("Expected an SVG document")
+	}
+	if n := strings.Count(svg, "<circle"); n < 4 {
+		t.Errorf("Expected circles for the stones and the candidate move, got %d", n)
+	}
+	for _, text := range []string{">1</text>", ">2</text>", ">52.3</text>", ">120</text>", ">A</text>", "&lt;after 2 moves&gt;"} {
+		if !strings.Contains(svg, text) {
+			t.Errorf("Expected the SVG to contain %q", text)
+		}
+	}
+	bad := Options{Variation: []board.Move{{Color: board.White, Point: board.Point{X: 2, Y: 2}}}}
+	if err := WriteSVG(&buf, b, bad); err == nil {
+		t.Errorf("Expected an error for a variation that plays on an occupied point")
+	}
+}
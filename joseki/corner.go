@@ -0,0 +1,212 @@
+// Package joseki recognizes corner sequences, regardless of which corner they are played in,
+// how they are reflected and which color starts them, and checks games against a dictionary of joseki
+package joseki
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// Corner is one of the four corners of the board
+type Corner int
+
+// The corners of the board
+const (
+	UpperLeft Corner = iota
+	UpperRight
+	LowerLeft
+	LowerRight
+)
+
+// Corners lists all the corners
+var Corners = []Corner{UpperLeft, UpperRight, LowerLeft, LowerRight}
+
+// String returns the name of the corner
+func (c Corner) String() string {
+	switch c {
+	case UpperLeft:
+		return "upper left"
+	case UpperRight:
+		return "upper right"
+	case LowerLeft:
+		return "lower left"
+	case LowerRight:
+		return "lower right"
+	}
+	return fmt.Sprintf("Corner(%d)", int(c))
+}
+
+// Tenuki is the local move used for a player that played elsewhere instead of answering in the corner
+const Tenuki = "tenuki"
+
+// CornerOf returns the corner that the point is in. Points on the center lines of the board are not in any corner.
+func CornerOf(p board.Point, width, height int) (Corner, bool) {
+	if p.IsPass() || p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height {
+		return 0, false
+	}
+	left, right := p.X < width/2, p.X >= (width+1)/2
+	upper, lower := p.Y < height/2, p.Y >= (height+1)/2
+	switch {
+	case upper && left:
+		return UpperLeft, true
+	case upper && right:
+		return UpperRight, true
+	case lower && left:
+		return LowerLeft, true
+	case lower && right:
+		return LowerRight, true
+	}
+	return 0, false
+}
+
+// Orientation maps between the points of a corner and the normalized coordinates of a pattern,
+// where (0, 0) is the corner point itself
+type Orientation struct {
+	Corner        Corner
+	Width, Height int
+	// Swap reflects the pattern along the diagonal of the corner
+	Swap bool
+	// First is the color of the player that started the sequence, who is black in the pattern
+	First board.Color
+}
+
+// local returns the coordinates of the point, counted from the corner, before any reflection
+func (o Orientation) local(p board.Point) (int, int) {
+	x, y := p.X, p.Y
+	if o.Corner == UpperRight || o.Corner == LowerRight {
+		x = o.Width - 1 - x
+	}
+	if o.Corner == LowerLeft || o.Corner == LowerRight {
+		y = o.Height - 1 - y
+	}
+	return x, y
+}
+
+// Normalize returns the pattern coordinates of a point in the corner
+func (o Orientation) Normalize(p board.Point) (int, int) {
+	x, y := o.local(p)
+	if o.Swap {
+		x, y = y, x
+	}
+	return x, y
+}
+
+// Point returns the point on the board for the pattern coordinates
+func (o Orientation) Point(x, y int) board.Point {
+	if o.Swap {
+		x, y = y, x
+	}
+	if o.Corner == UpperRight || o.Corner == LowerRight {
+		x = o.Width - 1 - x
+	}
+	if o.Corner == LowerLeft || o.Corner == LowerRight {
+		y = o.Height - 1 - y
+	}
+	return board.Point{X: x, Y: y}
+}
+
+// Color returns the color in the pattern for a color on the board, or the other way around
+func (o Orientation) Color(c board.Color) board.Color {
+	if o.First == board.White {
+		return c.Opponent()
+	}
+	return c
+}
+
+// Token returns the normalized form of a move in the corner, like "B dc" or "W tenuki"
+func (o Orientation) Token(c board.Color, p board.Point) string {
+	if p.IsPass() {
+		return o.Color(c).String() + " " + Tenuki
+	}
+	x, y := o.Normalize(p)
+	return o.Color(c).String() + " " + board.SGF(board.Point{X: x, Y: y})
+}
+
+// Move returns the color and GTP vertex on the board for a normalized move
+func (o Orientation) Move(token string) (board.Color, string, error) {
+	fields := strings.Fields(token)
+	if len(fields) != 2 {
+		return board.Empty, "", fmt.Errorf("invalid joseki move: %q", token)
+	}
+	c, err := board.ParseColor(fields[0])
+	if err != nil {
+		return board.Empty, "", err
+	}
+	c = o.Color(c)
+	if fields[1] == Tenuki {
+		return c, Tenuki, nil
+	}
+	p, err := board.ParseSGF(fields[1], board.MaxSize, board.MaxSize)
+	if err != nil {
+		return board.Empty, "", err
+	}
+	return c, board.Vertex(o.Point(p.X, p.Y), o.Height), nil
+}
+
+// Sequence is the moves that were played in one corner, in normalized form
+type Sequence struct {
+	Orientation Orientation
+	// Tokens are the normalized moves, where a tenuki is inserted when the same player plays twice in a row
+	Tokens []string
+	// Turns are the indices of the moves in the game, where a tenuki has the index of the move played elsewhere
+	Turns []int
+	// Moves are the moves as played, with "tenuki" for moves played elsewhere
+	Moves []string
+	// decided is true when the reflection has been chosen, which happens at the first move off the diagonal
+	decided bool
+	last    board.Color
+	lastAt  int
+}
+
+// add appends a move that was played in the corner at the given turn
+func (s *Sequence) add(c board.Color, p board.Point, turn int) {
+	if len(s.Tokens) == 0 {
+		s.Orientation.First = c
+	} else if c == s.last {
+		// The opponent played elsewhere
+		s.Tokens = append(s.Tokens, s.Orientation.Token(c.Opponent(), board.Pass))
+		s.Turns = append(s.Turns, s.lastAt+1)
+		s.Moves = append(s.Moves, Tenuki)
+	}
+	if !s.decided {
+		if x, y := s.Orientation.local(p); x != y {
+			// The first move off the diagonal decides the reflection, and is normalized to be closer to the left or right edge
+			s.Orientation.Swap = x > y
+			s.decided = true
+		}
+	}
+	s.Tokens = append(s.Tokens, s.Orientation.Token(c, p))
+	s.Turns = append(s.Turns, turn)
+	s.Moves = append(s.Moves, board.Vertex(p, s.Orientation.Height))
+	s.last, s.lastAt = c, turn
+}
+
+// Split divides the moves of a game into the sequences played in each corner.
+// Corners without moves are not included.
+func Split(moves []katago.Move, width, height int) (map[Corner]*Sequence, error) {
+	sequences := make(map[Corner]*Sequence)
+	for i, move := range moves {
+		c, err := board.ParseColor(string(move.Color))
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		p, err := board.ParseVertex(string(move.Vertex), width, height)
+		if err != nil {
+			return nil, fmt.Errorf("move %d: %v", i+1, err)
+		}
+		corner, ok := CornerOf(p, width, height)
+		if !ok {
+			continue
+		}
+		s, ok := sequences[corner]
+		if !ok {
+			s = &Sequence{Orientation: Orientation{Corner: corner, Width: width, Height: height}}
+			sequences[corner] = s
+		}
+		s.add(c, p, i)
+	}
+	return sequences, nil
+}
@@ -0,0 +1,71 @@
+package joseki
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+func TestCornerOf(t *testing.T) {
+	cases := []struct {
+		p      board.Point
+		corner Corner
+		ok     bool
+	}{
+		{board.Point{X: 3, Y: 3}, UpperLeft, true},
+		{board.Point{X: 15, Y: 3}, UpperRight, true},
+		{board.Point{X: 3, Y: 15}, LowerLeft, true},
+		{board.Point{X: 15, Y: 15}, LowerRight, true},
+		{board.Point{X: 9, Y: 3}, 0, false},
+		{board.Pass, 0, false},
+	}
+	for _, c := range cases {
+		corner, ok := CornerOf(c.p, 19, 19)
+		if ok != c.ok || (ok && corner != c.corner) {
+			t.Errorf("Expected %v (%v) for %v, got %v (%v)", c.corner, c.ok, c.p, corner, ok)
+		}
+	}
+}
+
+func TestSplitNormalizes(t *testing.T) {
+	// The same sequence in the upper right corner with black first,
+	// and reflected in the lower left corner with white first
+	moves := []katago.Move{
+		{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "D4"},
+		{Color: "W", Vertex: "R17"}, {Color: "B", Vertex: "C3"},
+		{Color: "B", Vertex: "Q17"}, {Color: "W", Vertex: "C4"},
+	}
+	sequences, err := Split(moves, 19, 19)
+	if err != nil {
+		t.Fatalf("Failed to split the game: %v", err)
+	}
+	upperRight, lowerLeft := sequences[UpperRight], sequences[LowerLeft]
+	if upperRight == nil || lowerLeft == nil || len(sequences) != 2 {
+		t.Fatalf("Expected sequences in two corners, got %v", sequences)
+	}
+	expected := []string{"B dd", "W cc", "B cd"}
+	for i, token := range expected {
+		if upperRight.Tokens[i] != token || lowerLeft.Tokens[i] != token {
+			t.Errorf("Expected %s for move %d in both corners, got %s and %s", token, i+1, upperRight.Tokens[i], lowerLeft.Tokens[i])
+		}
+	}
+	if lowerLeft.Orientation.First != board.White || !upperRight.Orientation.Swap || lowerLeft.Orientation.Swap {
+		t.Errorf("Unexpected orientations %+v and %+v", upperRight.Orientation, lowerLeft.Orientation)
+	}
+	if _, vertex, err := lowerLeft.Orientation.Move("B cd"); err != nil || vertex != "C4" {
+		t.Errorf("Expected C4, got %s (%v)", vertex, err)
+	}
+}
+
+func TestSplitTenuki(t *testing.T) {
+	moves := []katago.Move{{Color: "B", Vertex: "D4"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "K10"}, {Color: "W", Vertex: "D3"}}
+	sequences, err := Split(moves, 19, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := sequences[LowerLeft]
+	if len(s.Tokens) != 4 || s.Tokens[2] != "B tenuki" || s.Turns[2] != 2 {
+		t.Errorf("Expected a black tenuki at turn 2, got %v and %v", s.Tokens, s.Turns)
+	}
+}
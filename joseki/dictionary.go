@@ -0,0 +1,228 @@
+package joseki
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// node is a position in the tree of joseki, reached by the normalized moves from the root
+type node struct {
+	children map[string]*node
+	order    []string
+	// name is set when a named joseki ends here
+	name string
+}
+
+// child returns the child for the given move, creating it if needed
+func (n *node) child(token string) *node {
+	if c, ok := n.children[token]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = make(map[string]*node)
+	}
+	c := &node{}
+	n.children[token] = c
+	n.order = append(n.order, token)
+	return c
+}
+
+// Dictionary is a collection of joseki, stored in normalized form so that they are recognized in any corner,
+// in both reflections and with either color starting
+type Dictionary struct {
+	root node
+	size int
+}
+
+// NewDictionary creates an empty joseki dictionary
+func NewDictionary() *Dictionary {
+	return &Dictionary{}
+}
+
+// Len returns the number of joseki that have been added
+func (d *Dictionary) Len() int {
+	return d.size
+}
+
+// Add adds a joseki, given as the moves in one corner of a board of the given size.
+// A pass can be used for a player that plays elsewhere. All prefixes of the sequence are also considered joseki.
+func (d *Dictionary) Add(name string, moves []katago.Move, width, height int) error {
+	s := &Sequence{}
+	corner := Corner(-1)
+	for i, move := range moves {
+		c, err := board.ParseColor(string(move.Color))
+		if err != nil {
+			return fmt.Errorf("joseki %s, move %d: %v", name, i+1, err)
+		}
+		p, err := board.ParseVertex(string(move.Vertex), width, height)
+		if err != nil {
+			return fmt.Errorf("joseki %s, move %d: %v", name, i+1, err)
+		}
+		if p.IsPass() {
+			if len(s.Tokens) == 0 {
+				return fmt.Errorf("joseki %s can not start with a tenuki", name)
+			}
+			s.Tokens = append(s.Tokens, s.Orientation.Token(c, p))
+			s.last = c
+			continue
+		}
+		pc, ok := CornerOf(p, width, height)
+		if !ok || (corner >= 0 && pc != corner) {
+			return fmt.Errorf("joseki %s, move %d: %s is not in the same corner as the first move", name, i+1, move.Vertex)
+		}
+		if corner < 0 {
+			corner = pc
+			s.Orientation = Orientation{Corner: corner, Width: width, Height: height}
+		}
+		s.add(c, p, i)
+	}
+	if len(s.Tokens) == 0 {
+		return errors.New("a joseki needs at least one move")
+	}
+	n := &d.root
+	for _, token := range s.Tokens {
+		n = n.child(token)
+	}
+	if n.name == "" {
+		d.size++
+	}
+	n.name = name
+	return nil
+}
+
+// Lookup follows normalized moves from the start of a corner sequence. It returns the name of the
+// last named joseki along the way, the normalized moves that are known to continue the sequence,
+// and false if the sequence leaves the dictionary.
+func (d *Dictionary) Lookup(tokens []string) (string, []string, bool) {
+	n := &d.root
+	name := ""
+	for _, token := range tokens {
+		next, ok := n.children[token]
+		if !ok {
+			return name, nil, false
+		}
+		n = next
+		if n.name != "" {
+			name = n.name
+		}
+	}
+	return name, append([]string{}, n.order...), true
+}
+
+// Deviation is a move that left the joseki dictionary
+type Deviation struct {
+	Corner Corner
+	// Turn is the index of the deviating move in the game, so that Turn+1 is the move number
+	Turn int
+	// Move is the GTP vertex of the move, or "tenuki" if the player played elsewhere
+	Move string
+	// Joseki is the name of the last named joseki that the sequence followed, if any
+	Joseki string
+	// Expected are the GTP vertices of the moves that would have continued the joseki
+	Expected []string
+	// EngineMove and EnginePV are what KataGo prefers in the position before the deviation, when analyzed
+	EngineMove string
+	EnginePV   []string
+}
+
+// String describes the deviation, like "deviation from joseki at move 14 in the upper right corner (R3), expected Q3"
+func (d Deviation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "deviation from joseki at move %d in the %s corner (%s)", d.Turn+1, d.Corner, d.Move)
+	if d.Joseki != "" {
+		fmt.Fprintf(&sb, " after %s", d.Joseki)
+	}
+	if len(d.Expected) > 0 {
+		fmt.Fprintf(&sb, ", expected %s", strings.Join(d.Expected, " or "))
+	}
+	if d.EngineMove != "" {
+		fmt.Fprintf(&sb, ", KataGo prefers %s", d.EngineMove)
+	}
+	return sb.String()
+}
+
+// Check splits the game into corner sequences and returns the first deviation from the dictionary in each corner,
+// ordered by move number and then by move. Corners where the first move is not in the dictionary are not reported.
+func (d *Dictionary) Check(moves []katago.Move, width, height int) ([]Deviation, error) {
+	sequences, err := Split(moves, width, height)
+	if err != nil {
+		return nil, err
+	}
+	var deviations []Deviation
+	for corner, s := range sequences {
+		n := &d.root
+		name := ""
+		for i, token := range s.Tokens {
+			next, ok := n.children[token]
+			if ok {
+				n = next
+				if n.name != "" {
+					name = n.name
+				}
+				continue
+			}
+			if i == 0 || len(n.children) == 0 {
+				// The corner is not a known joseki, or the joseki was already finished
+				break
+			}
+			deviation := Deviation{Corner: corner, Turn: s.Turns[i], Move: s.Moves[i], Joseki: name}
+			for _, expected := range n.order {
+				_, vertex, err := s.Orientation.Move(expected)
+				if err != nil {
+					return nil, err
+				}
+				deviation.Expected = append(deviation.Expected, vertex)
+			}
+			deviations = append(deviations, deviation)
+			break
+		}
+	}
+	// A tenuki in one corner has the turn of the move that was played elsewhere, which can be a deviation in
+	// another corner, so ties are broken by the move to not depend on the order of the map
+	sort.Slice(deviations, func(i, j int) bool {
+		if deviations[i].Turn != deviations[j].Turn {
+			return deviations[i].Turn < deviations[j].Turn
+		}
+		return deviations[i].Move < deviations[j].Move
+	})
+	return deviations, nil
+}
+
+// nextReview numbers the calls to Review, so that the IDs of concurrent calls on the same engine do not collide
+var nextReview atomic.Uint64
+
+// Review checks the game against the dictionary, and asks KataGo for the preferred move
+// in the position before each deviation, using the given number of visits
+func (d *Dictionary) Review(k *katago.KataGo, game katago.Position, visits int) ([]Deviation, error) {
+	deviations, err := d.Check(game.Moves, game.BoardXSize, game.BoardYSize)
+	if err != nil || len(deviations) == 0 {
+		return deviations, err
+	}
+	review := nextReview.Add(1)
+	requests := make([]katago.AnalysisRequest, len(deviations))
+	for i, deviation := range deviations {
+		p := game
+		p.Moves = game.Moves[:deviation.Turn]
+		requests[i] = p.Request(fmt.Sprintf("joseki-%d-%d", review, deviation.Turn))
+		requests[i].MaxVisits = visits
+	}
+	responses, err := k.Analyze(requests)
+	if err != nil {
+		return nil, err
+	}
+	for i, response := range responses {
+		best, ok := response.BestMove()
+		if !ok {
+			continue
+		}
+		deviations[i].EngineMove = best.Move
+		deviations[i].EnginePV = best.PV
+	}
+	return deviations, nil
+}
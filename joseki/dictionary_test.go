@@ -0,0 +1,118 @@
+package joseki
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestDictionary(t *testing.T) *Dictionary {
+	t.Helper()
+	d := NewDictionary()
+	if err := d.Add("3-3 invasion", []katago.Move{{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "R17"}, {Color: "B", Vertex: "Q17"}, {Color: "W", Vertex: "R16"}}, 19, 19); err != nil {
+		t.Fatalf("Failed to add a joseki: %v", err)
+	}
+	if err := d.Add("3-3 invasion, other side", []katago.Move{{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "R17"}, {Color: "B", Vertex: "R16"}, {Color: "W", Vertex: "Q17"}}, 19, 19); err != nil {
+		t.Fatalf("Failed to add a joseki: %v", err)
+	}
+	return d
+}
+
+func TestDictionaryAdd(t *testing.T) {
+	d := newTestDictionary(t)
+	// The two lines are reflections of each other
+	if d.Len() != 1 {
+		t.Errorf("Expected 1 joseki, got %d", d.Len())
+	}
+	name, next, ok := d.Lookup([]string{"B dd", "W cc"})
+	if !ok || name != "" || len(next) != 1 || next[0] != "B cd" {
+		t.Errorf("Expected B cd to continue the joseki, got %q %v %v", name, next, ok)
+	}
+	if err := d.Add("split", []katago.Move{{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "D4"}}, 19, 19); err == nil {
+		t.Error("Expected an error for a joseki in two corners")
+	}
+}
+
+func TestDictionaryCheck(t *testing.T) {
+	d := newTestDictionary(t)
+	moves := []katago.Move{
+		// Finished joseki in the upper right corner, which is not a deviation
+		{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "R17"}, {Color: "B", Vertex: "Q17"}, {Color: "W", Vertex: "R16"}, {Color: "B", Vertex: "R15"},
+		// Reflected and with white first in the lower left corner, where black plays elsewhere at move 9
+		{Color: "W", Vertex: "D4"}, {Color: "B", Vertex: "C3"}, {Color: "W", Vertex: "D3"}, {Color: "B", Vertex: "K10"}, {Color: "W", Vertex: "E3"},
+	}
+	deviations, err := d.Check(moves, 19, 19)
+	if err != nil {
+		t.Fatalf("Failed to check the game: %v", err)
+	}
+	if len(deviations) != 1 {
+		t.Fatalf("Expected one deviation, got %v", deviations)
+	}
+	deviation := deviations[0]
+	if deviation.Corner != LowerLeft || deviation.Turn != 8 || deviation.Move != "tenuki" {
+		t.Errorf("Expected a tenuki at move 9 in the lower left corner, got %v", deviation)
+	}
+	if len(deviation.Expected) != 1 || deviation.Expected[0] != "C4" {
+		t.Errorf("Expected C4, got %v", deviation.Expected)
+	}
+	if s := deviation.String(); !strings.HasPrefix(s, "deviation from joseki at move 9 in the lower left corner") {
+		t.Errorf("Unexpected description: %s", s)
+	}
+}
+
+func TestDictionaryCheckSameTurn(t *testing.T) {
+	d := newTestDictionary(t)
+	moves := []katago.Move{
+		{Color: "B", Vertex: "D4"}, {Color: "W", Vertex: "C3"}, {Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "R17"},
+		// A deviation in the lower left corner, which is also a tenuki by black in the upper right corner
+		{Color: "B", Vertex: "E3"}, {Color: "W", Vertex: "R16"},
+	}
+	// The corners are in a map, so check several times
+	for i := 0; i < 100; i++ {
+		deviations, err := d.Check(moves, 19, 19)
+		if err != nil {
+			t.Fatalf("Failed to check the game: %v", err)
+		}
+		if len(deviations) != 2 || deviations[0].Move != "E3" || deviations[1].Move != "tenuki" || deviations[1].Turn != 4 {
+			t.Fatalf("Expected E3 and then a tenuki at move 5, got %v", deviations)
+		}
+	}
+}
+
+func TestDictionaryReview(t *testing.T) {
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	d := newTestDictionary(t)
+	game := katago.Position{
+		Moves:      []katago.Move{{Color: "B", Vertex: "Q16"}, {Color: "W", Vertex: "R17"}, {Color: "B", Vertex: "R15"}},
+		Rules:      katago.Chinese,
+		Komi:       7.5,
+		BoardXSize: 19,
+		BoardYSize: 19,
+	}
+	deviations, err := d.Review(k, game, 20)
+	if err != nil {
+		t.Fatalf("Failed to review the game: %v", err)
+	}
+	if len(deviations) != 1 || deviations[0].Turn != 2 || deviations[0].EngineMove == "" {
+		t.Errorf("Expected a deviation at move 3 with a preferred move, got %v", deviations)
+	}
+
+	// Reviews of the same game at the same time use different IDs
+	errs := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := d.Review(k, game, 200)
+			errs <- err
+		}()
+	}
+	for i := 0; i < 2; i++ {
+		if err := <-errs; err != nil {
+			t.Errorf("Expected concurrent reviews to succeed, got %v", err)
+		}
+	}
+}
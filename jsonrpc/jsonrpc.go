@@ -0,0 +1,321 @@
+// Package jsonrpc exposes a KataGo analysis engine with JSON-RPC 2.0, over stdio or TCP, for tools like
+// editor plugins and Electron apps that already speak JSON-RPC. Messages are either one JSON value per line,
+// or framed with a Content-Length header as in the Language Server Protocol. Replies use the same framing.
+//
+// The methods are:
+//
+//	analyze    params: an AnalysisRequest, result: an AnalysisResponse
+//	terminate  params: {"id": "..."}, result: true
+//	health     result: {"status": "ok"}
+package jsonrpc
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xyproto/katago"
+)
+
+// Version is the JSON-RPC version
+const Version = "2.0"
+
+// Error codes from the JSON-RPC specification, and the server errors used by this package
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeDuplicateID    = -32001
+	CodeUnknownQuery   = -32002
+)
+
+// Request is a JSON-RPC request. Requests without an ID are notifications, which get no reply.
+type Request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Error is a JSON-RPC error object
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("JSON-RPC error %d: %s", e.Code, e.Message)
+}
+
+// Response is a JSON-RPC response
+type Response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+// terminateParams are the parameters of the terminate method
+type terminateParams struct {
+	ID string `json:"id"`
+}
+
+// Server answers JSON-RPC calls with a KataGo engine
+type Server struct {
+	katago *katago.KataGo
+	nextID atomic.Uint64
+}
+
+// NewServer creates a JSON-RPC server for the given engine
+func NewServer(k *katago.KataGo) *Server {
+	return &Server{katago: k}
+}
+
+// ListenAndServe accepts TCP connections on the given address, like "localhost:4321", and serves each one
+func ListenAndServe(addr string, k *katago.KataGo) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return NewServer(k).ServeListener(l)
+}
+
+// ServeStdio reads calls from stdin and writes the replies to stdout, until stdin is closed
+func ServeStdio(k *katago.KataGo) error {
+	return NewServer(k).Serve(os.Stdin, os.Stdout)
+}
+
+// ServeListener serves the connections that are accepted by the listener
+func (s *Server) ServeListener(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := s.Serve(conn, conn); err != nil {
+				log.Printf("JSON-RPC connection error: %v", err)
+			}
+		}()
+	}
+}
+
+// writer writes replies with the same framing as the messages they answer
+type writer struct {
+	mut sync.Mutex
+	w   io.Writer
+}
+
+func (w *writer) write(v any, framed bool) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Failed to marshal a JSON-RPC reply: %v", err)
+		return
+	}
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	if framed {
+		_, err = fmt.Fprintf(w.w, "Content-Length: %d\r\n\r\n%s", len(data), data)
+	} else {
+		_, err = fmt.Fprintf(w.w, "%s\n", data)
+	}
+	if err != nil {
+		log.Printf("Failed to write a JSON-RPC reply: %v", err)
+	}
+}
+
+// readMessage reads one message, and reports if it was framed with a Content-Length header
+func readMessage(r *bufio.Reader) ([]byte, bool, error) {
+	for {
+		line, err := r.ReadBytes('\n')
+		if err != nil && (err != io.EOF || len(bytes.TrimSpace(line)) == 0) {
+			return nil, false, err
+		}
+		trimmed := bytes.TrimSpace(line)
+		if len(trimmed) == 0 {
+			continue
+		}
+		name, value, ok := strings.Cut(string(trimmed), ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			return trimmed, false, nil
+		}
+		length, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil || length < 0 {
+			return nil, false, fmt.Errorf("invalid Content-Length: %q", value)
+		}
+		// Skip any other headers, up to the empty line
+		for {
+			header, err := r.ReadBytes('\n')
+			if err != nil {
+				return nil, false, err
+			}
+			if len(bytes.TrimSpace(header)) == 0 {
+				break
+			}
+		}
+		message := make([]byte, length)
+		if _, err := io.ReadFull(r, message); err != nil {
+			return nil, false, err
+		}
+		return message, true, nil
+	}
+}
+
+// Serve reads calls from r and writes the replies to w, until r is closed. Calls are handled concurrently,
+// so replies may come in a different order than the calls, and are matched by their IDs.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	br := bufio.NewReader(r)
+	out := &writer{w: w}
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		message, framed, err := readMessage(br)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if reply := s.handleMessage(message); reply != nil {
+				out.write(reply, framed)
+			}
+		}()
+	}
+}
+
+// handleMessage handles a single call or a batch of calls, and returns the reply, or nil if there is none
+func (s *Server) handleMessage(message []byte) any {
+	if len(message) > 0 && message[0] == '[' {
+		var batch []json.RawMessage
+		if err := json.Unmarshal(message, &batch); err != nil {
+			return errorResponse(nil, CodeParseError, err.Error())
+		}
+		if len(batch) == 0 {
+			return errorResponse(nil, CodeInvalidRequest, "empty batch")
+		}
+		replies := make([]*Response, len(batch))
+		var wg sync.WaitGroup
+		for i, call := range batch {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				replies[i] = s.handleCall(call)
+			}()
+		}
+		wg.Wait()
+		var results []*Response
+		for _, reply := range replies {
+			if reply != nil {
+				results = append(results, reply)
+			}
+		}
+		if len(results) == 0 {
+			return nil
+		}
+		return results
+	}
+	if reply := s.handleCall(message); reply != nil {
+		return reply
+	}
+	return nil
+}
+
+// errorResponse creates a response with an error
+func errorResponse(id json.RawMessage, code int, message string) *Response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &Response{JSONRPC: Version, ID: id, Error: &Error{Code: code, Message: message}}
+}
+
+// handleCall handles one call, and returns nil for notifications
+func (s *Server) handleCall(message []byte) *Response {
+	var request Request
+	if err := json.Unmarshal(message, &request); err != nil {
+		return errorResponse(nil, CodeParseError, err.Error())
+	}
+	if request.JSONRPC != Version || request.Method == "" {
+		return errorResponse(request.ID, CodeInvalidRequest, "not a JSON-RPC 2.0 request")
+	}
+	result, rpcErr := s.call(request.Method, request.Params)
+	if request.ID == nil {
+		return nil
+	}
+	if rpcErr != nil {
+		return &Response{JSONRPC: Version, ID: request.ID, Error: rpcErr}
+	}
+	return &Response{JSONRPC: Version, ID: request.ID, Result: result}
+}
+
+// call runs a method
+func (s *Server) call(method string, params json.RawMessage) (any, *Error) {
+	switch method {
+	case "analyze":
+		var request katago.AnalysisRequest
+		if err := json.Unmarshal(params, &request); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+		if request.ID == "" {
+			request.ID = fmt.Sprintf("jsonrpc-%d", s.nextID.Add(1))
+		}
+		if request.Moves == nil {
+			request.Moves = []katago.Move{}
+		}
+		if err := request.Rules.Validate(); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+		responses, err := s.katago.Analyze([]katago.AnalysisRequest{request})
+		if err != nil {
+			return nil, engineError(err)
+		}
+		if len(responses) > 1 {
+			// A request for several turns gets one response for each turn
+			return responses, nil
+		}
+		return responses[0], nil
+	case "terminate":
+		var p terminateParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &Error{CodeInvalidParams, err.Error()}
+		}
+		if err := s.katago.Terminate(p.ID); err != nil {
+			return nil, engineError(err)
+		}
+		return true, nil
+	case "health":
+		if !s.katago.Running() {
+			return nil, &Error{CodeInternalError, "KataGo is not running"}
+		}
+		return map[string]string{"status": "ok"}, nil
+	}
+	return nil, &Error{CodeMethodNotFound, "unknown method: " + method}
+}
+
+// engineError converts an error from the engine to a JSON-RPC error
+func engineError(err error) *Error {
+	switch {
+	case errors.Is(err, katago.ErrDuplicateID):
+		return &Error{CodeDuplicateID, err.Error()}
+	case errors.Is(err, katago.ErrUnknownQuery):
+		return &Error{CodeUnknownQuery, err.Error()}
+	case errors.Is(err, katago.ErrBadRequest):
+		return &Error{CodeInvalidParams, err.Error()}
+	}
+	return &Error{CodeInternalError, err.Error()}
+}
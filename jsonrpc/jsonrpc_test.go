@@ -0,0 +1,148 @@
+package jsonrpc
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return NewServer(k)
+}
+
+// serve runs the server on the given input and returns the output lines
+func serve(t *testing.T, s *Server, input string) []string {
+	t.Helper()
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(out.String()), "\n")
+}
+
+const analyzeCall = `{"jsonrpc":"2.0","id":1,"method":"analyze","params":{"moves":[["B","E5"]],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":20,"analyzeTurns":[1]}}`
+
+func TestAnalyze(t *testing.T) {
+	s := newTestServer(t)
+	lines := serve(t, s, analyzeCall+"\n")
+	if len(lines) != 1 {
+		t.Fatalf("Expected 1 reply, got %d", len(lines))
+	}
+	var reply struct {
+		ID     int                     `json:"id"`
+		Result katago.AnalysisResponse `json:"result"`
+		Error  *Error                  `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &reply); err != nil {
+		t.Fatal(err)
+	}
+	if reply.Error != nil {
+		t.Fatalf("Unexpected error: %v", reply.Error)
+	}
+	if reply.ID != 1 || reply.Result.TurnNumber != 1 || len(reply.Result.MoveInfos) == 0 {
+		t.Errorf("Unexpected reply: %s", lines[0])
+	}
+}
+
+func TestErrors(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		call string
+		code int
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"nope"}`, CodeMethodNotFound},
+		{`{"jsonrpc":"2.0","id":1,"method":"analyze","params":{"rules":"nope"}}`, CodeInvalidParams},
+		{`{"jsonrpc":"2.0","id":1,"method":"terminate","params":{"id":"missing"}}`, CodeUnknownQuery},
+		{`{"id":1,"method":"health"}`, CodeInvalidRequest},
+		{`{"jsonrpc":`, CodeParseError},
+	}
+	for _, test := range tests {
+		lines := serve(t, s, test.call+"\n")
+		var reply Response
+		if err := json.Unmarshal([]byte(lines[0]), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Error == nil || reply.Error.Code != test.code {
+			t.Errorf("Expected error code %d for %s, got %s", test.code, test.call, lines[0])
+		}
+	}
+}
+
+func TestBatchAndNotification(t *testing.T) {
+	s := newTestServer(t)
+	batch := `[{"jsonrpc":"2.0","id":"a","method":"health"},{"jsonrpc":"2.0","method":"health"},{"jsonrpc":"2.0","id":"b","method":"health"}]`
+	lines := serve(t, s, batch+"\n")
+	var replies []Response
+	if err := json.Unmarshal([]byte(lines[0]), &replies); err != nil {
+		t.Fatal(err)
+	}
+	if len(replies) != 2 || string(replies[0].ID) != `"a"` || string(replies[1].ID) != `"b"` {
+		t.Errorf("Unexpected batch reply: %s", lines[0])
+	}
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(`{"jsonrpc":"2.0","method":"health"}`+"\n"), &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("Expected no reply to a notification, got %q", out.String())
+	}
+}
+
+func TestContentLength(t *testing.T) {
+	s := newTestServer(t)
+	call := `{"jsonrpc":"2.0","id":7,"method":"health"}`
+	input := "Content-Length: " + strconv.Itoa(len(call)) + "\r\n\r\n" + call
+	var out strings.Builder
+	if err := s.Serve(strings.NewReader(input), &out); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(strings.NewReader(out.String()))
+	message, framed, err := readMessage(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !framed || !strings.Contains(string(message), `"id":7`) {
+		t.Errorf("Expected a framed reply, got %q", out.String())
+	}
+}
+
+func TestTCP(t *testing.T) {
+	s := newTestServer(t)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go s.ServeListener(l)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := io.WriteString(conn, `{"jsonrpc":"2.0","id":1,"method":"health"}`+"\n"); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(line, `"status":"ok"`) {
+		t.Errorf("Unexpected reply: %s", line)
+	}
+}
@@ -1,134 +1,465 @@
 package katago
 
 import (
-	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // AnalysisRequest represents a request to analyze a position or a sequence of moves
 type AnalysisRequest struct {
-	ID            string      `json:"id"`
-	InitialStones [][2]string `json:"initialStones,omitempty"`
-	Moves         [][2]string `json:"moves"`
-	Rules         string      `json:"rules"`
-	Komi          float64     `json:"komi"`
-	BoardXSize    int         `json:"boardXSize"`
-	BoardYSize    int         `json:"boardYSize"`
-	MaxVisits     int         `json:"maxVisits,omitempty"`
-	AnalyzeTurns  []int       `json:"analyzeTurns"`
+	ID                 string  `json:"id"`
+	InitialStones      []Move  `json:"initialStones,omitempty"`
+	Moves              []Move  `json:"moves"`
+	InitialPlayer      string  `json:"initialPlayer,omitempty"`
+	Rules              Rules   `json:"rules"`
+	Komi               float64 `json:"komi"`
+	WhiteHandicapBonus string  `json:"whiteHandicapBonus,omitempty"`
+	BoardXSize         int     `json:"boardXSize"`
+	BoardYSize         int     `json:"boardYSize"`
+	MaxVisits          int     `json:"maxVisits,omitempty"`
+	AnalyzeTurns       []int   `json:"analyzeTurns"`
+	IncludeOwnership   bool    `json:"includeOwnership,omitempty"`
+	IncludePolicy      bool    `json:"includePolicy,omitempty"`
+	// IncludePVVisits adds the number of visits of each move in the principal variations
+	IncludePVVisits bool `json:"includePVVisits,omitempty"`
+	// IncludeOwnershipStdev adds the standard deviation of the ownership of each point
+	IncludeOwnershipStdev bool `json:"includeOwnershipStdev,omitempty"`
+	// IncludeMovesOwnership adds the ownership after each candidate move to the move infos
+	IncludeMovesOwnership bool `json:"includeMovesOwnership,omitempty"`
+	// ReportDuringSearchEvery makes KataGo report the results so far at this interval, in seconds
+	ReportDuringSearchEvery float64 `json:"reportDuringSearchEvery,omitempty"`
+	// OverrideSettings replaces settings from the config file for this request, like "humanSLProfile"
+	OverrideSettings map[string]any `json:"overrideSettings,omitempty"`
+	// AvoidMoves and AllowMoves keep the search from looking at some of the moves
+	AvoidMoves []MoveRestriction `json:"avoidMoves,omitempty"`
+	AllowMoves []MoveRestriction `json:"allowMoves,omitempty"`
+	// Priority decides which queries KataGo searches first, where higher values go first
+	Priority int `json:"priority,omitempty"`
+	// Timeout terminates the search when it takes longer than this, and the results so far are returned
+	Timeout time.Duration `json:"-"`
+}
+
+// MoveRestriction gives moves that a player may not play, or the only moves that a player may play,
+// during the first UntilDepth moves of the search
+type MoveRestriction struct {
+	Player     string   `json:"player"`
+	Moves      []string `json:"moves"`
+	UntilDepth int      `json:"untilDepth"`
 }
 
 // AnalysisResponse represents the response from KataGo for an analysis request
 type AnalysisResponse struct {
-	ID        string        `json:"id"`
-	MoveInfos []MoveInfoExt `json:"moveInfos"`
+	ID         string        `json:"id"`
+	TurnNumber int           `json:"turnNumber"`
+	MoveInfos  []MoveInfoExt `json:"moveInfos"`
+	RootInfo   RootInfo      `json:"rootInfo"`
+	Ownership  []float64     `json:"ownership,omitempty"`
+	Policy     []float64     `json:"policy,omitempty"`
+	// OwnershipStdev is the standard deviation of the ownership of each point over the search, when
+	// IncludeOwnershipStdev is set. High values are points that are still uncertain.
+	OwnershipStdev []float64 `json:"ownershipStdev,omitempty"`
+	// HumanPolicy is the policy of the human SL model, when it is loaded and IncludePolicy is set
+	HumanPolicy []float64 `json:"humanPolicy,omitempty"`
+	// IsDuringSearch is true for the reports that are sent while the search is still running
+	IsDuringSearch bool `json:"isDuringSearch"`
+	// NoResults is true when the query was terminated before the search started
+	NoResults bool `json:"noResults,omitempty"`
+	// Warnings are the warnings that KataGo sent for the query, like for fields that it does not know
+	Warnings []Warning `json:"warnings,omitempty"`
+}
+
+// Warning is a warning that KataGo sent for a query, before the response
+type Warning struct {
+	// Field is the field of the request that the warning is about, if any
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
 }
 
 // MoveInfoExt represents the extended information about a move analyzed by KataGo
 type MoveInfoExt struct {
-	Move    string  `json:"move"`
-	Winrate float64 `json:"winrate"`
+	Move      string   `json:"move"`
+	Visits    int      `json:"visits"`
+	Winrate   float64  `json:"winrate"`
+	ScoreLead float64  `json:"scoreLead"`
+	Prior     float64  `json:"prior"`
+	Order     int      `json:"order"`
+	PV        []string `json:"pv"`
+	// ScoreMean is the same as ScoreLead, for compatibility, and ScoreStdev is the uncertainty of the score
+	ScoreMean  float64 `json:"scoreMean,omitempty"`
+	ScoreStdev float64 `json:"scoreStdev,omitempty"`
+	// ScoreSelfplay is the expected score if KataGo played the game out against itself after the move
+	ScoreSelfplay float64 `json:"scoreSelfplay,omitempty"`
+	// LCB is the lower confidence bound of the winrate, and Utility and UtilityLCB are the utility that the search
+	// maximizes, which also counts the score, and its lower confidence bound
+	LCB        float64 `json:"lcb,omitempty"`
+	Utility    float64 `json:"utility,omitempty"`
+	UtilityLCB float64 `json:"utilityLcb,omitempty"`
+	// PVVisits and PVEdgeVisits are the visits of each move in the PV, when IncludePVVisits is set
+	PVVisits     []int `json:"pvVisits,omitempty"`
+	PVEdgeVisits []int `json:"pvEdgeVisits,omitempty"`
+	// HumanPrior is the probability of the move according to the human SL model, when it is loaded
+	HumanPrior float64 `json:"humanPrior,omitempty"`
+	// Ownership is the predicted ownership if the move is played, when IncludeMovesOwnership is set.
+	// It has the same layout and perspective as the ownership of the response.
+	Ownership []float64 `json:"ownership,omitempty"`
+}
+
+// RootInfo represents KataGo's overall evaluation of the analyzed position
+type RootInfo struct {
+	Winrate   float64 `json:"winrate"`
+	ScoreLead float64 `json:"scoreLead"`
+	// ScoreSelfplay is the expected score if KataGo played the game out against itself, and ScoreStdev is
+	// the uncertainty of the score
+	ScoreSelfplay float64 `json:"scoreSelfplay,omitempty"`
+	ScoreStdev    float64 `json:"scoreStdev,omitempty"`
+	Visits        int     `json:"visits"`
+	CurrentPlayer string  `json:"currentPlayer"`
 }
 
 // KataGo represents a KataGo analysis engine instance
 type KataGo struct {
-	cmd    *exec.Cmd
-	stdin  io.WriteCloser
-	stdout *bufio.Reader
-	stderr *bufio.Scanner
+	// proc is the KataGo process that new requests are sent to
+	proc atomic.Pointer[process]
+	// swapMut is held while sending a request, and while SwapModel switches to a new process
+	swapMut    sync.RWMutex
+	configFile string
+	nextID     atomic.Uint64
+	cache      Cache
+	// binary, overrides, env, humanModel and analysisThreads are set by the options, before KataGo is started
+	binary     string
+	overrides  map[string]string
+	env        []string
+	humanModel string
+	// analysisThreads replaces numAnalysisThreads in the config file, if set
+	analysisThreads int
+	// defaults fill in the fields that the requests leave empty, if set
+	defaults *RequestDefaults
+	// queue limits the number of requests that are waiting for a response, if set
+	queue *queueLimit
+	// limiter limits how fast requests are sent, and how many visits they ask for, if set
+	limiter *rateLimiter
+
+	// flightMut protects flights, which holds the running queries that concurrent calls for the same position can share
+	flightMut sync.Mutex
+	flights   map[flightKey]*flight
+
+	// writeMut makes sure that lines written by concurrent callers are not mixed up
+	writeMut sync.Mutex
+	// mut protects pending, which holds the queries that are waiting for a response, by ID
+	mut     sync.Mutex
+	pending map[string]*query
+	// finished is signaled when a query is answered or given up, and uses mut
+	finished *sync.Cond
+	// preemptedIDs are the answered queries whose search was stopped by preemption, and uses mut
+	preemptedIDs map[string]bool
+	// terminatedIDs are the answered queries whose search was stopped by Terminate or TerminateAll, and uses mut
+	terminatedIDs map[string]bool
+	// warnings are the warnings that KataGo sent for the registered queries, by ID, and uses mut
+	warnings map[string][]Warning
+	// stats are the totals and timings for Stats, protected by mut
+	stats engineStats
+	// progress is called with the reports during the search, if set, from its own goroutine that reads
+	// progressReports until progressDone is closed
+	progress        func(id string, visitsDone int, snapshot AnalysisResponse)
+	progressReports chan []byte
+	progressDone    chan struct{}
+	progressOnce    sync.Once
+	// clearCacheEachGame makes AnalyzeGame clear the neural network cache first
+	clearCacheEachGame bool
+	// preemption makes requests with a higher priority stop the running requests with a lower priority
+	preemption bool
+	// version is the version of KataGo once it is known, protected by mut
+	version *Version
 }
 
-// NewKataGo creates a new KataGo analysis engine instance
-func NewKataGo(configFile, modelFile string) (*KataGo, error) {
-	cmd := exec.Command("katago", "analysis", "-config", configFile, "-model", modelFile)
-	stdin, err := cmd.StdinPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get stdin: %v", err)
+// NewKataGo creates a new KataGo analysis engine instance. If the config file or the model file is "",
+// it is found with FindConfig or FindModel.
+func NewKataGo(configFile, modelFile string, options ...Option) (*KataGo, error) {
+	var err error
+	k := newEngine(options)
+	if err := checkOverrides(k.overrides); err != nil {
+		return nil, err
 	}
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get stdout: %v", err)
+	if k.binary == "" {
+		binary, err := FindBinary()
+		if err != nil {
+			return nil, err
+		}
+		k.binary = binary
 	}
-	stderr, err := cmd.StderrPipe()
+	if configFile == "" || modelFile == "" {
+		dirs := searchDirs(k.binary)
+		if configFile == "" {
+			if configFile, err = findConfig(dirs); err != nil {
+				return nil, err
+			}
+		}
+		if modelFile == "" {
+			if modelFile, err = findModel(dirs); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	k.configFile = configFile
+	p, err := k.start(modelFile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get stderr: %v", err)
+		return nil, err
 	}
+	k.proc.Store(p)
+	return k, nil
+}
 
+// newEngine returns an engine with the given options, that is not yet connected to a KataGo process
+func newEngine(options []Option) *KataGo {
 	k := &KataGo{
-		cmd:    cmd,
-		stdin:  stdin,
-		stdout: bufio.NewReader(stdout),
-		stderr: bufio.NewScanner(stderr),
+		pending:       make(map[string]*query),
+		preemptedIDs:  make(map[string]bool),
+		terminatedIDs: make(map[string]bool),
+		warnings:      make(map[string][]Warning),
 	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start KataGo: %v", err)
+	k.finished = sync.NewCond(&k.mut)
+	for _, option := range options {
+		option(k)
 	}
+	k.startProgress()
+	return k
+}
 
-	go k.readStderr()
-
-	return k, nil
+// newID returns a unique query ID with the given prefix, for queries that the package creates on its own
+func (k *KataGo) newID(prefix string) string {
+	return fmt.Sprintf("%s-%d", prefix, k.nextID.Add(1))
 }
 
-// readStderr reads from KataGo's stderr for logging purposes
-func (k *KataGo) readStderr() {
-	for k.stderr.Scan() {
-		fmt.Printf("KataGo stderr: %s\n", k.stderr.Text())
+// collect reads the responses of a sent request, one for each analyzed turn, sends the request again if it was
+// preempted, and stops its timeout. The responses are sorted by turn number. A search that was stopped without
+// any results gives ErrQueryTimeout if the timeout stopped it, or else ErrQueryTerminated, and stopped
+// reports if the results are incomplete because the timeout or a termination stopped the search.
+func (k *KataGo) collect(request AnalysisRequest, p *process, ch chan []byte, stop func() bool) (responses []AnalysisResponse, stopped bool, err error) {
+	// Read the responses from KataGo, one for each analyzed turn
+	lines, err := k.waitTurns(p, ch, request.turns())
+	if err != nil {
+		return nil, false, fmt.Errorf("error reading response: %w", err)
+	}
+	if k.preemption {
+		// A request that was stopped by one with a higher priority is sent again
+		if lines, err = k.retryPreempted(request, lines); err != nil {
+			return nil, false, fmt.Errorf("error reading response: %w", err)
+		}
+	}
+
+	timedOut := stop()
+	stopped = k.takeTerminated(request.ID) || timedOut
+	responses = make([]AnalysisResponse, 0, len(lines))
+	results := false
+	for _, line := range lines {
+		if err := responseError(line); err != nil {
+			return nil, false, err
+		}
+		var response AnalysisResponse
+		if err := json.Unmarshal(line, &response); err != nil {
+			return nil, false, fmt.Errorf("failed to unmarshal response: %v", err)
+		}
+		response.Warnings = k.warningsFor(request.ID)
+
+		// Log the response received
+		log.Printf("Received response: %v", response)
+		results = results || !response.NoResults
+		responses = append(responses, response)
 	}
-	if err := k.stderr.Err(); err != nil {
-		fmt.Printf("Error reading stderr: %v\n", err)
+	// The turns may be answered in any order
+	slices.SortFunc(responses, func(a, b AnalysisResponse) int {
+		return a.TurnNumber - b.TurnNumber
+	})
+	switch {
+	case timedOut && !results:
+		return nil, false, fmt.Errorf("%w: %s", ErrQueryTimeout, request.ID)
+	case !results:
+		return nil, false, fmt.Errorf("%w: %s", ErrQueryTerminated, request.ID)
 	}
+	return responses, stopped, nil
 }
 
-// Analyze sends multiple analysis requests to KataGo and returns the responses
-func (k *KataGo) Analyze(requests []AnalysisRequest) ([]AnalysisResponse, error) {
-	var responses []AnalysisResponse
-	responseMap := make(map[string]AnalysisResponse)
+// Analyze sends multiple analysis requests to KataGo and returns the responses, in the order of the requests.
+// A request with several AnalyzeTurns gets one response for each turn, in the order of the turn numbers.
+// Requests for a position that is already in the cache, or that is analyzed by an earlier request
+// in the same call, are not sent, and get a copy of the response with their own ID and turn number.
+// Requests for a position that a concurrent call is analyzing with the same settings wait for that query.
+func (k *KataGo) Analyze(requests []AnalysisRequest) (responses []AnalysisResponse, err error) {
+	responseMap := make(map[string][]AnalysisResponse)
 
+	if k.defaults != nil {
+		withDefaults := make([]AnalysisRequest, len(requests))
+		for i, request := range requests {
+			withDefaults[i] = k.defaults.apply(request)
+		}
+		requests = withDefaults
+	}
 	for _, request := range requests {
+		if err := request.Validate(); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrBadRequest, err)
+		}
+	}
+
+	// Requests for the same position are only sent once, and share the response.
+	// The cache holds the responses for the canonical orientation of each position.
+	duplicateOf, keys := deduplicate(requests)
+	// The cache and the running queries are shared per model, so that a swapped model does not get the old responses
+	model := k.Model()
+	for i, key := range keys {
+		key.hash = withModel(key.hash, model)
+		keys[i] = key
+	}
+	cached := make(map[int]AnalysisResponse)
+	if k.cache != nil {
+		for i, key := range keys {
+			if response, ok := k.cache.Get(key.hash); ok {
+				cached[i] = key.fromCanonical(response)
+			}
+		}
+	}
+	var unanswered []int
+	for i := range requests {
+		_, duplicate := duplicateOf[i]
+		if _, ok := cached[i]; !ok && !duplicate {
+			unanswered = append(unanswered, i)
+		}
+	}
+	// Positions that a concurrent call is already analyzing with the same settings wait for that query
+	joined := k.joinFlights(requests, keys, unanswered)
+	var ids []string
+	var toSend []AnalysisRequest
+	var sent []int
+	for _, i := range unanswered {
+		if _, ok := joined[i]; ok {
+			continue
+		}
+		ids = append(ids, requests[i].ID)
+		toSend = append(toSend, requests[i])
+		sent = append(sent, i)
+	}
+	if err := k.queue.acquire(len(ids)); err != nil {
+		return nil, err
+	}
+	defer k.queue.release(len(ids))
+	visits := k.limiter.acquire(toSend)
+	defer k.limiter.release(visits)
+	channels, err := k.registerRequests(toSend)
+	if err != nil {
+		return nil, err
+	}
+	defer k.unregister(ids...)
+
+	procs := make([]*process, 0, len(toSend))
+	timeouts := make([]func() bool, 0, len(toSend))
+	defer func() {
+		for _, stop := range timeouts {
+			stop()
+		}
+	}()
+	for _, request := range toSend {
 		// Log the request being sent
 		log.Printf("Sending request: %v", request)
 
 		// Send analysis request to KataGo
-		requestJSON, err := json.Marshal(request)
+		p, err := k.send(request)
 		if err != nil {
-			return nil, fmt.Errorf("failed to marshal request: %v", err)
+			return nil, err
 		}
-		fmt.Fprintf(k.stdin, "%s\n", requestJSON)
+		procs = append(procs, p)
+		timeouts = append(timeouts, k.terminateAfter(request))
 	}
+	if k.preemption {
+		k.preempt(toSend)
+	}
+	started := k.startFlights(requests, keys, sent)
+	defer func() {
+		k.landFlights(requests, keys, started, nil, err)
+	}()
 
-	for len(responseMap) < len(requests) {
-		// Read response from KataGo
-		responseJSON, err := k.stdout.ReadString('\n')
+	stopped := make(map[string]bool)
+	complete := make(map[string][]AnalysisResponse)
+	for i, ch := range channels {
+		turnResponses, partial, err := k.collect(toSend[i], procs[i], ch, timeouts[i])
 		if err != nil {
-			return nil, fmt.Errorf("error reading response: %v", err)
+			return nil, err
 		}
-
-		var response AnalysisResponse
-		if err := json.Unmarshal([]byte(responseJSON), &response); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal response: %v", err)
+		stopped[ids[i]] = partial
+		responseMap[ids[i]] = turnResponses
+		if !partial {
+			complete[ids[i]] = turnResponses
+		}
+	}
+	// The concurrent calls get the responses before this call waits for theirs,
+	// so that calls that wait for each other can not deadlock. The results of a search that was stopped
+	// are not complete, so the concurrent calls send their own query instead.
+	k.landFlights(requests, keys, started, complete, nil)
+	shared := make(map[int]AnalysisResponse)
+	for i, f := range joined {
+		<-f.done
+		switch {
+		case errors.Is(f.err, ErrQueryTerminated):
+			own, err := k.Analyze([]AnalysisRequest{requests[i]})
+			if err != nil {
+				return nil, err
+			}
+			shared[i] = keys[i].toCanonical(own[0])
+		case f.err != nil:
+			return nil, fmt.Errorf("request %s shares a query for the same position: %w", requests[i].ID, f.err)
+		default:
+			shared[i] = f.response
 		}
-
-		// Log the response received
-		log.Printf("Received response: %v", response)
-		responseMap[response.ID] = response
 	}
 
-	for _, request := range requests {
-		responses = append(responses, responseMap[request.ID])
+	for i, request := range requests {
+		if canonical, ok := shared[i]; ok {
+			response := keys[i].fromCanonical(canonical)
+			response.ID = request.ID
+			response.TurnNumber = request.lastTurn()
+			responses = append(responses, response)
+			continue
+		}
+		if response, ok := cached[i]; ok {
+			response.ID = request.ID
+			response.TurnNumber = request.lastTurn()
+			responses = append(responses, response)
+			continue
+		}
+		if j, ok := duplicateOf[i]; ok {
+			// The duplicate may be a rotated or reflected version of the position
+			var response AnalysisResponse
+			if canonical, ok := shared[j]; ok {
+				response = keys[i].fromCanonical(canonical)
+			} else if response, ok = cached[j]; ok {
+				response = keys[i].fromCanonical(keys[j].toCanonical(response))
+			} else {
+				response = keys[i].fromCanonical(keys[j].toCanonical(responseMap[requests[j].ID][0]))
+			}
+			response.ID = request.ID
+			response.TurnNumber = request.lastTurn()
+			responses = append(responses, response)
+			continue
+		}
+		turnResponses := responseMap[request.ID]
+		// The results of a search that timed out or was terminated are not complete, and are not cached.
+		// Only requests for one turn have a key, and a model that was swapped during the call may have answered.
+		if key, ok := keys[i]; ok && k.cache != nil && !stopped[request.ID] && k.Model() == model {
+			k.cache.Put(key.hash, key.toCanonical(turnResponses[0]))
+		}
+		responses = append(responses, turnResponses...)
 	}
 
 	return responses, nil
 }
 
-// Close shuts down the KataGo process by closing its stdin
+// Close shuts down the KataGo process by closing its stdin. KataGo finishes the running queries before exiting.
 func (k *KataGo) Close() error {
-	if err := k.stdin.Close(); err != nil {
-		return fmt.Errorf("failed to close KataGo stdin: %v", err)
-	}
-	return k.cmd.Wait() // Wait for the process to exit cleanly
+	defer k.stopProgress()
+	return k.current().close()
 }
@@ -37,8 +37,8 @@ func TestKataGoAnalyze(t *testing.T) {
 	requests := []AnalysisRequest{
 		{
 			ID:            "test1",
-			InitialStones: [][2]string{{"B", "Q16"}},
-			Moves:         [][2]string{{"W", "D4"}},
+			InitialStones: []Move{{"B", "Q16"}},
+			Moves:         []Move{{"W", "D4"}},
 			Rules:         "tromp-taylor",
 			Komi:          7.5,
 			BoardXSize:    19,
@@ -82,8 +82,8 @@ func TestKataGoMultipleRequests(t *testing.T) {
 	requests := []AnalysisRequest{
 		{
 			ID:            "test1",
-			InitialStones: [][2]string{{"B", "Q16"}},
-			Moves:         [][2]string{{"W", "D4"}},
+			InitialStones: []Move{{"B", "Q16"}},
+			Moves:         []Move{{"W", "D4"}},
 			Rules:         "tromp-taylor",
 			Komi:          7.5,
 			BoardXSize:    19,
@@ -93,8 +93,8 @@ func TestKataGoMultipleRequests(t *testing.T) {
 		},
 		{
 			ID:            "test2",
-			InitialStones: [][2]string{{"B", "Q4"}},
-			Moves:         [][2]string{{"W", "D16"}},
+			InitialStones: []Move{{"B", "Q4"}},
+			Moves:         []Move{{"W", "D16"}},
 			Rules:         "tromp-taylor",
 			Komi:          7.5,
 			BoardXSize:    19,
@@ -111,13 +111,19 @@ func TestKataGoMultipleRequests(t *testing.T) {
 		t.Fatalf("Failed to analyze requests: %v", err)
 	}
 
-	// Validate responses
-	for i, request := range requests {
-		response := responses[i]
+	// Validate responses, where each request gets one response for each of its two turns
+	if len(responses) != 2*len(requests) {
+		t.Fatalf("Expected %d responses, got %d", 2*len(requests), len(responses))
+	}
+	for i, response := range responses {
+		request := requests[i/2]
 		log.Printf("Received response for request %s: %v", request.ID, response)
 		if response.ID != request.ID {
 			t.Errorf("Expected response ID %s, got %s", request.ID, response.ID)
 		}
+		if response.TurnNumber != i%2 {
+			t.Errorf("Expected turn %d for request %s, got %d", i%2, request.ID, response.TurnNumber)
+		}
 		if len(response.MoveInfos) == 0 {
 			t.Errorf("Expected move infos in response for request %s, got none", request.ID)
 		}
@@ -0,0 +1,96 @@
+package katago
+
+import (
+	"errors"
+	"math"
+)
+
+// FairKomiVisits is the number of visits used for each evaluation by FairKomi
+var FairKomiVisits = 1000
+
+// fairKomiRange is how far from the first estimate FairKomi searches, in points
+const fairKomiRange = 4.0
+
+// roundToHalf rounds to the nearest multiple of 0.5, since KataGo only accepts integer or half-integer komi
+func roundToHalf(x float64) float64 {
+	return math.Round(x*2) / 2
+}
+
+// evaluate analyzes the position and returns the winrate and score lead from black's point of view
+func (k *KataGo) evaluate(p Position, visits int) (float64, float64, error) {
+	request := p.Request(k.newID("evaluate"))
+	request.MaxVisits = visits
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(responses) == 0 {
+		return 0, 0, errors.New("no response when evaluating the position")
+	}
+	rootInfo := responses[0].RootInfo
+	if rootInfo.CurrentPlayer == "" {
+		rootInfo.CurrentPlayer = p.ToPlay()
+	}
+	sign := blackSign(rootInfo.CurrentPlayer)
+	winrate := rootInfo.Winrate
+	if sign < 0 {
+		winrate = 1 - winrate
+	}
+	return winrate, sign * rootInfo.ScoreLead, nil
+}
+
+// FairKomi searches for the komi that makes the position even, where black has a winrate of about 50%.
+// The komi of the given position is used as the starting point. The first estimate comes from the score lead,
+// and is then refined by bisecting on the winrate, since the score lead is not exact.
+// This is useful for variant rules, unusual board sizes and handicap games.
+func (k *KataGo) FairKomi(p Position) (float64, error) {
+	winrate, lead, err := k.evaluate(p, FairKomiVisits)
+	if err != nil {
+		return 0, err
+	}
+	if winrate == 0.5 {
+		return p.Komi, nil
+	}
+	estimate := math.Max(-150, math.Min(150, roundToHalf(p.Komi+lead)))
+
+	winrateAt := func(komi float64) (float64, error) {
+		q := p
+		q.Komi = komi
+		winrate, _, err := k.evaluate(q, FairKomiVisits)
+		return winrate, err
+	}
+
+	// Black's winrate goes down as the komi goes up, so lo should favor black and hi should favor white
+	lo, hi := estimate-fairKomiRange, estimate+fairKomiRange
+	loWinrate, err := winrateAt(lo)
+	if err != nil {
+		return 0, err
+	}
+	hiWinrate, err := winrateAt(hi)
+	if err != nil {
+		return 0, err
+	}
+	if loWinrate < 0.5 || hiWinrate > 0.5 {
+		// The winrate does not cross 50% within the range, so the score lead estimate is the best guess
+		return estimate, nil
+	}
+	for hi-lo > 0.5 {
+		mid := roundToHalf((lo + hi) / 2)
+		if mid == lo || mid == hi {
+			break
+		}
+		midWinrate, err := winrateAt(mid)
+		if err != nil {
+			return 0, err
+		}
+		if midWinrate >= 0.5 {
+			lo, loWinrate = mid, midWinrate
+		} else {
+			hi, hiWinrate = mid, midWinrate
+		}
+	}
+	if loWinrate-0.5 <= 0.5-hiWinrate {
+		return lo, nil
+	}
+	return hi, nil
+}
@@ -0,0 +1,34 @@
+package katago
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRoundToHalf(t *testing.T) {
+	cases := map[float64]float64{7.3: 7.5, 6.2: 6, -0.3: -0.5, 0.1: 0}
+	for x, expected := range cases {
+		if r := roundToHalf(x); r != expected {
+			t.Errorf("Expected %.1f when rounding %.2f, got %.1f", expected, x, r)
+		}
+	}
+}
+
+func TestKataGoFairKomi(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	p := Position{
+		Rules:      "chinese",
+		Komi:       7,
+		BoardXSize: 9,
+		BoardYSize: 9,
+	}
+	komi, err := katago.FairKomi(p)
+	if err != nil {
+		t.Fatalf("Failed to find fair komi: %v", err)
+	}
+	if komi*2 != math.Round(komi*2) || math.Abs(komi) > 150 {
+		t.Errorf("Expected an integer or half-integer komi, got %f", komi)
+	}
+}
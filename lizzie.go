@@ -0,0 +1,44 @@
+package katago
+
+import (
+	"strconv"
+	"strings"
+)
+
+// formatNumber formats a number like KataGo does in its GTP output
+func formatNumber(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
+
+// KataAnalyze formats the response as a line of output from the kata-analyze GTP command, with one
+// "info move ..." entry for each move, so that front-ends like Lizzie and LizzieYzy can show the analysis.
+// The ownership is added at the end of the line if it is included in the response and ownership is true.
+// The winrates and scores are from the point of view of the player to move, as for kata-analyze.
+func (r AnalysisResponse) KataAnalyze(ownership bool) string {
+	var sb strings.Builder
+	for i, info := range r.MoveInfos {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		scoreMean := info.ScoreMean
+		if scoreMean == 0 {
+			scoreMean = info.ScoreLead
+		}
+		sb.WriteString("info move " + info.Move)
+		sb.WriteString(" visits " + strconv.Itoa(info.Visits))
+		sb.WriteString(" winrate " + formatNumber(info.Winrate))
+		sb.WriteString(" scoreMean " + formatNumber(scoreMean))
+		sb.WriteString(" scoreStdev " + formatNumber(info.ScoreStdev))
+		sb.WriteString(" scoreLead " + formatNumber(info.ScoreLead))
+		sb.WriteString(" prior " + formatNumber(info.Prior))
+		sb.WriteString(" order " + strconv.Itoa(info.Order))
+		sb.WriteString(" pv " + strings.Join(info.PV, " "))
+	}
+	if ownership && len(r.Ownership) > 0 {
+		sb.WriteString(" ownership")
+		for _, v := range r.Ownership {
+			sb.WriteString(" " + formatNumber(v))
+		}
+	}
+	return sb.String()
+}
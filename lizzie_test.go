@@ -0,0 +1,24 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestKataAnalyze(t *testing.T) {
+	r := AnalysisResponse{
+		MoveInfos: []MoveInfoExt{
+			{Move: "D4", Visits: 120, Winrate: 0.52, ScoreLead: 1.5, ScoreStdev: 20, Prior: 0.3, Order: 0, PV: []string{"D4", "Q16"}},
+			{Move: "Q4", Visits: 30, Winrate: 0.5, ScoreLead: 0.25, ScoreMean: 0.25, Prior: 0.1, Order: 1, PV: []string{"Q4"}},
+		},
+		Ownership: []float64{0.5, -0.25},
+	}
+	expected := "info move D4 visits 120 winrate 0.52 scoreMean 1.5 scoreStdev 20 scoreLead 1.5 prior 0.3 order 0 pv D4 Q16 " +
+		"info move Q4 visits 30 winrate 0.5 scoreMean 0.25 scoreStdev 0 scoreLead 0.25 prior 0.1 order 1 pv Q4"
+	if line := r.KataAnalyze(false); line != expected {
+		t.Errorf("Expected %q, got %q", expected, line)
+	}
+	if line := r.KataAnalyze(true); !strings.HasSuffix(line, "pv Q4 ownership 0.5 -0.25") {
+		t.Errorf("Expected the ownership at the end, got %q", line)
+	}
+}
@@ -0,0 +1,160 @@
+// Package match compares two engine configurations, like two models or two sets of settings, by the results
+// of games between them. The Elo difference is estimated with a confidence interval, and a sequential
+// probability ratio test (SPRT) can stop a match as soon as the result is clear.
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// Score is the result of a match, from the point of view of the first configuration
+type Score struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Games returns the number of games played
+func (s Score) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Fraction returns the score of the first configuration, from 0 to 1, where a draw counts as half a win
+func (s Score) Fraction() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + 0.5*float64(s.Draws)) / float64(s.Games())
+}
+
+// variance returns the variance of the result of one game, where a win is 1, a draw 0.5 and a loss 0
+func (s Score) variance() float64 {
+	n := float64(s.Games())
+	if n == 0 {
+		return 0
+	}
+	p := s.Fraction()
+	return (float64(s.Wins)*(1-p)*(1-p) + float64(s.Draws)*(0.5-p)*(0.5-p) + float64(s.Losses)*p*p) / n
+}
+
+// EloDiff converts a score from 0 to 1 to an Elo difference. A score of 0 or 1 gives an infinite difference.
+func EloDiff(fraction float64) float64 {
+	switch {
+	case fraction <= 0:
+		return math.Inf(-1)
+	case fraction >= 1:
+		return math.Inf(1)
+	}
+	return 400 * math.Log10(fraction/(1-fraction))
+}
+
+// ExpectedScore converts an Elo difference to the expected score, from 0 to 1
+func ExpectedScore(elo float64) float64 {
+	return 1 / (1 + math.Pow(10, -elo/400))
+}
+
+// Elo is an estimated Elo difference, with a confidence interval
+type Elo struct {
+	Diff  float64
+	Lower float64
+	Upper float64
+}
+
+// String formats the Elo difference and the interval, like "+35.2 [+12.1, +58.9]"
+func (e Elo) String() string {
+	return fmt.Sprintf("%+.1f [%+.1f, %+.1f]", e.Diff, e.Lower, e.Upper)
+}
+
+// Elo estimates how many Elo points stronger the first configuration is, with an interval that contains the
+// true difference with the given confidence, like 0.95. The interval comes from the standard error of the
+// score, which needs a few dozen games to be meaningful.
+func (s Score) Elo(confidence float64) Elo {
+	p := s.Fraction()
+	e := Elo{Diff: EloDiff(p), Lower: math.Inf(-1), Upper: math.Inf(1)}
+	if n := s.Games(); n > 0 {
+		z := math.Sqrt2 * math.Erfinv(confidence)
+		margin := z * math.Sqrt(s.variance()/float64(n))
+		e.Lower, e.Upper = EloDiff(p-margin), EloDiff(p+margin)
+	}
+	return e
+}
+
+// LOS returns the likelihood of superiority, the probability that the first configuration is stronger,
+// from the wins and the losses. Draws do not count.
+func (s Score) LOS() float64 {
+	decisive := s.Wins + s.Losses
+	if decisive == 0 {
+		return 0.5
+	}
+	return 0.5 * (1 + math.Erf(float64(s.Wins-s.Losses)/math.Sqrt(2*float64(decisive))))
+}
+
+// Decision is the outcome of a sequential probability ratio test
+type Decision int
+
+// SPRT decisions
+const (
+	// Continue means that more games are needed
+	Continue Decision = iota
+	// AcceptH0 means that the first configuration is not stronger by Elo1, but at most by Elo0
+	AcceptH0
+	// AcceptH1 means that the first configuration is stronger by at least Elo1
+	AcceptH1
+)
+
+// String returns the name of the decision
+func (d Decision) String() string {
+	switch d {
+	case Continue:
+		return "Continue"
+	case AcceptH0:
+		return "H0"
+	case AcceptH1:
+		return "H1"
+	}
+	return fmt.Sprintf("Decision(%d)", int(d))
+}
+
+// SPRT is a sequential probability ratio test between the hypothesis H0, that the first configuration is
+// Elo0 points stronger, and H1, that it is Elo1 points stronger. Alpha is the probability of accepting H1 when
+// H0 is true, and Beta of accepting H0 when H1 is true. The test can be checked after every game, and
+// stops the match with far fewer games than a fixed number of games would need.
+type SPRT struct {
+	Elo0  float64
+	Elo1  float64
+	Alpha float64
+	Beta  float64
+}
+
+// DefaultSPRT tests if a change gains at least 5 Elo, with 5% error rates
+var DefaultSPRT = SPRT{Elo0: 0, Elo1: 5, Alpha: 0.05, Beta: 0.05}
+
+// Bounds returns the log likelihood ratios where H0 and H1 are accepted
+func (t SPRT) Bounds() (float64, float64) {
+	return math.Log(t.Beta / (1 - t.Alpha)), math.Log((1 - t.Beta) / t.Alpha)
+}
+
+// LLR returns the log likelihood ratio of H1 over H0 for the score, with the normal approximation of the
+// results of the games, or 0 before there is any variance in the results
+func (t SPRT) LLR(s Score) float64 {
+	variance := s.variance()
+	if variance <= 0 {
+		return 0
+	}
+	s0, s1 := ExpectedScore(t.Elo0), ExpectedScore(t.Elo1)
+	n := float64(s.Games())
+	return n * (s1 - s0) * (2*s.Fraction() - s0 - s1) / (2 * variance)
+}
+
+// Decide returns whether the test has accepted one of the hypotheses for the score
+func (t SPRT) Decide(s Score) Decision {
+	lower, upper := t.Bounds()
+	switch llr := t.LLR(s); {
+	case llr >= upper:
+		return AcceptH1
+	case llr <= lower:
+		return AcceptH0
+	}
+	return Continue
+}
@@ -0,0 +1,74 @@
+package match
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEloDiff(t *testing.T) {
+	if d := EloDiff(0.5); d != 0 {
+		t.Errorf("Expected 0 Elo for an even score, got %f", d)
+	}
+	if d := EloDiff(0.75); math.Abs(d-190.85) > 0.01 {
+		t.Errorf("Expected about 190.85 Elo for a score of 0.75, got %f", d)
+	}
+	if !math.IsInf(EloDiff(1), 1) || !math.IsInf(EloDiff(0), -1) {
+		t.Error("Expected an infinite difference for a perfect score")
+	}
+	if p := ExpectedScore(EloDiff(0.3)); math.Abs(p-0.3) > 1e-9 {
+		t.Errorf("Expected ExpectedScore to be the inverse of EloDiff, got %f", p)
+	}
+}
+
+func TestScoreElo(t *testing.T) {
+	s := Score{Wins: 60, Draws: 0, Losses: 40}
+	if s.Games() != 100 || s.Fraction() != 0.6 {
+		t.Errorf("Expected 100 games and a score of 0.6, got %d and %f", s.Games(), s.Fraction())
+	}
+	e := s.Elo(0.95)
+	if math.Abs(e.Diff-70.4) > 0.1 {
+		t.Errorf("Expected about +70.4 Elo, got %f", e.Diff)
+	}
+	// The standard error of the score is 0.049, so the interval is 0.6 ± 0.096
+	if math.Abs(e.Lower-EloDiff(0.6-1.96*0.049)) > 2 || math.Abs(e.Upper-EloDiff(0.6+1.96*0.049)) > 2 {
+		t.Errorf("Unexpected interval: %v", e)
+	}
+	if wider := s.Elo(0.99); wider.Lower >= e.Lower || wider.Upper <= e.Upper {
+		t.Errorf("Expected a wider interval for 99%% confidence, got %v and %v", wider, e)
+	}
+	if str := (Elo{Diff: 35.25, Lower: 12.1, Upper: 58.9}).String(); str != "+35.2 [+12.1, +58.9]" {
+		t.Errorf("Unexpected string: %s", str)
+	}
+	if e := (Score{}).Elo(0.95); e.Diff != 0 || !math.IsInf(e.Lower, -1) || !math.IsInf(e.Upper, 1) {
+		t.Errorf("Expected an unbounded interval without games, got %v", e)
+	}
+}
+
+func TestLOS(t *testing.T) {
+	if los := (Score{Wins: 10, Losses: 10, Draws: 5}).LOS(); los != 0.5 {
+		t.Errorf("Expected a LOS of 0.5 for an even match, got %f", los)
+	}
+	if los := (Score{Wins: 60, Losses: 40}).LOS(); los < 0.97 || los > 0.98 {
+		t.Errorf("Expected a LOS of about 0.977, got %f", los)
+	}
+}
+
+func TestSPRT(t *testing.T) {
+	lower, upper := DefaultSPRT.Bounds()
+	if math.Abs(lower+2.944) > 0.001 || math.Abs(upper-2.944) > 0.001 {
+		t.Errorf("Expected bounds of ±2.944, got %f and %f", lower, upper)
+	}
+	test := SPRT{Elo0: 0, Elo1: 50, Alpha: 0.05, Beta: 0.05}
+	if d := test.Decide(Score{Wins: 3, Losses: 2}); d != Continue {
+		t.Errorf("Expected to continue after 5 games, got %v", d)
+	}
+	if d := test.Decide(Score{Wins: 300, Draws: 20, Losses: 180}); d != AcceptH1 {
+		t.Errorf("Expected H1 for a clearly stronger configuration, got %v", d)
+	}
+	if d := test.Decide(Score{Wins: 240, Draws: 20, Losses: 240}); d != AcceptH0 {
+		t.Errorf("Expected H0 for an even match, got %v", d)
+	}
+	if llr := test.LLR(Score{Wins: 5}); llr != 0 {
+		t.Errorf("Expected no LLR without variance, got %f", llr)
+	}
+}
@@ -0,0 +1,40 @@
+package match
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// CSVHeader is the first row that WriteCSV writes
+var CSVHeader = []string{"game", "opening", "black", "white", "winner", "result", "reason", "moves"}
+
+// WriteJSON writes the results as JSON lines, with one result on each line
+func WriteJSON(w io.Writer, results []Result) error {
+	enc := json.NewEncoder(w)
+	for _, r := range results {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes the results as CSV, with one row for each game. Columns are separated by comma, which can
+// be '\t' for TSV.
+func WriteCSV(w io.Writer, results []Result, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for _, r := range results {
+		row := []string{strconv.Itoa(r.Game), strconv.Itoa(r.Opening), r.Black, r.White, r.Winner, r.Result, r.Reason, strconv.Itoa(r.Moves)}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
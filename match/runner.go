@@ -0,0 +1,214 @@
+package match
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago"
+)
+
+// Player is one of the two configurations in a match
+type Player struct {
+	Name   string
+	Engine *katago.KataGo
+	// Visits is the number of visits for each move, or 0 for maxVisits in the config file
+	Visits int
+	// Overrides replaces settings from the config file for the moves of this player, like "playoutDoublingAdvantage"
+	Overrides map[string]any
+}
+
+// Reasons that a game ended
+const (
+	// ReasonPasses means that both players passed, and the game was scored
+	ReasonPasses = "passes"
+	// ReasonResign means that the game was adjudicated, since one player was too far behind
+	ReasonResign = "resign"
+	// ReasonMoveLimit means that the game reached MaxMoves, and was scored
+	ReasonMoveLimit = "move limit"
+)
+
+// Result is the result of one game of a match
+type Result struct {
+	// Game is the number of the game, from 1
+	Game int `json:"game"`
+	// Opening is the index of the opening that the game started from
+	Opening int    `json:"opening"`
+	Black   string `json:"black"`
+	White   string `json:"white"`
+	// Winner is the name of the player that won, or empty for a draw
+	Winner string `json:"winner"`
+	// Result is the result in SGF notation, like "B+3.5", "W+R" or "0" for a draw
+	Result string `json:"result"`
+	Reason string `json:"reason"`
+	// Moves is the number of moves that were played after the opening
+	Moves int `json:"moves"`
+	// Position is the final position of the game
+	Position katago.Position `json:"-"`
+}
+
+// Tally adds up the results of the games, from the point of view of the player with the given name
+func Tally(results []Result, name string) Score {
+	var s Score
+	for _, r := range results {
+		switch r.Winner {
+		case "":
+			s.Draws++
+		case name:
+			s.Wins++
+		default:
+			s.Losses++
+		}
+	}
+	return s
+}
+
+// Match plays games between two configurations. Each opening is played twice in a row, with the colors
+// swapped, so that neither configuration gets the better side of an opening more often, and the openings
+// are used in turn. The games are scored by the engine of A when both players pass or at MaxMoves.
+type Match struct {
+	A Player
+	B Player
+	// Openings are the positions that the games start from. Without openings, the games start from an empty 19x19 board.
+	Openings []katago.Position
+	// Games is the largest number of games to play
+	Games int
+	// MaxMoves is the largest number of moves in a game after the opening, or 0 for twice the number of points
+	MaxMoves int
+	// AdjudicateScore ends a game when the player to move has been behind by at least this many points for
+	// AdjudicateMoves of its moves in a row, as if it resigned, which saves the time of playing out lost games.
+	// 0 turns adjudication off.
+	AdjudicateScore float64
+	AdjudicateMoves int
+	// SPRT stops the match as soon as it accepts one of its hypotheses, for A against B
+	SPRT *SPRT
+	// OnResult is called after each game, with the score of A so far, for logging
+	OnResult func(result Result, score Score)
+}
+
+// Run plays the games of the match, and returns their results. The match stops early if the SPRT accepts
+// one of its hypotheses, and the games played so far are returned if the context is done.
+func (m *Match) Run(ctx context.Context) ([]Result, error) {
+	if m.A.Engine == nil || m.B.Engine == nil {
+		return nil, errors.New("both players need an engine")
+	}
+	a, b := m.A, m.B
+	if a.Name == "" {
+		a.Name = "A"
+	}
+	if b.Name == "" {
+		b.Name = "B"
+	}
+	if a.Name == b.Name {
+		return nil, fmt.Errorf("both players are named %q", a.Name)
+	}
+	openings := m.Openings
+	if len(openings) == 0 {
+		openings = []katago.Position{katago.NewPosition(19, 19)}
+	}
+	var results []Result
+	for game := 0; game < m.Games; game++ {
+		opening := (game / 2) % len(openings)
+		black, white := a, b
+		if game%2 == 1 {
+			black, white = b, a
+		}
+		result, err := m.play(ctx, game+1, openings[opening], black, white)
+		if err != nil {
+			if ctx.Err() != nil {
+				return results, ctx.Err()
+			}
+			return results, fmt.Errorf("game %d: %w", game+1, err)
+		}
+		result.Opening = opening
+		results = append(results, result)
+		score := Tally(results, a.Name)
+		if m.OnResult != nil {
+			m.OnResult(result, score)
+		}
+		if m.SPRT != nil && m.SPRT.Decide(score) != Continue {
+			break
+		}
+	}
+	return results, nil
+}
+
+// play plays one game from the opening, and scores it
+func (m *Match) play(ctx context.Context, game int, opening katago.Position, black, white Player) (Result, error) {
+	p := opening
+	p.Moves = append([]katago.Move{}, opening.Moves...)
+	result := Result{Game: game, Black: black.Name, White: white.Name, Reason: ReasonMoveLimit}
+	maxMoves := m.MaxMoves
+	if maxMoves <= 0 {
+		maxMoves = 2 * p.BoardXSize * p.BoardYSize
+	}
+	passes := 0
+	behind := make(map[katago.Color]int)
+	for result.Moves < maxMoves {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		color := katago.Color(p.ToPlay())
+		player := black
+		if color == katago.White {
+			player = white
+		}
+		request := p.Request(fmt.Sprintf("match-%d-%d", game, len(p.Moves)))
+		request.MaxVisits = player.Visits
+		for key, value := range player.Overrides {
+			request.SetOverride(key, value)
+		}
+		responses, err := player.Engine.Analyze([]katago.AnalysisRequest{request})
+		if err != nil {
+			return Result{}, err
+		}
+		if len(responses) == 0 {
+			return Result{}, errors.New("no response for the move")
+		}
+		response := responses[0]
+		if response.RootInfo.CurrentPlayer == "" {
+			response.RootInfo.CurrentPlayer = string(color)
+		}
+		if m.AdjudicateScore > 0 {
+			if response.ScoreFor(color) <= -m.AdjudicateScore {
+				behind[color]++
+			} else {
+				behind[color] = 0
+			}
+			if behind[color] >= max(m.AdjudicateMoves, 1) {
+				winner := color.Opponent()
+				result.Winner = black.Name
+				if winner == katago.White {
+					result.Winner = white.Name
+				}
+				result.Result, result.Reason, result.Position = string(winner)+"+R", ReasonResign, p
+				return result, nil
+			}
+		}
+		vertex := katago.Pass
+		if move, ok := response.SelectMove(katago.DefaultMoveSelection); ok {
+			vertex = katago.Vertex(move.Move)
+		}
+		p.Moves = append(p.Moves, katago.Move{Color: color, Vertex: vertex})
+		result.Moves++
+		if !strings.EqualFold(string(vertex), string(katago.Pass)) {
+			passes = 0
+		} else if passes++; passes >= 2 {
+			result.Reason = ReasonPasses
+			break
+		}
+	}
+	score, err := m.A.Engine.Score(p)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to score the game: %w", err)
+	}
+	result.Result, result.Position = score.Result, p
+	switch {
+	case strings.HasPrefix(score.Result, "B+"):
+		result.Winner = black.Name
+	case strings.HasPrefix(score.Result, "W+"):
+		result.Winner = white.Name
+	}
+	return result, nil
+}
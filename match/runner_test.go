@@ -0,0 +1,172 @@
+package match
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestEngine(t *testing.T) *katago.KataGo {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return k
+}
+
+func newTestOpenings() []katago.Position {
+	a, b := katago.NewPosition(9, 9), katago.NewPosition(9, 9)
+	b.Moves = []katago.Move{{Color: katago.Black, Vertex: "E5"}}
+	return []katago.Position{a, b}
+}
+
+func TestTally(t *testing.T) {
+	results := []Result{{Winner: "new"}, {Winner: "old"}, {Winner: "new"}, {}}
+	if s := Tally(results, "new"); s != (Score{Wins: 2, Draws: 1, Losses: 1}) {
+		t.Errorf("Expected 2 wins, 1 draw and 1 loss, got %+v", s)
+	}
+}
+
+func TestRun(t *testing.T) {
+	k := newTestEngine(t)
+	var reported []Score
+	m := Match{
+		A:        Player{Name: "new", Engine: k, Visits: 10},
+		B:        Player{Name: "old", Engine: k, Visits: 10},
+		Openings: newTestOpenings(),
+		Games:    4,
+		MaxMoves: 4,
+		OnResult: func(result Result, score Score) { reported = append(reported, score) },
+	}
+	results, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 || len(reported) != 4 {
+		t.Fatalf("Expected 4 games, got %d results and %d reports", len(results), len(reported))
+	}
+	for i, r := range results {
+		black, opening := "new", i/2
+		if i%2 == 1 {
+			black = "old"
+		}
+		if r.Game != i+1 || r.Black != black || r.Opening != opening {
+			t.Errorf("Expected game %d with %s as black from opening %d, got %+v", i+1, black, opening, r)
+		}
+		if r.Reason != ReasonMoveLimit || r.Moves != 4 || len(r.Position.Moves) != 4+opening {
+			t.Errorf("Expected game %d to reach the move limit, got %+v", i+1, r)
+		}
+		if (r.Winner == "") != (r.Result == "0") || r.Result == "" {
+			t.Errorf("Expected the winner to match the result, got %+v", r)
+		}
+	}
+	if last := reported[3]; last != Tally(results, "new") || last.Games() != 4 {
+		t.Errorf("Expected the score of all the games, got %+v", last)
+	}
+}
+
+func TestRunAdjudicate(t *testing.T) {
+	k := newTestEngine(t)
+	m := Match{
+		A:               Player{Name: "new", Engine: k, Visits: 10},
+		B:               Player{Engine: k, Visits: 10},
+		Openings:        newTestOpenings()[:1],
+		Games:           2,
+		MaxMoves:        50,
+		AdjudicateScore: 0.01,
+		AdjudicateMoves: 1,
+	}
+	results, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Reason != ReasonResign || !strings.HasSuffix(r.Result, "+R") || r.Winner == "" {
+			t.Errorf("Expected the game to be adjudicated, got %+v", r)
+		}
+		if r.Black != "B" && r.White != "B" {
+			t.Errorf("Expected the unnamed player to be named B, got %+v", r)
+		}
+	}
+}
+
+func TestRunSPRT(t *testing.T) {
+	k := newTestEngine(t)
+	m := Match{
+		A:        Player{Name: "new", Engine: k, Visits: 10},
+		B:        Player{Name: "old", Engine: k, Visits: 10},
+		Openings: newTestOpenings(),
+		Games:    100,
+		MaxMoves: 2,
+		// The bounds are so close that any difference in the first games decides the test
+		SPRT: &SPRT{Elo0: 0, Elo1: 400, Alpha: 0.45, Beta: 0.45},
+	}
+	results, err := m.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) == 100 {
+		t.Errorf("Expected the SPRT to stop the match early, got %+v", Tally(results, "new"))
+	}
+	if d := m.SPRT.Decide(Tally(results, "new")); d == Continue {
+		t.Errorf("Expected the SPRT to decide, got %v", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := m.Run(ctx); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	m.B.Name = "new"
+	if _, err := m.Run(context.Background()); err == nil {
+		t.Error("Expected an error for two players with the same name")
+	}
+}
+
+func TestWriteLogs(t *testing.T) {
+	results := []Result{
+		{Game: 1, Black: "new", White: "old", Winner: "new", Result: "B+3.5", Reason: ReasonPasses, Moves: 120},
+		{Game: 2, Black: "old", White: "new", Result: "0", Reason: ReasonMoveLimit, Moves: 400},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, results); err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Split(strings.TrimSpace(buf.String()), "\n"); len(lines) != 2 || !strings.Contains(lines[0], `"result":"B+3.5"`) {
+		t.Errorf("Expected one JSON line for each game, got %q", buf.String())
+	}
+	buf.Reset()
+	if err := WriteCSV(&buf, results, ','); err != nil {
+		t.Fatal(err)
+	}
+	expected := "game,opening,black,white,winner,result,reason,moves\n1,0,new,old,new,B+3.5,passes,120\n2,0,old,new,,0,move limit,400\n"
+	if buf.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, buf.String())
+	}
+}
+
+func TestRunError(t *testing.T) {
+	k := newTestEngine(t)
+	opening := katago.NewPosition(9, 9)
+	opening.Komi = 7.25
+	m := Match{
+		A:        Player{Name: "new", Engine: k, Visits: 10},
+		B:        Player{Name: "old", Engine: k, Visits: 10},
+		Openings: []katago.Position{opening},
+		Games:    1,
+		MaxMoves: 4,
+	}
+	if _, err := m.Run(context.Background()); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
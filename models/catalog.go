@@ -0,0 +1,115 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Errors from Verify
+var (
+	ErrCorrupt   = errors.New("corrupted model file")
+	ErrTruncated = errors.New("truncated model file")
+	ErrChecksum  = errors.New("model file checksum mismatch")
+	// ErrLFSPointer means that the file is a Git LFS pointer, because the repository was cloned without LFS
+	ErrLFSPointer = errors.New("model file is a Git LFS pointer, not the network itself")
+)
+
+// Entry is a network with a known size and SHA256 checksum
+type Entry struct {
+	Name   string
+	Size   int64
+	SHA256 string
+}
+
+// Catalog holds the networks with known sizes and checksums. Entries can be added for other networks.
+var Catalog = []Entry{
+	{Name: "kata1-b28c512nbt-s7332806912-d4357057652", Size: 271357345, SHA256: "ff6861c6ddcebe3739befb5a633cbdb195f7adcfb70fce8733c062f95e619d1d"},
+}
+
+// Lookup finds a network in the catalog, by name or by file name
+func Lookup(name string) (Entry, bool) {
+	name = ParseName(filepath.Base(name), "").Name
+	for _, entry := range Catalog {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+// lfsPrefix is how Git LFS pointer files start
+const lfsPrefix = "version https://git-lfs.github.com/spec/"
+
+// Verify checks that a model file is complete, before it is given to KataGo, which otherwise fails with an
+// unclear error. Networks in the catalog are checked by their size and checksum, while other compressed
+// networks are checked by decompressing them.
+func Verify(filename string) error {
+	entry, ok := Lookup(filename)
+	if !ok {
+		entry = Entry{Name: filepath.Base(filename)}
+	}
+	return VerifyEntry(filename, entry)
+}
+
+// VerifyEntry checks a model file against a catalog entry. The size and checksum are only checked if they are set.
+func VerifyEntry(filename string, entry Entry) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	// Downloads are verified before the ".part" suffix is removed
+	compressed := strings.HasSuffix(strings.TrimSuffix(filename, ".part"), ".gz")
+	r := bufio.NewReader(f)
+	head, _ := r.Peek(len(lfsPrefix))
+	if bytes.HasPrefix(head, []byte(lfsPrefix)) {
+		return fmt.Errorf("%w: %s", ErrLFSPointer, filename)
+	}
+	if entry.Size > 0 {
+		switch {
+		case info.Size() < entry.Size:
+			return fmt.Errorf("%w: %s has %d of %d bytes", ErrTruncated, filename, info.Size(), entry.Size)
+		case info.Size() > entry.Size:
+			return fmt.Errorf("%w: %s has %d bytes, expected %d", ErrCorrupt, filename, info.Size(), entry.Size)
+		}
+	}
+	if compressed && (len(head) < 2 || head[0] != 0x1f || head[1] != 0x8b) {
+		return fmt.Errorf("%w: %s is not gzip compressed", ErrCorrupt, filename)
+	}
+	if entry.SHA256 != "" {
+		h := sha256.New()
+		if _, err := io.Copy(h, r); err != nil {
+			return err
+		}
+		if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, entry.SHA256) {
+			return fmt.Errorf("%w: %s has SHA256 %s, expected %s", ErrChecksum, filename, sum, entry.SHA256)
+		}
+		return nil
+	}
+	if compressed {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrCorrupt, filename, err)
+		}
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("%w: %s", ErrTruncated, filename)
+			}
+			return fmt.Errorf("%w: %s: %v", ErrCorrupt, filename, err)
+		}
+	}
+	return nil
+}
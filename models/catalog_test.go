@@ -0,0 +1,73 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestVerifyLFSPointer(t *testing.T) {
+	// The model file in the repository is a Git LFS pointer
+	if err := Verify("../model.bin.gz"); !errors.Is(err, ErrLFSPointer) {
+		t.Errorf("Expected ErrLFSPointer, got %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "kata1-test.bin.gz")
+	if err := os.WriteFile(filename, modelData, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(filename); err != nil {
+		t.Errorf("Expected a valid file, got %v", err)
+	}
+	sum := sha256.Sum256(modelData)
+	entry := Entry{Name: "kata1-test", Size: int64(len(modelData)), SHA256: hex.EncodeToString(sum[:])}
+	if err := VerifyEntry(filename, entry); err != nil {
+		t.Errorf("Expected the checksum to match, got %v", err)
+	}
+
+	truncated := filepath.Join(dir, "kata1-truncated.bin.gz")
+	if err := os.WriteFile(truncated, modelData[:len(modelData)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(truncated); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected ErrTruncated, got %v", err)
+	}
+	if err := VerifyEntry(truncated, entry); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected ErrTruncated from the size, got %v", err)
+	}
+
+	corrupted := append([]byte(nil), modelData...)
+	corrupted[len(corrupted)/2] ^= 0xff
+	if err := os.WriteFile(filename, corrupted, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyEntry(filename, entry); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Expected ErrChecksum, got %v", err)
+	}
+	if err := Verify(filename); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt, got %v", err)
+	}
+
+	if err := os.WriteFile(filename, []byte("not gzip"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(filename); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Expected ErrCorrupt for uncompressed data, got %v", err)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	entry, ok := Lookup("/models/kata1-b28c512nbt-s7332806912-d4357057652.bin.gz")
+	if !ok || entry.Size != 271357345 {
+		t.Errorf("Expected the network to be in the catalog, got %+v", entry)
+	}
+	if _, ok := Lookup("kata1-unknown"); ok {
+		t.Errorf("Expected an unknown network")
+	}
+}
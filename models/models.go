@@ -0,0 +1,271 @@
+// Package models lists and downloads the official KataGo networks from katagotraining.org into a cache
+// directory, and returns the path of the model file for katago.NewKataGo
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Default locations of the networks
+const (
+	DefaultListURL = "https://katagotraining.org/networks/"
+	DefaultBaseURL = "https://media.katagotraining.org/uploaded/networks/models/kata1/"
+)
+
+// ErrNotFound is returned when a network is not in the list of networks
+var ErrNotFound = errors.New("network not found")
+
+// Network is a KataGo network file
+type Network struct {
+	// Name is the name of the network, like "kata1-b28c512nbt-s7332806912-d4357057652"
+	Name string
+	URL  string
+	// Blocks and Channels describe the size of the network, and are 0 if the name does not include them
+	Blocks   int
+	Channels int
+	// Steps is the number of training steps, and is 0 if the name does not include it
+	Steps int64
+}
+
+// Filename returns the name of the model file, like "kata1-b28c512nbt-s7332806912-d4357057652.bin.gz"
+func (n Network) Filename() string {
+	return n.Name + ".bin.gz"
+}
+
+var namePattern = regexp.MustCompile(`-b(\d+)c(\d+)[a-z]*-s(\d+)`)
+
+// ParseName returns a network with the size and training steps from a name like
+// "kata1-b28c512nbt-s7332806912-d4357057652", and the download URL below baseURL
+func ParseName(name, baseURL string) Network {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".bin")
+	n := Network{Name: name, URL: strings.TrimSuffix(baseURL, "/") + "/" + name + ".bin.gz"}
+	if m := namePattern.FindStringSubmatch(name); m != nil {
+		n.Blocks, _ = strconv.Atoi(m[1])
+		n.Channels, _ = strconv.Atoi(m[2])
+		n.Steps, _ = strconv.ParseInt(m[3], 10, 64)
+	}
+	return n
+}
+
+// Downloader downloads networks into a cache directory
+type Downloader struct {
+	Client *http.Client
+	// ListURL is the page that links to the networks, and BaseURL is where networks are downloaded from by name
+	ListURL string
+	BaseURL string
+	// CacheDir is the directory where the networks are stored
+	CacheDir string
+	// Progress is called while downloading, with the number of bytes so far and the total,
+	// which is -1 if the size is not known
+	Progress func(name string, downloaded, total int64)
+}
+
+// DefaultCacheDir returns the directory where networks are stored by default, like ~/.cache/katago/models
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "katago", "models"), nil
+}
+
+// NewDownloader creates a downloader that stores networks in the given directory,
+// or in DefaultCacheDir if the directory is empty
+func NewDownloader(cacheDir string) (*Downloader, error) {
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = DefaultCacheDir(); err != nil {
+			return nil, err
+		}
+	}
+	return &Downloader{
+		Client:   http.DefaultClient,
+		ListURL:  DefaultListURL,
+		BaseURL:  DefaultBaseURL,
+		CacheDir: cacheDir,
+	}, nil
+}
+
+var linkPattern = regexp.MustCompile(`href="([^"]+\.bin\.gz)"`)
+
+// List returns the networks that are linked from the list page, with the most trained networks first
+func (d *Downloader) List(ctx context.Context) ([]Network, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.ListURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list networks: %s", resp.Status)
+	}
+	page, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	base, err := url.Parse(d.ListURL)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var networks []Network
+	for _, m := range linkPattern.FindAllStringSubmatch(string(page), -1) {
+		link, err := base.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		n := ParseName(path.Base(link.Path), d.BaseURL)
+		n.URL = link.String()
+		if seen[n.Name] {
+			continue
+		}
+		seen[n.Name] = true
+		networks = append(networks, n)
+	}
+	sort.SliceStable(networks, func(i, j int) bool { return networks[i].Steps > networks[j].Steps })
+	return networks, nil
+}
+
+// Find returns the network with the given name from the list
+func (d *Downloader) Find(ctx context.Context, name string) (Network, error) {
+	networks, err := d.List(ctx)
+	if err != nil {
+		return Network{}, err
+	}
+	name = ParseName(name, d.BaseURL).Name
+	for _, n := range networks {
+		if n.Name == name {
+			return n, nil
+		}
+	}
+	return Network{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+}
+
+// Path returns the path where the network is stored in the cache directory
+func (d *Downloader) Path(n Network) string {
+	return filepath.Join(d.CacheDir, n.Filename())
+}
+
+// Get returns the path of the named network, and downloads it from BaseURL if it is not in the cache
+func (d *Downloader) Get(ctx context.Context, name string) (string, error) {
+	return d.Download(ctx, ParseName(name, d.BaseURL))
+}
+
+// Download downloads the network into the cache directory, unless it is already there, and returns the path.
+// An interrupted download is resumed where it stopped. The downloaded file is checked with VerifyEntry.
+func (d *Downloader) Download(ctx context.Context, n Network) (string, error) {
+	filename := d.Path(n)
+	if _, err := os.Stat(filename); err == nil {
+		return filename, nil
+	}
+	if err := os.MkdirAll(d.CacheDir, 0o755); err != nil {
+		return "", err
+	}
+	partial := filename + ".part"
+	if err := d.fetch(ctx, n, partial); err != nil {
+		return "", err
+	}
+	entry, ok := Lookup(n.Name)
+	if !ok {
+		entry = Entry{Name: n.Name}
+	}
+	if err := VerifyEntry(partial, entry); err != nil {
+		// The partial file can not be resumed, so the next attempt starts over
+		os.Remove(partial)
+		return "", err
+	}
+	if err := os.Rename(partial, filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// fetch downloads the network to the partial file, and continues from the end of the file if it exists
+func (d *Downloader) fetch(ctx context.Context, n Network, partial string) error {
+	var offset int64
+	if info, err := os.Stat(partial); err == nil {
+		offset = info.Size()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.URL, nil)
+	if err != nil {
+		return err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+	case http.StatusOK:
+		// The server does not support resuming, so the download starts over
+		offset = 0
+		flags |= os.O_TRUNC
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is already complete
+		return nil
+	case http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrNotFound, n.Name)
+	default:
+		return fmt.Errorf("failed to download %s: %s", n.Name, resp.Status)
+	}
+	total := int64(-1)
+	if resp.ContentLength >= 0 {
+		total = offset + resp.ContentLength
+	}
+
+	f, err := os.OpenFile(partial, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	downloaded := offset
+	buf := make([]byte, 256*1024)
+	for {
+		count, readErr := resp.Body.Read(buf)
+		if count > 0 {
+			if _, err := f.Write(buf[:count]); err != nil {
+				f.Close()
+				return err
+			}
+			downloaded += int64(count)
+			if d.Progress != nil {
+				d.Progress(n.Name, downloaded, total)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			f.Close()
+			return fmt.Errorf("download of %s was interrupted after %d bytes: %v", n.Name, downloaded, readErr)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if total >= 0 && downloaded != total {
+		return fmt.Errorf("download of %s is incomplete: got %d of %d bytes", n.Name, downloaded, total)
+	}
+	return nil
+}
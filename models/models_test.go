@@ -0,0 +1,148 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	smallName = "kata1-b18c384nbt-s9131461376-d4087399203"
+	largeName = "kata1-b28c512nbt-s7332806912-d4357057652"
+)
+
+var modelData = gzipData(1 << 20)
+
+// gzipData returns compressed random data, which does not compress much
+func gzipData(size int) []byte {
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write(data)
+	gz.Close()
+	return buf.Bytes()
+}
+
+// newTestServer serves a list page and the network files, and records the Range headers
+func newTestServer(t *testing.T) (*httptest.Server, *[]string) {
+	t.Helper()
+	var mut sync.Mutex
+	var ranges []string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/networks/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><a href="/models/` + largeName + `.bin.gz">large</a>
+<a href="/models/` + smallName + `.bin.gz">small</a> <a href="/models/` + smallName + `.bin.gz">again</a></html>`))
+	})
+	mux.HandleFunc("/models/", func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mut.Unlock()
+		if !strings.HasSuffix(r.URL.Path, smallName+".bin.gz") {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, "model.bin.gz", time.Time{}, bytes.NewReader(modelData))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts, &ranges
+}
+
+func newTestDownloader(t *testing.T, ts *httptest.Server) *Downloader {
+	t.Helper()
+	d, err := NewDownloader(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.ListURL = ts.URL + "/networks/"
+	d.BaseURL = ts.URL + "/models/"
+	return d
+}
+
+func TestParseName(t *testing.T) {
+	n := ParseName(largeName+".bin.gz", "https://example.com/models/")
+	if n.Name != largeName || n.Blocks != 28 || n.Channels != 512 || n.Steps != 7332806912 {
+		t.Errorf("Unexpected network: %+v", n)
+	}
+	if n.URL != "https://example.com/models/"+largeName+".bin.gz" {
+		t.Errorf("Unexpected URL: %s", n.URL)
+	}
+}
+
+func TestList(t *testing.T) {
+	ts, _ := newTestServer(t)
+	d := newTestDownloader(t, ts)
+	networks, err := d.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(networks) != 2 || networks[0].Name != smallName || networks[1].Name != largeName {
+		t.Errorf("Expected the two networks, most trained first, got %v", networks)
+	}
+	if _, err := d.Find(context.Background(), "kata1-missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestDownloadAndResume(t *testing.T) {
+	ts, ranges := newTestServer(t)
+	d := newTestDownloader(t, ts)
+	n, err := d.Find(context.Background(), smallName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Pretend that an earlier download was interrupted
+	if err := os.WriteFile(d.Path(n)+".part", modelData[:1000], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var lastDownloaded, lastTotal int64
+	d.Progress = func(name string, downloaded, total int64) {
+		lastDownloaded, lastTotal = downloaded, total
+	}
+	filename, err := d.Download(context.Background(), n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Dir(filename) != d.CacheDir {
+		t.Errorf("Expected the file to be in the cache directory, got %s", filename)
+	}
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, modelData) {
+		t.Errorf("Expected %d bytes, got %d", len(modelData), len(data))
+	}
+	if len(*ranges) != 1 || (*ranges)[0] != "bytes=1000-" {
+		t.Errorf("Expected the download to resume at 1000 bytes, got %v", *ranges)
+	}
+	if lastDownloaded != int64(len(modelData)) || lastTotal != int64(len(modelData)) {
+		t.Errorf("Unexpected progress: %d of %d", lastDownloaded, lastTotal)
+	}
+	// A cached network is not downloaded again
+	if _, err := d.Get(context.Background(), smallName); err != nil {
+		t.Fatal(err)
+	}
+	if len(*ranges) != 1 {
+		t.Errorf("Expected no new downloads, got %v", *ranges)
+	}
+}
+
+func TestDownloadNotFound(t *testing.T) {
+	ts, _ := newTestServer(t)
+	d := newTestDownloader(t, ts)
+	if _, err := d.Get(context.Background(), largeName); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package katago
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Color is the color of a player or a stone, as used by the analysis protocol
+type Color string
+
+const (
+	Black Color = "B"
+	White Color = "W"
+)
+
+// ParseColor parses a color like "B", "w", "black" or "White"
+func ParseColor(s string) (Color, error) {
+	c, err := board.ParseColor(s)
+	if err != nil {
+		return "", err
+	}
+	return Color(c.String()), nil
+}
+
+// Opponent returns the other color
+func (c Color) Opponent() Color {
+	switch c {
+	case Black:
+		return White
+	case White:
+		return Black
+	}
+	return c
+}
+
+// Vertex is a GTP coordinate like "Q16", or "pass"
+type Vertex string
+
+// Pass is the vertex used for passing instead of placing a stone
+const Pass Vertex = "pass"
+
+// ParseVertex parses a GTP vertex like "Q16", "q16" or "pass". The vertex must fit on the largest
+// supported board, and is checked against the size of the board when the position is replayed.
+func ParseVertex(s string) (Vertex, error) {
+	p, err := board.ParseVertex(s, board.MaxSize, board.MaxSize)
+	if err != nil {
+		return "", err
+	}
+	if p.IsPass() {
+		return Pass, nil
+	}
+	return Vertex(strings.ToUpper(strings.TrimSpace(s))), nil
+}
+
+// IsPass checks if this vertex is a pass
+func (v Vertex) IsPass() bool {
+	return strings.EqualFold(string(v), string(Pass))
+}
+
+// Move is a stone placed by a player, or a pass. It is marshalled as the [color, vertex] pairs
+// used by the "moves" and "initialStones" fields of an analysis request.
+type Move struct {
+	Color  Color
+	Vertex Vertex
+}
+
+// NewMove creates a move, checking that the color is black or white, and that the vertex is valid
+func NewMove(color, vertex string) (Move, error) {
+	c, err := ParseColor(color)
+	if err != nil {
+		return Move{}, err
+	}
+	v, err := ParseVertex(vertex)
+	if err != nil {
+		return Move{}, err
+	}
+	return Move{c, v}, nil
+}
+
+// String returns the move as the color followed by the vertex, like "B Q16"
+func (m Move) String() string {
+	return string(m.Color) + " " + string(m.Vertex)
+}
+
+// Pair returns the move as a [color, vertex] pair
+func (m Move) Pair() [2]string {
+	return [2]string{string(m.Color), string(m.Vertex)}
+}
+
+// MarshalJSON writes the move as a [color, vertex] pair
+func (m Move) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Pair())
+}
+
+// UnmarshalJSON reads a [color, vertex] pair, and checks that it is valid
+func (m *Move) UnmarshalJSON(data []byte) error {
+	var pair []string
+	if err := json.Unmarshal(data, &pair); err != nil {
+		return err
+	}
+	if len(pair) != 2 {
+		return fmt.Errorf("invalid move %s: expected a color and a vertex", data)
+	}
+	move, err := NewMove(pair[0], pair[1])
+	if err != nil {
+		return fmt.Errorf("invalid move %s: %v", data, err)
+	}
+	*m = move
+	return nil
+}
+
+// Moves creates moves from [color, vertex] pairs, as returned by the board package
+func Moves(pairs [][2]string) ([]Move, error) {
+	moves := make([]Move, 0, len(pairs))
+	for _, pair := range pairs {
+		m, err := NewMove(pair[0], pair[1])
+		if err != nil {
+			return nil, err
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// Pairs returns the moves as [color, vertex] pairs, as used by the board package
+func Pairs(moves []Move) [][2]string {
+	pairs := make([][2]string, 0, len(moves))
+	for _, m := range moves {
+		pairs = append(pairs, m.Pair())
+	}
+	return pairs
+}
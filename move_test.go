@@ -0,0 +1,64 @@
+package katago
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestNewMove(t *testing.T) {
+	m, err := NewMove("black", "q16")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != (Move{Black, "Q16"}) {
+		t.Errorf("Expected B Q16, got %v", m)
+	}
+	if m, err := NewMove("w", "PASS"); err != nil || m.Vertex != Pass || !m.Vertex.IsPass() {
+		t.Errorf("Expected a pass, got %v and %v", m, err)
+	}
+	for _, c := range [][2]string{{"X", "D4"}, {"B", "I5"}, {"B", "Z99"}, {"W", ""}} {
+		if _, err := NewMove(c[0], c[1]); err == nil {
+			t.Errorf("Expected an error for %v", c)
+		}
+	}
+	if Black.Opponent() != White || White.Opponent() != Black {
+		t.Error("Expected black and white to be opponents")
+	}
+}
+
+func TestMoveJSON(t *testing.T) {
+	data, err := json.Marshal([]Move{{Black, "E5"}, {White, Pass}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `[["B","E5"],["W","pass"]]` {
+		t.Errorf("Expected the moves to be marshalled as pairs, got %s", data)
+	}
+	var moves []Move
+	if err := json.Unmarshal([]byte(`[["b","d4"],["W","pass"]]`), &moves); err != nil {
+		t.Fatal(err)
+	}
+	if len(moves) != 2 || moves[0] != (Move{Black, "D4"}) || moves[1] != (Move{White, Pass}) {
+		t.Errorf("Expected B D4 and W pass, got %v", moves)
+	}
+	if err := json.Unmarshal([]byte(`[["B","D4","x"]]`), &moves); err == nil {
+		t.Error("Expected an error for a move with three values")
+	}
+	if err := json.Unmarshal([]byte(`[["G","D4"]]`), &moves); err == nil {
+		t.Error("Expected an error for an invalid color")
+	}
+}
+
+func TestPairs(t *testing.T) {
+	moves, err := Moves([][2]string{{"B", "D4"}, {"W", "Q16"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pairs := Pairs(moves)
+	if len(pairs) != 2 || pairs[1] != [2]string{"W", "Q16"} {
+		t.Errorf("Expected the pairs to round trip, got %v", pairs)
+	}
+	if _, err := Moves([][2]string{{"B", "D4"}, {"B", "nowhere"}}); err == nil {
+		t.Error("Expected an error for an invalid vertex")
+	}
+}
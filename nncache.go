@@ -0,0 +1,38 @@
+package katago
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// WithNNCacheSize sets the size of the cache of neural network evaluations in KataGo, which holds 2^powerOfTwo
+// positions. Every position takes roughly 1.5 KB on 19x19, so the default of 20 allows about 1.5 GB.
+// A larger cache reuses more of the searches on a long-running server, and a smaller one uses less memory.
+func WithNNCacheSize(powerOfTwo int) Option {
+	return WithOverrides(map[string]string{"nnCacheSizePowerOfTwo": strconv.Itoa(powerOfTwo)})
+}
+
+// WithClearCacheEachGame makes AnalyzeGame clear the neural network cache of KataGo before each game,
+// so that the evaluations of unrelated games do not crowd each other out. Queries that are running
+// at the same time lose their cached evaluations too, and only get slower.
+func WithClearCacheEachGame() Option {
+	return func(k *KataGo) {
+		k.clearCacheEachGame = true
+	}
+}
+
+// ClearCache empties the neural network cache of KataGo, with the clear_cache action, for example
+// between unrelated batches. The Cache that is given with WithCache is not changed.
+func (k *KataGo) ClearCache() error {
+	if err := k.require(CapabilityClearCache); err != nil {
+		return err
+	}
+	line, err := k.action(map[string]any{"action": "clear_cache"})
+	if err != nil {
+		return err
+	}
+	if err := responseError(line); err != nil {
+		return fmt.Errorf("failed to clear the cache of KataGo: %w", err)
+	}
+	return nil
+}
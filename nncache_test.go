@@ -0,0 +1,43 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithNNCacheSize(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithNNCacheSize(18))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	if args := strings.Join(k.current().cmd.Args, " "); !strings.Contains(args, "nnCacheSizePowerOfTwo=18") {
+		t.Errorf("Expected the cache size to be overridden, got %s", args)
+	}
+}
+
+func TestClearCache(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+	if err := katago.ClearCache(); err != nil {
+		t.Errorf("Failed to clear the cache: %v", err)
+	}
+}
+
+func TestClearCacheEachGame(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithClearCacheEachGame())
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	request.Moves = []Move{{Black, "E5"}, {White, "C3"}}
+	responses, err := k.AnalyzeGame(request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 {
+		t.Errorf("Expected 3 responses, got %d", len(responses))
+	}
+}
@@ -0,0 +1,242 @@
+// Package ogs fetches games from online-go.com (OGS) with its REST API, converts them to positions that
+// KataGo can analyze, and can create reviews of the games on OGS
+package ogs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+	"github.com/xyproto/katago/sgf"
+)
+
+// DefaultBaseURL is the address of OGS
+const DefaultBaseURL = "https://online-go.com"
+
+// ErrNoToken is returned when creating a review without an access token
+var ErrNoToken = errors.New("an OGS access token is needed")
+
+// Client talks to the OGS REST API
+type Client struct {
+	HTTPClient *http.Client
+	BaseURL    string
+	// Token is an OAuth2 access token, which is only needed for creating reviews
+	Token string
+}
+
+// NewClient returns a client for online-go.com
+func NewClient() *Client {
+	return &Client{HTTPClient: http.DefaultClient, BaseURL: DefaultBaseURL}
+}
+
+// Player is one of the players of a game
+type Player struct {
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+}
+
+// GameData is the record of a game, as stored by OGS
+type GameData struct {
+	Width         int     `json:"width"`
+	Height        int     `json:"height"`
+	Komi          float64 `json:"komi"`
+	Rules         string  `json:"rules"`
+	Handicap      int     `json:"handicap"`
+	InitialPlayer string  `json:"initial_player"`
+	InitialState  struct {
+		Black string `json:"black"`
+		White string `json:"white"`
+	} `json:"initial_state"`
+	// Moves are the x and y coordinates from the top left corner, and the time used in milliseconds.
+	// A pass is at -1, -1.
+	Moves   [][]float64 `json:"moves"`
+	Outcome string      `json:"outcome"`
+	Winner  int         `json:"winner"`
+}
+
+// Game is a game on OGS
+type Game struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Players struct {
+		Black Player `json:"black"`
+		White Player `json:"white"`
+	} `json:"players"`
+	GameData GameData `json:"gamedata"`
+}
+
+var gameIDPattern = regexp.MustCompile(`(?:^|/game/(?:view/)?)(\d+)/?$`)
+
+// ParseGameID returns the ID of a game from an ID like "12345", or a URL like "https://online-go.com/game/12345"
+func ParseGameID(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if u, err := url.Parse(s); err == nil && u.Host != "" {
+		s = u.Path
+	}
+	m := gameIDPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, fmt.Errorf("not an OGS game: %q", s)
+	}
+	return strconv.Atoi(m[1])
+}
+
+// get sends a GET request to the API and returns the body of the response
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(c.BaseURL, "/")+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get %s: %s", path, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Game fetches a game by ID
+func (c *Client) Game(ctx context.Context, id int) (*Game, error) {
+	data, err := c.get(ctx, fmt.Sprintf("/api/v1/games/%d", id))
+	if err != nil {
+		return nil, err
+	}
+	var g Game
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, fmt.Errorf("failed to parse game %d: %v", id, err)
+	}
+	return &g, nil
+}
+
+// SGF fetches a game by ID as an SGF file
+func (c *Client) SGF(ctx context.Context, id int) (string, error) {
+	data, err := c.get(ctx, fmt.Sprintf("/api/v1/games/%d/sgf", id))
+	return string(data), err
+}
+
+// CreateReview creates a review of the game on OGS, and returns the ID of the review, which can be
+// opened at /review/<id> to add comments and variations. Token must be set.
+func (c *Client) CreateReview(ctx context.Context, gameID int) (int, error) {
+	if c.Token == "" {
+		return 0, ErrNoToken
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s/api/v1/games/%d/reviews", strings.TrimSuffix(c.BaseURL, "/"), gameID), strings.NewReader("{}"))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return 0, fmt.Errorf("failed to create a review of game %d: %s", gameID, resp.Status)
+	}
+	var review struct {
+		ID int `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&review); err != nil {
+		return 0, fmt.Errorf("failed to parse the review: %v", err)
+	}
+	return review.ID, nil
+}
+
+// stones parses a list of points in SGF coordinates without separators, like "ddpp"
+func stones(s string, color string, width, height int) ([]katago.Move, error) {
+	if len(s)%2 != 0 {
+		return nil, fmt.Errorf("invalid initial stones: %q", s)
+	}
+	var result []katago.Move
+	for i := 0; i < len(s); i += 2 {
+		p, err := board.ParseSGF(s[i:i+2], width, height)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, katago.Move{Color: katago.Color(color), Vertex: katago.Vertex(board.Vertex(p, height))})
+	}
+	return result, nil
+}
+
+// Position converts the game to a position for KataGo. Handicap stones that black placed
+// at the start of the game become initial stones, and white plays first after them.
+func (g *Game) Position() (katago.Position, error) {
+	d := g.GameData
+	if d.Width == 0 || d.Height == 0 {
+		return katago.Position{}, fmt.Errorf("game %d has no board size", g.ID)
+	}
+	p := katago.Position{
+		Moves:      []katago.Move{},
+		Rules:      sgf.ParseRules(d.Rules),
+		Komi:       d.Komi,
+		BoardXSize: d.Width,
+		BoardYSize: d.Height,
+	}
+	black, err := stones(d.InitialState.Black, "B", d.Width, d.Height)
+	if err != nil {
+		return katago.Position{}, err
+	}
+	white, err := stones(d.InitialState.White, "W", d.Width, d.Height)
+	if err != nil {
+		return katago.Position{}, err
+	}
+	p.InitialStones = append(black, white...)
+	color := board.Black
+	if d.InitialPlayer == "white" {
+		color = board.White
+	}
+	moves := d.Moves
+	if d.Handicap > 1 && color == board.Black && len(p.InitialStones) == 0 {
+		// The handicap stones are the first moves of black
+		for _, m := range moves[:min(d.Handicap, len(moves))] {
+			if len(m) < 2 || m[0] < 0 {
+				return katago.Position{}, fmt.Errorf("game %d: invalid handicap stone", g.ID)
+			}
+			vertex := board.Vertex(board.Point{X: int(m[0]), Y: int(m[1])}, d.Height)
+			p.InitialStones = append(p.InitialStones, katago.Move{Color: katago.Black, Vertex: katago.Vertex(vertex)})
+		}
+		moves = moves[min(d.Handicap, len(moves)):]
+		color = board.White
+	}
+	if color == board.White {
+		p.InitialPlayer = "W"
+	}
+	for _, m := range moves {
+		if len(m) < 2 {
+			return katago.Position{}, fmt.Errorf("game %d: invalid move %v", g.ID, m)
+		}
+		vertex := katago.Pass
+		if m[0] >= 0 && m[1] >= 0 {
+			vertex = katago.Vertex(board.Vertex(board.Point{X: int(m[0]), Y: int(m[1])}, d.Height))
+		}
+		p.Moves = append(p.Moves, katago.Move{Color: katago.Color(color.String()), Vertex: vertex})
+		color = color.Opponent()
+	}
+	if _, err := p.Board(); err != nil {
+		return katago.Position{}, fmt.Errorf("game %d: %v", g.ID, err)
+	}
+	return p, nil
+}
+
+// Analyze analyzes every turn of the game, and returns the responses indexed by turn number
+func Analyze(k *katago.KataGo, g *Game, visits int) ([]katago.AnalysisResponse, error) {
+	p, err := g.Position()
+	if err != nil {
+		return nil, err
+	}
+	request := p.Request(fmt.Sprintf("ogs-%d", g.ID))
+	request.MaxVisits = visits
+	return k.AnalyzeGame(request)
+}
@@ -0,0 +1,125 @@
+package ogs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+const gameJSON = `{"id": 123, "name": "Friendly Match", "players": {"black": {"id": 1, "username": "alice"}, "white": {"id": 2, "username": "bob"}},
+"gamedata": {"width": 9, "height": 9, "komi": 0.5, "rules": "japanese", "handicap": 2, "initial_player": "black",
+"initial_state": {"black": "", "white": ""}, "moves": [[2, 6, 100], [6, 2, 200], [4, 4, 3000], [-1, -1, 50], [2, 2, 10]]}}`
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/v1/games/123", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(gameJSON))
+	})
+	mux.HandleFunc("GET /api/v1/games/123/sgf", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("(;SZ[9];B[ee])"))
+	})
+	mux.HandleFunc("POST /api/v1/games/123/reviews", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"id": 77}`))
+	})
+	ts := httptest.NewServer(mux)
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestParseGameID(t *testing.T) {
+	for s, expected := range map[string]int{
+		"12345":                                  12345,
+		"https://online-go.com/game/12345":       12345,
+		"https://online-go.com/game/view/67890/": 67890,
+	} {
+		if id, err := ParseGameID(s); err != nil || id != expected {
+			t.Errorf("Expected %d for %q, got %d (%v)", expected, s, id, err)
+		}
+	}
+	if _, err := ParseGameID("https://online-go.com/player/1"); err == nil {
+		t.Errorf("Expected an error for a player page")
+	}
+}
+
+func TestGame(t *testing.T) {
+	ts := newTestServer(t)
+	c := NewClient()
+	c.BaseURL = ts.URL
+	g, err := c.Game(context.Background(), 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Players.Black.Username != "alice" || g.Name != "Friendly Match" {
+		t.Errorf("Unexpected game: %+v", g)
+	}
+	p, err := g.Position()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(p.InitialStones) != 2 || p.InitialStones[0] != (katago.Move{Color: "B", Vertex: "C3"}) || p.InitialPlayer != "W" {
+		t.Errorf("Expected 2 handicap stones and white to play, got %v and %s", p.InitialStones, p.InitialPlayer)
+	}
+	expected := []katago.Move{{Color: "W", Vertex: "E5"}, {Color: "B", Vertex: "pass"}, {Color: "W", Vertex: "C7"}}
+	if len(p.Moves) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, p.Moves)
+	}
+	for i := range expected {
+		if p.Moves[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], p.Moves[i])
+		}
+	}
+	if p.Rules != katago.Japanese || p.Komi != 0.5 {
+		t.Errorf("Expected Japanese rules with 0.5 komi, got %s and %v", p.Rules, p.Komi)
+	}
+	if s, err := c.SGF(context.Background(), 123); err != nil || !strings.HasPrefix(s, "(;") {
+		t.Errorf("Expected an SGF, got %q (%v)", s, err)
+	}
+	if _, err := c.Game(context.Background(), 124); err == nil {
+		t.Errorf("Expected an error for a missing game")
+	}
+}
+
+func TestCreateReview(t *testing.T) {
+	ts := newTestServer(t)
+	c := NewClient()
+	c.BaseURL = ts.URL
+	if _, err := c.CreateReview(context.Background(), 123); !errors.Is(err, ErrNoToken) {
+		t.Errorf("Expected ErrNoToken, got %v", err)
+	}
+	c.Token = "secret"
+	if id, err := c.CreateReview(context.Background(), 123); err != nil || id != 77 {
+		t.Errorf("Expected review 77, got %d (%v)", id, err)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	ts := newTestServer(t)
+	c := NewClient()
+	c.BaseURL = ts.URL
+	g, err := c.Game(context.Background(), 123)
+	if err != nil {
+		t.Fatal(err)
+	}
+	responses, err := Analyze(k, g, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 4 {
+		t.Errorf("Expected 4 responses, got %d", len(responses))
+	}
+}
@@ -0,0 +1,66 @@
+package katago
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Option configures a KataGo instance when it is created
+type Option func(*KataGo)
+
+// WithCache makes Analyze look up single-turn requests in the given cache before sending them to KataGo,
+// and store the responses that KataGo returns
+func WithCache(cache Cache) Option {
+	return func(k *KataGo) {
+		k.cache = cache
+	}
+}
+
+// WithOverrides passes settings that replace the ones in the config file to KataGo, with -override-config,
+// like {"numSearchThreads": "16", "logAllRequests": "true"}. Later options replace the settings of earlier ones.
+// Keys and values can not contain commas, equals signs or newlines, and NewKataGo returns an error if they do.
+func WithOverrides(settings map[string]string) Option {
+	return func(k *KataGo) {
+		if k.overrides == nil {
+			k.overrides = make(map[string]string)
+		}
+		for key, value := range settings {
+			k.overrides[key] = value
+		}
+	}
+}
+
+// checkOverrides checks that the settings can be passed with -override-config
+func checkOverrides(settings map[string]string) error {
+	for key, value := range settings {
+		if key == "" || strings.ContainsAny(key, ",= \t\r\n") {
+			return fmt.Errorf("invalid config override key %q", key)
+		}
+		if strings.ContainsAny(value, ",=\r\n") {
+			return fmt.Errorf("invalid value for config override %s: %q", key, value)
+		}
+	}
+	return nil
+}
+
+// formatOverrides formats the settings for -override-config, sorted by key
+func formatOverrides(settings map[string]string) string {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + settings[key]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// WithAnalysisPVLen sets the default maximum length of the principal variations that KataGo reports,
+// which a request can change with SetAnalysisPVLen
+func WithAnalysisPVLen(length int) Option {
+	return WithOverrides(map[string]string{"analysisPVLen": strconv.Itoa(length)})
+}
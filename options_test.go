@@ -0,0 +1,32 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithOverrides(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz",
+		WithOverrides(map[string]string{"numSearchThreads": "4", "logAllRequests": "true"}),
+		WithOverrides(map[string]string{"numSearchThreads": "8"}))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	args := strings.Join(k.current().cmd.Args, " ")
+	if !strings.Contains(args, "-override-config logAllRequests=true,numSearchThreads=8") {
+		t.Errorf("Expected the sorted overrides, with the later value, got %s", args)
+	}
+}
+
+func TestWithOverridesInvalid(t *testing.T) {
+	for _, settings := range []map[string]string{
+		{"maxVisits": "1,numSearchThreads=1"},
+		{"": "1"},
+		{"max visits": "1"},
+	} {
+		if _, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithOverrides(settings)); err == nil {
+			t.Errorf("Expected an error for %v", settings)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package katago
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// OwnershipMap holds the predicted owner of each point on the board, as values from -1 (white) to 1 (black)
+type OwnershipMap struct {
+	width, height int
+	values        []float64
+}
+
+// NewOwnershipMap creates an ownership map from a flat, row-major slice of values starting at the top left
+// corner of the board, as reported by KataGo. The perspective is the color that positive values belong to,
+// which is the player to move when reportAnalysisWinratesAs is SIDETOMOVE.
+func NewOwnershipMap(values []float64, width, height int, perspective board.Color) (*OwnershipMap, error) {
+	if len(values) != width*height {
+		return nil, fmt.Errorf("expected %d ownership values for a %dx%d board, got %d", width*height, width, height, len(values))
+	}
+	var sign float64
+	switch perspective {
+	case board.Black:
+		sign = 1
+	case board.White:
+		sign = -1
+	default:
+		return nil, fmt.Errorf("invalid ownership perspective: %v", perspective)
+	}
+	normalized := make([]float64, len(values))
+	for i, v := range values {
+		normalized[i] = sign * v
+	}
+	return &OwnershipMap{width: width, height: height, values: normalized}, nil
+}
+
+// OwnershipMap returns the ownership of the analyzed position, for a board of the given size.
+// The values are expected to be reported from the side to move, as in analysis_example.cfg.
+func (r AnalysisResponse) OwnershipMap(width, height int) (*OwnershipMap, error) {
+	if len(r.Ownership) == 0 {
+		return nil, fmt.Errorf("response %s has no ownership, set IncludeOwnership in the request", r.ID)
+	}
+	perspective, err := board.ParseColor(r.RootInfo.CurrentPlayer)
+	if err != nil {
+		return nil, fmt.Errorf("response %s has no current player: %v", r.ID, err)
+	}
+	return NewOwnershipMap(r.Ownership, width, height, perspective)
+}
+
+// OwnershipStdevMap returns the standard deviation of the ownership of each point, from 0 for settled points
+// up to 1, for a board of the given size. The request must have IncludeOwnershipStdev set.
+func (r AnalysisResponse) OwnershipStdevMap(width, height int) (*OwnershipMap, error) {
+	if len(r.OwnershipStdev) == 0 {
+		return nil, fmt.Errorf("response %s has no ownership stdev, set IncludeOwnershipStdev in the request", r.ID)
+	}
+	// The standard deviation is the same from both points of view
+	return NewOwnershipMap(r.OwnershipStdev, width, height, board.Black)
+}
+
+// MoveOwnershipMap returns the predicted ownership if the given candidate move is played, for a board of the
+// given size. The request must have IncludeMovesOwnership set.
+func (r AnalysisResponse) MoveOwnershipMap(move string, width, height int) (*OwnershipMap, error) {
+	for _, info := range r.MoveInfos {
+		if !strings.EqualFold(info.Move, move) {
+			continue
+		}
+		if len(info.Ownership) == 0 {
+			return nil, fmt.Errorf("move %s of response %s has no ownership, set IncludeMovesOwnership in the request", info.Move, r.ID)
+		}
+		perspective, err := board.ParseColor(r.RootInfo.CurrentPlayer)
+		if err != nil {
+			return nil, fmt.Errorf("response %s has no current player: %v", r.ID, err)
+		}
+		return NewOwnershipMap(info.Ownership, width, height, perspective)
+	}
+	return nil, fmt.Errorf("move %s is not a candidate move of response %s", move, r.ID)
+}
+
+// Width returns the width of the board
+func (m *OwnershipMap) Width() int {
+	return m.width
+}
+
+// Height returns the height of the board
+func (m *OwnershipMap) Height() int {
+	return m.height
+}
+
+// At returns the ownership at (x, y), from -1 (owned by white) to 1 (owned by black)
+func (m *OwnershipMap) At(x, y int) float64 {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return 0
+	}
+	return m.values[y*m.width+x]
+}
+
+// For returns the ownership at (x, y) from the point of view of the given color, from -1 to 1
+func (m *OwnershipMap) For(c board.Color, x, y int) float64 {
+	if c == board.White {
+		return -m.At(x, y)
+	}
+	return m.At(x, y)
+}
+
+// Owner returns the color that owns (x, y) with at least the given certainty (0 to 1), or board.Empty
+func (m *OwnershipMap) Owner(x, y int, threshold float64) board.Color {
+	switch v := m.At(x, y); {
+	case v >= threshold && v > 0:
+		return board.Black
+	case v <= -threshold && v < 0:
+		return board.White
+	}
+	return board.Empty
+}
+
+// Total returns the expected number of points owned by the given color, by summing up its ownership
+func (m *OwnershipMap) Total(c board.Color) float64 {
+	total := 0.0
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if v := m.For(c, x, y); v > 0 {
+				total += v
+			}
+		}
+	}
+	return total
+}
+
+// Count returns the number of points owned by the given color with at least the given certainty (0 to 1)
+func (m *OwnershipMap) Count(c board.Color, threshold float64) int {
+	count := 0
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if m.Owner(x, y, threshold) == c {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+// Uncertain returns the points with a value of at least the given threshold, which for a map from
+// OwnershipStdevMap are the points that are still uncertain
+func (m *OwnershipMap) Uncertain(threshold float64) []board.Point {
+	var points []board.Point
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if m.At(x, y) >= threshold {
+				points = append(points, board.Point{X: x, Y: y})
+			}
+		}
+	}
+	return points
+}
+
+// Diff returns a map with the change in ownership since the previous position
+func (m *OwnershipMap) Diff(previous *OwnershipMap) (*OwnershipMap, error) {
+	if previous.width != m.width || previous.height != m.height {
+		return nil, fmt.Errorf("can not compare a %dx%d ownership map with a %dx%d one", m.width, m.height, previous.width, previous.height)
+	}
+	diff := &OwnershipMap{width: m.width, height: m.height, values: make([]float64, len(m.values))}
+	for i := range m.values {
+		diff.values[i] = m.values[i] - previous.values[i]
+	}
+	return diff, nil
+}
+
+// Changed returns the points where the ownership changed by at least the given amount since the previous position
+func (m *OwnershipMap) Changed(previous *OwnershipMap, amount float64) ([]board.Point, error) {
+	diff, err := m.Diff(previous)
+	if err != nil {
+		return nil, err
+	}
+	var points []board.Point
+	for y := 0; y < m.height; y++ {
+		for x := 0; x < m.width; x++ {
+			if v := diff.At(x, y); v >= amount || v <= -amount {
+				points = append(points, board.Point{X: x, Y: y})
+			}
+		}
+	}
+	return points, nil
+}
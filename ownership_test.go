@@ -0,0 +1,116 @@
+package katago
+
+import (
+	"math"
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestOwnershipMap(t *testing.T) {
+	// A 3x2 board, reported from white's point of view
+	values := []float64{0.9, 0.8, -0.2, -1.0, 0.1, 0.0}
+	m, err := NewOwnershipMap(values, 3, 2, board.White)
+	if err != nil {
+		t.Fatalf("Failed to create ownership map: %v", err)
+	}
+	if m.At(0, 0) != -0.9 {
+		t.Errorf("Expected -0.9 at (0, 0), got %f", m.At(0, 0))
+	}
+	if m.For(board.White, 0, 1) != -1.0 {
+		t.Errorf("Expected -1.0 for white at (0, 1), got %f", m.For(board.White, 0, 1))
+	}
+	if m.Owner(0, 1, 0.5) != board.Black || m.Owner(1, 1, 0.5) != board.Empty {
+		t.Errorf("Expected (0, 1) to be black and (1, 1) to be undecided")
+	}
+	if c := m.Count(board.White, 0.5); c != 2 {
+		t.Errorf("Expected 2 white points, got %d", c)
+	}
+	if total := m.Total(board.Black); math.Abs(total-1.2) > 1e-9 {
+		t.Errorf("Expected 1.2 black points, got %f", total)
+	}
+	if _, err := NewOwnershipMap(values, 3, 3, board.Black); err == nil {
+		t.Errorf("Expected an error for a mismatched board size")
+	}
+}
+
+func TestOwnershipChanged(t *testing.T) {
+	before, _ := NewOwnershipMap([]float64{0, 0, 0, 0}, 2, 2, board.Black)
+	after, _ := NewOwnershipMap([]float64{0, 0.9, 0, -0.1}, 2, 2, board.Black)
+	changed, err := after.Changed(before, 0.5)
+	if err != nil {
+		t.Fatalf("Failed to compare ownership maps: %v", err)
+	}
+	if len(changed) != 1 || changed[0] != (board.Point{X: 1, Y: 0}) {
+		t.Errorf("Expected only (1, 0) to change, got %v", changed)
+	}
+
+	response := AnalysisResponse{Ownership: []float64{1, -1, 1, -1}, RootInfo: RootInfo{CurrentPlayer: "W"}}
+	m, err := response.OwnershipMap(2, 2)
+	if err != nil {
+		t.Fatalf("Failed to get ownership map from response: %v", err)
+	}
+	if m.At(0, 0) != -1 {
+		t.Errorf("Expected white to own (0, 0), got %f", m.At(0, 0))
+	}
+}
+
+func TestMoveOwnership(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.MaxVisits = 20
+	request.IncludeMovesOwnership = true
+	responses, err := katago.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	response := responses[0]
+	if len(response.MoveInfos) == 0 || len(response.MoveInfos[0].Ownership) != 81 {
+		t.Fatalf("Expected 81 ownership values for the best move, got %v", response.MoveInfos)
+	}
+	move := response.MoveInfos[0].Move
+	m, err := response.MoveOwnershipMap(move, 9, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Width() != 9 || m.Height() != 9 {
+		t.Errorf("Expected a 9x9 map, got %dx%d", m.Width(), m.Height())
+	}
+	if _, err := response.MoveOwnershipMap("pass-not-a-move", 9, 9); err == nil {
+		t.Error("Expected an error for a move that is not a candidate")
+	}
+	response.MoveInfos[0].Ownership = nil
+	if _, err := response.MoveOwnershipMap(move, 9, 9); err == nil {
+		t.Error("Expected an error for a move without ownership")
+	}
+}
+
+func TestOwnershipStdev(t *testing.T) {
+	response := AnalysisResponse{ID: "stdev", OwnershipStdev: []float64{0.05, 0.6, 0.3, 0.8}}
+	m, err := response.OwnershipStdevMap(2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	points := m.Uncertain(0.5)
+	if len(points) != 2 || points[0] != (board.Point{X: 1, Y: 0}) || points[1] != (board.Point{X: 1, Y: 1}) {
+		t.Errorf("Expected (1, 0) and (1, 1) to be uncertain, got %v", points)
+	}
+	if _, err := (AnalysisResponse{}).OwnershipStdevMap(2, 2); err == nil {
+		t.Error("Expected an error for a response without the ownership stdev")
+	}
+
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+	request := NewRequest9x9()
+	request.MaxVisits = 20
+	request.IncludeOwnershipStdev = true
+	responses, err := katago.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses[0].OwnershipStdev) != 81 {
+		t.Errorf("Expected 81 stdev values, got %d", len(responses[0].OwnershipStdev))
+	}
+}
@@ -0,0 +1,103 @@
+package katago
+
+import (
+	"fmt"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Phase is the phase of a game that a move was played in
+type Phase int
+
+// Game phases, in the order that they are played
+const (
+	Opening Phase = iota
+	Middlegame
+	Endgame
+)
+
+// Phases lists all game phases, in the order that they are played
+var Phases = []Phase{Opening, Middlegame, Endgame}
+
+// String returns the name of the phase
+func (p Phase) String() string {
+	switch p {
+	case Opening:
+		return "Opening"
+	case Middlegame:
+		return "Middlegame"
+	case Endgame:
+		return "Endgame"
+	}
+	return fmt.Sprintf("Phase(%d)", int(p))
+}
+
+// GamePhase returns the phase of the game for the move with the given turn number, from the number of stones
+// on the board before the move and the number of points on the board. On 19x19, the opening is the first 40
+// moves while there are fewer than 36 stones, and the endgame starts at move 180 or when there are 144 stones,
+// and both are scaled by the size of the board. This is only a rough heuristic, since the phases of a game
+// overlap, and a fight can start in the opening or in the endgame.
+func GamePhase(turn, stones, points int) Phase {
+	switch {
+	case turn >= points/2 || stones >= points*2/5:
+		return Endgame
+	case turn < points/9 && stones < points/10:
+		return Opening
+	}
+	return Middlegame
+}
+
+// Phases returns the phase of the game for each of the moves of the position, by replaying them
+func (p Position) Phases() ([]Phase, error) {
+	initial := p
+	initial.Moves = nil
+	b, err := initial.Board()
+	if err != nil {
+		return nil, err
+	}
+	points := b.Width() * b.Height()
+	phases := make([]Phase, len(p.Moves))
+	for i, move := range p.Moves {
+		phases[i] = GamePhase(i, len(b.Stones()), points)
+		c, err := board.ParseColor(string(move.Color))
+		if err != nil {
+			return nil, err
+		}
+		point, err := b.ParseVertex(string(move.Vertex))
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Play(c, point); err != nil {
+			return nil, fmt.Errorf("move %d at %s: %v", i+1, move.Vertex, err)
+		}
+	}
+	return phases, nil
+}
+
+// SummarizePhases returns per-player grade statistics for each phase of the game, keyed by color ("B" or "W"),
+// so that players can see if they lose their points in the opening, in the middlegame or in the endgame.
+// The graded moves are matched to the moves of the position by their turn number.
+func SummarizePhases(p Position, graded []GradedMove) (map[string]map[Phase]*PlayerSummary, error) {
+	phases, err := p.Phases()
+	if err != nil {
+		return nil, err
+	}
+	byPhase := make(map[Phase][]GradedMove)
+	for _, move := range graded {
+		if move.Turn < 0 || move.Turn >= len(phases) {
+			return nil, fmt.Errorf("graded move %d is not a move of the position", move.Turn)
+		}
+		phase := phases[move.Turn]
+		byPhase[phase] = append(byPhase[phase], move)
+	}
+	summaries := make(map[string]map[Phase]*PlayerSummary)
+	for phase, moves := range byPhase {
+		for color, summary := range SummarizeGrades(moves) {
+			if summaries[color] == nil {
+				summaries[color] = make(map[Phase]*PlayerSummary)
+			}
+			summaries[color][phase] = summary
+		}
+	}
+	return summaries, nil
+}
@@ -0,0 +1,91 @@
+package katago
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+// newPhaseTestPosition returns a 9x9 game where black fills the bottom of the board and white the top
+func newPhaseTestPosition(n int) Position {
+	p := Position{Rules: "tromp-taylor", BoardXSize: 9, BoardYSize: 9}
+	for i := 0; i < n; i++ {
+		k := i / 2
+		point := board.Point{X: k % 9, Y: 8 - k/9}
+		color := Black
+		if i%2 == 1 {
+			point.Y = k / 9
+			color = White
+		}
+		p.Moves = append(p.Moves, Move{color, Vertex(board.Vertex(point, 9))})
+	}
+	return p
+}
+
+func TestGamePhase(t *testing.T) {
+	cases := []struct {
+		turn, stones int
+		expected     Phase
+	}{
+		{0, 0, Opening},
+		{39, 35, Opening},
+		{40, 30, Middlegame},
+		{20, 36, Middlegame},
+		{120, 100, Middlegame},
+		{150, 144, Endgame},
+		{180, 120, Endgame},
+	}
+	for _, c := range cases {
+		if phase := GamePhase(c.turn, c.stones, 361); phase != c.expected {
+			t.Errorf("Expected %v for turn %d with %d stones, got %v", c.expected, c.turn, c.stones, phase)
+		}
+	}
+}
+
+func TestPhases(t *testing.T) {
+	phases, err := newPhaseTestPosition(45).Phases()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, phase := range phases {
+		expected := Middlegame
+		switch {
+		case i < 8:
+			expected = Opening
+		case i >= 32:
+			expected = Endgame
+		}
+		if phase != expected {
+			t.Errorf("Expected %v for move %d, got %v", expected, i+1, phase)
+		}
+	}
+}
+
+func TestSummarizePhases(t *testing.T) {
+	p := newPhaseTestPosition(45)
+	graded := []GradedMove{
+		{Turn: 0, Color: "B", PointsLost: 1},
+		{Turn: 2, Color: "B", PointsLost: 2},
+		{Turn: 10, Color: "B", PointsLost: 4},
+		{Turn: 33, Color: "W", PointsLost: 7, Grade: Blunder},
+	}
+	summaries, err := SummarizePhases(p, graded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := summaries["B"][Opening]; s == nil || s.Moves != 2 || s.AveragePointsLost() != 1.5 {
+		t.Errorf("Expected black to lose 1.5 points per move in the opening, got %+v", s)
+	}
+	if s := summaries["B"][Middlegame]; s == nil || s.TotalPointsLost != 4 {
+		t.Errorf("Expected black to lose 4 points in the middlegame, got %+v", s)
+	}
+	if _, ok := summaries["B"][Endgame]; ok {
+		t.Error("Expected no endgame summary for black")
+	}
+	if s := summaries["W"][Endgame]; s == nil || s.Counts[Blunder] != 1 {
+		t.Errorf("Expected a blunder by white in the endgame, got %+v", s)
+	}
+	if _, err := SummarizePhases(p, []GradedMove{{Turn: 45}}); err == nil {
+		t.Error("Expected an error for a graded move that is not in the position")
+	}
+}
@@ -0,0 +1,95 @@
+package katago
+
+import (
+	"fmt"
+	"math"
+)
+
+// Points is an estimate of the score in points, from black's point of view, which is what human reviewers
+// reason in, rather than winrates
+type Points struct {
+	// Lead is how many points black is ahead, negative if white is ahead. It is the komi that would make the game even.
+	Lead float64
+	// Selfplay is the expected score if KataGo played the game out against itself, which is larger than
+	// the lead, since KataGo keeps its advantage
+	Selfplay float64
+	// Stdev is the standard deviation of the final score
+	Stdev float64
+}
+
+// Points returns the estimated score of the position, from black's point of view
+func (r AnalysisResponse) Points() Points {
+	sign := blackSign(r.RootInfo.CurrentPlayer)
+	return Points{
+		Lead:     sign * r.RootInfo.ScoreLead,
+		Selfplay: sign * r.RootInfo.ScoreSelfplay,
+		Stdev:    r.RootInfo.ScoreStdev,
+	}
+}
+
+// MovePoints returns the estimated score after the given move, from black's point of view,
+// or false if the move was not analyzed
+func (r AnalysisResponse) MovePoints(move string) (Points, bool) {
+	sign := blackSign(r.RootInfo.CurrentPlayer)
+	for _, info := range r.MoveInfos {
+		if info.Move == move {
+			return Points{
+				Lead:     sign * info.ScoreLead,
+				Selfplay: sign * info.ScoreSelfplay,
+				Stdev:    info.ScoreStdev,
+			}, true
+		}
+	}
+	return Points{}, false
+}
+
+// For returns the points from the point of view of the given color, where a positive lead means that
+// the color is ahead
+func (p Points) For(color Color) Points {
+	sign := blackSign(string(color))
+	return Points{Lead: sign * p.Lead, Selfplay: sign * p.Selfplay, Stdev: p.Stdev}
+}
+
+// BlackWinProbability converts the score to the probability that black wins, by taking the final score to be
+// normally distributed around the lead, with the standard deviation. This is only a rough estimate, since the
+// winrate of KataGo also depends on how hard the position is to play.
+func (p Points) BlackWinProbability() float64 {
+	if p.Stdev <= 0 {
+		switch {
+		case p.Lead > 0:
+			return 1
+		case p.Lead < 0:
+			return 0
+		}
+		return 0.5
+	}
+	return 0.5 * (1 + math.Erf(p.Lead/(p.Stdev*math.Sqrt2)))
+}
+
+// LeadForWinProbability is the inverse of BlackWinProbability, and returns how many points black must be
+// ahead to win with the given probability, for a final score with the given standard deviation
+func LeadForWinProbability(blackWinProbability, stdev float64) float64 {
+	return stdev * math.Sqrt2 * math.Erfinv(2*blackWinProbability-1)
+}
+
+// FormatLead formats the number of points that black is ahead with one decimal, like "B+4.2" or "W+0.5",
+// or "Even" if it rounds to zero. Unlike FormatResult, the lead is not rounded to what a counted game could give.
+func FormatLead(blackLead float64) string {
+	rounded := math.Round(blackLead*10) / 10
+	switch {
+	case rounded > 0:
+		return fmt.Sprintf("B+%.1f", rounded)
+	case rounded < 0:
+		return fmt.Sprintf("W+%.1f", -rounded)
+	}
+	return "Even"
+}
+
+// String formats the lead and the standard deviation, like "B+4.2 ± 6.1", or only the lead if there is no
+// standard deviation
+func (p Points) String() string {
+	if p.Stdev <= 0 {
+		return FormatLead(p.Lead)
+	}
+	return fmt.Sprintf("%s ± %.1f", FormatLead(p.Lead), p.Stdev)
+}
@@ -0,0 +1,59 @@
+package katago
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPoints(t *testing.T) {
+	response := AnalysisResponse{
+		RootInfo: RootInfo{ScoreLead: 4.2, ScoreSelfplay: 5.0, ScoreStdev: 6.1, CurrentPlayer: "W"},
+		MoveInfos: []MoveInfoExt{
+			{Move: "D4", ScoreLead: 4.5, ScoreSelfplay: 5.5, ScoreStdev: 6.0},
+		},
+	}
+	p := response.Points()
+	if p.Lead != -4.2 || p.Selfplay != -5.0 || p.Stdev != 6.1 {
+		t.Errorf("Expected the points from black's point of view, got %+v", p)
+	}
+	if s := p.String(); s != "W+4.2 ± 6.1" {
+		t.Errorf("Expected W+4.2 ± 6.1, got %s", s)
+	}
+	if w := p.For(White); w.Lead != 4.2 || w.Selfplay != 5.0 {
+		t.Errorf("Expected white to be ahead, got %+v", w)
+	}
+	m, ok := response.MovePoints("D4")
+	if !ok || m.Lead != -4.5 || m.Stdev != 6.0 {
+		t.Errorf("Unexpected points for D4: %+v, %t", m, ok)
+	}
+	if _, ok := response.MovePoints("Q16"); ok {
+		t.Error("Expected no points for a move that was not analyzed")
+	}
+}
+
+func TestFormatLead(t *testing.T) {
+	for lead, want := range map[float64]string{4.24: "B+4.2", -0.46: "W+0.5", 0.04: "Even", -0.04: "Even", 12: "B+12.0"} {
+		if got := FormatLead(lead); got != want {
+			t.Errorf("Expected %s for %g, got %s", want, lead, got)
+		}
+	}
+	if s := (Points{Lead: 1.5}).String(); s != "B+1.5" {
+		t.Errorf("Expected B+1.5 without a standard deviation, got %s", s)
+	}
+}
+
+func TestBlackWinProbability(t *testing.T) {
+	if w := (Points{Lead: 0, Stdev: 10}).BlackWinProbability(); w != 0.5 {
+		t.Errorf("Expected 0.5 for an even game, got %g", w)
+	}
+	// One standard deviation ahead is about 84%
+	if w := (Points{Lead: 10, Stdev: 10}).BlackWinProbability(); math.Abs(w-0.8413) > 0.001 {
+		t.Errorf("Expected about 0.841, got %g", w)
+	}
+	if w := (Points{Lead: -1}).BlackWinProbability(); w != 0 {
+		t.Errorf("Expected 0 for a certain loss, got %g", w)
+	}
+	if lead := LeadForWinProbability(0.8413, 10); math.Abs(lead-10) > 0.01 {
+		t.Errorf("Expected a lead of about 10, got %g", lead)
+	}
+}
@@ -0,0 +1,229 @@
+package katago
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+// Pool runs one KataGo engine for each GPU, and shards the requests across them, for maximizing
+// the throughput on analysis boxes with several GPUs. Each request goes to the running engine with
+// the fewest requests in flight.
+type Pool struct {
+	mut     sync.Mutex
+	engines []*poolEngine
+}
+
+// poolEngine is one engine of a pool, and inFlight is protected by the mut of the pool
+type poolEngine struct {
+	katago   *KataGo
+	device   int
+	inFlight int
+}
+
+// DeviceHealth is the state of the engine for one device of a pool
+type DeviceHealth struct {
+	// Device is the index of the GPU, or -1 for an engine that is not pinned to a device
+	Device  int
+	Running bool
+	// InFlight is the number of requests that the pool has sent to the engine and that are not answered yet
+	InFlight int
+	Stats    Stats
+}
+
+// nvidiaGPUPattern matches the lines of "nvidia-smi -L", like "GPU 0: NVIDIA GeForce RTX 3080 (UUID: ...)"
+var nvidiaGPUPattern = regexp.MustCompile(`^GPU (\d+):`)
+
+// parseGPUList returns the indices of the GPUs in the output of "nvidia-smi -L"
+func parseGPUList(output []byte) []int {
+	var devices []int
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if m := nvidiaGPUPattern.FindStringSubmatch(scanner.Text()); m != nil {
+			index, _ := strconv.Atoi(m[1])
+			devices = append(devices, index)
+		}
+	}
+	return devices
+}
+
+// DetectGPUs returns the indices of the NVIDIA GPUs of the host, as listed by nvidia-smi
+func DetectGPUs() ([]int, error) {
+	output, err := exec.Command("nvidia-smi", "-L").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the GPUs with nvidia-smi: %v", err)
+	}
+	devices := parseGPUList(output)
+	if len(devices) == 0 {
+		return nil, errors.New("nvidia-smi did not list any GPUs")
+	}
+	return devices, nil
+}
+
+// NewPool starts one engine for each of the devices, pinned to it with WithDevices, and with the given options.
+// If no devices are given, they are found with DetectGPUs, and if none are found, the pool has one engine
+// that is not pinned to a device.
+func NewPool(configFile, modelFile string, backend Backend, devices []int, options ...Option) (*Pool, error) {
+	if len(devices) == 0 {
+		detected, err := DetectGPUs()
+		if err != nil {
+			log.Printf("Starting one engine without pinning it to a device: %v", err)
+			detected = []int{-1}
+		}
+		devices = detected
+	}
+	p := &Pool{}
+	for _, device := range devices {
+		engineOptions := options
+		if device >= 0 {
+			engineOptions = append([]Option{WithDevices(backend, device)}, options...)
+		}
+		k, err := NewKataGo(configFile, modelFile, engineOptions...)
+		if err != nil {
+			p.Close()
+			return nil, fmt.Errorf("failed to start the engine for device %d: %w", device, err)
+		}
+		p.engines = append(p.engines, &poolEngine{katago: k, device: device})
+	}
+	return p, nil
+}
+
+// pick returns the running engine with the fewest requests in flight, and counts one more request for it
+func (p *Pool) pick() (*poolEngine, error) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	var best *poolEngine
+	for _, e := range p.engines {
+		if e.katago.Running() && (best == nil || e.inFlight < best.inFlight) {
+			best = e
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: no engine of the pool is running", ErrEngineCrashed)
+	}
+	best.inFlight++
+	return best, nil
+}
+
+// done counts n requests of the engine as answered
+func (p *Pool) done(e *poolEngine, n int) {
+	p.mut.Lock()
+	e.inFlight -= n
+	p.mut.Unlock()
+}
+
+// Analyze shards the requests across the engines, and returns the responses in the order of the requests,
+// as for KataGo.Analyze
+func (p *Pool) Analyze(requests []AnalysisRequest) ([]AnalysisResponse, error) {
+	groups := make(map[*poolEngine][]int)
+	for i := range requests {
+		e, err := p.pick()
+		if err != nil {
+			for e, indices := range groups {
+				p.done(e, len(indices))
+			}
+			return nil, err
+		}
+		groups[e] = append(groups[e], i)
+	}
+	results := make([][]AnalysisResponse, len(requests))
+	var (
+		wg     sync.WaitGroup
+		errMut sync.Mutex
+		errs   []error
+	)
+	for e, indices := range groups {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer p.done(e, len(indices))
+			group := make([]AnalysisRequest, len(indices))
+			for j, i := range indices {
+				group[j] = requests[i]
+			}
+			responses, err := e.katago.Analyze(group)
+			if err != nil {
+				errMut.Lock()
+				errs = append(errs, fmt.Errorf("device %d: %w", e.device, err))
+				errMut.Unlock()
+				return
+			}
+			// A request for several turns has one response for each turn
+			for j, i := range indices {
+				n := group[j].turns()
+				results[i], responses = responses[:n], responses[n:]
+			}
+		}()
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	var responses []AnalysisResponse
+	for _, r := range results {
+		responses = append(responses, r...)
+	}
+	return responses, nil
+}
+
+// AnalyzeStream sends the request to the engine with the fewest requests in flight, as for KataGo.AnalyzeStream
+func (p *Pool) AnalyzeStream(request AnalysisRequest, interim func(AnalysisResponse)) (AnalysisResponse, error) {
+	e, err := p.pick()
+	if err != nil {
+		return AnalysisResponse{}, err
+	}
+	defer p.done(e, 1)
+	return e.katago.AnalyzeStream(request, interim)
+}
+
+// Terminate stops the search of a running query on whichever engine it was sent to
+func (p *Pool) Terminate(id string) error {
+	for _, e := range p.engines {
+		if err := e.katago.Terminate(id); !errors.Is(err, ErrUnknownQuery) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownQuery, id)
+}
+
+// Engines returns the engines of the pool, in the order of the devices
+func (p *Pool) Engines() []*KataGo {
+	engines := make([]*KataGo, len(p.engines))
+	for i, e := range p.engines {
+		engines[i] = e.katago
+	}
+	return engines
+}
+
+// Health returns the state and the statistics of the engine for each device
+func (p *Pool) Health() []DeviceHealth {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	health := make([]DeviceHealth, len(p.engines))
+	for i, e := range p.engines {
+		health[i] = DeviceHealth{
+			Device:   e.device,
+			Running:  e.katago.Running(),
+			InFlight: e.inFlight,
+			Stats:    e.katago.Stats(),
+		}
+	}
+	return health
+}
+
+// Close shuts down all the engines of the pool
+func (p *Pool) Close() error {
+	var errs []error
+	for _, e := range p.engines {
+		if err := e.katago.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("device %d: %w", e.device, err))
+		}
+	}
+	return errors.Join(errs...)
+}
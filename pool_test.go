@@ -0,0 +1,65 @@
+package katago
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseGPUList(t *testing.T) {
+	output := "GPU 0: NVIDIA GeForce RTX 3090 (UUID: GPU-1234)\nGPU 1: NVIDIA GeForce RTX 3090 (UUID: GPU-5678)\n"
+	devices := parseGPUList([]byte(output))
+	if len(devices) != 2 || devices[0] != 0 || devices[1] != 1 {
+		t.Errorf("Expected devices 0 and 1, got %v", devices)
+	}
+	if devices := parseGPUList([]byte("No devices were found\n")); len(devices) != 0 {
+		t.Errorf("Expected no devices, got %v", devices)
+	}
+}
+
+func TestPool(t *testing.T) {
+	pool, err := NewPool("analysis_example.cfg", "model.bin.gz", CUDA, []int{0, 1})
+	if err != nil {
+		t.Fatalf("Failed to start the pool: %v", err)
+	}
+	defer func() {
+		if err := pool.Close(); err != nil {
+			t.Errorf("Failed to close the pool: %v", err)
+		}
+	}()
+	for i, k := range pool.Engines() {
+		if args := strings.Join(k.current().cmd.Args, " "); !strings.Contains(args, fmt.Sprintf("cudaDeviceToUseThread0=%d", i)) {
+			t.Errorf("Expected engine %d to be pinned to its device, got %s", i, args)
+		}
+	}
+
+	var requests []AnalysisRequest
+	for _, vertex := range []Vertex{"C3", "E5", "D4", "C4"} {
+		request := NewRequest9x9()
+		request.MaxVisits = 10
+		request.Moves = []Move{{Black, vertex}}
+		request.AnalyzeTurns = []int{0, 1}
+		requests = append(requests, request)
+	}
+	responses, err := pool.Analyze(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 8 {
+		t.Fatalf("Expected 8 responses, got %d", len(responses))
+	}
+	for i, response := range responses {
+		if response.ID != requests[i/2].ID || response.TurnNumber != i%2 {
+			t.Errorf("Expected response %d to be for %s turn %d, got %s turn %d", i, requests[i/2].ID, i%2, response.ID, response.TurnNumber)
+		}
+	}
+	health := pool.Health()
+	if len(health) != 2 {
+		t.Fatalf("Expected the health of 2 devices, got %v", health)
+	}
+	for _, h := range health {
+		if !h.Running || h.InFlight != 0 || h.Stats.Queries != 2 {
+			t.Errorf("Expected a running engine that answered 2 queries, got %+v", h)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package katago
+
+import (
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Position is a board position, given by the initial stones and the moves that were played from them
+type Position struct {
+	InitialStones      []Move
+	Moves              []Move
+	InitialPlayer      string
+	Rules              Rules
+	Komi               float64
+	WhiteHandicapBonus string
+	BoardXSize         int
+	BoardYSize         int
+}
+
+// Request returns a request for analyzing the position after all the moves have been played
+func (p Position) Request(id string) AnalysisRequest {
+	moves := p.Moves
+	if moves == nil {
+		// KataGo requires the moves to be an array, even when empty
+		moves = []Move{}
+	}
+	return AnalysisRequest{
+		ID:                 id,
+		InitialStones:      p.InitialStones,
+		Moves:              moves,
+		InitialPlayer:      p.InitialPlayer,
+		Rules:              p.Rules,
+		Komi:               p.Komi,
+		BoardXSize:         p.BoardXSize,
+		BoardYSize:         p.BoardYSize,
+		AnalyzeTurns:       []int{len(p.Moves)},
+		WhiteHandicapBonus: p.WhiteHandicapBonus,
+	}
+}
+
+// ToPlay returns the color of the player to move next, "B" or "W", assuming that the players alternate
+func (p Position) ToPlay() string {
+	if len(p.Moves) == 0 {
+		if strings.EqualFold(p.InitialPlayer, "W") {
+			return "W"
+		}
+		return "B"
+	}
+	if strings.EqualFold(string(p.Moves[len(p.Moves)-1].Color), "B") {
+		return "W"
+	}
+	return "B"
+}
+
+// Board replays the position on a board that follows the rules of the position,
+// which also checks that all of the moves are legal
+func (p Position) Board() (*board.Board, error) {
+	rules, err := p.Rules.Detailed()
+	if err != nil {
+		return nil, err
+	}
+	b, err := rules.NewBoard(p.BoardXSize, p.BoardYSize)
+	if err != nil {
+		return nil, err
+	}
+	if p.InitialPlayer != "" {
+		c, err := board.ParseColor(p.InitialPlayer)
+		if err != nil {
+			return nil, err
+		}
+		b.SetToPlay(c)
+	}
+	if err := b.Load(Pairs(p.InitialStones), Pairs(p.Moves)); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
@@ -0,0 +1,23 @@
+package katago
+
+import "testing"
+
+func TestPositionRequest(t *testing.T) {
+	p := Position{
+		Moves:      []Move{{"B", "Q16"}, {"W", "D4"}, {"B", "Q4"}},
+		Rules:      "tromp-taylor",
+		Komi:       7.5,
+		BoardXSize: 19,
+		BoardYSize: 19,
+	}
+	request := p.Request("position1")
+	if request.ID != "position1" || len(request.AnalyzeTurns) != 1 || request.AnalyzeTurns[0] != 3 {
+		t.Errorf("Expected a request for turn 3 with ID position1, got %v", request)
+	}
+	if p.ToPlay() != "W" {
+		t.Errorf("Expected white to play, got %s", p.ToPlay())
+	}
+	if (Position{}).ToPlay() != "B" {
+		t.Errorf("Expected black to play on an empty board")
+	}
+}
@@ -0,0 +1,116 @@
+package katago
+
+import (
+	"errors"
+	"log"
+)
+
+// WithPreemption makes requests stop the running requests that have a lower priority, so that interactive
+// queries get the whole engine even when it is busy with batch jobs. The stopped requests are sent again
+// when no request with a higher priority is running, and Analyze returns the results of the new search.
+// Requests sent with AnalyzeStream can preempt other requests, but are never preempted themselves.
+func WithPreemption() Option {
+	return func(k *KataGo) {
+		k.preemption = true
+	}
+}
+
+// preempt terminates the running requests that have a lower priority than the highest of the given ones,
+// except for the given ones themselves
+func (k *KataGo) preempt(requests []AnalysisRequest) {
+	if len(requests) == 0 {
+		return
+	}
+	priority := requests[0].Priority
+	own := make(map[string]bool)
+	for _, request := range requests {
+		priority = max(priority, request.Priority)
+		own[request.ID] = true
+	}
+	var ids []string
+	k.mut.Lock()
+	for id, q := range k.pending {
+		if q.sent && q.reports == nil && q.priority < priority && !own[id] {
+			q.preempted = true
+			ids = append(ids, id)
+		}
+	}
+	k.mut.Unlock()
+	for _, id := range ids {
+		if err := k.Terminate(id); err != nil {
+			if errors.Is(err, ErrUnknownQuery) {
+				// The query was answered before it could be stopped, so the response is complete
+				k.clearPreempted(id)
+				continue
+			}
+			log.Printf("Failed to preempt %s: %v", id, err)
+		}
+	}
+}
+
+// clearPreempted removes the preempted flag of a query
+func (k *KataGo) clearPreempted(id string) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	if q, ok := k.pending[id]; ok {
+		q.preempted = false
+	}
+	delete(k.preemptedIDs, id)
+}
+
+// takePreempted checks if the response for the ID came from a preempted search, and clears the flag
+func (k *KataGo) takePreempted(id string) bool {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	preempted := k.preemptedIDs[id]
+	delete(k.preemptedIDs, id)
+	return preempted
+}
+
+// waitForPriority waits until no request with a higher priority than the given one is running
+func (k *KataGo) waitForPriority(priority int) error {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	for {
+		select {
+		case <-k.current().done:
+			return ErrEngineCrashed
+		default:
+		}
+		higher := false
+		for _, q := range k.pending {
+			if q.sent && q.priority > priority {
+				higher = true
+				break
+			}
+		}
+		if !higher {
+			return nil
+		}
+		k.finished.Wait()
+	}
+}
+
+// retryPreempted sends a preempted request again when no request with a higher priority is running,
+// and returns the final responses of the new search. The given responses are returned if the request was not preempted.
+func (k *KataGo) retryPreempted(request AnalysisRequest, lines [][]byte) ([][]byte, error) {
+	for k.takePreempted(request.ID) {
+		if err := k.waitForPriority(request.Priority); err != nil {
+			return nil, err
+		}
+		log.Printf("Sending preempted request again: %s", request.ID)
+		ch, err := k.registerTurns(request.ID, request.turns())
+		if err != nil {
+			return nil, err
+		}
+		p, err := k.send(request)
+		if err != nil {
+			k.unregister(request.ID)
+			return nil, err
+		}
+		if lines, err = k.waitTurns(p, ch, request.turns()); err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
@@ -0,0 +1,82 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPreemption(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithPreemption())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	batch := NewRequest9x9()
+	batch.MaxVisits = 10000 // 0.5 seconds
+	type result struct {
+		response AnalysisResponse
+		err      error
+	}
+	done := make(chan result)
+	start := time.Now()
+	go func() {
+		responses, err := k.Analyze([]AnalysisRequest{batch})
+		if err != nil {
+			done <- result{err: err}
+			return
+		}
+		done <- result{response: responses[0]}
+	}()
+	for !k.isPending(batch.ID) {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	interactive := NewRequest9x9()
+	interactive.Moves = []Move{{"B", "E5"}}
+	interactive.AnalyzeTurns = []int{1}
+	interactive.MaxVisits = 4000 // 0.2 seconds
+	interactive.Priority = 10
+	if _, err := k.Analyze([]AnalysisRequest{interactive}); err != nil {
+		t.Fatal(err)
+	}
+	// The batch request was stopped, and is searched again now that the interactive one is done
+	select {
+	case r := <-done:
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		if r.response.RootInfo.Visits != batch.MaxVisits {
+			t.Errorf("Expected the full search of the batch request, got %d visits", r.response.RootInfo.Visits)
+		}
+		if elapsed := time.Since(start); elapsed < 700*time.Millisecond {
+			t.Errorf("Expected the batch request to be searched again from the start, it took %v", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a response for the batch request")
+	}
+}
+
+func TestPreemptLowerOnly(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithPreemption())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	r1, r2 := NewRequest9x9(), NewRequest9x9()
+	r1.MaxVisits, r2.MaxVisits = 100, 100
+	r2.Priority = 5
+	// Requests in the same call with different priorities do not preempt each other
+	responses, err := k.Analyze([]AnalysisRequest{r1, r2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.takePreempted(r1.ID) {
+		t.Errorf("Expected the request with the lower priority to run")
+	}
+	for _, response := range responses {
+		if response.RootInfo.Visits != 100 {
+			t.Errorf("Expected 100 visits, got %d", response.RootInfo.Visits)
+		}
+	}
+}
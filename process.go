@@ -0,0 +1,182 @@
+package katago
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// process is one running KataGo process. An engine has one current process, which the requests are sent to,
+// and SwapModel replaces it with a new one while the old one finishes the queries that were sent to it.
+type process struct {
+	// cmd is the command that started KataGo, or nil if the engine is connected to a sidecar with conn
+	cmd    *exec.Cmd
+	conn   net.Conn
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	stderr *bufio.Scanner
+	// model is the model file that the process was started with
+	model string
+	// done is closed when KataGo stops sending output, and readErr is the reason
+	done    chan struct{}
+	readErr error
+	// devices are the GPUs or OpenCL devices that KataGo reported using, protected by the mut of the engine
+	devices []Device
+	// started is closed when KataGo is ready to handle requests, or has stopped writing to stderr,
+	// and ready is set before it is closed if KataGo said that it is ready
+	started     chan struct{}
+	startedOnce sync.Once
+	ready       bool
+	// stderrDone is closed when all of stderr has been read
+	stderrDone chan struct{}
+}
+
+// current returns the process that new requests are sent to
+func (k *KataGo) current() *process {
+	return k.proc.Load()
+}
+
+// start starts a KataGo process with the config file and the options of the engine, and the given model
+func (k *KataGo) start(modelFile string) (*process, error) {
+	cmd := exec.Command(k.binary, "analysis", "-config", k.configFile, "-model", modelFile)
+	if len(k.overrides) > 0 {
+		cmd.Args = append(cmd.Args, "-override-config", formatOverrides(k.overrides))
+	}
+	if k.humanModel != "" {
+		cmd.Args = append(cmd.Args, "-human-model", k.humanModel)
+	}
+	if k.analysisThreads > 0 {
+		cmd.Args = append(cmd.Args, "-analysis-threads", strconv.Itoa(k.analysisThreads))
+	}
+	if k.env != nil {
+		cmd.Env = append(os.Environ(), k.env...)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get stdin: %v", ErrEngineNotStarted, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get stdout: %v", ErrEngineNotStarted, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to get stderr: %v", ErrEngineNotStarted, err)
+	}
+	p := &process{
+		cmd:        cmd,
+		stdin:      stdin,
+		stdout:     bufio.NewReader(stdout),
+		stderr:     bufio.NewScanner(stderr),
+		model:      modelFile,
+		done:       make(chan struct{}),
+		started:    make(chan struct{}),
+		stderrDone: make(chan struct{}),
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrEngineNotStarted, err)
+	}
+
+	go k.readStderr(p)
+	go k.readLoop(p)
+
+	return p, nil
+}
+
+// readStderr reads from KataGo's stderr for logging purposes, and looks for the devices that are used
+func (k *KataGo) readStderr(p *process) {
+	defer close(p.stderrDone)
+	defer p.startedOnce.Do(func() { close(p.started) })
+	for p.stderr.Scan() {
+		line := p.stderr.Text()
+		log.Printf("KataGo stderr: %s", line)
+		if device, ok := parseDevice(line); ok {
+			k.mut.Lock()
+			p.devices = append(p.devices, device)
+			k.mut.Unlock()
+		}
+		if v, ok := parseStderrVersion(line); ok {
+			k.mut.Lock()
+			if k.version == nil {
+				k.version = &v
+			}
+			k.mut.Unlock()
+		}
+		if strings.HasPrefix(line, "Started, ready to begin handling requests") {
+			p.startedOnce.Do(func() {
+				p.ready = true
+				close(p.started)
+			})
+		}
+	}
+	if err := p.stderr.Err(); err != nil {
+		log.Printf("Error reading stderr: %v", err)
+	}
+}
+
+// readLoop reads the lines from KataGo and delivers each one to the caller that registered its ID
+func (k *KataGo) readLoop(p *process) {
+	defer func() {
+		close(p.done)
+		k.mut.Lock()
+		k.finished.Broadcast()
+		k.mut.Unlock()
+	}()
+	for {
+		line, err := p.stdout.ReadBytes('\n')
+		if len(line) > 0 {
+			k.deliver(line)
+		}
+		if err != nil {
+			p.readErr = err
+			return
+		}
+	}
+}
+
+// running checks if the process is still running and answering
+func (p *process) running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// close closes the input of the process, which makes KataGo finish the running queries and exit,
+// and waits for it to exit
+func (p *process) close() error {
+	if p.conn != nil {
+		return p.disconnect()
+	}
+	if err := p.stdin.Close(); err != nil {
+		return fmt.Errorf("failed to close KataGo stdin: %v", err)
+	}
+	// The output must be read before waiting
+	<-p.done
+	<-p.stderrDone
+	return p.cmd.Wait()
+}
+
+// kill stops the process right away, and waits for it to exit
+func (p *process) kill() {
+	if p.conn != nil {
+		p.conn.Close()
+		<-p.done
+		return
+	}
+	if err := p.cmd.Process.Kill(); err != nil {
+		log.Printf("Failed to kill KataGo: %v", err)
+	}
+	<-p.done
+	<-p.stderrDone
+	p.cmd.Wait()
+}
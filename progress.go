@@ -0,0 +1,68 @@
+package katago
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// WithProgress calls fn with each report that KataGo sends during the search of a query, with the ID of the
+// query, the visits so far and the results so far, so that command line tools can show progress bars, and
+// servers can stop searches that have enough visits at a soft deadline. Requests that do not set
+// ReportDuringSearchEvery are sent with DefaultReportInterval. The function is called from its own goroutine,
+// in order, and it may call other methods, like Terminate and ETA. Reports are skipped if it falls behind.
+func WithProgress(fn func(id string, visitsDone int, snapshot AnalysisResponse)) Option {
+	return func(k *KataGo) {
+		k.progress = fn
+	}
+}
+
+// startProgress starts the goroutine that calls the progress function, if there is one
+func (k *KataGo) startProgress() {
+	if k.progress == nil {
+		return
+	}
+	k.progressReports = make(chan []byte, reportBuffer)
+	k.progressDone = make(chan struct{})
+	go func() {
+		for {
+			select {
+			case line := <-k.progressReports:
+				var response AnalysisResponse
+				if err := json.Unmarshal(line, &response); err != nil {
+					log.Printf("Failed to unmarshal interim response: %v", err)
+					continue
+				}
+				k.progress(response.ID, response.RootInfo.Visits, response)
+			case <-k.progressDone:
+				return
+			}
+		}
+	}()
+}
+
+// stopProgress stops the goroutine that calls the progress function
+func (k *KataGo) stopProgress() {
+	if k.progressDone != nil {
+		k.progressOnce.Do(func() { close(k.progressDone) })
+	}
+}
+
+// ETA estimates how long a running query needs before it reaches MaxVisits for all of its turns, from the
+// visits so far and the time since it was sent. It returns false if the query is not running, if its request
+// does not set MaxVisits, or if KataGo has not reported any visits for it yet.
+func (k *KataGo) ETA(id string) (time.Duration, bool) {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	q, ok := k.pending[id]
+	if !ok || !q.sent || q.maxVisits <= 0 {
+		return 0, false
+	}
+	done := q.visits + q.latestVisits
+	if done <= 0 {
+		return 0, false
+	}
+	remaining := max(q.remaining*q.maxVisits-q.latestVisits, 0)
+	perVisit := time.Since(q.sentAt) / time.Duration(done)
+	return perVisit * time.Duration(remaining), true
+}
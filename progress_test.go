@@ -0,0 +1,76 @@
+package katago
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithProgress(t *testing.T) {
+	var (
+		mut    sync.Mutex
+		visits []int
+		ids    = make(map[string]bool)
+		etas   int
+		k      *KataGo
+	)
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithProgress(func(id string, visitsDone int, snapshot AnalysisResponse) {
+		mut.Lock()
+		defer mut.Unlock()
+		ids[id] = true
+		visits = append(visits, visitsDone)
+		if !snapshot.IsDuringSearch || snapshot.RootInfo.Visits != visitsDone {
+			t.Errorf("Expected a report during the search with %d visits, got %+v", visitsDone, snapshot.RootInfo)
+		}
+		if eta, ok := k.ETA(id); ok && eta > 0 {
+			etas++
+		}
+	}))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	request := NewRequest9x9()
+	// The search takes about a second, and is reported every DefaultReportInterval
+	request.MaxVisits = 20000
+	if _, err := k.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Fatal(err)
+	}
+	mut.Lock()
+	defer mut.Unlock()
+	if len(visits) == 0 || !ids[request.ID] || len(ids) != 1 {
+		t.Fatalf("Expected reports for %s, got %v for %v", request.ID, visits, ids)
+	}
+	for i := 1; i < len(visits); i++ {
+		if visits[i] < visits[i-1] {
+			t.Errorf("Expected the visits to grow, got %v", visits)
+		}
+	}
+	if etas == 0 {
+		t.Error("Expected an ETA during the search")
+	}
+	if _, ok := k.ETA(request.ID); ok {
+		t.Error("Expected no ETA for a finished query")
+	}
+}
+
+func TestETA(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	if _, ok := k.ETA("missing"); ok {
+		t.Error("Expected no ETA for an unknown query")
+	}
+	k.mut.Lock()
+	k.pending["q"] = &query{sent: true, maxVisits: 100, latestVisits: 25, remaining: 2, sentAt: time.Now().Add(-time.Second)}
+	k.mut.Unlock()
+	eta, ok := k.ETA("q")
+	if !ok || eta < 6900*time.Millisecond || eta > 7100*time.Millisecond {
+		t.Errorf("Expected an ETA of about 7s for 175 of 200 visits left after 25 visits in 1s, got %v", eta)
+	}
+	k.mut.Lock()
+	delete(k.pending, "q")
+	k.mut.Unlock()
+}
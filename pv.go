@@ -0,0 +1,120 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// ExploredDepth returns how many moves of the principal variation were searched with at least minVisits visits,
+// which shows how far the PV can be trusted. IncludePVVisits must be set in the request.
+func (m MoveInfoExt) ExploredDepth(minVisits int) int {
+	depth := 0
+	for _, visits := range m.PVVisits {
+		if visits < minVisits {
+			break
+		}
+		depth++
+	}
+	return depth
+}
+
+// ExploredPV returns the part of the principal variation that was searched with at least minVisits visits
+func (m MoveInfoExt) ExploredPV(minVisits int) []string {
+	return m.PV[:min(len(m.PV), m.ExploredDepth(minVisits))]
+}
+
+// SetAnalysisPVLen sets the maximum length of the principal variations that KataGo reports for this request.
+// Longer variations take more space in the responses, and the last moves are often barely searched.
+func (r *AnalysisRequest) SetAnalysisPVLen(length int) {
+	r.SetOverride("analysisPVLen", length)
+}
+
+// ExpandedPV is the position at the end of the principal variation of a candidate move, with its analysis,
+// for showing why KataGo suggests the move
+type ExpandedPV struct {
+	// Move is the candidate move, and PV the moves that were played from the position, starting with it
+	Move string
+	PV   []Move
+	// Position is the position after the PV, and Board the board with the stones after it, for drawing
+	Position Position
+	Board    *board.Board
+	// Analysis is the analysis of the position after the PV
+	Analysis AnalysisResponse
+}
+
+// ExpandPV plays the principal variation of a candidate move of the position on a board, and analyzes the
+// position at the end of it with the given number of visits. To stop where the PV was barely searched,
+// shorten info.PV with ExploredPV first. A candidate without a PV plays only the move.
+func (k *KataGo) ExpandPV(p Position, info MoveInfoExt, visits int) (ExpandedPV, error) {
+	expanded, err := k.ExpandPVs(p, []MoveInfoExt{info}, visits)
+	if err != nil {
+		return ExpandedPV{}, err
+	}
+	return expanded[0], nil
+}
+
+// ExpandPVs expands the principal variations of several candidate moves of the position, like the ones from
+// TopMoves, as for ExpandPV. All the positions are sent to KataGo at once.
+func (k *KataGo) ExpandPVs(p Position, infos []MoveInfoExt, visits int) ([]ExpandedPV, error) {
+	expanded := make([]ExpandedPV, len(infos))
+	requests := make([]AnalysisRequest, len(infos))
+	for i, info := range infos {
+		e, err := playPV(p, info)
+		if err != nil {
+			return nil, err
+		}
+		expanded[i] = e
+		requests[i] = e.Position.Request(k.newID("pv"))
+		requests[i].MaxVisits = visits
+	}
+	if len(requests) == 0 {
+		return nil, nil
+	}
+	responses, err := k.Analyze(requests)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) != len(requests) {
+		return nil, errors.New("expected one response for each PV")
+	}
+	for i := range expanded {
+		expanded[i].Analysis = responses[i]
+	}
+	return expanded, nil
+}
+
+// playPV plays the principal variation of a candidate move on the board of the position
+func playPV(p Position, info MoveInfoExt) (ExpandedPV, error) {
+	b, err := p.Board()
+	if err != nil {
+		return ExpandedPV{}, err
+	}
+	pv := info.PV
+	if len(pv) == 0 {
+		pv = []string{info.Move}
+	}
+	color := Color(p.ToPlay())
+	e := ExpandedPV{Move: info.Move, Position: p, Board: b}
+	for i, vertex := range pv {
+		move, err := NewMove(string(color), vertex)
+		if err != nil {
+			return ExpandedPV{}, fmt.Errorf("PV of %s: %v", info.Move, err)
+		}
+		c, _ := board.ParseColor(string(color))
+		point, err := b.ParseVertex(vertex)
+		if err == nil {
+			err = b.Play(c, point)
+		}
+		if err != nil {
+			return ExpandedPV{}, fmt.Errorf("PV of %s, move %d at %s: %w", info.Move, i+1, strings.ToUpper(vertex), err)
+		}
+		e.PV = append(e.PV, move)
+		color = color.Opponent()
+	}
+	e.Position.Moves = append(slices.Clone(p.Moves), e.PV...)
+	return e, nil
+}
@@ -0,0 +1,114 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestExploredDepth(t *testing.T) {
+	m := MoveInfoExt{PV: []string{"D4", "Q16", "C3", "D3"}, PVVisits: []int{100, 40, 9, 10}}
+	if depth := m.ExploredDepth(10); depth != 2 {
+		t.Errorf("Expected a depth of 2, got %d", depth)
+	}
+	if pv := m.ExploredPV(1); len(pv) != 4 {
+		t.Errorf("Expected the whole PV, got %v", pv)
+	}
+	if pv := (MoveInfoExt{PV: []string{"D4"}}).ExploredPV(1); len(pv) != 0 {
+		t.Errorf("Expected no explored moves without PV visits, got %v", pv)
+	}
+}
+
+func TestIncludePVVisits(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.MaxVisits = 50
+	request.IncludePVVisits = true
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	best := responses[0].MoveInfos[0]
+	if len(best.PVVisits) != len(best.PV) || len(best.PVEdgeVisits) != len(best.PV) || best.PVVisits[0] != best.Visits {
+		t.Errorf("Expected visits for each PV move, got %v for %v", best.PVVisits, best.PV)
+	}
+}
+
+func TestAnalysisPVLen(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithAnalysisPVLen(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.MaxVisits = 50
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv := responses[0].MoveInfos[0].PV; len(pv) > 3 {
+		t.Errorf("Expected at most 3 PV moves, got %v", pv)
+	}
+	request.ID = "long"
+	request.SetAnalysisPVLen(8)
+	responses, err = k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pv := responses[0].MoveInfos[0].PV; len(pv) <= 3 {
+		t.Errorf("Expected more than 3 PV moves, got %v", pv)
+	}
+}
+
+func TestExpandPVs(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{Black, "E5"}}
+	request := p.Request("pv")
+	request.MaxVisits = 50
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	top := responses[0].TopMoves(2)
+	expanded, err := k.ExpandPVs(p, top, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expanded) != 2 {
+		t.Fatalf("Expected 2 expanded PVs, got %d", len(expanded))
+	}
+	for i, e := range expanded {
+		info := top[i]
+		if e.Move != info.Move || len(e.PV) != len(info.PV) || e.PV[0] != (Move{White, Vertex(info.Move)}) {
+			t.Errorf("Expected the PV %v of %s, starting with white, got %v", info.PV, info.Move, e.PV)
+		}
+		if len(e.Position.Moves) != 1+len(info.PV) || e.Analysis.TurnNumber != len(e.Position.Moves) {
+			t.Errorf("Expected the analysis of the position after the PV, got turn %d", e.Analysis.TurnNumber)
+		}
+		point, err := e.Board.ParseVertex(info.Move)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c := e.Board.At(point); c != board.White {
+			t.Errorf("Expected a white stone at %s, got %v", info.Move, c)
+		}
+	}
+	if len(p.Moves) != 1 {
+		t.Errorf("Expected the position to be left as it was, got %v", p.Moves)
+	}
+
+	single, err := k.ExpandPV(p, MoveInfoExt{Move: "C3"}, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(single.PV) != 1 || single.PV[0].Vertex != "C3" {
+		t.Errorf("Expected only the move without a PV, got %v", single.PV)
+	}
+	if _, err := k.ExpandPV(p, MoveInfoExt{Move: "C3", PV: []string{"C3", "C3"}}, 20); !errors.Is(err, board.ErrOccupied) {
+		t.Errorf("Expected ErrOccupied for an illegal PV, got %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQueueFull is returned when the queue limit is reached and the engine does not wait for room
+var ErrQueueFull = errors.New("too many requests are waiting for KataGo")
+
+// QueueFullPolicy decides what happens to a request when the queue limit has been reached
+type QueueFullPolicy int
+
+// Queue policies
+const (
+	// QueueBlock waits until enough of the earlier requests have been answered
+	QueueBlock QueueFullPolicy = iota
+	// QueueFailFast returns ErrQueueFull right away
+	QueueFailFast
+)
+
+// queueLimit counts the requests that have been sent to KataGo and are not yet answered
+type queueLimit struct {
+	mut      sync.Mutex
+	cond     *sync.Cond
+	limit    int
+	inFlight int
+	policy   QueueFullPolicy
+}
+
+// WithQueueLimit limits how many requests can be waiting for KataGo at the same time, counting both the
+// requests that KataGo is searching and the ones that it has queued. This keeps a burst of requests from
+// building up a long backlog in front of one GPU. A single call with more requests than the limit is
+// allowed when nothing else is waiting.
+func WithQueueLimit(limit int, policy QueueFullPolicy) Option {
+	return func(k *KataGo) {
+		k.queue = newQueueLimit(limit, policy)
+	}
+}
+
+// newQueueLimit creates an empty queue limit
+func newQueueLimit(limit int, policy QueueFullPolicy) *queueLimit {
+	q := &queueLimit{limit: limit, policy: policy}
+	q.cond = sync.NewCond(&q.mut)
+	return q
+}
+
+// acquire reserves room for n requests, and either waits for room or fails, depending on the policy
+func (q *queueLimit) acquire(n int) error {
+	if q == nil || n == 0 {
+		return nil
+	}
+	q.mut.Lock()
+	defer q.mut.Unlock()
+	for q.inFlight > 0 && q.inFlight+n > q.limit {
+		if q.policy == QueueFailFast {
+			return fmt.Errorf("%w: %d of %d", ErrQueueFull, q.inFlight, q.limit)
+		}
+		q.cond.Wait()
+	}
+	q.inFlight += n
+	return nil
+}
+
+// release frees the room of n answered requests
+func (q *queueLimit) release(n int) {
+	if q == nil || n == 0 {
+		return
+	}
+	q.mut.Lock()
+	q.inFlight -= n
+	q.mut.Unlock()
+	q.cond.Broadcast()
+}
+
+// QueueLength returns how many requests are waiting for KataGo, when WithQueueLimit is used
+func (k *KataGo) QueueLength() int {
+	if k.queue == nil {
+		return 0
+	}
+	k.queue.mut.Lock()
+	defer k.queue.mut.Unlock()
+	return k.queue.inFlight
+}
@@ -0,0 +1,60 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueueLimit(t *testing.T) {
+	q := newQueueLimit(2, QueueFailFast)
+	if err := q.acquire(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.acquire(1); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Expected ErrQueueFull, got %v", err)
+	}
+	q.release(2)
+	if err := q.acquire(3); err != nil {
+		t.Errorf("Expected a large call to be allowed when the queue is empty, got %v", err)
+	}
+	var none *queueLimit
+	if err := none.acquire(100); err != nil {
+		t.Errorf("Expected no limit, got %v", err)
+	}
+}
+
+func TestQueueBlock(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithQueueLimit(1, QueueBlock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanupKataGo(t, k)
+	slow := NewRequest9x9()
+	slow.MaxVisits = 4000 // 0.2 seconds
+	done := make(chan error)
+	go func() {
+		_, err := k.Analyze([]AnalysisRequest{slow})
+		done <- err
+	}()
+	for k.QueueLength() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	fast := NewRequest9x9()
+	fast.MaxVisits = 10
+	if _, err := k.Analyze([]AnalysisRequest{fast}); err != nil {
+		t.Fatal(err)
+	}
+	// The fast request had to wait for the slow one
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	default:
+		t.Errorf("Expected the slow request to be answered first")
+	}
+	if n := k.QueueLength(); n != 0 {
+		t.Errorf("Expected an empty queue, got %d", n)
+	}
+}
@@ -0,0 +1,182 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rank is a player strength, where 1 is 1 dan, 9 is 9 dan, 0 is 1 kyu and -29 is 30 kyu
+type Rank int
+
+// Common ranks
+const (
+	Rank30k Rank = -29
+	Rank20k Rank = -19
+	Rank10k Rank = -9
+	Rank1k  Rank = 0
+	Rank1d  Rank = 1
+	Rank9d  Rank = 9
+)
+
+// Kyu returns a kyu rank, from 1 to 30
+func Kyu(k int) Rank {
+	return Rank(1 - k)
+}
+
+// Dan returns a dan rank, from 1 to 9
+func Dan(d int) Rank {
+	return Rank(d)
+}
+
+// String returns the rank as a string, like "5k" or "3d"
+func (r Rank) String() string {
+	if r > 0 {
+		return strconv.Itoa(int(r)) + "d"
+	}
+	return strconv.Itoa(1-int(r)) + "k"
+}
+
+// HumanSLProfile returns the name of the KataGo human SL profile for this rank, like "rank_5k".
+// The human SL model supports ranks from 20k to 9d.
+func (r Rank) HumanSLProfile() string {
+	return "rank_" + r.String()
+}
+
+// ParseRank parses a rank like "5k", "3d" or "12 kyu"
+func ParseRank(s string) (Rank, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "rank_")
+	s = strings.ReplaceAll(s, " ", "")
+	var suffix string
+	for _, candidate := range []string{"kyu", "dan", "k", "d"} {
+		if strings.HasSuffix(s, candidate) {
+			suffix = candidate
+			s = strings.TrimSuffix(s, candidate)
+			break
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || suffix == "" {
+		return 0, fmt.Errorf("invalid rank: %q", s+suffix)
+	}
+	switch suffix {
+	case "k", "kyu":
+		if n < 1 || n > 30 {
+			return 0, fmt.Errorf("kyu rank out of range: %d", n)
+		}
+		return Kyu(n), nil
+	default:
+		if n < 1 || n > 9 {
+			return 0, fmt.Errorf("dan rank out of range: %d", n)
+		}
+		return Dan(n), nil
+	}
+}
+
+// rankCalibration maps the average points lost per move to a rank.
+// The values are rough estimates, collected from reviews of amateur games.
+var rankCalibration = []struct {
+	pointsLost float64
+	rank       Rank
+}{
+	{0.4, Rank9d},
+	{0.8, Dan(5)},
+	{1.2, Rank1d},
+	{1.6, Kyu(3)},
+	{2.1, Kyu(6)},
+	{3.0, Rank10k},
+	{4.2, Kyu(15)},
+	{5.5, Rank20k},
+	{8.0, Rank30k},
+}
+
+// maxCountedPointsLost caps the points lost for a single move, so that one lost group does not dominate the estimate
+const maxCountedPointsLost = 20.0
+
+// RankEstimate is the estimated strength of a player
+type RankEstimate struct {
+	Rank              Rank
+	Moves             int
+	AveragePointsLost float64
+}
+
+// rankFromPointsLost interpolates the rank calibration table
+func rankFromPointsLost(pointsLost float64) Rank {
+	first, last := rankCalibration[0], rankCalibration[len(rankCalibration)-1]
+	if pointsLost <= first.pointsLost {
+		return first.rank
+	}
+	if pointsLost >= last.pointsLost {
+		return last.rank
+	}
+	for i := 1; i < len(rankCalibration); i++ {
+		lo, hi := rankCalibration[i-1], rankCalibration[i]
+		if pointsLost <= hi.pointsLost {
+			f := (pointsLost - lo.pointsLost) / (hi.pointsLost - lo.pointsLost)
+			return Rank(math.Round(float64(lo.rank) + f*float64(hi.rank-lo.rank)))
+		}
+	}
+	return last.rank
+}
+
+// EstimateRank estimates the strength of the player that played the given moves, which may come from several games
+func EstimateRank(moves []GradedMove) (RankEstimate, error) {
+	if len(moves) == 0 {
+		return RankEstimate{}, errors.New("no graded moves to estimate a rank from")
+	}
+	total := 0.0
+	for _, move := range moves {
+		total += math.Min(move.PointsLost, maxCountedPointsLost)
+	}
+	average := total / float64(len(moves))
+	return RankEstimate{
+		Rank:              rankFromPointsLost(average),
+		Moves:             len(moves),
+		AveragePointsLost: average,
+	}, nil
+}
+
+// EstimateRanks estimates the strength of both players of a game, keyed by color ("B" or "W")
+func EstimateRanks(graded []GradedMove) map[string]RankEstimate {
+	byColor := make(map[string][]GradedMove)
+	for _, move := range graded {
+		byColor[move.Color] = append(byColor[move.Color], move)
+	}
+	estimates := make(map[string]RankEstimate, len(byColor))
+	for color, moves := range byColor {
+		if estimate, err := EstimateRank(moves); err == nil {
+			estimates[color] = estimate
+		}
+	}
+	return estimates
+}
+
+// minMoveProbability is used instead of zero when the human SL model gave a played move no probability at all
+const minMoveProbability = 1e-6
+
+// EstimateRankFromHumanPolicy estimates the rank of a player by using the output of the KataGo human SL model.
+// For each rank profile that was queried, probabilities holds the probability that the model gave to each of the
+// moves the player actually played. The rank that explains the played moves best is returned.
+func EstimateRankFromHumanPolicy(probabilities map[Rank][]float64) (Rank, error) {
+	if len(probabilities) == 0 {
+		return 0, errors.New("no human SL model probabilities to estimate a rank from")
+	}
+	var (
+		bestRank      Rank
+		bestLogLikely = math.Inf(-1)
+	)
+	for rank, moveProbabilities := range probabilities {
+		logLikely := 0.0
+		for _, p := range moveProbabilities {
+			logLikely += math.Log(math.Max(p, minMoveProbability))
+		}
+		// Break ties on the lower rank, so that the result does not depend on the map order
+		if logLikely > bestLogLikely || (logLikely == bestLogLikely && rank < bestRank) {
+			bestRank, bestLogLikely = rank, logLikely
+		}
+	}
+	return bestRank, nil
+}
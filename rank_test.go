@@ -0,0 +1,69 @@
+package katago
+
+import "testing"
+
+func TestParseRank(t *testing.T) {
+	cases := map[string]Rank{
+		"5k":       Kyu(5),
+		"1k":       Rank1k,
+		"3d":       Dan(3),
+		"12 kyu":   Kyu(12),
+		"rank_20k": Rank20k,
+	}
+	for s, expected := range cases {
+		rank, err := ParseRank(s)
+		if err != nil {
+			t.Errorf("Failed to parse rank %q: %v", s, err)
+			continue
+		}
+		if rank != expected {
+			t.Errorf("Expected %v for %q, got %v", expected, s, rank)
+		}
+	}
+	for _, s := range []string{"", "0k", "10d", "5x"} {
+		if _, err := ParseRank(s); err == nil {
+			t.Errorf("Expected an error when parsing %q", s)
+		}
+	}
+	if p := Kyu(5).HumanSLProfile(); p != "rank_5k" {
+		t.Errorf("Expected rank_5k, got %s", p)
+	}
+}
+
+func TestEstimateRank(t *testing.T) {
+	strong := []GradedMove{{PointsLost: 0.2}, {PointsLost: 0.6}}
+	weak := []GradedMove{{PointsLost: 4}, {PointsLost: 7}}
+	strongEstimate, err := EstimateRank(strong)
+	if err != nil {
+		t.Fatalf("Failed to estimate rank: %v", err)
+	}
+	weakEstimate, err := EstimateRank(weak)
+	if err != nil {
+		t.Fatalf("Failed to estimate rank: %v", err)
+	}
+	if strongEstimate.Rank <= weakEstimate.Rank {
+		t.Errorf("Expected the strong player (%v) to be ranked above the weak player (%v)", strongEstimate.Rank, weakEstimate.Rank)
+	}
+	if _, err := EstimateRank(nil); err == nil {
+		t.Errorf("Expected an error when estimating a rank from no moves")
+	}
+	estimates := EstimateRanks([]GradedMove{{Color: "B", PointsLost: 1}, {Color: "W", PointsLost: 3}})
+	if len(estimates) != 2 {
+		t.Errorf("Expected estimates for both players, got %d", len(estimates))
+	}
+}
+
+func TestEstimateRankFromHumanPolicy(t *testing.T) {
+	probabilities := map[Rank][]float64{
+		Kyu(10): {0.05, 0.1, 0.02},
+		Kyu(1):  {0.3, 0.4, 0.2},
+		Dan(5):  {0.1, 0.2, 0.0},
+	}
+	rank, err := EstimateRankFromHumanPolicy(probabilities)
+	if err != nil {
+		t.Fatalf("Failed to estimate rank: %v", err)
+	}
+	if rank != Kyu(1) {
+		t.Errorf("Expected 1k, got %v", rank)
+	}
+}
@@ -0,0 +1,80 @@
+package katago
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit makes the client a polite tenant of an engine that is shared with others
+type RateLimit struct {
+	// RequestsPerSecond is how many requests are sent each second on average, or 0 for no limit
+	RequestsPerSecond float64
+	// Burst is how many requests can be sent at once after an idle period, and is at least 1
+	Burst int
+	// MaxVisits is how many visits the requests that are waiting for a response can ask for together,
+	// or 0 for no limit. Requests without MaxVisits count as SchedulerDefaultVisits.
+	MaxVisits int
+}
+
+// rateLimiter is a token bucket for the requests, and a budget for the visits in flight
+type rateLimiter struct {
+	mut      sync.Mutex
+	cond     *sync.Cond
+	limit    RateLimit
+	tokens   float64
+	last     time.Time
+	inFlight float64
+}
+
+// WithRateLimit limits how fast requests are sent to KataGo, and how many visits they can ask for at the
+// same time. Calls wait until they are allowed to send. A single call with more visits than the limit is
+// allowed when nothing else is waiting for a response.
+func WithRateLimit(limit RateLimit) Option {
+	return func(k *KataGo) {
+		limit.Burst = max(1, limit.Burst)
+		k.limiter = &rateLimiter{limit: limit, tokens: float64(limit.Burst), last: time.Now()}
+		k.limiter.cond = sync.NewCond(&k.limiter.mut)
+	}
+}
+
+// acquire waits until the requests can be sent, and returns the visits that must be released afterwards
+func (l *rateLimiter) acquire(requests []AnalysisRequest) float64 {
+	if l == nil || len(requests) == 0 {
+		return 0
+	}
+	visits := cost(requests)
+	l.mut.Lock()
+	if l.limit.MaxVisits > 0 {
+		for l.inFlight > 0 && l.inFlight+visits > float64(l.limit.MaxVisits) {
+			l.cond.Wait()
+		}
+		l.inFlight += visits
+	} else {
+		visits = 0
+	}
+	var wait time.Duration
+	if rate := l.limit.RequestsPerSecond; rate > 0 {
+		// The tokens are reserved right away, and the call waits until the bucket would have had them
+		now := time.Now()
+		l.tokens = min(float64(l.limit.Burst), l.tokens+now.Sub(l.last).Seconds()*rate)
+		l.last = now
+		l.tokens -= float64(len(requests))
+		if l.tokens < 0 {
+			wait = time.Duration(-l.tokens / rate * float64(time.Second))
+		}
+	}
+	l.mut.Unlock()
+	time.Sleep(wait)
+	return visits
+}
+
+// release frees the visits of answered requests
+func (l *rateLimiter) release(visits float64) {
+	if l == nil || visits == 0 {
+		return
+	}
+	l.mut.Lock()
+	l.inFlight -= visits
+	l.mut.Unlock()
+	l.cond.Broadcast()
+}
@@ -0,0 +1,55 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimitRequests(t *testing.T) {
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithRateLimit(RateLimit{RequestsPerSecond: 20, Burst: 1}))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+
+	start := time.Now()
+	for range 5 {
+		request := NewRequest9x9()
+		request.MaxVisits = 1
+		if _, err := katago.Analyze([]AnalysisRequest{request}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first request uses the burst, and the other four wait 50ms each
+	if elapsed := time.Since(start); elapsed < 190*time.Millisecond {
+		t.Errorf("Expected the requests to take at least 200ms, got %v", elapsed)
+	}
+}
+
+func TestRateLimitVisits(t *testing.T) {
+	k := &KataGo{}
+	WithRateLimit(RateLimit{MaxVisits: 150})(k)
+	l := k.limiter
+	request := NewRequest9x9()
+	request.MaxVisits = 100
+	visits := l.acquire([]AnalysisRequest{request})
+	acquired := make(chan float64)
+	go func() {
+		acquired <- l.acquire([]AnalysisRequest{request})
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected the second request to wait for the visits of the first")
+	case <-time.After(50 * time.Millisecond):
+	}
+	l.release(visits)
+	select {
+	case second := <-acquired:
+		l.release(second)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the second request to be sent after the first was answered")
+	}
+	if l.inFlight != 0 {
+		t.Errorf("Expected no visits in flight, got %v", l.inFlight)
+	}
+}
@@ -0,0 +1,263 @@
+package remote
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// Client sends analysis requests to a remote Server over one connection
+type Client struct {
+	conn    net.Conn
+	scanner *bufio.Scanner
+	out     *lineWriter
+	nextID  atomic.Uint64
+	mut     sync.Mutex
+	pending map[string]*call
+	// done is closed when the connection is closed, and err is the reason
+	done chan struct{}
+	err  error
+}
+
+// call is a request that is waiting for its responses
+type call struct {
+	turns     int
+	responses []katago.AnalysisResponse
+	interim   func(katago.AnalysisResponse)
+	finished  chan error
+}
+
+// reply is a line from the server, which is either a response or a failure
+type reply struct {
+	katago.AnalysisResponse
+	TerminateID string `json:"terminateId"`
+	Error       string `json:"error"`
+	Kind        string `json:"kind"`
+}
+
+// Dial connects to a server with TLS, and sends the token. If config is nil, the system roots are trusted.
+func Dial(addr, token string, config *tls.Config) (*Client, error) {
+	dialer := &net.Dialer{Timeout: DefaultHandshakeTimeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", addr, err)
+	}
+	c, err := NewClient(conn, token)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient sends the token over a connection that is already open, like a plain TCP connection on
+// a trusted network, and returns a client for it once the server has accepted the token
+func NewClient(conn net.Conn, token string) (*Client, error) {
+	c := &Client{
+		conn:    conn,
+		scanner: newScanner(conn),
+		out:     &lineWriter{w: conn},
+		pending: make(map[string]*call),
+		done:    make(chan struct{}),
+	}
+	conn.SetDeadline(time.Now().Add(DefaultHandshakeTimeout))
+	if err := c.out.write(hello{Token: token}); err != nil {
+		return nil, fmt.Errorf("failed to send the hello: %v", err)
+	}
+	if !c.scanner.Scan() {
+		if err := c.scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read the answer to the hello: %v", err)
+		}
+		return nil, errors.New("the server closed the connection")
+	}
+	var w welcome
+	if err := json.Unmarshal(c.scanner.Bytes(), &w); err != nil {
+		return nil, fmt.Errorf("invalid answer to the hello: %v", err)
+	}
+	if !w.OK {
+		return nil, fmt.Errorf("the server did not accept the hello: %s", w.Error)
+	}
+	conn.SetDeadline(time.Time{})
+	go c.readLoop()
+	return c, nil
+}
+
+// readLoop reads the lines from the server and delivers each one to the call with its ID
+func (c *Client) readLoop() {
+	for c.scanner.Scan() {
+		var r reply
+		if err := json.Unmarshal(c.scanner.Bytes(), &r); err != nil {
+			log.Printf("Failed to unmarshal a remote response: %v", err)
+			continue
+		}
+		if r.TerminateID != "" {
+			log.Printf("Failed to terminate %s: %s", r.TerminateID, r.Error)
+			continue
+		}
+		c.deliver(r)
+	}
+	err := c.scanner.Err()
+	if err == nil {
+		err = errors.New("the server closed the connection")
+	}
+	c.mut.Lock()
+	c.err = fmt.Errorf("%w: %v", katago.ErrEngineCrashed, err)
+	for id, call := range c.pending {
+		call.finished <- c.err
+		delete(c.pending, id)
+	}
+	c.mut.Unlock()
+	close(c.done)
+}
+
+// deliver passes a reply to its call. The reports during the search are passed on right away,
+// from the goroutine that reads the connection.
+func (c *Client) deliver(r reply) {
+	c.mut.Lock()
+	call, ok := c.pending[r.ID]
+	if !ok {
+		c.mut.Unlock()
+		log.Printf("Got a remote response for an unknown query: %s", r.ID)
+		return
+	}
+	switch {
+	case r.Error != "":
+		delete(c.pending, r.ID)
+		c.mut.Unlock()
+		err := errors.New(r.Error)
+		if target := kindError(r.Kind); target != nil {
+			err = fmt.Errorf("%w: %s", target, r.Error)
+		}
+		call.finished <- err
+	case r.IsDuringSearch:
+		c.mut.Unlock()
+		if call.interim != nil {
+			call.interim(r.AnalysisResponse)
+		}
+	default:
+		call.responses = append(call.responses, r.AnalysisResponse)
+		finished := len(call.responses) == call.turns
+		if finished {
+			delete(c.pending, r.ID)
+		}
+		c.mut.Unlock()
+		if finished {
+			call.finished <- nil
+		}
+	}
+}
+
+// start registers a call for the request and sends it
+func (c *Client) start(request katago.AnalysisRequest, interim func(katago.AnalysisResponse)) (*call, error) {
+	call := &call{turns: max(1, len(request.AnalyzeTurns)), interim: interim, finished: make(chan error, 1)}
+	c.mut.Lock()
+	if c.err != nil {
+		c.mut.Unlock()
+		return nil, c.err
+	}
+	if _, ok := c.pending[request.ID]; ok {
+		c.mut.Unlock()
+		return nil, fmt.Errorf("%w: %s", katago.ErrDuplicateID, request.ID)
+	}
+	c.pending[request.ID] = call
+	c.mut.Unlock()
+	if err := c.out.write(request); err != nil {
+		c.mut.Lock()
+		delete(c.pending, request.ID)
+		c.mut.Unlock()
+		return nil, fmt.Errorf("failed to send request %s: %v", request.ID, err)
+	}
+	return call, nil
+}
+
+// Analyze sends the requests, and returns the responses in the order of the requests, with one response
+// for each turn, as for KataGo.Analyze. Requests without an ID are given one.
+func (c *Client) Analyze(requests []katago.AnalysisRequest) ([]katago.AnalysisResponse, error) {
+	calls := make([]*call, 0, len(requests))
+	var err error
+	for _, request := range requests {
+		if request.ID == "" {
+			request.ID = fmt.Sprintf("remote-%d", c.nextID.Add(1))
+		}
+		var call *call
+		if call, err = c.start(request, nil); err != nil {
+			break
+		}
+		calls = append(calls, call)
+	}
+	// The requests that were sent are waited for, even if one of them could not be sent
+	var responses []katago.AnalysisResponse
+	for _, call := range calls {
+		if callErr := <-call.finished; callErr != nil && err == nil {
+			err = callErr
+		}
+		responses = append(responses, call.responses...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return responses, nil
+}
+
+// AnalyzeStream sends one request for one turn, and calls interim with the reports during the search,
+// every ReportDuringSearchEvery seconds, or katago.DefaultReportInterval. The interim function is called
+// from the goroutine that reads the connection, so it should return quickly, but it may call Terminate.
+func (c *Client) AnalyzeStream(request katago.AnalysisRequest, interim func(katago.AnalysisResponse)) (katago.AnalysisResponse, error) {
+	if len(request.AnalyzeTurns) > 1 {
+		return katago.AnalysisResponse{}, fmt.Errorf("%w: request %s analyzes %d turns, use Analyze", katago.ErrBadRequest, request.ID, len(request.AnalyzeTurns))
+	}
+	if request.ID == "" {
+		request.ID = fmt.Sprintf("remote-%d", c.nextID.Add(1))
+	}
+	if request.ReportDuringSearchEvery <= 0 {
+		request.ReportDuringSearchEvery = katago.DefaultReportInterval
+	}
+	call, err := c.start(request, interim)
+	if err != nil {
+		return katago.AnalysisResponse{}, err
+	}
+	if err := <-call.finished; err != nil {
+		return katago.AnalysisResponse{}, err
+	}
+	return call.responses[0], nil
+}
+
+// Terminate stops the search of a running query, which then returns the results found so far
+func (c *Client) Terminate(id string) error {
+	c.mut.Lock()
+	_, ok := c.pending[id]
+	c.mut.Unlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", katago.ErrUnknownQuery, id)
+	}
+	if err := c.out.write(terminateMessage{Action: "terminate", TerminateID: id}); err != nil {
+		return fmt.Errorf("failed to terminate %s: %v", id, err)
+	}
+	return nil
+}
+
+// Running checks if the connection to the server is still open
+func (c *Client) Running() bool {
+	select {
+	case <-c.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Close closes the connection, and the calls that are waiting fail with ErrEngineCrashed
+func (c *Client) Close() error {
+	err := c.conn.Close()
+	<-c.done
+	return err
+}
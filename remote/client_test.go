@@ -0,0 +1,175 @@
+package remote
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+// newTLSServer serves a fake engine over TLS, with the test certificate of httptest, and returns the address
+// and the configuration for the clients
+func newTLSServer(t *testing.T) (string, *tls.Config) {
+	t.Helper()
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	serverConfig := &tls.Config{Certificates: ts.TLS.Certificates}
+	clientConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	ts.Close()
+
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(k, "secret").ServeListener(l)
+	t.Cleanup(func() {
+		l.Close()
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return l.Addr().String(), clientConfig
+}
+
+// dialTest connects a client to a test server
+func dialTest(t *testing.T, addr string, config *tls.Config) *Client {
+	t.Helper()
+	c, err := Dial(addr, "secret", config)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+// newTLSClient serves a fake engine over TLS and connects to it
+func newTLSClient(t *testing.T) *Client {
+	t.Helper()
+	addr, config := newTLSServer(t)
+	return dialTest(t, addr, config)
+}
+
+func TestClientAnalyze(t *testing.T) {
+	c := newTLSClient(t)
+	var requests []katago.AnalysisRequest
+	for _, vertex := range []katago.Vertex{"C3", "E5"} {
+		request := katago.NewRequest9x9()
+		request.MaxVisits = 10
+		request.Moves = []katago.Move{{Color: katago.Black, Vertex: vertex}}
+		request.AnalyzeTurns = []int{0, 1}
+		requests = append(requests, request)
+	}
+	requests[1].ID = ""
+	responses, err := c.Analyze(requests)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %d", len(responses))
+	}
+	if responses[0].ID != requests[0].ID || responses[1].TurnNumber != 1 || responses[2].ID == "" || len(responses[3].MoveInfos) == 0 {
+		t.Errorf("Unexpected responses: %+v", responses)
+	}
+
+	bad := katago.NewRequest9x9()
+	bad.Rules = "unknown"
+	if _, err := c.Analyze([]katago.AnalysisRequest{bad}); !errors.Is(err, katago.ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+}
+
+func TestTwoClients(t *testing.T) {
+	addr, config := newTLSServer(t)
+	clients := []*Client{dialTest(t, addr, config), dialTest(t, addr, config)}
+	var wg sync.WaitGroup
+	errs := make([]error, len(clients))
+	for i, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Both clients number their requests from remote-1
+			request := katago.NewRequest9x9()
+			request.ID = ""
+			request.MaxVisits = 2000
+			request.Moves = []katago.Move{{Color: katago.Black, Vertex: katago.Vertex([]string{"C3", "E5"}[i])}}
+			responses, err := c.Analyze([]katago.AnalysisRequest{request})
+			if err == nil && (len(responses) != 1 || responses[0].ID != "remote-1") {
+				err = fmt.Errorf("expected one response for remote-1, got %+v", responses)
+			}
+			errs[i] = err
+		}()
+	}
+	wg.Wait()
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Client %d: %v", i+1, err)
+		}
+	}
+}
+
+func TestClientAnalyzeStream(t *testing.T) {
+	c := newTLSClient(t)
+	request := katago.NewRequest9x9()
+	request.MaxVisits = 100000
+	request.ReportDuringSearchEvery = 0.1
+	var (
+		mut     sync.Mutex
+		reports int
+	)
+	response, err := c.AnalyzeStream(request, func(r katago.AnalysisResponse) {
+		mut.Lock()
+		reports++
+		mut.Unlock()
+		if !r.IsDuringSearch {
+			t.Errorf("Expected a report during the search, got %+v", r)
+		}
+		c.Terminate(request.ID)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports == 0 {
+		t.Error("Expected at least one report during the search")
+	}
+	if response.IsDuringSearch || response.RootInfo.Visits >= 100000 {
+		t.Errorf("Expected a final response for a terminated search, got %d visits", response.RootInfo.Visits)
+	}
+	if err := c.Terminate(request.ID); !errors.Is(err, katago.ErrUnknownQuery) {
+		t.Errorf("Expected ErrUnknownQuery for a finished query, got %v", err)
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	c := newTLSClient(t)
+	if !c.Running() {
+		t.Fatal("Expected the client to be running")
+	}
+	c.Close()
+	if c.Running() {
+		t.Error("Expected the client to be closed")
+	}
+	if _, err := c.Analyze([]katago.AnalysisRequest{katago.NewRequest9x9()}); !errors.Is(err, katago.ErrEngineCrashed) {
+		t.Errorf("Expected ErrEngineCrashed after closing, got %v", err)
+	}
+}
+
+func TestDialWrongToken(t *testing.T) {
+	addr := newTestServer(t, "secret")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := NewClient(conn, "wrong"); err == nil {
+		t.Error("Expected the wrong token to be rejected")
+	}
+}
@@ -0,0 +1,344 @@
+// Package remote connects to a KataGo engine on another host with a small line based protocol over TLS,
+// so that remote analysis needs neither SSH nor the overhead of HTTP. Each message is one line of JSON.
+//
+// The client starts by sending a hello with the token that the server was given:
+//
+//	{"token":"..."}
+//
+// and the server answers {"ok":true}, or {"error":"..."} before it closes the connection. After that, each
+// line from the client is an analysis request with an ID, or {"action":"terminate","terminateId":"..."},
+// and each line from the server is a response, as KataGo writes them, or {"id":"...","error":"...","kind":"..."}
+// if a request failed, or {"terminateId":"...","error":"..."} if a termination failed. Requests that set
+// reportDuringSearchEvery also get the reports during the search, where isDuringSearch is true. Several
+// requests can run at once on one connection. The IDs only need to be unique within one connection, since
+// the server gives each connection its own prefix for the IDs that it sends to the engine, and a connection
+// can only terminate its own queries.
+package remote
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// MaxMessageSize is the longest line that is accepted, in bytes
+var MaxMessageSize = 1 << 20
+
+// DefaultHandshakeTimeout is how long a new connection has to send its hello, or to get an answer to it
+const DefaultHandshakeTimeout = 10 * time.Second
+
+// interimBuffer is how many reports during the search can be waiting to be sent for one query,
+// before the oldest are dropped
+const interimBuffer = 8
+
+// hello is the first message from the client
+type hello struct {
+	Token string `json:"token"`
+}
+
+// welcome is the answer of the server to the hello
+type welcome struct {
+	OK    bool   `json:"ok,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// message is a message from the client. It is either an analysis request, or an action that terminates
+// one of the queries of the client.
+type message struct {
+	katago.AnalysisRequest
+	Action      string `json:"action,omitempty"`
+	TerminateID string `json:"terminateId,omitempty"`
+}
+
+// terminateMessage is the message that the client sends for terminating a query
+type terminateMessage struct {
+	Action      string `json:"action"`
+	TerminateID string `json:"terminateId"`
+}
+
+// failure is sent to the client when a request or a termination fails, and Kind tells which error of
+// the katago package it was
+type failure struct {
+	ID          string `json:"id,omitempty"`
+	TerminateID string `json:"terminateId,omitempty"`
+	Error       string `json:"error"`
+	Kind        string `json:"kind,omitempty"`
+}
+
+// kinds are the names of the errors of the katago package that are sent with a failure
+var kinds = []struct {
+	name string
+	err  error
+}{
+	{"bad_request", katago.ErrBadRequest},
+	{"duplicate_id", katago.ErrDuplicateID},
+	{"unknown_query", katago.ErrUnknownQuery},
+	{"timeout", katago.ErrQueryTimeout},
+	{"terminated", katago.ErrQueryTerminated},
+	{"crashed", katago.ErrEngineCrashed},
+}
+
+// errorKind returns the name of the katago error that err wraps, or "" if it is none of them
+func errorKind(err error) string {
+	for _, kind := range kinds {
+		if errors.Is(err, kind.err) {
+			return kind.name
+		}
+	}
+	return ""
+}
+
+// kindError returns the katago error with the given name, or nil if there is none
+func kindError(name string) error {
+	for _, kind := range kinds {
+		if kind.name == name {
+			return kind.err
+		}
+	}
+	return nil
+}
+
+// newScanner returns a scanner for the lines of a connection, of at most MaxMessageSize bytes
+func newScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxMessageSize)
+	return scanner
+}
+
+// lineWriter writes one JSON value per line, from several goroutines
+type lineWriter struct {
+	mut sync.Mutex
+	w   io.Writer
+}
+
+func (w *lineWriter) write(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	_, err = w.w.Write(append(data, '\n'))
+	return err
+}
+
+// Server serves a KataGo engine to the clients that know the token
+type Server struct {
+	katago *katago.KataGo
+	token  string
+	// HandshakeTimeout is how long a new connection has to send its hello, or DefaultHandshakeTimeout if 0
+	HandshakeTimeout time.Duration
+	// nextConn numbers the connections, for the prefixes of their query IDs
+	nextConn atomic.Int64
+}
+
+// NewServer creates a server for the given engine. Clients must send the token in their hello.
+func NewServer(k *katago.KataGo, token string) *Server {
+	return &Server{katago: k, token: token}
+}
+
+// ListenAndServeTLS accepts TLS connections on the given address, like ":4433", with the certificate and
+// key in the given PEM files, and serves each one
+func ListenAndServeTLS(addr, certFile, keyFile, token string, k *katago.KataGo) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load the certificate: %v", err)
+	}
+	l, err := tls.Listen("tcp", addr, &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12})
+	if err != nil {
+		return err
+	}
+	return NewServer(k, token).ServeListener(l)
+}
+
+// ServeListener serves the connections that are accepted by the listener
+func (s *Server) ServeListener(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			if err := s.ServeConn(conn); err != nil {
+				log.Printf("Remote connection error: %v", err)
+			}
+		}()
+	}
+}
+
+// handshake reads the hello and answers it
+func (s *Server) handshake(conn net.Conn, scanner *bufio.Scanner, out *lineWriter) error {
+	timeout := s.HandshakeTimeout
+	if timeout <= 0 {
+		timeout = DefaultHandshakeTimeout
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+	defer conn.SetDeadline(time.Time{})
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return fmt.Errorf("failed to read the hello: %v", err)
+		}
+		return errors.New("the connection was closed before the hello")
+	}
+	var h hello
+	if err := json.Unmarshal(scanner.Bytes(), &h); err != nil {
+		out.write(welcome{Error: "invalid hello"})
+		return fmt.Errorf("invalid hello: %v", err)
+	}
+	if subtle.ConstantTimeCompare([]byte(h.Token), []byte(s.token)) != 1 {
+		out.write(welcome{Error: "invalid token"})
+		return fmt.Errorf("invalid token from %s", conn.RemoteAddr())
+	}
+	return out.write(welcome{OK: true})
+}
+
+// ServeConn serves one connection until the client closes it. The queries of the client that are
+// still running when it is closed are terminated.
+func (s *Server) ServeConn(conn net.Conn) error {
+	scanner := newScanner(conn)
+	out := &lineWriter{w: conn}
+	if err := s.handshake(conn, scanner, out); err != nil {
+		return err
+	}
+
+	// The IDs of the client are only unique for this connection, so they get a prefix on the way to
+	// the engine, and the prefix is removed from the responses
+	prefix := fmt.Sprintf("conn%d-", s.nextConn.Add(1))
+	var (
+		wg      sync.WaitGroup
+		mut     sync.Mutex
+		running = make(map[string]bool)
+	)
+	defer func() {
+		// The client is gone, so there is no reason to keep searching
+		mut.Lock()
+		for id := range running {
+			s.katago.Terminate(prefix + id)
+		}
+		mut.Unlock()
+		wg.Wait()
+	}()
+	send := func(v any) {
+		if err := out.write(v); err != nil {
+			log.Printf("Failed to write a remote message: %v", err)
+		}
+	}
+	for scanner.Scan() {
+		var m message
+		if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+			send(failure{Error: fmt.Sprintf("invalid request: %v", err), Kind: "bad_request"})
+			continue
+		}
+		switch {
+		case m.Action == "terminate":
+			mut.Lock()
+			ok := running[m.TerminateID]
+			mut.Unlock()
+			err := fmt.Errorf("%w: %s", katago.ErrUnknownQuery, m.TerminateID)
+			if ok {
+				err = s.katago.Terminate(prefix + m.TerminateID)
+			}
+			if err != nil {
+				send(failure{TerminateID: m.TerminateID, Error: unprefix(err.Error(), prefix), Kind: errorKind(err)})
+			}
+			continue
+		case m.Action != "":
+			send(failure{ID: m.ID, Error: fmt.Sprintf("unknown action: %s", m.Action), Kind: "bad_request"})
+			continue
+		case m.ID == "":
+			send(failure{Error: "the request has no id", Kind: "bad_request"})
+			continue
+		}
+		request := m.AnalysisRequest
+		mut.Lock()
+		duplicate := running[request.ID]
+		running[request.ID] = true
+		mut.Unlock()
+		if duplicate {
+			err := fmt.Errorf("%w: %s", katago.ErrDuplicateID, request.ID)
+			send(failure{ID: request.ID, Error: err.Error(), Kind: errorKind(err)})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.query(request, prefix, send)
+			mut.Lock()
+			delete(running, request.ID)
+			mut.Unlock()
+		}()
+	}
+	return scanner.Err()
+}
+
+// unprefix removes the prefix of a connection from the IDs in a message
+func unprefix(message, prefix string) string {
+	return strings.ReplaceAll(message, prefix, "")
+}
+
+// query runs one request with the prefix of the connection, and sends its responses to the client without
+// the prefix. The reports during the search are queued, so that a slow client can not hold up the engine.
+func (s *Server) query(request katago.AnalysisRequest, prefix string, send func(any)) {
+	id := request.ID
+	request.ID = prefix + id
+	fail := func(err error) {
+		send(failure{ID: id, Error: unprefix(err.Error(), prefix), Kind: errorKind(err)})
+	}
+	if request.ReportDuringSearchEvery <= 0 || len(request.AnalyzeTurns) > 1 {
+		responses, err := s.katago.Analyze([]katago.AnalysisRequest{request})
+		if err != nil {
+			fail(err)
+			return
+		}
+		for _, response := range responses {
+			response.ID = id
+			send(response)
+		}
+		return
+	}
+	interim := make(chan katago.AnalysisResponse, interimBuffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for response := range interim {
+			response.ID = id
+			send(response)
+		}
+	}()
+	response, err := s.katago.AnalyzeStream(request, func(response katago.AnalysisResponse) {
+		select {
+		case interim <- response:
+		default:
+			// The client is behind, so the oldest report is dropped to make room for the newest
+			select {
+			case <-interim:
+			default:
+			}
+			select {
+			case interim <- response:
+			default:
+			}
+		}
+	})
+	close(interim)
+	<-done
+	if err != nil {
+		fail(err)
+		return
+	}
+	response.ID = id
+	send(response)
+}
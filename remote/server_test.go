@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// newTestServer serves a fake engine on a local TCP port, and returns the address
+func newTestServer(t *testing.T, token string) string {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go NewServer(k, token).ServeListener(l)
+	t.Cleanup(func() {
+		l.Close()
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return l.Addr().String()
+}
+
+func TestHandshake(t *testing.T) {
+	addr := newTestServer(t, "secret")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte(`{"token":"wrong"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	line, err := r.ReadBytes('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var w welcome
+	if err := json.Unmarshal(line, &w); err != nil {
+		t.Fatal(err)
+	}
+	if w.OK || w.Error != "invalid token" {
+		t.Errorf("Expected the token to be rejected, got %s", line)
+	}
+	if _, err := r.ReadBytes('\n'); err == nil {
+		t.Error("Expected the connection to be closed")
+	}
+}
+
+func TestServeConnFailures(t *testing.T) {
+	addr := newTestServer(t, "secret")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	messages := []string{
+		`{"token":"secret"}`,
+		`not json`,
+		`{"moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9}`,
+		`{"action":"terminate","terminateId":"nothing"}`,
+	}
+	if _, err := conn.Write([]byte(strings.Join(messages, "\n") + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	var lines []string
+	for range 4 {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != `{"ok":true}` {
+		t.Errorf("Expected the hello to be accepted, got %s", lines[0])
+	}
+	var f failure
+	if err := json.Unmarshal([]byte(lines[1]), &f); err != nil || f.Kind != "bad_request" {
+		t.Errorf("Expected a bad request for invalid JSON, got %s", lines[1])
+	}
+	if err := json.Unmarshal([]byte(lines[2]), &f); err != nil || f.Kind != "bad_request" || !strings.Contains(f.Error, "no id") {
+		t.Errorf("Expected a bad request for a request without an ID, got %s", lines[2])
+	}
+	f = failure{}
+	if err := json.Unmarshal([]byte(lines[3]), &f); err != nil || f.TerminateID != "nothing" || f.Kind != "unknown_query" {
+		t.Errorf("Expected an unknown query for the termination, got %s", lines[3])
+	}
+}
+
+// dialRaw connects to a test server without a client, and sends the hello
+func dialRaw(t *testing.T, addr string) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	r := bufio.NewReader(conn)
+	if _, err := conn.Write([]byte(`{"token":"secret"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := r.ReadString('\n'); err != nil || strings.TrimSpace(line) != `{"ok":true}` {
+		t.Fatalf("Expected the hello to be accepted, got %q and %v", line, err)
+	}
+	return conn, r
+}
+
+func TestTerminateOtherConnection(t *testing.T) {
+	addr := newTestServer(t, "secret")
+	a, ra := dialRaw(t, addr)
+	b, rb := dialRaw(t, addr)
+	if _, err := a.Write([]byte(`{"id":"long","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":100000}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	// The other connection can not see the query
+	if _, err := b.Write([]byte(`{"action":"terminate","terminateId":"long"}` + "\n")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := rb.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f failure
+	if err := json.Unmarshal([]byte(line), &f); err != nil || f.TerminateID != "long" || f.Kind != "unknown_query" {
+		t.Errorf("Expected an unknown query for the other connection, got %s", line)
+	}
+	// The query may not have reached the engine yet, so the termination is tried again until it does
+	for range 50 {
+		if _, err := a.Write([]byte(`{"action":"terminate","terminateId":"long"}` + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if line, err = ra.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+		if f = (failure{}); json.Unmarshal([]byte(line), &f) != nil || f.TerminateID == "" {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	var response katago.AnalysisResponse
+	if err := json.Unmarshal([]byte(line), &response); err != nil || response.ID != "long" {
+		t.Errorf("Expected the terminated query to answer with its own ID, got %s", line)
+	}
+}
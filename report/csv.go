@@ -0,0 +1,71 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// CSVHeader is the first row that WriteCSV writes
+var CSVHeader = []string{"turn", "player", "move", "best_move", "black_winrate", "black_score_lead", "points_lost", "grade", "visits"}
+
+// formatFloat formats a number for a spreadsheet
+func formatFloat(f float64, decimals int) string {
+	return strconv.FormatFloat(f, 'f', decimals, 64)
+}
+
+// WriteCSV writes one row for each turn of the review, with the player to move, the move that was played,
+// the move KataGo prefers, black's winrate and score lead, the points lost by the move, its grade and the
+// number of visits. Columns are separated by comma, which can be '\t' for TSV. Values that are not known,
+// like the move after the last turn or the evaluation of turns that were not analyzed, are left empty.
+func WriteCSV(w io.Writer, r Review, comma rune) error {
+	p := r.Position
+	if _, err := p.Board(); err != nil {
+		return err
+	}
+	responses := make(map[int]katago.AnalysisResponse, len(r.Responses))
+	for _, response := range r.Responses {
+		responses[response.TurnNumber] = response
+	}
+	grades := make(map[int]katago.GradedMove, len(r.Graded))
+	for _, g := range r.Graded {
+		grades[g.Turn] = g
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write(CSVHeader); err != nil {
+		return err
+	}
+	for turn := 0; turn <= len(p.Moves); turn++ {
+		q := p
+		q.Moves = p.Moves[:turn]
+		row := make([]string, len(CSVHeader))
+		row[0] = strconv.Itoa(turn)
+		row[1] = q.ToPlay()
+		if turn < len(p.Moves) {
+			row[1], row[2] = string(p.Moves[turn].Color), string(p.Moves[turn].Vertex)
+		}
+		if response, ok := responses[turn]; ok {
+			toPlay, _ := board.ParseColor(row[1])
+			winrate, lead := BlackView(response, toPlay)
+			row[4], row[5] = formatFloat(winrate, 4), formatFloat(lead, 2)
+			row[8] = strconv.Itoa(response.RootInfo.Visits)
+			if best, ok := response.BestMove(); ok {
+				row[3] = best.Move
+			}
+		}
+		if g, ok := grades[turn]; ok {
+			row[3] = g.BestMove
+			row[6] = formatFloat(g.PointsLost, 2)
+			row[7] = g.Grade.String()
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,33 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+)
+
+func TestWriteCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, testReview(), '\t'); err != nil {
+		t.Fatal(err)
+	}
+	cr := csv.NewReader(&buf)
+	cr.Comma = '\t'
+	rows, err := cr.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 5 {
+		t.Fatalf("Expected a header and 4 turns, got %d rows", len(rows))
+	}
+	expected := []string{"1", "W", "A1", "G3", "0.4000", "-2.00", "8.00", "Blunder", "0"}
+	for i := range expected {
+		if rows[2][i] != expected[i] {
+			t.Errorf("Expected %q in column %s, got %q", expected[i], CSVHeader[i], rows[2][i])
+		}
+	}
+	// The last turn has no move and was not graded
+	if last := rows[4]; last[1] != "W" || last[2] != "" || last[6] != "" || last[3] != "G3" {
+		t.Errorf("Unexpected last row: %v", last)
+	}
+}
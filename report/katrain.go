@@ -0,0 +1,231 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+	"github.com/xyproto/katago/sgf"
+)
+
+// katrainAnalysis is the analysis of a node, as KaTrain stores it in the KT property. The ownership and
+// the policy are stored separately, and all the values are from the point of view of black.
+type katrainAnalysis struct {
+	Root      katago.RootInfo               `json:"root"`
+	Moves     map[string]katago.MoveInfoExt `json:"moves"`
+	Completed bool                          `json:"completed"`
+}
+
+// float16 converts a number to the bits of a half precision float, rounding to the nearest value
+func float16(f float64) uint16 {
+	bits := math.Float32bits(float32(f))
+	sign := uint16(bits>>16) & 0x8000
+	exponent := int(bits>>23&0xff) - 127 + 15
+	mantissa := bits & 0x7fffff
+	switch {
+	case math.IsNaN(f):
+		return 0x7e00
+	case exponent >= 0x1f:
+		return sign | 0x7c00
+	case exponent <= 0:
+		if exponent < -10 {
+			return sign
+		}
+		// Subnormal numbers
+		mantissa |= 0x800000
+		shift := uint(14 - exponent)
+		half := uint16(mantissa >> shift)
+		if mantissa>>(shift-1)&1 != 0 {
+			half++
+		}
+		return sign | half
+	}
+	half := sign | uint16(exponent)<<10 | uint16(mantissa>>13)
+	if mantissa&0x1000 != 0 {
+		// Rounding may carry into the exponent, which is still correct
+		half++
+	}
+	return half
+}
+
+// packFloats packs numbers as little endian half precision floats, gzips them and encodes them as base64,
+// which is how KaTrain stores the ownership and the policy
+func packFloats(values []float64) (string, error) {
+	data := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.LittleEndian.PutUint16(data[2*i:], float16(v))
+	}
+	return packBytes(data)
+}
+
+// packBytes gzips data and encodes it as base64
+func packBytes(data []byte) (string, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", err
+	}
+	if err := zw.Close(); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// katrainValues returns the values of the KT property for a response, where the values of the response are
+// for the side to move, which is toPlay unless the response says otherwise
+func katrainValues(response katago.AnalysisResponse, toPlay board.Color) ([]string, error) {
+	if response.RootInfo.CurrentPlayer != "" {
+		c, err := board.ParseColor(response.RootInfo.CurrentPlayer)
+		if err != nil {
+			return nil, err
+		}
+		toPlay = c
+	}
+	flip := toPlay == board.White
+	root := response.RootInfo
+	root.CurrentPlayer = toPlay.String()
+	a := katrainAnalysis{Root: root, Moves: make(map[string]katago.MoveInfoExt, len(response.MoveInfos)), Completed: true}
+	ownership := append([]float64(nil), response.Ownership...)
+	if flip {
+		a.Root.Winrate, a.Root.ScoreLead = 1-root.Winrate, -root.ScoreLead
+		for i := range ownership {
+			ownership[i] = -ownership[i]
+		}
+	}
+	for _, info := range response.MoveInfos {
+		if flip {
+			info.Winrate, info.ScoreLead, info.ScoreMean = 1-info.Winrate, -info.ScoreLead, -info.ScoreMean
+		}
+		a.Moves[info.Move] = info
+	}
+	main, err := json.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	var values []string
+	for _, part := range [][]float64{ownership, response.Policy} {
+		value, err := packFloats(part)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	value, err := packBytes(main)
+	if err != nil {
+		return nil, err
+	}
+	return append(values, value), nil
+}
+
+// formatScore formats black's score lead like KaTrain, as "B+2.5" or "W+0.3"
+func formatScore(lead float64) string {
+	if lead < 0 {
+		return fmt.Sprintf("W+%.1f", -lead)
+	}
+	return fmt.Sprintf("B+%.1f", lead)
+}
+
+// katrainComment returns a comment for a move in the style of KaTrain, given the analysis before
+// the move and after it
+func katrainComment(number int, move katago.Move, before, after *katago.AnalysisResponse, graded *katago.GradedMove, toPlay board.Color) string {
+	lines := []string{fmt.Sprintf("Move %d: %s %s", number, move.Color, move.Vertex)}
+	if after != nil {
+		winrate, lead := BlackView(*after, toPlay.Opponent())
+		lines = append(lines, "Score: "+formatScore(lead), fmt.Sprintf("Win rate: B %.1f%%", 100*winrate))
+	}
+	if before != nil {
+		if best, ok := before.BestMove(); ok {
+			lead := best.ScoreLead
+			if toPlay == board.White {
+				lead = -lead
+			}
+			lines = append(lines, fmt.Sprintf("Predicted top move was %s (%s).", best.Move, formatScore(lead)))
+			var pv []string
+			color := toPlay
+			for _, vertex := range best.PV {
+				pv = append(pv, color.String()+vertex)
+				color = color.Opponent()
+			}
+			if len(pv) > 0 {
+				lines = append(lines, "PV: "+strings.Join(pv, " "))
+			}
+		}
+	}
+	if graded != nil {
+		lines = append(lines, fmt.Sprintf("Estimated point loss: %.1f", graded.PointsLost))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// AddKaTrainAnalysis adds the analysis of the review to the main line of a game, so that KaTrain opens the game
+// with the analysis loaded and does not need to analyze it again. Each node gets KaTrain's KT property, with the
+// analysis of the position after the move, and each move gets a comment like the ones that KaTrain writes,
+// with the score, the winrate, the move KataGo prefers with its variation and the points lost.
+// The game is expected to have the moves of the position of the review.
+func AddKaTrainAnalysis(root *sgf.Node, r Review) error {
+	p := r.Position
+	initial := p
+	initial.Moves = nil
+	b, err := initial.Board()
+	if err != nil {
+		return err
+	}
+	responses := make(map[int]*katago.AnalysisResponse, len(r.Responses))
+	for i := range r.Responses {
+		responses[r.Responses[i].TurnNumber] = &r.Responses[i]
+	}
+	grades := make(map[int]*katago.GradedMove, len(r.Graded))
+	for i := range r.Graded {
+		grades[r.Graded[i].Turn] = &r.Graded[i]
+	}
+	turn := 0
+	for _, node := range root.MainLine() {
+		move, ok, err := node.Move(p.BoardXSize, p.BoardYSize)
+		if err != nil {
+			return err
+		}
+		if ok {
+			if turn >= len(p.Moves) || !strings.EqualFold(string(move.Vertex), string(p.Moves[turn].Vertex)) {
+				return fmt.Errorf("move %d of the game does not match the review", turn+1)
+			}
+			c, err := board.ParseColor(string(move.Color))
+			if err != nil {
+				return err
+			}
+			point, err := b.ParseVertex(string(move.Vertex))
+			if err != nil {
+				return err
+			}
+			if err := b.Play(c, point); err != nil {
+				return fmt.Errorf("move %d at %s: %v", turn+1, move.Vertex, err)
+			}
+			AddComment(node, katrainComment(turn+1, move, responses[turn], responses[turn+1], grades[turn], c))
+			turn++
+		} else if node != root {
+			continue
+		}
+		if response, ok := responses[turn]; ok {
+			values, err := katrainValues(*response, b.ToPlay())
+			if err != nil {
+				return err
+			}
+			node.Set("KT", values...)
+		}
+	}
+	return nil
+}
+
+// AddComment adds text to the comment of a node, after any existing comment
+func AddComment(n *sgf.Node, text string) {
+	if existing := n.Get("C"); existing != "" {
+		text = existing + "\n\n" + text
+	}
+	n.Set("C", text)
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago/sgf"
+)
+
+// unpack decodes a value of the KT property
+func unpack(t *testing.T, value string) []byte {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+// fromFloat16 converts the bits of a half precision float to a number
+func fromFloat16(h uint16) float64 {
+	sign := 1.0
+	if h&0x8000 != 0 {
+		sign = -1
+	}
+	exponent := int(h >> 10 & 0x1f)
+	mantissa := float64(h & 0x3ff)
+	if exponent == 0 {
+		return sign * mantissa * math.Pow(2, -24)
+	}
+	return sign * (1 + mantissa/1024) * math.Pow(2, float64(exponent-15))
+}
+
+func TestFloat16(t *testing.T) {
+	for _, f := range []float64{0, 1, -1, 0.5, 0.3333, -0.9871, 1e-5, 65504} {
+		if result := fromFloat16(float16(f)); math.Abs(result-f) > math.Abs(f)/1000+1e-7 {
+			t.Errorf("Expected %v, got %v", f, result)
+		}
+	}
+	if h := float16(1e6); h != 0x7c00 {
+		t.Errorf("Expected infinity, got %x", h)
+	}
+}
+
+func TestAddKaTrainAnalysis(t *testing.T) {
+	r := testReview()
+	r.Responses[1].Ownership = make([]float64, 81)
+	r.Responses[1].Ownership[0] = 0.75
+	root, err := sgf.ParseGame("(;SZ[9]KM[7];B[ee]C[Nice];W[ai];B[cg])")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddKaTrainAnalysis(root, r); err != nil {
+		t.Fatal(err)
+	}
+	nodes := root.MainLine()
+	for i, node := range nodes {
+		if len(node.Values("KT")) != 3 {
+			t.Fatalf("Expected 3 values of KT in node %d, got %v", i, node.Values("KT"))
+		}
+	}
+	// White is to move after the first move, so the values are flipped for black
+	values := nodes[1].Values("KT")
+	var a katrainAnalysis
+	if err := json.Unmarshal(unpack(t, values[2]), &a); err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(a.Root.Winrate-0.4) > 1e-9 || a.Root.ScoreLead != -2 || a.Moves["G3"].ScoreLead != -2 {
+		t.Errorf("Expected the values for black, got %+v", a)
+	}
+	ownership := unpack(t, values[0])
+	if len(ownership) != 2*81 || fromFloat16(binary.LittleEndian.Uint16(ownership)) != -0.75 {
+		t.Errorf("Expected 81 values of ownership for black, starting with -0.75")
+	}
+	comment := nodes[1].Get("C")
+	for _, expected := range []string{"Nice\n\nMove 1: B E5", "Score: W+2.0", "Win rate: B 40.0%", "Predicted top move was G3 (B+2.0).", "PV: BG3 WG7"} {
+		if !strings.Contains(comment, expected) {
+			t.Errorf("Expected the comment to contain %q, got %q", expected, comment)
+		}
+	}
+	if comment := nodes[2].Get("C"); !strings.Contains(comment, "Estimated point loss: 8.0") {
+		t.Errorf("Expected the points lost in the comment, got %q", comment)
+	}
+
+	other, _ := sgf.ParseGame("(;SZ[9];B[dd])")
+	if err := AddKaTrainAnalysis(other, r); err == nil {
+		t.Errorf("Expected an error for a game with other moves")
+	}
+}
@@ -0,0 +1,320 @@
+// Package report exports game reviews: as a standalone HTML page, with a board that can be stepped through,
+// a winrate graph, the list of mistakes and KataGo's variations, which can be opened in any browser,
+// as CSV with one row for each turn, for spreadsheets and statistics, and as SGF with the analysis
+// stored in the way KaTrain stores it
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// Review is a reviewed game
+type Review struct {
+	Title    string
+	Black    string
+	White    string
+	Position katago.Position
+	// Responses are the analysis of each turn, indexed by turn number, as returned by AnalyzeGame
+	Responses []katago.AnalysisResponse
+	// Graded are the graded moves, as returned by GradeMoves
+	Graded []katago.GradedMove
+}
+
+// variation is a variation drawn on the board
+type variation struct {
+	Stones  string   `json:"stones"`
+	Numbers [][2]int `json:"numbers"`
+	Moves   []string `json:"moves"`
+	Winrate float64  `json:"winrate"`
+}
+
+// turn is the board and the evaluation after a move
+type turn struct {
+	// Stones has one character for each point, row by row: ".", "b" or "w"
+	Stones string `json:"stones"`
+	// Last is the index of the point of the last move, or -1
+	Last     int    `json:"last"`
+	Move     string `json:"move"`
+	Analyzed bool   `json:"analyzed"`
+	// Winrate and Lead are for black
+	Winrate   float64    `json:"winrate"`
+	Lead      float64    `json:"lead"`
+	Grade     string     `json:"grade"`
+	Comment   string     `json:"comment"`
+	Variation *variation `json:"variation,omitempty"`
+}
+
+// page is the data for the template
+type page struct {
+	Title    string
+	Black    string
+	White    string
+	Graph    template.HTML
+	Mistakes []mistake
+	// Phases are the names of the game phases, for the columns of the table of points lost by phase
+	Phases    []string
+	PhaseRows []phaseRow
+	Data      pageData
+}
+
+// phaseRow is a row in the table of points lost by phase, with one cell for each phase
+type phaseRow struct {
+	Player string
+	Cells  []phaseCell
+}
+
+// phaseCell is the number of moves that a player played in a phase, and the points lost by them
+type phaseCell struct {
+	Moves      int
+	PointsLost float64
+}
+
+// pageData is embedded in the page as JSON
+type pageData struct {
+	Width   int      `json:"width"`
+	Height  int      `json:"height"`
+	Columns []string `json:"columns"`
+	Stars   []int    `json:"stars"`
+	Turns   []turn   `json:"turns"`
+}
+
+// mistake is an entry in the list of mistakes
+type mistake struct {
+	Turn       int
+	Color      string
+	Move       string
+	BestMove   string
+	PointsLost float64
+	Grade      string
+}
+
+// stones returns the points of the board, as in turn.Stones
+func stones(b *board.Board) string {
+	var sb strings.Builder
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			switch b.At(board.Point{X: x, Y: y}) {
+			case board.Black:
+				sb.WriteByte('b')
+			case board.White:
+				sb.WriteByte('w')
+			default:
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+// BlackView returns black's winrate and score lead from a response, where the values are for the side to move.
+// The side to move is taken from the response, or else it is the given color.
+func BlackView(response katago.AnalysisResponse, toPlay board.Color) (float64, float64) {
+	player := response.RootInfo.CurrentPlayer
+	if player == "" {
+		player = toPlay.String()
+	}
+	if strings.EqualFold(player, "W") {
+		return 1 - response.RootInfo.Winrate, -response.RootInfo.ScoreLead
+	}
+	return response.RootInfo.Winrate, response.RootInfo.ScoreLead
+}
+
+// playPV plays KataGo's preferred variation from a position, and returns the variation
+// that is drawn instead of the move that was played
+func playPV(b *board.Board, response katago.AnalysisResponse) (*variation, error) {
+	best, ok := response.BestMove()
+	if !ok || len(best.PV) == 0 {
+		return nil, nil
+	}
+	result := b.Clone()
+	v := &variation{Winrate: best.Winrate}
+	if b.ToPlay() == board.White {
+		v.Winrate = 1 - best.Winrate
+	}
+	numbers := make(map[board.Point]int)
+	for i, vertex := range best.PV {
+		p, err := result.ParseVertex(vertex)
+		if err != nil {
+			return nil, err
+		}
+		if err := result.Play(result.ToPlay(), p); err != nil {
+			return nil, fmt.Errorf("variation move %d at %s: %v", i+1, vertex, err)
+		}
+		v.Moves = append(v.Moves, vertex)
+		if !p.IsPass() {
+			numbers[p] = i + 1
+		}
+	}
+	v.Stones = stones(result)
+	for y := 0; y < result.Height(); y++ {
+		for x := 0; x < result.Width(); x++ {
+			p := board.Point{X: x, Y: y}
+			if n, ok := numbers[p]; ok && result.At(p) != board.Empty {
+				v.Numbers = append(v.Numbers, [2]int{y*result.Width() + x, n})
+			}
+		}
+	}
+	return v, nil
+}
+
+// build replays the game and collects the data for the page
+func build(r Review) (*page, error) {
+	p := r.Position
+	// Check the whole game first, and then replay it from the initial stones
+	if _, err := p.Board(); err != nil {
+		return nil, err
+	}
+	initial := p
+	initial.Moves = nil
+	b, err := initial.Board()
+	if err != nil {
+		return nil, err
+	}
+	grades := make(map[int]katago.GradedMove, len(r.Graded))
+	for _, g := range r.Graded {
+		grades[g.Turn] = g
+	}
+	responses := make(map[int]katago.AnalysisResponse, len(r.Responses))
+	for _, response := range r.Responses {
+		responses[response.TurnNumber] = response
+	}
+
+	title := r.Title
+	if title == "" {
+		title = "Game review"
+	}
+	pg := &page{Title: title, Black: r.Black, White: r.White}
+	pg.Data = pageData{Width: b.Width(), Height: b.Height()}
+	for x := 0; x < b.Width(); x++ {
+		pg.Data.Columns = append(pg.Data.Columns, board.ColumnName(x))
+	}
+	for _, s := range board.StarPoints(b.Width(), b.Height()) {
+		pg.Data.Stars = append(pg.Data.Stars, s.Y*b.Width()+s.X)
+	}
+	add := func(index int, t turn) {
+		if response, ok := responses[index]; ok {
+			t.Analyzed = true
+			t.Winrate, t.Lead = BlackView(response, b.ToPlay())
+		}
+		pg.Data.Turns = append(pg.Data.Turns, t)
+	}
+	add(0, turn{Stones: stones(b), Last: -1})
+	for i, move := range p.Moves {
+		c, err := board.ParseColor(string(move.Color))
+		if err != nil {
+			return nil, err
+		}
+		point, err := b.ParseVertex(string(move.Vertex))
+		if err != nil {
+			return nil, err
+		}
+		before := b.Clone()
+		if err := b.Play(c, point); err != nil {
+			return nil, fmt.Errorf("move %d at %s: %v", i+1, move.Vertex, err)
+		}
+		t := turn{Stones: stones(b), Last: -1, Move: string(move.Color) + " " + b.Vertex(point)}
+		if !point.IsPass() {
+			t.Last = point.Y*b.Width() + point.X
+		}
+		if g, ok := grades[i]; ok {
+			t.Grade = g.Grade.String()
+			if g.Grade >= katago.Inaccuracy {
+				t.Comment = fmt.Sprintf("%s: lost %.1f points. KataGo prefers %s.", g.Grade, g.PointsLost, g.BestMove)
+			} else {
+				t.Comment = fmt.Sprintf("%s (%.1f points lost)", g.Grade, g.PointsLost)
+			}
+			if g.Grade >= katago.Mistake {
+				pg.Mistakes = append(pg.Mistakes, mistake{
+					Turn:       i + 1,
+					Color:      g.Color,
+					Move:       g.Move,
+					BestMove:   g.BestMove,
+					PointsLost: g.PointsLost,
+					Grade:      g.Grade.String(),
+				})
+				if response, ok := responses[i]; ok {
+					before.SetToPlay(c)
+					if t.Variation, err = playPV(before, response); err != nil {
+						return nil, err
+					}
+				}
+			}
+		}
+		add(i+1, t)
+	}
+	pg.Graph = graph(pg.Data.Turns)
+	if pg.Phases, pg.PhaseRows, err = phaseTable(p, r.Graded); err != nil {
+		return nil, err
+	}
+	return pg, nil
+}
+
+// phaseTable returns the columns and the rows of the table of points lost by phase, with a row for each
+// player that has graded moves
+func phaseTable(p katago.Position, graded []katago.GradedMove) ([]string, []phaseRow, error) {
+	summaries, err := katago.SummarizePhases(p, graded)
+	if err != nil {
+		return nil, nil, err
+	}
+	var columns []string
+	for _, phase := range katago.Phases {
+		columns = append(columns, phase.String())
+	}
+	var rows []phaseRow
+	for _, player := range []struct{ color, name string }{{"B", "Black"}, {"W", "White"}} {
+		phases, ok := summaries[player.color]
+		if !ok {
+			continue
+		}
+		row := phaseRow{Player: player.name}
+		for _, phase := range katago.Phases {
+			var cell phaseCell
+			if s, ok := phases[phase]; ok {
+				cell = phaseCell{Moves: s.Moves, PointsLost: s.TotalPointsLost}
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// graph draws black's winrate over the game as an SVG image, with the mistakes marked
+func graph(turns []turn) template.HTML {
+	const width, height = 600.0, 150.0
+	step := width / float64(max(1, len(turns)-1))
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg id="graph" viewBox="0 0 %g %g" preserveAspectRatio="none">`, width, height)
+	fmt.Fprintf(&sb, `<rect width="%g" height="%g" fill="#f4f4f4"/>`, width, height)
+	fmt.Fprintf(&sb, `<line x1="0" y1="%g" x2="%g" y2="%g" stroke="#bbb" stroke-dasharray="4"/>`, height/2, width, height/2)
+	var points []string
+	for i, t := range turns {
+		if t.Analyzed {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", float64(i)*step, (1-t.Winrate)*height))
+		}
+	}
+	fmt.Fprintf(&sb, `<polyline points="%s" fill="none" stroke="#333" stroke-width="2"/>`, strings.Join(points, " "))
+	for i, t := range turns {
+		if t.Analyzed && t.Variation != nil {
+			fmt.Fprintf(&sb, `<circle cx="%.1f" cy="%.1f" r="4" fill="#d33"/>`, float64(i)*step, (1-t.Winrate)*height)
+		}
+	}
+	fmt.Fprintf(&sb, `<line id="cursor" x1="0" y1="0" x2="0" y2="%g" stroke="#36c" stroke-width="2"/>`, height)
+	sb.WriteString(`</svg>`)
+	return template.HTML(sb.String())
+}
+
+// WriteHTML writes the review as a standalone HTML page
+func WriteHTML(w io.Writer, r Review) error {
+	pg, err := build(r)
+	if err != nil {
+		return err
+	}
+	return pageTemplate.Execute(w, pg)
+}
@@ -0,0 +1,83 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func testReview() Review {
+	p := katago.Position{
+		Moves:      []katago.Move{{Color: "B", Vertex: "E5"}, {Color: "W", Vertex: "A1"}, {Color: "B", Vertex: "C3"}},
+		Rules:      katago.Chinese,
+		Komi:       7,
+		BoardXSize: 9,
+		BoardYSize: 9,
+	}
+	var responses []katago.AnalysisResponse
+	for turn := 0; turn <= len(p.Moves); turn++ {
+		responses = append(responses, katago.AnalysisResponse{
+			TurnNumber: turn,
+			RootInfo:   katago.RootInfo{Winrate: 0.6, ScoreLead: 2},
+			MoveInfos:  []katago.MoveInfoExt{{Move: "G3", Order: 0, Winrate: 0.6, ScoreLead: 2, PV: []string{"G3", "G7"}}},
+		})
+	}
+	graded := []katago.GradedMove{
+		{Turn: 0, Color: "B", Move: "E5", BestMove: "E5", Grade: katago.Excellent},
+		{Turn: 1, Color: "W", Move: "A1", BestMove: "G3", PointsLost: 8, Grade: katago.Blunder},
+	}
+	return Review{Title: "Test <game>", Black: "Alice", White: "Bob", Position: p, Responses: responses, Graded: graded}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, testReview()); err != nil {
+		t.Fatal(err)
+	}
+	s := buf.String()
+	for _, expected := range []string{
+		"<title>Test &lt;game&gt;</title>",
+		"Black: Alice",
+		`data-turn="2"`,
+		"lost 8.0 points. KataGo prefers G3.",
+		`<polyline points="0.0,60.0`,
+		`"variation":{"stones":`,
+		"<tr><th>White</th><td>8.0 (1)</td><td>&ndash;</td><td>&ndash;</td></tr>",
+	} {
+		if !strings.Contains(s, expected) {
+			t.Errorf("Expected the page to contain %q", expected)
+		}
+	}
+}
+
+func TestBuild(t *testing.T) {
+	pg, err := build(testReview())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pg.Data.Turns) != 4 {
+		t.Fatalf("Expected 4 turns, got %d", len(pg.Data.Turns))
+	}
+	after := pg.Data.Turns[2]
+	if after.Last != 72 || after.Stones[72] != 'w' || after.Stones[40] != 'b' {
+		t.Errorf("Expected a white stone at A1 and a black stone at E5, got %q", after.Stones)
+	}
+	// White is to move after E5, so black's winrate is 1 - 0.6
+	if after := pg.Data.Turns[1]; after.Winrate < 0.39 || after.Winrate > 0.41 || after.Lead != -2 {
+		t.Errorf("Expected black's winrate 0.4 and lead -2, got %v and %v", after.Winrate, after.Lead)
+	}
+	v := after.Variation
+	if v == nil || len(v.Numbers) != 2 || v.Stones[60] != 'w' || v.Stones[24] != 'b' {
+		t.Fatalf("Expected white G3 and black G7 in the variation, got %+v", v)
+	}
+	if len(pg.Mistakes) != 1 || pg.Mistakes[0].Turn != 2 {
+		t.Errorf("Expected one mistake at move 2, got %+v", pg.Mistakes)
+	}
+	r := testReview()
+	r.Position.Moves = append(r.Position.Moves, katago.Move{Color: "W", Vertex: "C3"})
+	if _, err := build(r); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
@@ -0,0 +1,145 @@
+package report
+
+import "html/template"
+
+// pageTemplate is the review page. The board is drawn by the script from the data of each turn,
+// so that the page works without a server and without any other files.
+var pageTemplate = template.Must(template.New("review").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1em; color: #222; }
+main { display: flex; flex-wrap: wrap; gap: 1.5em; }
+#board { width: min(90vw, 600px); }
+#board svg, #graph { display: block; width: 100%; }
+#graph { height: 150px; cursor: pointer; margin-top: 0.5em; }
+#side { flex: 1; min-width: 260px; }
+#controls button { font-size: 1.1em; min-width: 2.5em; }
+#comment { min-height: 3em; }
+#mistakes li { cursor: pointer; padding: 0.2em; }
+#mistakes li.current { background: #e8eefc; }
+.Blunder { color: #c00; }
+.Mistake { color: #d60; }
+#phases td, #phases th { padding: 0.2em 0.6em; text-align: left; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if or .Black .White}}<p>Black: {{.Black}} &middot; White: {{.White}}</p>{{end}}
+<main>
+<div>
+<div id="board"></div>
+{{.Graph}}
+</div>
+<div id="side">
+<div id="controls">
+<button id="first" title="First move">&#x23EE;</button>
+<button id="previous" title="Previous move (left arrow)">&#x25C0;</button>
+<button id="next" title="Next move (right arrow)">&#x25B6;</button>
+<button id="last" title="Last move">&#x23ED;</button>
+<label><input type="checkbox" id="variation"> Show KataGo's variation</label>
+</div>
+<h2 id="move"></h2>
+<p id="evaluation"></p>
+<p id="comment"></p>
+<h2>Mistakes</h2>
+{{if .Mistakes}}<ol id="mistakes">
+{{range .Mistakes}}<li data-turn="{{.Turn}}"><span class="{{.Grade}}">{{.Grade}}</span>: move {{.Turn}}, {{.Color}} {{.Move}}, lost {{printf "%.1f" .PointsLost}} points. KataGo prefers {{.BestMove}}.</li>
+{{end}}</ol>{{else}}<p>No mistakes.</p>{{end}}
+{{if .PhaseRows}}<h2>Points lost by phase (moves)</h2>
+<table id="phases">
+<tr><th></th>{{range .Phases}}<th>{{.}}</th>{{end}}</tr>
+{{range .PhaseRows}}<tr><th>{{.Player}}</th>{{range .Cells}}<td>{{if .Moves}}{{printf "%.1f" .PointsLost}} ({{.Moves}}){{else}}&ndash;{{end}}</td>{{end}}</tr>
+{{end}}</table>{{end}}
+</div>
+</main>
+<script>
+const review = {{.Data}};
+let current = 0;
+
+function draw() {
+  const t = review.turns[current];
+  const showVariation = document.getElementById("variation").checked && t.variation;
+  const w = review.width, h = review.height, cell = 30, margin = 30;
+  const stones = showVariation ? t.variation.stones : t.stones;
+  const x = i => margin + (i % w) * cell, y = i => margin + Math.floor(i / w) * cell;
+  let s = '<svg viewBox="0 0 ' + ((w - 1) * cell + 2 * margin) + ' ' + ((h - 1) * cell + 2 * margin) + '">';
+  s += '<rect width="100%" height="100%" fill="#dcb35c"/>';
+  for (let i = 0; i < w; i++) {
+    s += '<line x1="' + x(i) + '" y1="' + margin + '" x2="' + x(i) + '" y2="' + (margin + (h - 1) * cell) + '" stroke="#000"/>';
+    s += '<text x="' + x(i) + '" y="' + (margin / 2) + '" font-size="12" text-anchor="middle" dominant-baseline="middle">' + review.columns[i] + '</text>';
+  }
+  for (let j = 0; j < h; j++) {
+    s += '<line x1="' + margin + '" y1="' + y(j * w) + '" x2="' + (margin + (w - 1) * cell) + '" y2="' + y(j * w) + '" stroke="#000"/>';
+    s += '<text x="' + (margin / 2) + '" y="' + y(j * w) + '" font-size="12" text-anchor="middle" dominant-baseline="middle">' + (h - j) + '</text>';
+  }
+  for (const i of review.stars) {
+    s += '<circle cx="' + x(i) + '" cy="' + y(i) + '" r="3" fill="#000"/>';
+  }
+  for (let i = 0; i < stones.length; i++) {
+    if (stones[i] === ".") continue;
+    const fill = stones[i] === "b" ? "#000" : "#fff";
+    s += '<circle cx="' + x(i) + '" cy="' + y(i) + '" r="' + (cell * 0.47) + '" fill="' + fill + '" stroke="#000"/>';
+  }
+  if (showVariation) {
+    for (const [i, n] of t.variation.numbers) {
+      const fill = stones[i] === "b" ? "#fff" : "#000";
+      s += '<text x="' + x(i) + '" y="' + y(i) + '" font-size="14" fill="' + fill + '" text-anchor="middle" dominant-baseline="central">' + n + '</text>';
+    }
+  } else if (t.last >= 0) {
+    const stroke = stones[t.last] === "b" ? "#fff" : "#000";
+    s += '<circle cx="' + x(t.last) + '" cy="' + y(t.last) + '" r="' + (cell * 0.25) + '" fill="none" stroke="' + stroke + '" stroke-width="2"/>';
+  }
+  s += "</svg>";
+  document.getElementById("board").innerHTML = s;
+
+  document.getElementById("move").textContent = current === 0 ? "Start of the game" : "Move " + current + ": " + t.move;
+  let evaluation = "";
+  if (t.analyzed) {
+    const lead = t.lead < 0 ? "W+" + (-t.lead).toFixed(1) : "B+" + t.lead.toFixed(1);
+    evaluation = "Black winrate " + (100 * t.winrate).toFixed(1) + "%, " + lead;
+  }
+  if (showVariation) {
+    evaluation += " — KataGo's variation: " + t.variation.moves.join(" ") + ", black winrate " + (100 * t.variation.winrate).toFixed(1) + "%";
+  }
+  document.getElementById("evaluation").textContent = evaluation;
+  document.getElementById("comment").textContent = t.comment;
+  const cursor = document.getElementById("cursor");
+  const position = 600 * current / Math.max(1, review.turns.length - 1);
+  cursor.setAttribute("x1", position);
+  cursor.setAttribute("x2", position);
+  for (const li of document.querySelectorAll("#mistakes li")) {
+    li.classList.toggle("current", Number(li.dataset.turn) === current);
+  }
+}
+
+function go(turn, showVariation) {
+  current = Math.max(0, Math.min(review.turns.length - 1, turn));
+  document.getElementById("variation").checked = !!showVariation;
+  draw();
+}
+
+document.getElementById("first").onclick = () => go(0);
+document.getElementById("previous").onclick = () => go(current - 1);
+document.getElementById("next").onclick = () => go(current + 1);
+document.getElementById("last").onclick = () => go(review.turns.length - 1);
+document.getElementById("variation").onchange = draw;
+document.getElementById("graph").onclick = e => {
+  const r = e.currentTarget.getBoundingClientRect();
+  go(Math.round((e.clientX - r.left) / r.width * (review.turns.length - 1)));
+};
+for (const li of document.querySelectorAll("#mistakes li")) {
+  li.onclick = () => go(Number(li.dataset.turn), true);
+}
+document.addEventListener("keydown", e => {
+  if (e.key === "ArrowLeft") go(current - 1);
+  if (e.key === "ArrowRight") go(current + 1);
+});
+draw();
+</script>
+</body>
+</html>
+`))
@@ -0,0 +1,60 @@
+package katago
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// DefaultRules are the rules used by the request constructors. Area scoring is used since it is
+// well defined on all board sizes, while territory scoring can give surprising results on small boards.
+var DefaultRules = Chinese
+
+// requestCounter is used for creating unique IDs for the requests made by the request constructors
+var requestCounter atomic.Uint64
+
+// DefaultKomi returns a sensible komi for an empty board of the given size, with area scoring.
+// Black has a larger advantage from the first move on small boards, 9 points on 7x7 and 7 points on 9x9,
+// while 7.5 is the usual komi on 13x13, 19x19 and on rectangular boards.
+func DefaultKomi(width, height int) float64 {
+	switch {
+	case width*height < 9*9:
+		return 9
+	case width == 9 && height == 9:
+		return 7
+	default:
+		return 7.5
+	}
+}
+
+// NewPosition returns an empty position of the given size, with the default rules and komi
+func NewPosition(width, height int) Position {
+	return Position{
+		Moves:      []Move{},
+		Rules:      DefaultRules,
+		Komi:       DefaultKomi(width, height),
+		BoardXSize: width,
+		BoardYSize: height,
+	}
+}
+
+// NewRequestRect returns a request for analyzing an empty board with the given width and height,
+// with the default rules and komi for that size. Moves can be appended to the request before
+// sending it, but AnalyzeTurns must then be updated too.
+func NewRequestRect(width, height int) AnalysisRequest {
+	return NewPosition(width, height).Request(fmt.Sprintf("request-%d", requestCounter.Add(1)))
+}
+
+// NewRequest9x9 returns a request for analyzing an empty 9x9 board
+func NewRequest9x9() AnalysisRequest {
+	return NewRequestRect(9, 9)
+}
+
+// NewRequest13x13 returns a request for analyzing an empty 13x13 board
+func NewRequest13x13() AnalysisRequest {
+	return NewRequestRect(13, 13)
+}
+
+// NewRequest19x19 returns a request for analyzing an empty 19x19 board
+func NewRequest19x19() AnalysisRequest {
+	return NewRequestRect(19, 19)
+}
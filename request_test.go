@@ -0,0 +1,49 @@
+package katago
+
+import "testing"
+
+func TestNewRequest9x9(t *testing.T) {
+	request := NewRequest9x9()
+	if request.BoardXSize != 9 || request.BoardYSize != 9 {
+		t.Errorf("Expected a 9x9 board, got %dx%d", request.BoardXSize, request.BoardYSize)
+	}
+	if request.Komi != 7 {
+		t.Errorf("Expected a komi of 7, got %v", request.Komi)
+	}
+	if request.Rules != DefaultRules {
+		t.Errorf("Expected %s, got %s", DefaultRules, request.Rules)
+	}
+	if request.Moves == nil || len(request.AnalyzeTurns) != 1 || request.AnalyzeTurns[0] != 0 {
+		t.Errorf("Expected no moves and turn 0 to be analyzed, got %v and %v", request.Moves, request.AnalyzeTurns)
+	}
+	if other := NewRequest9x9(); other.ID == request.ID {
+		t.Errorf("Expected unique IDs, got %s twice", request.ID)
+	}
+}
+
+func TestNewRequestRect(t *testing.T) {
+	request := NewRequestRect(13, 9)
+	if request.BoardXSize != 13 || request.BoardYSize != 9 {
+		t.Errorf("Expected a 13x9 board, got %dx%d", request.BoardXSize, request.BoardYSize)
+	}
+	if request.Komi != 7.5 {
+		t.Errorf("Expected a komi of 7.5, got %v", request.Komi)
+	}
+	if DefaultKomi(7, 7) != 9 || DefaultKomi(19, 19) != 7.5 {
+		t.Errorf("Expected a komi of 9 on 7x7 and 7.5 on 19x19, got %v and %v", DefaultKomi(7, 7), DefaultKomi(19, 19))
+	}
+}
+
+func TestNewRequestAnalyze(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if len(responses) != 1 || responses[0].ID != request.ID {
+		t.Errorf("Expected one response for %s, got %v", request.ID, responses)
+	}
+}
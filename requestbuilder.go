@@ -0,0 +1,220 @@
+package katago
+
+import (
+	"fmt"
+	"time"
+)
+
+// RequestBuilder builds an analysis request one field at a time. The first invalid move or setting is
+// remembered and returned by Build, so that the calls can be chained without checking each of them.
+type RequestBuilder struct {
+	request AnalysisRequest
+	komiSet bool
+	err     error
+}
+
+// NewRequestBuilder starts a request for an empty 19x19 board, with the default rules and komi
+func NewRequestBuilder() *RequestBuilder {
+	return &RequestBuilder{request: AnalysisRequest{
+		Moves:      []Move{},
+		Rules:      DefaultRules,
+		Komi:       DefaultKomi(19, 19),
+		BoardXSize: 19,
+		BoardYSize: 19,
+	}}
+}
+
+// fail remembers the first error
+func (b *RequestBuilder) fail(err error) *RequestBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
+
+// ID sets the ID of the request. A new ID is used if it is not set.
+func (b *RequestBuilder) ID(id string) *RequestBuilder {
+	b.request.ID = id
+	return b
+}
+
+// Board sets the size of the board. The komi becomes the default komi for that size, unless it is set with Komi.
+func (b *RequestBuilder) Board(width, height int) *RequestBuilder {
+	b.request.BoardXSize, b.request.BoardYSize = width, height
+	if !b.komiSet {
+		b.request.Komi = DefaultKomi(width, height)
+	}
+	return b
+}
+
+// Rules sets the rules
+func (b *RequestBuilder) Rules(rules Rules) *RequestBuilder {
+	b.request.Rules = rules
+	return b
+}
+
+// Komi sets the komi
+func (b *RequestBuilder) Komi(komi float64) *RequestBuilder {
+	b.request.Komi = komi
+	b.komiSet = true
+	return b
+}
+
+// WhiteHandicapBonus sets how many points white gets for each handicap stone: "0", "N-1" or "N"
+func (b *RequestBuilder) WhiteHandicapBonus(bonus string) *RequestBuilder {
+	b.request.WhiteHandicapBonus = bonus
+	return b
+}
+
+// Stone places a stone before the first move, like a handicap stone
+func (b *RequestBuilder) Stone(color Color, vertex string) *RequestBuilder {
+	m, err := NewMove(string(color), vertex)
+	if err != nil {
+		return b.fail(fmt.Errorf("initial stone %d: %v", len(b.request.InitialStones)+1, err))
+	}
+	b.request.InitialStones = append(b.request.InitialStones, m)
+	return b
+}
+
+// InitialPlayer sets the player to move first
+func (b *RequestBuilder) InitialPlayer(color Color) *RequestBuilder {
+	if _, err := ParseColor(string(color)); err != nil {
+		return b.fail(fmt.Errorf("initial player: %v", err))
+	}
+	b.request.InitialPlayer = string(color)
+	return b
+}
+
+// Move plays a move, like Move(Black, "Q16") or Move(White, "pass")
+func (b *RequestBuilder) Move(color Color, vertex string) *RequestBuilder {
+	m, err := NewMove(string(color), vertex)
+	if err != nil {
+		return b.fail(fmt.Errorf("move %d: %v", len(b.request.Moves)+1, err))
+	}
+	b.request.Moves = append(b.request.Moves, m)
+	return b
+}
+
+// Moves plays the given moves
+func (b *RequestBuilder) Moves(moves ...Move) *RequestBuilder {
+	for _, m := range moves {
+		b.Move(m.Color, string(m.Vertex))
+	}
+	return b
+}
+
+// MaxVisits sets the maximum number of visits
+func (b *RequestBuilder) MaxVisits(visits int) *RequestBuilder {
+	b.request.MaxVisits = visits
+	return b
+}
+
+// AnalyzeTurns sets the turns to analyze. Only the position after all the moves is analyzed if it is not set.
+func (b *RequestBuilder) AnalyzeTurns(turns ...int) *RequestBuilder {
+	b.request.AnalyzeTurns = append([]int{}, turns...)
+	return b
+}
+
+// AnalyzeAllTurns analyzes every turn, from the initial position to the position after all the moves
+func (b *RequestBuilder) AnalyzeAllTurns() *RequestBuilder {
+	b.request.AnalyzeTurns = nil
+	for turn := 0; turn <= len(b.request.Moves); turn++ {
+		b.request.AnalyzeTurns = append(b.request.AnalyzeTurns, turn)
+	}
+	return b
+}
+
+// IncludeOwnership asks for the predicted ownership of each point
+func (b *RequestBuilder) IncludeOwnership() *RequestBuilder {
+	b.request.IncludeOwnership = true
+	return b
+}
+
+// IncludeOwnershipStdev asks for the standard deviation of the ownership of each point
+func (b *RequestBuilder) IncludeOwnershipStdev() *RequestBuilder {
+	b.request.IncludeOwnershipStdev = true
+	return b
+}
+
+// IncludeMovesOwnership asks for the ownership after each candidate move
+func (b *RequestBuilder) IncludeMovesOwnership() *RequestBuilder {
+	b.request.IncludeMovesOwnership = true
+	return b
+}
+
+// IncludePolicy asks for the raw policy of the neural network
+func (b *RequestBuilder) IncludePolicy() *RequestBuilder {
+	b.request.IncludePolicy = true
+	return b
+}
+
+// IncludePVVisits asks for the visits of each move in the principal variations
+func (b *RequestBuilder) IncludePVVisits() *RequestBuilder {
+	b.request.IncludePVVisits = true
+	return b
+}
+
+// ReportEvery makes KataGo report the results so far at the given interval, for AnalyzeStream
+func (b *RequestBuilder) ReportEvery(interval time.Duration) *RequestBuilder {
+	b.request.ReportDuringSearchEvery = interval.Seconds()
+	return b
+}
+
+// Override replaces a setting from the config file for this request, like "humanSLProfile"
+func (b *RequestBuilder) Override(key string, value any) *RequestBuilder {
+	if b.request.OverrideSettings == nil {
+		b.request.OverrideSettings = make(map[string]any)
+	}
+	b.request.OverrideSettings[key] = value
+	return b
+}
+
+// Avoid keeps the player from playing the given moves during the first untilDepth moves of the search
+func (b *RequestBuilder) Avoid(player Color, untilDepth int, vertices ...string) *RequestBuilder {
+	b.request.AvoidMoves = append(b.request.AvoidMoves, MoveRestriction{string(player), vertices, untilDepth})
+	return b
+}
+
+// Allow only lets the player play the given moves during the first untilDepth moves of the search
+func (b *RequestBuilder) Allow(player Color, untilDepth int, vertices ...string) *RequestBuilder {
+	b.request.AllowMoves = append(b.request.AllowMoves, MoveRestriction{string(player), vertices, untilDepth})
+	return b
+}
+
+// Priority sets the priority of the query, where higher values are searched first
+func (b *RequestBuilder) Priority(priority int) *RequestBuilder {
+	b.request.Priority = priority
+	return b
+}
+
+// Timeout terminates the search after this long, and the results so far are returned
+func (b *RequestBuilder) Timeout(timeout time.Duration) *RequestBuilder {
+	b.request.Timeout = timeout
+	return b
+}
+
+// MaxTime limits the search time of each analyzed turn, with the maxTime setting
+func (b *RequestBuilder) MaxTime(d time.Duration) *RequestBuilder {
+	b.request.SetMaxTime(d)
+	return b
+}
+
+// Build returns the request, or the first error from the builder, or the error from Validate
+func (b *RequestBuilder) Build() (AnalysisRequest, error) {
+	if b.err != nil {
+		return AnalysisRequest{}, b.err
+	}
+	r := b.request
+	r.InitialStones = append([]Move(nil), r.InitialStones...)
+	r.Moves = append([]Move{}, r.Moves...)
+	if r.ID == "" {
+		r.ID = fmt.Sprintf("request-%d", requestCounter.Add(1))
+	}
+	if r.AnalyzeTurns == nil {
+		r.AnalyzeTurns = []int{len(r.Moves)}
+	}
+	if err := r.Validate(); err != nil {
+		return AnalysisRequest{}, err
+	}
+	return r, nil
+}
@@ -0,0 +1,68 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestBuilder(t *testing.T) {
+	r, err := NewRequestBuilder().
+		ID("built").
+		Board(19, 19).
+		Rules(Japanese).
+		Komi(6.5).
+		Move(Black, "q16").
+		Move(White, "D4").
+		MaxVisits(100).
+		IncludeOwnership().
+		ReportEvery(500*time.Millisecond).
+		Override("humanSLProfile", "rank_5k").
+		MaxTime(2 * time.Second).
+		Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID != "built" || r.Rules != Japanese || r.Komi != 6.5 || r.MaxVisits != 100 || !r.IncludeOwnership {
+		t.Errorf("Expected the fields to be set, got %+v", r)
+	}
+	if len(r.Moves) != 2 || r.Moves[0] != (Move{Black, "Q16"}) {
+		t.Errorf("Expected B Q16 and W D4, got %v", r.Moves)
+	}
+	if len(r.AnalyzeTurns) != 1 || r.AnalyzeTurns[0] != 2 {
+		t.Errorf("Expected the last turn to be analyzed, got %v", r.AnalyzeTurns)
+	}
+	if r.ReportDuringSearchEvery != 0.5 || r.OverrideSettings["humanSLProfile"] != "rank_5k" || r.OverrideSettings["maxTime"] != 2.0 {
+		t.Errorf("Expected the report interval and the override, got %+v", r)
+	}
+}
+
+func TestRequestBuilderDefaults(t *testing.T) {
+	r, err := NewRequestBuilder().Board(9, 9).Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ID == "" || r.Komi != DefaultKomi(9, 9) || r.Rules != DefaultRules || r.Moves == nil {
+		t.Errorf("Expected the defaults for a 9x9 board, got %+v", r)
+	}
+	r, err = NewRequestBuilder().Move(Black, "C3").Move(White, "pass").AnalyzeAllTurns().Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.AnalyzeTurns) != 3 {
+		t.Errorf("Expected 3 turns, got %v", r.AnalyzeTurns)
+	}
+}
+
+func TestRequestBuilderErrors(t *testing.T) {
+	_, err := NewRequestBuilder().Move(Black, "Q16").Move(White, "nowhere").Move(Black, "D4").Build()
+	if err == nil || !strings.Contains(err.Error(), "move 2") {
+		t.Errorf("Expected an error for move 2, got %v", err)
+	}
+	if _, err := NewRequestBuilder().Board(9, 9).Move(Black, "Q16").Build(); err == nil {
+		t.Error("Expected an error for a move outside of the board")
+	}
+	if _, err := NewRequestBuilder().Komi(0.3).Build(); err == nil {
+		t.Error("Expected an error for an invalid komi")
+	}
+}
@@ -0,0 +1,65 @@
+package katago
+
+import (
+	"cmp"
+	"slices"
+)
+
+// BestMove returns the move that KataGo prefers, which is the one with the lowest order, or false if there
+// are no analyzed moves. SelectMove picks a move by its lower confidence bound instead.
+func (r AnalysisResponse) BestMove() (MoveInfoExt, bool) {
+	if len(r.MoveInfos) == 0 {
+		return MoveInfoExt{}, false
+	}
+	best := r.MoveInfos[0]
+	for _, info := range r.MoveInfos[1:] {
+		if info.Order < best.Order {
+			best = info
+		}
+	}
+	return best, true
+}
+
+// WinrateFor returns the probability that the given color wins, from 0 to 1. The winrate of the response
+// is for the side to move, and if the response has no current player, it is taken to be for black.
+func (r AnalysisResponse) WinrateFor(color Color) float64 {
+	if blackSign(r.RootInfo.CurrentPlayer) == blackSign(string(color)) {
+		return r.RootInfo.Winrate
+	}
+	return 1 - r.RootInfo.Winrate
+}
+
+// ScoreFor returns the number of points that the given color is ahead, negative if it is behind
+func (r AnalysisResponse) ScoreFor(color Color) float64 {
+	return blackSign(r.RootInfo.CurrentPlayer) * blackSign(string(color)) * r.RootInfo.ScoreLead
+}
+
+// TopMoves returns the n best candidate moves, or all of them if n is 0 or less, as for TopMovesWithVisits
+func (r AnalysisResponse) TopMoves(n int) []MoveInfoExt {
+	return r.TopMovesWithVisits(n, 0)
+}
+
+// TopMovesWithVisits returns the n best candidate moves that have at least minVisits visits, or all of them if
+// n is 0 or less, for showing in a UI. The moves are ordered by the order that KataGo gave them, then by the most
+// visits, and then by the name of the move, so that the same response always gives the same list.
+func (r AnalysisResponse) TopMovesWithVisits(n, minVisits int) []MoveInfoExt {
+	var moves []MoveInfoExt
+	for _, info := range r.MoveInfos {
+		if info.Visits >= minVisits {
+			moves = append(moves, info)
+		}
+	}
+	slices.SortStableFunc(moves, func(a, b MoveInfoExt) int {
+		if c := cmp.Compare(a.Order, b.Order); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(b.Visits, a.Visits); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Move, b.Move)
+	})
+	if n > 0 && len(moves) > n {
+		moves = moves[:n]
+	}
+	return moves
+}
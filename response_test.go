@@ -0,0 +1,66 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBestMove(t *testing.T) {
+	response := AnalysisResponse{MoveInfos: []MoveInfoExt{
+		{Move: "Q16", Order: 1},
+		{Move: "D4", Order: 0},
+		{Move: "C3", Order: 2},
+	}}
+	if best, ok := response.BestMove(); !ok || best.Move != "D4" {
+		t.Errorf("Expected D4, got %s", best.Move)
+	}
+	if _, ok := (AnalysisResponse{}).BestMove(); ok {
+		t.Error("Expected no best move for a response without moves")
+	}
+}
+
+func TestWinrateAndScoreFor(t *testing.T) {
+	response := AnalysisResponse{RootInfo: RootInfo{Winrate: 0.7, ScoreLead: 3.5, CurrentPlayer: "W"}}
+	if w := response.WinrateFor(White); w != 0.7 {
+		t.Errorf("Expected 0.7 for white, got %g", w)
+	}
+	if w := response.WinrateFor(Black); w < 0.299 || w > 0.301 {
+		t.Errorf("Expected 0.3 for black, got %g", w)
+	}
+	if s := response.ScoreFor(White); s != 3.5 {
+		t.Errorf("Expected 3.5 for white, got %g", s)
+	}
+	if s := response.ScoreFor(Black); s != -3.5 {
+		t.Errorf("Expected -3.5 for black, got %g", s)
+	}
+}
+
+func TestTopMoves(t *testing.T) {
+	response := AnalysisResponse{MoveInfos: []MoveInfoExt{
+		{Move: "C3", Order: 2, Visits: 10},
+		{Move: "Q16", Order: 1, Visits: 50},
+		{Move: "D4", Order: 0, Visits: 100},
+		{Move: "R4", Order: 1, Visits: 50},
+		{Move: "E5", Order: 1, Visits: 60},
+	}}
+	var names []string
+	for _, info := range response.TopMoves(4) {
+		names = append(names, info.Move)
+	}
+	if got := strings.Join(names, " "); got != "D4 E5 Q16 R4" {
+		t.Errorf("Expected D4 E5 Q16 R4, got %s", got)
+	}
+	if moves := response.TopMoves(0); len(moves) != 5 {
+		t.Errorf("Expected all 5 moves, got %d", len(moves))
+	}
+	names = nil
+	for _, info := range response.TopMovesWithVisits(0, 55) {
+		names = append(names, info.Move)
+	}
+	if got := strings.Join(names, " "); got != "D4 E5" {
+		t.Errorf("Expected D4 E5 with at least 55 visits, got %s", got)
+	}
+	if response.MoveInfos[0].Move != "C3" {
+		t.Error("Expected the moves of the response to be left as they are")
+	}
+}
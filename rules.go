@@ -0,0 +1,233 @@
+package katago
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xyproto/katago/board"
+)
+
+// Rules is a ruleset, either the name of one of the rulesets that KataGo knows or
+// a detailed ruleset in KataGo's short notation, like "koPOSITIONALscoreAREAtaxNONEsui1"
+type Rules string
+
+// Named rulesets
+const (
+	TrompTaylor  Rules = "tromp-taylor"
+	Chinese      Rules = "chinese"
+	ChineseOGS   Rules = "chinese-ogs"
+	ChineseKGS   Rules = "chinese-kgs"
+	Japanese     Rules = "japanese"
+	Korean       Rules = "korean"
+	StoneScoring Rules = "stone-scoring"
+	AGA          Rules = "aga"
+	BGA          Rules = "bga"
+	NewZealand   Rules = "new-zealand"
+	AGAButton    Rules = "aga-button"
+)
+
+// KoRule decides which board repetitions are forbidden
+type KoRule string
+
+// Ko rules
+const (
+	KoSimple      KoRule = "SIMPLE"
+	KoPositional  KoRule = "POSITIONAL"
+	KoSituational KoRule = "SITUATIONAL"
+)
+
+// ScoringRule decides how the score is counted
+type ScoringRule string
+
+// Scoring rules
+const (
+	ScoringArea      ScoringRule = "AREA"
+	ScoringTerritory ScoringRule = "TERRITORY"
+)
+
+// TaxRule decides which points are not counted
+type TaxRule string
+
+// Tax rules
+const (
+	// TaxNone counts all points
+	TaxNone TaxRule = "NONE"
+	// TaxSeki does not count points in seki, as in the Japanese rules
+	TaxSeki TaxRule = "SEKI"
+	// TaxAll removes two points for each group, as in stone scoring
+	TaxAll TaxRule = "ALL"
+)
+
+// DetailedRules describes a ruleset in detail
+type DetailedRules struct {
+	Ko      KoRule
+	Scoring ScoringRule
+	Tax     TaxRule
+	// Suicide allows multi-stone suicide
+	Suicide bool
+	// HasButton gives half a point to the first player that passes
+	HasButton bool
+	// WhiteHandicapBonus is how many points white gets for each handicap stone: "0", "N-1" or "N"
+	WhiteHandicapBonus string
+	// FriendlyPassOk means that the game can end without first removing dead stones
+	FriendlyPassOk bool
+}
+
+// namedRules are the detailed definitions of the named rulesets, as used by KataGo
+var namedRules = map[Rules]DetailedRules{
+	TrompTaylor:  {Ko: KoPositional, Scoring: ScoringArea, Tax: TaxNone, Suicide: true, WhiteHandicapBonus: "0"},
+	Chinese:      {Ko: KoSimple, Scoring: ScoringArea, Tax: TaxNone, WhiteHandicapBonus: "N", FriendlyPassOk: true},
+	ChineseOGS:   {Ko: KoPositional, Scoring: ScoringArea, Tax: TaxNone, WhiteHandicapBonus: "N", FriendlyPassOk: true},
+	ChineseKGS:   {Ko: KoPositional, Scoring: ScoringArea, Tax: TaxNone, WhiteHandicapBonus: "N", FriendlyPassOk: true},
+	Japanese:     {Ko: KoSimple, Scoring: ScoringTerritory, Tax: TaxSeki, WhiteHandicapBonus: "0", FriendlyPassOk: true},
+	Korean:       {Ko: KoSimple, Scoring: ScoringTerritory, Tax: TaxSeki, WhiteHandicapBonus: "0", FriendlyPassOk: true},
+	StoneScoring: {Ko: KoSimple, Scoring: ScoringArea, Tax: TaxAll, WhiteHandicapBonus: "0", FriendlyPassOk: true},
+	AGA:          {Ko: KoSituational, Scoring: ScoringArea, Tax: TaxNone, WhiteHandicapBonus: "N-1", FriendlyPassOk: true},
+	BGA:          {Ko: KoSituational, Scoring: ScoringArea, Tax: TaxNone, WhiteHandicapBonus: "N-1", FriendlyPassOk: true},
+	NewZealand:   {Ko: KoSituational, Scoring: ScoringArea, Tax: TaxNone, Suicide: true, WhiteHandicapBonus: "0"},
+	AGAButton:    {Ko: KoSituational, Scoring: ScoringArea, Tax: TaxNone, HasButton: true, WhiteHandicapBonus: "N-1", FriendlyPassOk: true},
+}
+
+// Validate checks that all the fields of the detailed rules have valid values
+func (d DetailedRules) Validate() error {
+	switch d.Ko {
+	case KoSimple, KoPositional, KoSituational:
+	default:
+		return fmt.Errorf("invalid ko rule: %q", d.Ko)
+	}
+	switch d.Scoring {
+	case ScoringArea, ScoringTerritory:
+	default:
+		return fmt.Errorf("invalid scoring rule: %q", d.Scoring)
+	}
+	switch d.Tax {
+	case TaxNone, TaxSeki, TaxAll:
+	default:
+		return fmt.Errorf("invalid tax rule: %q", d.Tax)
+	}
+	switch d.WhiteHandicapBonus {
+	case "", "0", "N-1", "N":
+	default:
+		return fmt.Errorf("invalid white handicap bonus: %q", d.WhiteHandicapBonus)
+	}
+	if d.HasButton && d.Scoring != ScoringArea {
+		return fmt.Errorf("the button is only supported with area scoring")
+	}
+	return nil
+}
+
+// boolFlag returns "1" or "0"
+func boolFlag(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Rules returns the detailed rules in KataGo's short notation, which can be used as the rules of a request
+func (d DetailedRules) Rules() Rules {
+	var sb strings.Builder
+	sb.WriteString("ko" + string(d.Ko))
+	sb.WriteString("score" + string(d.Scoring))
+	sb.WriteString("tax" + string(d.Tax))
+	sb.WriteString("sui" + boolFlag(d.Suicide))
+	if d.HasButton {
+		sb.WriteString("button1")
+	}
+	if d.WhiteHandicapBonus != "" && d.WhiteHandicapBonus != "0" {
+		sb.WriteString("whb" + d.WhiteHandicapBonus)
+	}
+	if d.FriendlyPassOk {
+		sb.WriteString("fpok1")
+	}
+	return Rules(sb.String())
+}
+
+// parseShortRules parses KataGo's short notation for detailed rules
+func parseShortRules(s string) (DetailedRules, error) {
+	d := DetailedRules{WhiteHandicapBonus: "0"}
+	rest := s
+	// The values are upper case and the keys are lower case, so each key ends where the next one starts
+	next := func(key string) (string, bool) {
+		if !strings.HasPrefix(rest, key) {
+			return "", false
+		}
+		rest = rest[len(key):]
+		end := 0
+		for end < len(rest) && !(rest[end] >= 'a' && rest[end] <= 'z') {
+			end++
+		}
+		value := rest[:end]
+		rest = rest[end:]
+		return value, true
+	}
+	for rest != "" {
+		var ok bool
+		var value string
+		switch {
+		case strings.HasPrefix(rest, "ko"):
+			value, ok = next("ko")
+			d.Ko = KoRule(value)
+		case strings.HasPrefix(rest, "score"):
+			value, ok = next("score")
+			d.Scoring = ScoringRule(value)
+		case strings.HasPrefix(rest, "tax"):
+			value, ok = next("tax")
+			d.Tax = TaxRule(value)
+		case strings.HasPrefix(rest, "sui"):
+			value, ok = next("sui")
+			d.Suicide = value == "1"
+		case strings.HasPrefix(rest, "button"):
+			value, ok = next("button")
+			d.HasButton = value == "1"
+		case strings.HasPrefix(rest, "whb"):
+			value, ok = next("whb")
+			d.WhiteHandicapBonus = value
+		case strings.HasPrefix(rest, "fpok"):
+			value, ok = next("fpok")
+			d.FriendlyPassOk = value == "1"
+		}
+		if !ok {
+			return DetailedRules{}, fmt.Errorf("invalid rules: %q", s)
+		}
+	}
+	if err := d.Validate(); err != nil {
+		return DetailedRules{}, fmt.Errorf("invalid rules %q: %v", s, err)
+	}
+	return d, nil
+}
+
+// Detailed returns the detailed definition of the rules
+func (r Rules) Detailed() (DetailedRules, error) {
+	if d, ok := namedRules[Rules(strings.ToLower(string(r)))]; ok {
+		return d, nil
+	}
+	if strings.HasPrefix(string(r), "ko") {
+		return parseShortRules(string(r))
+	}
+	return DetailedRules{}, fmt.Errorf("unknown rules: %q", string(r))
+}
+
+// Validate checks that the rules are either a known ruleset or valid detailed rules
+func (r Rules) Validate() error {
+	_, err := r.Detailed()
+	return err
+}
+
+// NewBoard creates an empty board that uses the ko and suicide rules of these rules
+func (d DetailedRules) NewBoard(width, height int) (*board.Board, error) {
+	b, err := board.New(width, height)
+	if err != nil {
+		return nil, err
+	}
+	switch d.Ko {
+	case KoPositional:
+		b.KoRule = board.PositionalSuperko
+	case KoSituational:
+		b.KoRule = board.SituationalSuperko
+	default:
+		b.KoRule = board.SimpleKo
+	}
+	b.Suicide = d.Suicide
+	return b, nil
+}
@@ -0,0 +1,82 @@
+package katago
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestNamedRules(t *testing.T) {
+	d, err := Japanese.Detailed()
+	if err != nil {
+		t.Fatalf("Failed to get the detailed Japanese rules: %v", err)
+	}
+	if d.Scoring != ScoringTerritory || d.Tax != TaxSeki || d.Ko != KoSimple {
+		t.Errorf("Expected territory scoring with seki tax and simple ko, got %+v", d)
+	}
+	if _, err := Rules("Chinese").Detailed(); err != nil {
+		t.Errorf("Expected rule names to be case insensitive, got %v", err)
+	}
+	for name := range namedRules {
+		if err := name.Validate(); err != nil {
+			t.Errorf("Expected %s to be valid, got %v", name, err)
+		}
+	}
+}
+
+func TestShortRules(t *testing.T) {
+	const short = Rules("koPOSITIONALscoreAREAtaxNONEsui1button1whbN-1fpok1")
+	d, err := short.Detailed()
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", short, err)
+	}
+	expected := DetailedRules{Ko: KoPositional, Scoring: ScoringArea, Tax: TaxNone, Suicide: true, HasButton: true, WhiteHandicapBonus: "N-1", FriendlyPassOk: true}
+	if d != expected {
+		t.Errorf("Expected %+v, got %+v", expected, d)
+	}
+	if d.Rules() != short {
+		t.Errorf("Expected %s, got %s", short, d.Rules())
+	}
+	for name, d := range namedRules {
+		parsed, err := d.Rules().Detailed()
+		if err != nil {
+			t.Errorf("Failed to parse the short notation of %s: %v", name, err)
+			continue
+		}
+		if parsed != d {
+			t.Errorf("Expected %s to round trip as %+v, got %+v", name, d, parsed)
+		}
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	for _, rules := range []Rules{"", "chess", "koBOGUSscoreAREAtaxNONEsui0", "koSIMPLEscoreTERRITORYtaxNONEsui0button1", "koSIMPLEscoreAREAtaxNONEsui0whb2", "koSIMPLEscoreAREAtaxNONEsui0extra1"} {
+		if err := rules.Validate(); err == nil {
+			t.Errorf("Expected %q to be invalid", rules)
+		}
+	}
+}
+
+func TestRulesNewBoard(t *testing.T) {
+	d, err := TrompTaylor.Detailed()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := d.NewBoard(9, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.KoRule != board.PositionalSuperko || !b.Suicide {
+		t.Errorf("Expected positional superko with suicide, got %v and %v", b.KoRule, b.Suicide)
+	}
+}
+
+func TestAnalyzeInvalidRules(t *testing.T) {
+	// The rules are checked before anything is sent, so no engine is needed
+	k := &KataGo{}
+	p := Position{Rules: "chess", BoardXSize: 9, BoardYSize: 9}
+	_, err := k.Analyze([]AnalysisRequest{p.Request("invalid")})
+	if err == nil {
+		t.Error("Expected an error for invalid rules")
+	}
+}
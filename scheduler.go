@@ -0,0 +1,223 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// SchedulerDefaultVisits is the cost of a request without MaxVisits, for the fairness of a Scheduler
+var SchedulerDefaultVisits = 500
+
+// Scheduler decides the order in which the requests of several clients are sent to one engine, for servers
+// that share an engine between users. At most the given number of calls run at the same time. When a slot
+// is free, the waiting call of the client that has been given the fewest visits so far goes first, so that
+// one client with a long search can not starve the quick queries of the others. Ties go by the deadline,
+// then the cheapest call first, and then in order. A call whose deadline passes while it waits is never sent.
+type Scheduler struct {
+	mut     sync.Mutex
+	slots   int
+	running map[string]int
+	waiting []*ticket
+	// used is the number of visits that each active client has been given. Clients are removed when they
+	// have no more calls, since a client that comes back starts level with the active clients anyway, so
+	// that client names that are only used once do not pile up.
+	used map[string]float64
+	seq  uint64
+}
+
+// ticket is a call that waits for a slot
+type ticket struct {
+	client   string
+	cost     float64
+	deadline time.Time
+	seq      uint64
+	ready    chan struct{}
+}
+
+// NewScheduler creates a scheduler that runs at most slots calls at the same time
+func NewScheduler(slots int) *Scheduler {
+	return &Scheduler{slots: max(1, slots), running: make(map[string]int), used: make(map[string]float64)}
+}
+
+// cost returns the number of visits that the requests are expected to use
+func cost(requests []AnalysisRequest) float64 {
+	total := 0.0
+	for _, r := range requests {
+		visits := r.MaxVisits
+		if visits <= 0 {
+			visits = SchedulerDefaultVisits
+		}
+		total += float64(visits * r.turns())
+	}
+	return total
+}
+
+// before checks if the ticket a goes before b
+func (s *Scheduler) before(a, b *ticket) bool {
+	if ua, ub := s.used[a.client], s.used[b.client]; ua != ub {
+		return ua < ub
+	}
+	switch {
+	case a.deadline.IsZero() != b.deadline.IsZero():
+		return !a.deadline.IsZero()
+	case !a.deadline.Equal(b.deadline):
+		return a.deadline.Before(b.deadline)
+	case a.cost != b.cost:
+		return a.cost < b.cost
+	}
+	return a.seq < b.seq
+}
+
+// active checks if the client has calls that are running or waiting, and must be called with mut held
+func (s *Scheduler) active(client string) bool {
+	if s.running[client] > 0 {
+		return true
+	}
+	for _, t := range s.waiting {
+		if t.client == client {
+			return true
+		}
+	}
+	return false
+}
+
+// forget removes the visits of the client if it has no more calls, and must be called with mut held
+func (s *Scheduler) forget(client string) {
+	if !s.active(client) {
+		delete(s.used, client)
+	}
+}
+
+// dispatch gives the free slots to the waiting calls that go first, and must be called with mut held
+func (s *Scheduler) dispatch() {
+	for s.slotsInUse() < s.slots && len(s.waiting) > 0 {
+		best := 0
+		for i, t := range s.waiting[1:] {
+			if s.before(t, s.waiting[best]) {
+				best = i + 1
+			}
+		}
+		t := s.waiting[best]
+		s.waiting = append(s.waiting[:best], s.waiting[best+1:]...)
+		s.running[t.client]++
+		s.used[t.client] += t.cost
+		close(t.ready)
+	}
+}
+
+// slotsInUse returns the number of running calls, and must be called with mut held
+func (s *Scheduler) slotsInUse() int {
+	n := 0
+	for _, count := range s.running {
+		n += count
+	}
+	return n
+}
+
+// acquire waits for a slot for a call of the client with the given cost, and returns the function that frees it
+func (s *Scheduler) acquire(ctx context.Context, client string, cost float64) (func(), error) {
+	deadline, _ := ctx.Deadline()
+	s.mut.Lock()
+	if !s.active(client) {
+		// A client that was idle starts level with the active clients, instead of with the visits it saved up
+		lowest, found := 0.0, false
+		for other, used := range s.used {
+			if other != client && (!found || used < lowest) {
+				lowest, found = used, true
+			}
+		}
+		if found {
+			s.used[client] = lowest
+		}
+	}
+	s.seq++
+	t := &ticket{client: client, cost: cost, deadline: deadline, seq: s.seq, ready: make(chan struct{})}
+	s.waiting = append(s.waiting, t)
+	s.dispatch()
+	s.mut.Unlock()
+
+	release := func() {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		if s.running[client]--; s.running[client] <= 0 {
+			delete(s.running, client)
+		}
+		s.forget(client)
+		s.dispatch()
+	}
+	select {
+	case <-t.ready:
+		return release, nil
+	case <-ctx.Done():
+	}
+	s.mut.Lock()
+	select {
+	case <-t.ready:
+		// The slot was given just as the context was done, so it is given to the next call
+		s.mut.Unlock()
+		release()
+	default:
+		for i, other := range s.waiting {
+			if other == t {
+				s.waiting = append(s.waiting[:i], s.waiting[i+1:]...)
+				break
+			}
+		}
+		s.forget(client)
+		s.mut.Unlock()
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: the deadline passed while waiting for the scheduler", ErrQueryTimeout)
+	}
+	return nil, ctx.Err()
+}
+
+// Analyze waits until the scheduler lets the client go, and then analyzes the requests with the engine.
+// The deadline of the context is used for the order of the calls of the client. Requests without
+// a Timeout are terminated at the deadline, and return the results found so far.
+func (s *Scheduler) Analyze(ctx context.Context, k *KataGo, client string, requests []AnalysisRequest) ([]AnalysisResponse, error) {
+	release, err := s.acquire(ctx, client, cost(requests))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return k.Analyze(withDeadline(ctx, requests))
+}
+
+// AnalyzeStream waits until the scheduler lets the client go, and then analyzes the request with
+// AnalyzeStream, in the same way as Analyze
+func (s *Scheduler) AnalyzeStream(ctx context.Context, k *KataGo, client string, request AnalysisRequest, interim func(AnalysisResponse)) (AnalysisResponse, error) {
+	release, err := s.acquire(ctx, client, cost([]AnalysisRequest{request}))
+	if err != nil {
+		return AnalysisResponse{}, err
+	}
+	defer release()
+	return k.AnalyzeStream(withDeadline(ctx, []AnalysisRequest{request})[0], interim)
+}
+
+// withDeadline gives the requests without a Timeout the time that is left until the deadline of the context
+func withDeadline(ctx context.Context, requests []AnalysisRequest) []AnalysisRequest {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return requests
+	}
+	left := max(time.Until(deadline), time.Millisecond)
+	timed := make([]AnalysisRequest, len(requests))
+	for i, r := range requests {
+		if r.Timeout == 0 {
+			r.Timeout = left
+		}
+		timed[i] = r
+	}
+	return timed
+}
+
+// Waiting returns the number of calls that wait for a slot
+func (s *Scheduler) Waiting() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return len(s.waiting)
+}
@@ -0,0 +1,136 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// queue acquires a slot in the background, and waits until the call is waiting
+func queue(t *testing.T, s *Scheduler, ctx context.Context, client string, cost float64, order chan<- string) {
+	t.Helper()
+	waiting := s.Waiting()
+	go func() {
+		release, err := s.acquire(ctx, client, cost)
+		if err != nil {
+			order <- "error"
+			return
+		}
+		order <- client
+		release()
+	}()
+	for s.Waiting() == waiting {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerFairness(t *testing.T) {
+	s := NewScheduler(1)
+	release, err := s.acquire(context.Background(), "big", 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan string, 5)
+	queue(t, s, context.Background(), "big", 10000, order)
+	queue(t, s, context.Background(), "big", 10000, order)
+	for range 3 {
+		queue(t, s, context.Background(), "quick", 10, order)
+	}
+	release()
+	var got []string
+	for range 5 {
+		got = append(got, <-order)
+	}
+	expected := []string{"quick", "big", "quick", "quick", "big"}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("Expected %v, got %v", expected, got)
+		}
+	}
+}
+
+func TestSchedulerDeadlineOrder(t *testing.T) {
+	s := NewScheduler(1)
+	release, err := s.acquire(context.Background(), "a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan string, 2)
+	queue(t, s, context.Background(), "late", 10, order)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	queue(t, s, ctx, "soon", 10, order)
+	release()
+	if first := <-order; first != "soon" {
+		t.Errorf("Expected the call with a deadline to go first, got %s", first)
+	}
+	<-order
+}
+
+func TestSchedulerDeadlinePassed(t *testing.T) {
+	s := NewScheduler(1)
+	release, err := s.acquire(context.Background(), "a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, "b", 10); !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("Expected ErrQueryTimeout, got %v", err)
+	}
+	if s.Waiting() != 0 {
+		t.Errorf("Expected no waiting calls, got %d", s.Waiting())
+	}
+}
+
+func TestSchedulerForgetsIdleClients(t *testing.T) {
+	s := NewScheduler(1)
+	release, err := s.acquire(context.Background(), "a", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	order := make(chan string, 100)
+	for i := range 100 {
+		queue(t, s, context.Background(), fmt.Sprintf("client-%d", i), 10, order)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := s.acquire(ctx, "gone", 10); !errors.Is(err, ErrQueryTimeout) {
+		t.Errorf("Expected ErrQueryTimeout, got %v", err)
+	}
+	release()
+	for range 100 {
+		<-order
+	}
+	// The last call may still be releasing its slot
+	remembered := func() int {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		return len(s.used)
+	}
+	for start := time.Now(); remembered() != 0 && time.Since(start) < time.Second; {
+		time.Sleep(time.Millisecond)
+	}
+	if n := remembered(); n != 0 {
+		t.Errorf("Expected the clients to be forgotten when they have no more calls, got %d", n)
+	}
+}
+
+func TestSchedulerAnalyze(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	s := NewScheduler(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	responses, err := s.Analyze(ctx, katago, "client", []AnalysisRequest{NewRequest9x9()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || len(responses[0].MoveInfos) == 0 {
+		t.Errorf("Expected one response with move infos, got %v", responses)
+	}
+}
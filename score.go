@@ -0,0 +1,85 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ScoreVisits is the number of visits used by Score
+var ScoreVisits = 1000
+
+// ScoreResult is the estimated outcome of a game
+type ScoreResult struct {
+	// Result is the result in SGF notation, like "B+3.5", "W+0.5" or "0" for a draw
+	Result string
+	// ScoreLead is the number of points that black is ahead, negative if white is ahead
+	ScoreLead float64
+	// BlackWinrate is the probability that black wins, from 0 to 1
+	BlackWinrate float64
+	// Ownership is the predicted owner of each point
+	Ownership *OwnershipMap
+}
+
+// blackSign returns 1 if the color is black and -1 if it is white, for converting
+// values reported from the side to move to black's point of view
+func blackSign(color string) float64 {
+	if strings.EqualFold(color, "W") {
+		return -1
+	}
+	return 1
+}
+
+// FormatResult formats the number of points that black is ahead as a game result, like "B+3.5".
+// The score is rounded so that it has the same fractional part as the komi, as a counted game would have.
+func FormatResult(blackLead, komi float64) string {
+	fraction := komi - math.Floor(komi)
+	rounded := math.Round(blackLead-fraction) + fraction
+	switch {
+	case rounded > 0:
+		return fmt.Sprintf("B+%g", rounded)
+	case rounded < 0:
+		return fmt.Sprintf("W+%g", -rounded)
+	}
+	return "0"
+}
+
+// Score estimates the final result of the position, together with the ownership of each point.
+// This is useful for scoring finished games, where the remaining dead stones are removed by the estimate.
+func (k *KataGo) Score(p Position) (*ScoreResult, error) {
+	request := p.Request(k.newID("score"))
+	request.MaxVisits = ScoreVisits
+	request.IncludeOwnership = true
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 0 {
+		return nil, errors.New("no response when scoring the position")
+	}
+	return scoreResponse(responses[0], p)
+}
+
+// scoreResponse converts a response for the final position to a ScoreResult
+func scoreResponse(response AnalysisResponse, p Position) (*ScoreResult, error) {
+	if response.RootInfo.CurrentPlayer == "" {
+		response.RootInfo.CurrentPlayer = p.ToPlay()
+	}
+	ownership, err := response.OwnershipMap(p.BoardXSize, p.BoardYSize)
+	if err != nil {
+		return nil, err
+	}
+	sign := blackSign(response.RootInfo.CurrentPlayer)
+	lead := sign * response.RootInfo.ScoreLead
+	winrate := response.RootInfo.Winrate
+	if sign < 0 {
+		winrate = 1 - winrate
+	}
+	return &ScoreResult{
+		Result:       FormatResult(lead, p.Komi),
+		ScoreLead:    lead,
+		BlackWinrate: winrate,
+		Ownership:    ownership,
+	}, nil
+}
@@ -0,0 +1,50 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFormatResult(t *testing.T) {
+	cases := []struct {
+		lead, komi float64
+		expected   string
+	}{
+		{3.2, 7.5, "B+3.5"},
+		{-0.4, 7.5, "W+0.5"},
+		{2.4, 6, "B+2"},
+		{0.3, 6, "0"},
+		{-12.9, 6.5, "W+12.5"},
+	}
+	for _, c := range cases {
+		if result := FormatResult(c.lead, c.komi); result != c.expected {
+			t.Errorf("Expected %s for a lead of %.1f with komi %.1f, got %s", c.expected, c.lead, c.komi, result)
+		}
+	}
+}
+
+func TestKataGoScore(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	p := Position{
+		Moves:      []Move{{"B", "E5"}, {"W", "C3"}, {"B", "G7"}},
+		Rules:      "chinese",
+		Komi:       7,
+		BoardXSize: 9,
+		BoardYSize: 9,
+	}
+	score, err := katago.Score(p)
+	if err != nil {
+		t.Fatalf("Failed to score position: %v", err)
+	}
+	if !strings.HasPrefix(score.Result, "B+") && !strings.HasPrefix(score.Result, "W+") && score.Result != "0" {
+		t.Errorf("Expected a result like B+3, got %s", score.Result)
+	}
+	if score.BlackWinrate < 0 || score.BlackWinrate > 1 {
+		t.Errorf("Expected winrate between 0 and 1, got %f", score.BlackWinrate)
+	}
+	if score.Ownership == nil || score.Ownership.Width() != 9 {
+		t.Errorf("Expected a 9x9 ownership map")
+	}
+}
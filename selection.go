@@ -0,0 +1,56 @@
+package katago
+
+// MoveSelection are the thresholds for SelectMove
+type MoveSelection struct {
+	// MinVisitsFraction leaves out the moves with fewer visits than this fraction of the visits of the most
+	// visited move, since their lower confidence bound is not reliable either
+	MinVisitsFraction float64
+	// MinVisits leaves out the moves with fewer visits than this
+	MinVisits int
+	// UseUtility compares the lower confidence bound of the utility, which also counts the score, instead of
+	// the lower confidence bound of the winrate
+	UseUtility bool
+}
+
+// DefaultMoveSelection is a move selection like the one in KataGo, which only considers the moves that have
+// at least a tenth of the visits of the most visited move
+var DefaultMoveSelection = MoveSelection{MinVisitsFraction: 0.1, MinVisits: 1}
+
+// SelectMove picks the move with the highest lower confidence bound among the moves that have enough visits,
+// as KataGo does, rather than the move with the highest winrate, which is often a move with few visits whose
+// winrate is high by chance. Ties go to the move with the most visits. If no move has enough visits, the most
+// visited move is picked. It returns false if there are no analyzed moves.
+func (r AnalysisResponse) SelectMove(s MoveSelection) (MoveInfoExt, bool) {
+	if len(r.MoveInfos) == 0 {
+		return MoveInfoExt{}, false
+	}
+	mostVisited := r.MoveInfos[0]
+	for _, info := range r.MoveInfos[1:] {
+		if info.Visits > mostVisited.Visits {
+			mostVisited = info
+		}
+	}
+	threshold := max(float64(s.MinVisits), s.MinVisitsFraction*float64(mostVisited.Visits))
+	bound := func(info MoveInfoExt) float64 {
+		if s.UseUtility {
+			return info.UtilityLCB
+		}
+		return info.LCB
+	}
+	var (
+		best  MoveInfoExt
+		found bool
+	)
+	for _, info := range r.MoveInfos {
+		if float64(info.Visits) < threshold {
+			continue
+		}
+		if !found || bound(info) > bound(best) || (bound(info) == bound(best) && info.Visits > best.Visits) {
+			best, found = info, true
+		}
+	}
+	if !found {
+		return mostVisited, true
+	}
+	return best, true
+}
@@ -0,0 +1,49 @@
+package katago
+
+import "testing"
+
+func TestSelectMove(t *testing.T) {
+	response := AnalysisResponse{MoveInfos: []MoveInfoExt{
+		{Move: "D4", Visits: 800, Winrate: 0.55, LCB: 0.54, UtilityLCB: 0.05},
+		{Move: "Q16", Visits: 150, Winrate: 0.56, LCB: 0.545, UtilityLCB: 0.02},
+		// The trap: a high winrate with very few visits
+		{Move: "C3", Visits: 5, Winrate: 0.9, LCB: 0.6, UtilityLCB: 0.5},
+	}}
+	best, ok := response.SelectMove(DefaultMoveSelection)
+	if !ok || best.Move != "Q16" {
+		t.Errorf("Expected Q16, which has the highest LCB with enough visits, got %s", best.Move)
+	}
+	best, _ = response.SelectMove(MoveSelection{MinVisitsFraction: 0.1, UseUtility: true})
+	if best.Move != "D4" {
+		t.Errorf("Expected D4, which has the highest utility LCB with enough visits, got %s", best.Move)
+	}
+	best, _ = response.SelectMove(MoveSelection{})
+	if best.Move != "C3" {
+		t.Errorf("Expected C3 without any visit threshold, got %s", best.Move)
+	}
+	best, _ = response.SelectMove(MoveSelection{MinVisits: 1000})
+	if best.Move != "D4" {
+		t.Errorf("Expected the most visited move when no move has enough visits, got %s", best.Move)
+	}
+	if _, ok := (AnalysisResponse{}).SelectMove(DefaultMoveSelection); ok {
+		t.Error("Expected no move for a response without moves")
+	}
+}
+
+func TestSelectMoveFromKataGo(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	request := NewRequest9x9()
+	request.MaxVisits = 100
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	best, ok := responses[0].SelectMove(DefaultMoveSelection)
+	if !ok || best.LCB == 0 || best.Visits == 0 {
+		t.Errorf("Expected a move with an LCB, got %+v", best)
+	}
+}
@@ -0,0 +1,222 @@
+// Package server exposes a KataGo analysis engine over HTTP, so that clients that are not written in Go
+// can share one engine. The JSON bodies mirror the request and response structs of the katago package.
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// MaxRequestSize is the largest request body that is accepted, in bytes
+var MaxRequestSize int64 = 1 << 20
+
+// Server handles the HTTP endpoints:
+//
+//	POST /analyze        analyze one request, or an array of requests
+//	GET /health          check that the engine is running
+//	DELETE /queries/{id} terminate a running query
+//	GET /stream          analyze over a WebSocket, with results during the search
+type Server struct {
+	katago    *katago.KataGo
+	mux       *http.ServeMux
+	nextID    atomic.Uint64
+	scheduler *katago.Scheduler
+	// nextConn numbers the WebSocket connections, for the prefixes of their query IDs
+	nextConn atomic.Int64
+	// trustClientID makes the scheduler use the X-Client-ID header
+	trustClientID bool
+}
+
+// New creates a server for the given engine
+func New(k *katago.KataGo) *Server {
+	s := &Server{katago: k, mux: http.NewServeMux()}
+	s.mux.HandleFunc("POST /analyze", s.analyze)
+	s.mux.HandleFunc("GET /health", s.health)
+	s.mux.HandleFunc("DELETE /queries/{id}", s.terminate)
+	s.mux.HandleFunc("GET /stream", s.stream)
+	return s
+}
+
+// SetScheduler makes POST /analyze share the engine fairly between clients, with the given scheduler.
+// A client is identified by its address, or by the X-Client-ID header after TrustClientIDHeader, and can
+// give a deadline with the X-Timeout header, like "2s". Requests that are still waiting at the deadline get a 504.
+func (s *Server) SetScheduler(scheduler *katago.Scheduler) {
+	s.scheduler = scheduler
+}
+
+// TrustClientIDHeader makes the scheduler identify clients by the X-Client-ID header, when it is set.
+// The header is chosen by the client, which can send a new one for each call to skip the queue, so this
+// is only for servers behind a trusted proxy that sets the header, or where all clients are trusted.
+func (s *Server) TrustClientIDHeader() {
+	s.trustClientID = true
+}
+
+// clientID returns the name of the client that sent the HTTP request, for the scheduler
+func (s *Server) clientID(r *http.Request) string {
+	if id := r.Header.Get("X-Client-ID"); id != "" && s.trustClientID {
+		return id
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// ServeHTTP implements http.Handler
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// ListenAndServe serves the engine on the given address, like ":8080"
+func ListenAndServe(addr string, k *katago.KataGo) error {
+	return http.ListenAndServe(addr, New(k))
+}
+
+// errorResponse is the body of all error responses
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// writeJSON writes v with the given status code
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
+}
+
+// writeError writes an error with the given status code
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// analyze handles POST /analyze. The body is either one request, which gives one response,
+// or an array of requests, which gives an array of responses in the same order. A request that
+// analyzes several turns gives an array with one response for each turn.
+// Requests without an ID are given one.
+func (s *Server) analyze(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, MaxRequestSize+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if int64(len(body)) > MaxRequestSize {
+		writeError(w, http.StatusRequestEntityTooLarge, errors.New("the request is too large"))
+		return
+	}
+	body = bytes.TrimSpace(body)
+	batch := len(body) > 0 && body[0] == '['
+	var requests []katago.AnalysisRequest
+	if batch {
+		err = json.Unmarshal(body, &requests)
+	} else {
+		var request katago.AnalysisRequest
+		err = json.Unmarshal(body, &request)
+		requests = []katago.AnalysisRequest{request}
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %v", err))
+		return
+	}
+	if len(requests) == 0 {
+		// An empty batch gets an empty list, and not null
+		writeJSON(w, http.StatusOK, make([]katago.AnalysisResponse, 0))
+		return
+	}
+	for i := range requests {
+		if requests[i].ID == "" {
+			requests[i].ID = fmt.Sprintf("http-%d", s.nextID.Add(1))
+		}
+		if err := requests[i].Rules.Validate(); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("request %s: %v", requests[i].ID, err))
+			return
+		}
+	}
+	var responses []katago.AnalysisResponse
+	if s.scheduler != nil {
+		ctx := r.Context()
+		if header := r.Header.Get("X-Timeout"); header != "" {
+			timeout, err := time.ParseDuration(header)
+			if err != nil || timeout <= 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid X-Timeout header: %q", header))
+				return
+			}
+			var cancel func()
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		responses, err = s.scheduler.Analyze(ctx, s.katago, s.clientID(r), requests)
+	} else {
+		responses, err = s.katago.Analyze(requests)
+	}
+	switch {
+	case errors.Is(err, katago.ErrDuplicateID):
+		writeError(w, http.StatusConflict, err)
+		return
+	case errors.Is(err, katago.ErrBadRequest):
+		writeError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, katago.ErrQueryTimeout):
+		writeError(w, http.StatusGatewayTimeout, err)
+		return
+	case errors.Is(err, katago.ErrEngineCrashed):
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// A request for several turns gets one response for each turn
+	if batch || len(responses) > 1 {
+		writeJSON(w, http.StatusOK, responses)
+		return
+	}
+	writeJSON(w, http.StatusOK, responses[0])
+}
+
+// healthResponse is the body of GET /health
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+// health handles GET /health
+func (s *Server) health(w http.ResponseWriter, r *http.Request) {
+	if !s.katago.Running() {
+		writeJSON(w, http.StatusServiceUnavailable, healthResponse{Status: "stopped"})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// terminateResponse is the body of DELETE /queries/{id}
+type terminateResponse struct {
+	ID         string `json:"id"`
+	Terminated bool   `json:"terminated"`
+}
+
+// terminate handles DELETE /queries/{id}. The client that sent the query gets the results found so far.
+func (s *Server) terminate(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.katago.Terminate(id)
+	switch {
+	case errors.Is(err, katago.ErrUnknownQuery):
+		writeError(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, terminateResponse{ID: id, Terminated: true})
+}
@@ -0,0 +1,187 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	ts := httptest.NewServer(New(k))
+	t.Cleanup(func() {
+		ts.Close()
+		if err := k.Close(); err != nil {
+			t.Errorf("Failed to close KataGo: %v", err)
+		}
+	})
+	return ts
+}
+
+func TestHealth(t *testing.T) {
+	ts := newTestServer(t)
+	resp, err := http.Get(ts.URL + "/health")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	ts := newTestServer(t)
+	body := `{"id":"q1","moves":[["B","E5"]],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":20,"analyzeTurns":[1]}`
+	resp, err := http.Post(ts.URL+"/analyze", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	var response katago.AnalysisResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		t.Fatal(err)
+	}
+	if response.ID != "q1" || response.TurnNumber != 1 || len(response.MoveInfos) == 0 {
+		t.Errorf("Unexpected response: %v", response)
+	}
+
+	batch := `[{"moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":10},` +
+		`{"moves":[["B","C3"]],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":10}]`
+	resp, err = http.Post(ts.URL+"/analyze", "application/json", strings.NewReader(batch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var responses []katago.AnalysisResponse
+	if err := json.NewDecoder(resp.Body).Decode(&responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 || responses[0].ID == "" || responses[0].ID == responses[1].ID {
+		t.Errorf("Expected two responses with generated IDs, got %v", responses)
+	}
+
+	resp, err = http.Post(ts.URL+"/analyze", "application/json", strings.NewReader("[]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if body, err := io.ReadAll(resp.Body); err != nil || strings.TrimSpace(string(body)) != "[]" {
+		t.Errorf("Expected an empty list for an empty batch, got %s (%v)", body, err)
+	}
+}
+
+func TestAnalyzeBadRequest(t *testing.T) {
+	ts := newTestServer(t)
+	for _, body := range []string{`{`, `{"moves":[],"rules":"chess","boardXSize":9,"boardYSize":9}`} {
+		resp, err := http.Post(ts.URL+"/analyze", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, got %d", body, resp.StatusCode)
+		}
+	}
+}
+
+func TestTerminateQuery(t *testing.T) {
+	ts := newTestServer(t)
+	body := `{"id":"slow","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":100000}`
+	done := make(chan int, 1)
+	go func() {
+		resp, err := http.Post(ts.URL+"/analyze", "application/json", strings.NewReader(body))
+		if err != nil {
+			done <- 0
+			return
+		}
+		resp.Body.Close()
+		done <- resp.StatusCode
+	}()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		req, _ := http.NewRequest(http.MethodDelete, ts.URL+"/queries/slow", nil)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			break
+		}
+		if resp.StatusCode != http.StatusNotFound || time.Now().After(deadline) {
+			t.Fatalf("Failed to terminate the query, got status %d", resp.StatusCode)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	select {
+	case status := <-done:
+		if status != http.StatusOK {
+			t.Errorf("Expected the terminated query to succeed, got status %d", status)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Expected the terminated query to return early")
+	}
+}
+
+func TestAnalyzeWithScheduler(t *testing.T) {
+	k, err := katago.NewKataGo("../analysis_example.cfg", "../model.bin.gz")
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer k.Close()
+	s := New(k)
+	s.SetScheduler(katago.NewScheduler(1))
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	body := `{"moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":10}`
+	for _, timeout := range []string{"10s", "soon"} {
+		req, err := http.NewRequest(http.MethodPost, ts.URL+"/analyze", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-Client-ID", "tester")
+		req.Header.Set("X-Timeout", timeout)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		expected := http.StatusOK
+		if timeout == "soon" {
+			expected = http.StatusBadRequest
+		}
+		if resp.StatusCode != expected {
+			t.Errorf("Expected status %d for X-Timeout %s, got %d", expected, timeout, resp.StatusCode)
+		}
+	}
+}
+
+func TestClientID(t *testing.T) {
+	s := New(nil)
+	r := httptest.NewRequest(http.MethodPost, "/analyze", nil)
+	r.RemoteAddr = "10.0.0.5:4321"
+	r.Header.Set("X-Client-ID", "tester")
+	// The header is chosen by the client, so it is only used when it is trusted
+	if id := s.clientID(r); id != "10.0.0.5" {
+		t.Errorf("Expected the address of the client, got %s", id)
+	}
+	s.TrustClientIDHeader()
+	if id := s.clientID(r); id != "tester" {
+		t.Errorf("Expected the ID in the header, got %s", id)
+	}
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/xyproto/katago"
+)
+
+// streamMessage is a message from a client on the /stream endpoint. It is either an analysis request,
+// or an action that terminates one of the queries of the client.
+type streamMessage struct {
+	katago.AnalysisRequest
+	Action      string `json:"action,omitempty"`
+	TerminateID string `json:"terminateId,omitempty"`
+}
+
+// streamError is sent to the client when a message can not be handled
+type streamError struct {
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// interimBuffer is how many interim reports can be waiting to be sent for one query, before the oldest are dropped
+const interimBuffer = 8
+
+// stream handles GET /stream, which upgrades to a WebSocket. Each text message from the client is an analysis
+// request, and the server answers with JSON messages for the results during the search, where isDuringSearch
+// is true, followed by the final result. Several queries can run at once on the same connection. A message
+// like {"action":"terminate","terminateId":"..."} stops one of the queries. The IDs only need to be unique
+// within one connection, and a connection can only terminate its own queries.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrade(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer conn.Close()
+
+	// The IDs of the client are only unique for this connection, so they get a prefix on the way to
+	// the engine, and the prefix is removed from the messages to the client
+	prefix := fmt.Sprintf("ws%d-", s.nextConn.Add(1))
+	var (
+		wg      sync.WaitGroup
+		mut     sync.Mutex
+		running = make(map[string]bool)
+	)
+	defer func() {
+		// The client is gone, so there is no reason to keep searching
+		mut.Lock()
+		for id := range running {
+			s.katago.Terminate(prefix + id)
+		}
+		mut.Unlock()
+		wg.Wait()
+	}()
+	send := func(v any) {
+		data, err := json.Marshal(v)
+		if err != nil {
+			log.Printf("Failed to marshal a stream message: %v", err)
+			return
+		}
+		if err := conn.writeText(data); err != nil {
+			log.Printf("Failed to write a stream message: %v", err)
+		}
+	}
+	for {
+		data, err := conn.readMessage()
+		if err != nil {
+			if !errors.Is(err, errClosed) {
+				log.Printf("Failed to read a stream message: %v", err)
+			}
+			return
+		}
+		var message streamMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			send(streamError{Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+		if message.Action == "terminate" {
+			mut.Lock()
+			ok := running[message.TerminateID]
+			mut.Unlock()
+			err := fmt.Errorf("%w: %s", katago.ErrUnknownQuery, message.TerminateID)
+			if ok {
+				err = s.katago.Terminate(prefix + message.TerminateID)
+			}
+			if err != nil {
+				send(streamError{ID: message.TerminateID, Error: unprefix(err.Error(), prefix)})
+			}
+			continue
+		}
+		if message.Action != "" {
+			send(streamError{ID: message.ID, Error: fmt.Sprintf("unknown action: %s", message.Action)})
+			continue
+		}
+		request := message.AnalysisRequest
+		if request.ID == "" {
+			request.ID = fmt.Sprintf("ws-%d", s.nextID.Add(1))
+		}
+		mut.Lock()
+		duplicate := running[request.ID]
+		running[request.ID] = true
+		mut.Unlock()
+		if duplicate {
+			send(streamError{ID: request.ID, Error: fmt.Errorf("%w: %s", katago.ErrDuplicateID, request.ID).Error()})
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.streamQuery(request, prefix, send)
+			mut.Lock()
+			delete(running, request.ID)
+			mut.Unlock()
+		}()
+	}
+}
+
+// unprefix removes the prefix of a connection from the IDs in a message
+func unprefix(message, prefix string) string {
+	return strings.ReplaceAll(message, prefix, "")
+}
+
+// streamQuery runs one query with the prefix of the connection, and sends the interim and final results to
+// the client without the prefix. Interim results are queued, so that a slow client can not hold up the engine.
+func (s *Server) streamQuery(request katago.AnalysisRequest, prefix string, send func(any)) {
+	id := request.ID
+	request.ID = prefix + id
+	interim := make(chan katago.AnalysisResponse, interimBuffer)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for response := range interim {
+			response.ID = id
+			send(response)
+		}
+	}()
+	response, err := s.katago.AnalyzeStream(request, func(response katago.AnalysisResponse) {
+		select {
+		case interim <- response:
+		default:
+			// The client is behind, so the oldest report is dropped to make room for the newest
+			select {
+			case <-interim:
+			default:
+			}
+			select {
+			case interim <- response:
+			default:
+			}
+		}
+	})
+	close(interim)
+	<-done
+	if err != nil {
+		send(streamError{ID: id, Error: unprefix(err.Error(), prefix)})
+		return
+	}
+	response.ID = id
+	send(response)
+}
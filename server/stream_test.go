@@ -0,0 +1,189 @@
+package server
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xyproto/katago"
+)
+
+// wsClient is a minimal WebSocket client for testing
+type wsClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialWebSocket(t *testing.T, url string) *wsClient {
+	t.Helper()
+	addr := strings.TrimPrefix(url, "http://")
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var key [16]byte
+	rand.Read(key[:])
+	handshake := "GET /stream HTTP/1.1\r\nHost: " + addr + "\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Key: " + base64.StdEncoding.EncodeToString(key[:]) + "\r\nSec-WebSocket-Version: 13\r\n\r\n"
+	if _, err := conn.Write([]byte(handshake)); err != nil {
+		t.Fatal(err)
+	}
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("Expected status 101, got %d", resp.StatusCode)
+	}
+	return &wsClient{conn: conn, r: r}
+}
+
+// send writes a masked text frame
+func (c *wsClient) send(t *testing.T, data string) {
+	t.Helper()
+	frame := []byte{0x81}
+	if len(data) < 126 {
+		frame = append(frame, 0x80|byte(len(data)))
+	} else {
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(data)))
+	}
+	mask := []byte{1, 2, 3, 4}
+	frame = append(frame, mask...)
+	for i := 0; i < len(data); i++ {
+		frame = append(frame, data[i]^mask[i%4])
+	}
+	if _, err := c.conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// receive reads an unmasked frame from the server
+func (c *wsClient) receive(t *testing.T) []byte {
+	t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		t.Fatal(err)
+	}
+	length := int(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		io.ReadFull(c.r, ext[:])
+		length = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		io.ReadFull(c.r, ext[:])
+		length = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		t.Fatal(err)
+	}
+	return payload
+}
+
+func TestStream(t *testing.T) {
+	ts := newTestServer(t)
+	c := dialWebSocket(t, ts.URL)
+	defer c.conn.Close()
+	// 20000 visits take about a second, with reports every 0.1 seconds
+	c.send(t, `{"id":"live","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":20000,"reportDuringSearchEvery":0.1}`)
+	interim := 0
+	for {
+		var response katago.AnalysisResponse
+		if err := json.Unmarshal(c.receive(t), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.ID != "live" {
+			t.Fatalf("Expected a response for live, got %v", response)
+		}
+		if !response.IsDuringSearch {
+			if response.RootInfo.Visits != 20000 {
+				t.Errorf("Expected 20000 visits in the final response, got %d", response.RootInfo.Visits)
+			}
+			break
+		}
+		interim++
+	}
+	if interim == 0 {
+		t.Error("Expected results during the search")
+	}
+}
+
+func TestStreamTerminate(t *testing.T) {
+	ts := newTestServer(t)
+	c := dialWebSocket(t, ts.URL)
+	defer c.conn.Close()
+	c.send(t, `{"id":"stop","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":100000,"reportDuringSearchEvery":0.1}`)
+	// Wait for the first report, so that the query is running
+	c.receive(t)
+	c.send(t, `{"action":"terminate","terminateId":"stop"}`)
+	start := time.Now()
+	for {
+		var response katago.AnalysisResponse
+		if err := json.Unmarshal(c.receive(t), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !response.IsDuringSearch {
+			break
+		}
+	}
+	if time.Since(start) > 3*time.Second {
+		t.Error("Expected the terminated query to finish early")
+	}
+}
+
+func TestStreamTwoConnections(t *testing.T) {
+	ts := newTestServer(t)
+	a, b := dialWebSocket(t, ts.URL), dialWebSocket(t, ts.URL)
+	defer a.conn.Close()
+	defer b.conn.Close()
+
+	// The same ID can be used on both connections at once
+	request := `{"id":"q1","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":2000}`
+	a.send(t, request)
+	b.send(t, request)
+	for _, c := range []*wsClient{a, b} {
+		data := c.receive(t)
+		var response katago.AnalysisResponse
+		if err := json.Unmarshal(data, &response); err != nil || response.ID != "q1" || response.RootInfo.Visits != 2000 {
+			t.Errorf("Expected a response for q1 on both connections, got %s", data)
+		}
+	}
+
+	// A connection can not terminate the queries of another one
+	a.send(t, `{"id":"long","moves":[],"rules":"chinese","komi":7,"boardXSize":9,"boardYSize":9,"maxVisits":100000,"reportDuringSearchEvery":0.1}`)
+	a.receive(t)
+	b.send(t, `{"action":"terminate","terminateId":"long"}`)
+	var failure streamError
+	if data := b.receive(t); json.Unmarshal(data, &failure) != nil || failure.ID != "long" || !strings.Contains(failure.Error, "no query") {
+		t.Errorf("Expected an unknown query for the other connection, got %s", data)
+	}
+	for range 3 {
+		var response katago.AnalysisResponse
+		if data := a.receive(t); json.Unmarshal(data, &response) != nil || !response.IsDuringSearch {
+			t.Fatalf("Expected the query to keep running, got %s", data)
+		}
+	}
+	a.send(t, `{"action":"terminate","terminateId":"long"}`)
+	for {
+		var response katago.AnalysisResponse
+		if err := json.Unmarshal(a.receive(t), &response); err != nil {
+			t.Fatal(err)
+		}
+		if !response.IsDuringSearch {
+			break
+		}
+	}
+}
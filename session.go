@@ -0,0 +1,248 @@
+package katago
+
+import (
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/xyproto/katago/board"
+)
+
+// ErrSuperseded is returned by Session.Eval when the position changed before the evaluation was done
+var ErrSuperseded = errors.New("the position changed during the evaluation")
+
+// Session follows a game that is being played, like a live game in a GUI. The moves are checked
+// when they are played, each evaluation gets its own query ID, the evaluations that are still running
+// when the position changes are terminated, and the result for the current position is kept, so
+// evaluating it again does not start a new search. A session can also ponder, with Ponder.
+type Session struct {
+	k      *KataGo
+	visits int
+
+	mut      sync.Mutex
+	position Position
+	board    *board.Board
+	// last is the response for the current position, if it has been evaluated
+	last *AnalysisResponse
+	// generation counts the changes of the position, and running are the IDs of the evaluations that are running
+	generation int
+	running    map[string]bool
+	// ponder is called with the results of pondering, while ponderID is the query that ponders
+	ponder   func(AnalysisResponse)
+	ponderID string
+}
+
+// PonderVisits is the maximum number of visits when pondering, which is high enough that
+// the search goes on until the position changes or the pondering is stopped
+var PonderVisits = 1000000
+
+// NewSession starts a session from the given position, where each evaluation uses the given number of visits,
+// or the default of the engine if it is 0
+func (k *KataGo) NewSession(p Position, visits int) (*Session, error) {
+	b, err := p.Board()
+	if err != nil {
+		return nil, err
+	}
+	p.Moves = slices.Clone(p.Moves)
+	return &Session{k: k, visits: visits, position: p, board: b, running: make(map[string]bool)}, nil
+}
+
+// Position returns the current position
+func (s *Session) Position() Position {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	p := s.position
+	p.Moves = slices.Clone(p.Moves)
+	return p
+}
+
+// Board returns a copy of the board of the current position
+func (s *Session) Board() *board.Board {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.board.Clone()
+}
+
+// ToPlay returns the color of the player to move next, "B" or "W"
+func (s *Session) ToPlay() string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.board.ToPlay().String()
+}
+
+// Play plays a move for the player to move next, like "D4" or "pass", and terminates the running evaluations
+func (s *Session) Play(vertex string) error {
+	s.mut.Lock()
+	p, err := s.board.ParseVertex(vertex)
+	if err != nil {
+		s.mut.Unlock()
+		return err
+	}
+	c := s.board.ToPlay()
+	if err := s.board.Play(c, p); err != nil {
+		s.mut.Unlock()
+		return err
+	}
+	s.position.Moves = append(s.position.Moves, Move{Color(c.String()), Vertex(s.board.Vertex(p))})
+	s.supersede()
+	return nil
+}
+
+// Undo takes back the last move, and terminates the running evaluations
+func (s *Session) Undo() error {
+	s.mut.Lock()
+	if err := s.board.Undo(); err != nil {
+		s.mut.Unlock()
+		return err
+	}
+	s.position.Moves = s.position.Moves[:len(s.position.Moves)-1]
+	s.supersede()
+	return nil
+}
+
+// SetPosition replaces the current position, and terminates the running evaluations
+func (s *Session) SetPosition(p Position) error {
+	b, err := p.Board()
+	if err != nil {
+		return err
+	}
+	p.Moves = slices.Clone(p.Moves)
+	s.mut.Lock()
+	s.position, s.board = p, b
+	s.supersede()
+	return nil
+}
+
+// supersede forgets the result for the old position and terminates the running evaluations.
+// It is called with the mutex locked, and unlocks it before terminating.
+func (s *Session) supersede() {
+	s.generation++
+	s.last = nil
+	var ids []string
+	for id := range s.running {
+		ids = append(ids, id)
+	}
+	pondering := s.ponder != nil
+	s.mut.Unlock()
+	for _, id := range ids {
+		// The query may just have been answered, which is fine
+		s.k.Terminate(id)
+	}
+	if pondering {
+		s.startPondering()
+	}
+}
+
+// Ponder analyzes the current position in the background until it changes, and then starts over with the
+// new position, so that KataGo can think during the time of the opponent. The report function is called with
+// the results so far while searching, from the goroutine that reads the output of KataGo, so it should
+// return quickly. The final result of a search that is done before the position changes is used by Eval.
+// Close waits for the running searches, so StopPondering should be called before closing the engine.
+func (s *Session) Ponder(report func(AnalysisResponse)) {
+	s.mut.Lock()
+	s.ponder = report
+	id := s.ponderID
+	s.mut.Unlock()
+	if id != "" {
+		// Only one search ponders at a time
+		s.k.Terminate(id)
+	}
+	s.startPondering()
+}
+
+// StopPondering stops the pondering that was started by Ponder
+func (s *Session) StopPondering() {
+	s.mut.Lock()
+	id := s.ponderID
+	s.ponder, s.ponderID = nil, ""
+	s.mut.Unlock()
+	if id != "" {
+		s.k.Terminate(id)
+	}
+}
+
+// Pondering checks if the session is pondering
+func (s *Session) Pondering() bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.ponder != nil
+}
+
+// startPondering starts a long search of the current position
+func (s *Session) startPondering() {
+	s.mut.Lock()
+	report := s.ponder
+	if report == nil {
+		s.mut.Unlock()
+		return
+	}
+	generation := s.generation
+	request := s.position.Request(s.k.newID("ponder"))
+	request.Moves = slices.Clone(request.Moves)
+	request.MaxVisits = PonderVisits
+	s.running[request.ID] = true
+	s.ponderID = request.ID
+	s.mut.Unlock()
+
+	// current checks that the position has not changed and that this search is still the one that ponders
+	current := func() bool {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+		return generation == s.generation && s.ponderID == request.ID
+	}
+	go func() {
+		var stale sync.Once
+		response, err := s.k.AnalyzeStream(request, func(interim AnalysisResponse) {
+			if current() {
+				report(interim)
+				return
+			}
+			// The position changed before the query was sent, so Terminate could not stop it then
+			stale.Do(func() {
+				s.k.Terminate(request.ID)
+			})
+		})
+		s.mut.Lock()
+		delete(s.running, request.ID)
+		done := err == nil && generation == s.generation && s.ponderID == request.ID
+		if done {
+			s.last = &response
+			s.ponderID = ""
+		}
+		s.mut.Unlock()
+		if done {
+			report(response)
+		}
+	}()
+}
+
+// Eval evaluates the current position, or returns the earlier result if it has already been evaluated.
+// ErrSuperseded is returned if the position is changed before the evaluation is done.
+func (s *Session) Eval() (AnalysisResponse, error) {
+	s.mut.Lock()
+	if s.last != nil {
+		response := *s.last
+		s.mut.Unlock()
+		return response, nil
+	}
+	generation := s.generation
+	request := s.position.Request(s.k.newID("session"))
+	request.Moves = slices.Clone(request.Moves)
+	request.MaxVisits = s.visits
+	s.running[request.ID] = true
+	s.mut.Unlock()
+
+	responses, err := s.k.Analyze([]AnalysisRequest{request})
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.running, request.ID)
+	if generation != s.generation {
+		return AnalysisResponse{}, ErrSuperseded
+	}
+	if err != nil {
+		return AnalysisResponse{}, err
+	}
+	s.last = &responses[0]
+	return responses[0], nil
+}
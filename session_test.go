@@ -0,0 +1,184 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSession(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	s, err := k.NewSession(NewPosition(9, 9), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Play("E5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Play("E5"); err == nil {
+		t.Errorf("Expected an error for playing on an occupied point")
+	}
+	if err := s.Play("C3"); err != nil {
+		t.Fatal(err)
+	}
+	if s.ToPlay() != "B" || len(s.Position().Moves) != 2 {
+		t.Errorf("Expected black to play after 2 moves, got %s after %v", s.ToPlay(), s.Position().Moves)
+	}
+	first, err := s.Eval()
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := s.Eval()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.ID != first.ID {
+		t.Errorf("Expected the earlier result to be reused, got %s and %s", first.ID, again.ID)
+	}
+	if err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if response, err := s.Eval(); err != nil || response.ID == first.ID || response.RootInfo.CurrentPlayer != "W" {
+		t.Errorf("Expected a new evaluation with white to play, got %v (%v)", response.RootInfo.CurrentPlayer, err)
+	}
+}
+
+func TestSessionSuperseded(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	s, err := k.NewSession(NewPosition(9, 9), 100000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := s.Eval()
+		done <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+	if err := s.Play("E5"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrSuperseded) {
+			t.Errorf("Expected ErrSuperseded, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the evaluation of the old position to be terminated")
+	}
+}
+
+func TestPonder(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	s, err := k.NewSession(NewPosition(9, 9), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := make(chan AnalysisResponse, 100)
+	s.Ponder(func(response AnalysisResponse) {
+		select {
+		case reports <- response:
+		default:
+		}
+	})
+	if !s.Pondering() {
+		t.Errorf("Expected the session to ponder")
+	}
+	first := <-reports
+	if err := s.Play("E5"); err != nil {
+		t.Fatal(err)
+	}
+	// The pondering starts over with the new position, where white is to play
+	deadline := time.After(5 * time.Second)
+	for {
+		select {
+		case response := <-reports:
+			if response.ID == first.ID {
+				continue
+			}
+			if response.RootInfo.CurrentPlayer != "W" {
+				t.Errorf("Expected white to play in the new position, got %s", response.RootInfo.CurrentPlayer)
+			}
+			s.StopPondering()
+			if s.Pondering() {
+				t.Errorf("Expected the pondering to stop")
+			}
+			return
+		case <-deadline:
+			t.Fatal("Expected reports for the new position")
+		}
+	}
+}
+
+func TestPonderPlayAtOnce(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	s, err := k.NewSession(NewPosition(9, 9), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reports := make(chan AnalysisResponse, 100)
+	s.Ponder(func(response AnalysisResponse) {
+		select {
+		case reports <- response:
+		default:
+		}
+	})
+	// The move may come before the first search is sent, which must then stop by itself
+	if err := s.Play("E5"); err != nil {
+		t.Fatal(err)
+	}
+	for response := range reports {
+		if response.RootInfo.CurrentPlayer == "W" {
+			break
+		}
+	}
+	s.StopPondering()
+	deadline := time.Now().Add(2 * time.Second)
+	for k.Stats().QueueDepth > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected all the searches to stop, got %d running", k.Stats().QueueDepth)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSessionSupersededCache(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(NewLRUCache(10)))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	s, err := k.NewSession(NewPosition(9, 9), 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error)
+	go func() {
+		_, err := s.Eval()
+		done <- err
+	}()
+	for k.Stats().QueueDepth == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if err := s.Play("E5"); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; !errors.Is(err, ErrSuperseded) {
+		t.Fatalf("Expected ErrSuperseded, got %v", err)
+	}
+	// The stopped search is not cached, so the position is searched again with all the visits
+	if err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	response, err := s.Eval()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.RootInfo.Visits != 20000 {
+		t.Errorf("Expected 20000 visits after going back, got %d", response.RootInfo.Visits)
+	}
+}
@@ -0,0 +1,278 @@
+package katago
+
+import (
+	"archive/zip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/xyproto/katago/config"
+	"github.com/xyproto/katago/models"
+)
+
+// Defaults for Setup
+const (
+	DefaultSetupVersion = "1.15.3"
+	DefaultSetupNetwork = "kata1-b28c512nbt-s7332806912-d4357057652"
+	DefaultReleaseURL   = "https://github.com/lightvector/KataGo/releases/download"
+)
+
+// releaseBackends are the names of the backends in the file names of the KataGo releases
+var releaseBackends = map[Backend]string{
+	OpenCL:   "opencl",
+	CUDA:     "cuda12.1-cudnn8.9.7",
+	TensorRT: "trt10.2.0-cuda12.5",
+	Eigen:    "eigen",
+}
+
+// SetupOptions are the settings for Setup
+type SetupOptions struct {
+	// Dir is where the binary, the network and the config file are stored. The default is ~/.katago,
+	// where NewKataGo finds the config file and the network when they are not given.
+	Dir string
+	// Backend is the backend of the KataGo binary that is downloaded. The default is OpenCL, which works
+	// with most GPUs.
+	Backend Backend
+	// Version is the KataGo release to download, like "1.15.3"
+	Version string
+	// Network is the name of the network to download
+	Network string
+	// Config is written as the analysis config, instead of config.Default, and replaces an existing config
+	Config *config.Config
+	// DownloadBinary downloads KataGo even if FindBinary finds it
+	DownloadBinary bool
+	Client         *http.Client
+	// ReleaseURL is where KataGo releases are downloaded from, and NetworkURL is where networks are downloaded from
+	ReleaseURL string
+	NetworkURL string
+	// Progress is called while downloading, with the name of the file, the number of bytes so far and the total,
+	// which is -1 if the size is not known
+	Progress func(name string, downloaded, total int64)
+	// Options are passed on to NewKataGo
+	Options []Option
+}
+
+// ReleaseAsset returns the file name of the KataGo release for a backend and a platform,
+// like "katago-v1.15.3-opencl-linux-x64.zip". Releases are only made for Linux and Windows on x64.
+func ReleaseAsset(version string, backend Backend, goos, goarch string) (string, error) {
+	name, ok := releaseBackends[backend]
+	if !ok {
+		return "", fmt.Errorf("no KataGo release for the %s backend", backend)
+	}
+	if goos == "darwin" {
+		return "", fmt.Errorf("no KataGo release for macOS, install it with Homebrew: brew install katago")
+	}
+	if goos != "linux" && goos != "windows" || goarch != "amd64" {
+		return "", fmt.Errorf("no KataGo release for %s/%s", goos, goarch)
+	}
+	return fmt.Sprintf("katago-v%s-%s-%s-x64.zip", strings.TrimPrefix(version, "v"), name, goos), nil
+}
+
+// download downloads a URL to a file
+func download(ctx context.Context, client *http.Client, url, filename string, progress func(string, int64, int64)) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	f, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	var r io.Reader = resp.Body
+	if progress != nil {
+		r = &progressReader{r: resp.Body, name: filepath.Base(filename), total: resp.ContentLength, progress: progress}
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	return f.Close()
+}
+
+// progressReader reports how much has been read
+type progressReader struct {
+	r        io.Reader
+	name     string
+	read     int64
+	total    int64
+	progress func(string, int64, int64)
+}
+
+// Read reads and reports the progress
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.read += int64(n)
+	if n > 0 {
+		p.progress(p.name, p.read, p.total)
+	}
+	return n, err
+}
+
+// unzip extracts a zip file into a directory, and returns the path of the KataGo binary in it
+func unzip(filename, dir string) (string, error) {
+	zr, err := zip.OpenReader(filename)
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+	var binary string
+	for _, f := range zr.File {
+		path := filepath.Join(dir, f.Name)
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return "", fmt.Errorf("invalid file name in %s: %q", filename, f.Name)
+		}
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return "", err
+			}
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return "", err
+		}
+		mode := f.Mode().Perm() | 0o644
+		base := filepath.Base(f.Name)
+		if base == "katago" || base == "katago.exe" {
+			binary = path
+			mode |= 0o755
+		}
+		if err := extract(f, path, mode); err != nil {
+			return "", err
+		}
+	}
+	if binary == "" {
+		return "", fmt.Errorf("%s does not contain the KataGo binary", filename)
+	}
+	return binary, nil
+}
+
+// extract writes a file from a zip file
+func extract(f *zip.File, path string, mode os.FileMode) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, rc); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// downloadBinary downloads and extracts a KataGo release into the directory, unless it is already there,
+// and returns the path of the binary
+func downloadBinary(ctx context.Context, opts SetupOptions) (string, error) {
+	asset, err := ReleaseAsset(opts.Version, opts.Backend, runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(opts.Dir, strings.TrimSuffix(asset, ".zip"))
+	for _, name := range binaryNames() {
+		if path := filepath.Join(dir, name); isExecutable(path) {
+			return path, nil
+		}
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return "", err
+	}
+	archive := filepath.Join(opts.Dir, asset)
+	url := fmt.Sprintf("%s/v%s/%s", strings.TrimSuffix(opts.ReleaseURL, "/"), strings.TrimPrefix(opts.Version, "v"), asset)
+	if err := download(ctx, opts.Client, url, archive, opts.Progress); err != nil {
+		return "", err
+	}
+	defer os.Remove(archive)
+	return unzip(archive, dir)
+}
+
+// setupConfig writes the analysis config, unless there already is one
+func setupConfig(opts SetupOptions) (string, error) {
+	filename := filepath.Join(opts.Dir, "analysis.cfg")
+	if _, err := os.Stat(filename); err == nil && opts.Config == nil {
+		return filename, nil
+	}
+	c := config.Default()
+	if opts.Config != nil {
+		c = *opts.Config
+	} else {
+		c.LogDir = filepath.Join(opts.Dir, "analysis_logs")
+		if opts.Backend == Eigen {
+			// Without a GPU, there is no point in more search threads than cores
+			c.NumSearchThreads = runtime.NumCPU()
+			c.NumAnalysisThreads = 1
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(opts.Dir, 0o755); err != nil {
+		return "", err
+	}
+	return filename, c.WriteFile(filename)
+}
+
+// Setup gets everything that is needed for running KataGo and starts it. It uses the installed KataGo,
+// or downloads a release for the platform and the backend, downloads a network, and writes an analysis
+// config, and then returns the engine. Files that are already in the directory are used again,
+// so only the first call downloads anything.
+func Setup(ctx context.Context, opts SetupOptions) (*KataGo, error) {
+	if opts.Dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		opts.Dir = filepath.Join(home, ".katago")
+	}
+	if opts.Backend == "" {
+		opts.Backend = OpenCL
+	}
+	if opts.Version == "" {
+		opts.Version = DefaultSetupVersion
+	}
+	if opts.Network == "" {
+		opts.Network = DefaultSetupNetwork
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+	if opts.ReleaseURL == "" {
+		opts.ReleaseURL = DefaultReleaseURL
+	}
+	if opts.NetworkURL == "" {
+		opts.NetworkURL = models.DefaultBaseURL
+	}
+
+	binary, err := FindBinary()
+	if err != nil || opts.DownloadBinary {
+		if binary, err = downloadBinary(ctx, opts); err != nil {
+			return nil, fmt.Errorf("failed to set up the KataGo binary: %w", err)
+		}
+	}
+	d := &models.Downloader{Client: opts.Client, BaseURL: opts.NetworkURL, CacheDir: opts.Dir, Progress: opts.Progress}
+	modelFile, err := d.Get(ctx, opts.Network)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the network: %w", err)
+	}
+	configFile, err := setupConfig(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up the config: %w", err)
+	}
+	return NewKataGo(configFile, modelFile, append([]Option{WithBinary(binary)}, opts.Options...)...)
+}
@@ -0,0 +1,164 @@
+package katago
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago/config"
+	"github.com/xyproto/katago/models"
+)
+
+func TestReleaseAsset(t *testing.T) {
+	name, err := ReleaseAsset("v1.15.3", OpenCL, "linux", "amd64")
+	if err != nil || name != "katago-v1.15.3-opencl-linux-x64.zip" {
+		t.Errorf("Expected katago-v1.15.3-opencl-linux-x64.zip, got %s (%v)", name, err)
+	}
+	name, err = ReleaseAsset("1.15.3", CUDA, "windows", "amd64")
+	if err != nil || name != "katago-v1.15.3-cuda12.1-cudnn8.9.7-windows-x64.zip" {
+		t.Errorf("Expected the CUDA release for Windows, got %s (%v)", name, err)
+	}
+	if _, err := ReleaseAsset("1.15.3", OpenCL, "darwin", "arm64"); err == nil || !strings.Contains(err.Error(), "brew") {
+		t.Errorf("Expected a hint about Homebrew, got %v", err)
+	}
+	if _, err := ReleaseAsset("1.15.3", OpenCL, "linux", "arm64"); err == nil {
+		t.Error("Expected an error for linux/arm64")
+	}
+	if _, err := ReleaseAsset("1.15.3", Backend("metal"), "linux", "amd64"); err == nil {
+		t.Error("Expected an error for an unknown backend")
+	}
+}
+
+func TestDownloadBinary(t *testing.T) {
+	if runtime.GOOS != "linux" || runtime.GOARCH != "amd64" {
+		t.Skip("releases are only made for x64")
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range map[string]string{"katago": "#!/bin/sh\nexit 0\n", "README.txt": "KataGo"} {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path != "/v1.15.3/katago-v1.15.3-eigen-linux-x64.zip" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(buf.Bytes())
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	var downloaded int64
+	opts := SetupOptions{Dir: dir, Backend: Eigen, Version: "1.15.3", Client: server.Client(), ReleaseURL: server.URL,
+		Progress: func(name string, n, total int64) { downloaded = n }}
+	binary, err := downloadBinary(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "katago-v1.15.3-eigen-linux-x64", "katago"); binary != expected {
+		t.Errorf("Expected %s, got %s", expected, binary)
+	}
+	if !isExecutable(binary) {
+		t.Errorf("Expected %s to be executable", binary)
+	}
+	if downloaded != int64(buf.Len()) {
+		t.Errorf("Expected progress up to %d bytes, got %d", buf.Len(), downloaded)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "katago-v1.15.3-eigen-linux-x64.zip")); !os.IsNotExist(err) {
+		t.Errorf("Expected the zip file to be removed, got %v", err)
+	}
+	// The binary is already there, so nothing is downloaded again
+	if _, err := downloadBinary(context.Background(), opts); err != nil || requests != 1 {
+		t.Errorf("Expected 1 request, got %d (%v)", requests, err)
+	}
+	opts.Version = "1.14.0"
+	if _, err := downloadBinary(context.Background(), opts); err == nil {
+		t.Error("Expected an error for a missing release")
+	}
+}
+
+func TestUnzipInvalidName(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "bad.zip")
+	f, err := os.Create(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("../katago")
+	w.Write([]byte("#!/bin/sh\n"))
+	zw.Close()
+	f.Close()
+	if _, err := unzip(archive, filepath.Join(dir, "out")); err == nil {
+		t.Error("Expected an error for a file outside of the directory")
+	}
+}
+
+func TestSetup(t *testing.T) {
+	var network bytes.Buffer
+	zw := gzip.NewWriter(&network)
+	zw.Write([]byte("network"))
+	zw.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/test-network.bin.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(network.Bytes())
+	}))
+	defer server.Close()
+	dir := t.TempDir()
+	k, err := Setup(context.Background(), SetupOptions{Dir: dir, Network: "test-network", Client: server.Client(), NetworkURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	if _, err := k.Version(); err != nil {
+		t.Errorf("Expected the engine to run, got %v", err)
+	}
+	cfg, err := config.ParseFile(filepath.Join(dir, "analysis.cfg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := filepath.Join(dir, "analysis_logs"); cfg.LogDir != expected {
+		t.Errorf("Expected the logs in %s, got %s", expected, cfg.LogDir)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "test-network.bin.gz")); err != nil {
+		t.Errorf("Expected the network to be downloaded, got %v", err)
+	}
+	if !strings.Contains(strings.Join(k.current().cmd.Args, " "), filepath.Join(dir, "test-network.bin.gz")) {
+		t.Errorf("Expected KataGo to run with the downloaded network, got %v", k.current().cmd.Args)
+	}
+
+	// A given config replaces the one that was written
+	c := config.Default()
+	c.NumSearchThreads = 3
+	k2, err := Setup(context.Background(), SetupOptions{Dir: dir, Network: "test-network", Config: &c, Client: server.Client(), NetworkURL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k2.Close()
+	if cfg, err := config.ParseFile(filepath.Join(dir, "analysis.cfg")); err != nil || cfg.NumSearchThreads != 3 {
+		t.Errorf("Expected 3 search threads, got %d (%v)", cfg.NumSearchThreads, err)
+	}
+	if _, err := Setup(context.Background(), SetupOptions{Dir: dir, Network: "missing", Client: server.Client(), NetworkURL: server.URL}); !errors.Is(err, models.ErrNotFound) {
+		t.Errorf("Expected ErrNotFound for a missing network, got %v", err)
+	}
+}
@@ -0,0 +1,163 @@
+package sgf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// rulesNames maps the values of the RU property that are used by common servers and editors to KataGo rules
+var rulesNames = map[string]katago.Rules{
+	"japanese":     katago.Japanese,
+	"jp":           katago.Japanese,
+	"chinese":      katago.Chinese,
+	"cn":           katago.Chinese,
+	"korean":       katago.Korean,
+	"aga":          katago.AGA,
+	"bga":          katago.BGA,
+	"nz":           katago.NewZealand,
+	"new zealand":  katago.NewZealand,
+	"new-zealand":  katago.NewZealand,
+	"tromp-taylor": katago.TrompTaylor,
+	"tromp taylor": katago.TrompTaylor,
+	"tt":           katago.TrompTaylor,
+	"ing":          katago.NewZealand,
+}
+
+// ParseRules converts the value of an RU property to KataGo rules, and returns katago.DefaultRules
+// if the rules are missing or unknown
+func ParseRules(s string) katago.Rules {
+	s = strings.TrimSpace(s)
+	if rules, ok := rulesNames[strings.ToLower(s)]; ok {
+		return rules
+	}
+	if katago.Rules(s).Validate() == nil {
+		return katago.Rules(s)
+	}
+	return katago.DefaultRules
+}
+
+// BoardSize returns the board size from the SZ property, which is either one number or "width:height".
+// The default size is 19x19.
+func BoardSize(root *Node) (int, int, error) {
+	sz := root.Get("SZ")
+	if sz == "" {
+		return 19, 19, nil
+	}
+	ws, hs, rect := strings.Cut(sz, ":")
+	width, err := strconv.Atoi(strings.TrimSpace(ws))
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid board size: %q", sz)
+	}
+	height := width
+	if rect {
+		if height, err = strconv.Atoi(strings.TrimSpace(hs)); err != nil {
+			return 0, 0, fmt.Errorf("invalid board size: %q", sz)
+		}
+	}
+	return width, height, nil
+}
+
+// vertex converts SGF coordinates to a GTP vertex. An empty value, and "tt" on boards up to 19x19, are passes.
+func vertex(coords string, width, height int) (string, error) {
+	if coords == "" || (coords == "tt" && width <= 19 && height <= 19) {
+		return "pass", nil
+	}
+	return board.SGFToGTP(coords, width, height)
+}
+
+// expandPoints expands compressed point lists, like "aa:cc", to the points in the rectangle
+func expandPoints(values []string) []string {
+	var points []string
+	for _, value := range values {
+		from, to, ok := strings.Cut(value, ":")
+		if !ok || len(from) != 2 || len(to) != 2 {
+			points = append(points, value)
+			continue
+		}
+		for x := min(from[0], to[0]); x <= max(from[0], to[0]); x++ {
+			for y := min(from[1], to[1]); y <= max(from[1], to[1]); y++ {
+				points = append(points, string([]byte{x, y}))
+			}
+		}
+	}
+	return points
+}
+
+// Move returns the move of a node, as a color and a GTP vertex, and false if the node has no move
+func (n *Node) Move(width, height int) (katago.Move, bool, error) {
+	for _, color := range []string{"B", "W"} {
+		if values := n.Values(color); values != nil {
+			v, err := vertex(values[0], width, height)
+			if err != nil {
+				return katago.Move{}, false, err
+			}
+			return katago.Move{Color: katago.Color(color), Vertex: katago.Vertex(v)}, true, nil
+		}
+	}
+	return katago.Move{}, false, nil
+}
+
+// Position returns the position at the end of the main line of the game, with the board size, komi and rules
+// of the game, and the handicap and setup stones of the root node as initial stones. DefaultKomi is used
+// when the komi is missing.
+// Setup stones that are added later in the game are not supported.
+func Position(root *Node) (katago.Position, error) {
+	width, height, err := BoardSize(root)
+	if err != nil {
+		return katago.Position{}, err
+	}
+	p := katago.Position{
+		Rules:      ParseRules(root.Get("RU")),
+		Komi:       katago.DefaultKomi(width, height),
+		BoardXSize: width,
+		BoardYSize: height,
+	}
+	if km := root.Get("KM"); km != "" {
+		komi, err := strconv.ParseFloat(strings.TrimSpace(km), 64)
+		if err != nil {
+			return katago.Position{}, fmt.Errorf("invalid komi: %q", km)
+		}
+		p.Komi = komi
+	}
+	for _, color := range []string{"B", "W"} {
+		for _, coords := range expandPoints(root.Values("A" + color)) {
+			v, err := vertex(coords, width, height)
+			if err != nil {
+				return katago.Position{}, err
+			}
+			p.InitialStones = append(p.InitialStones, katago.Move{Color: katago.Color(color), Vertex: katago.Vertex(v)})
+		}
+	}
+	if pl := strings.ToUpper(root.Get("PL")); pl == "B" || pl == "W" {
+		p.InitialPlayer = pl
+	} else if len(p.InitialStones) > 0 && root.Has("HA") && !root.Has("AW") {
+		// After placing handicap stones, white plays first
+		p.InitialPlayer = "W"
+	}
+	for i, node := range root.MainLine() {
+		if i > 0 && (node.Has("AB") || node.Has("AW") || node.Has("AE")) {
+			return katago.Position{}, fmt.Errorf("setup stones after the first move are not supported")
+		}
+		move, ok, err := node.Move(width, height)
+		if err != nil {
+			return katago.Position{}, err
+		}
+		if ok {
+			p.Moves = append(p.Moves, move)
+		}
+	}
+	return p, nil
+}
+
+// ParsePosition parses SGF data and returns the position at the end of the main line of the first game
+func ParsePosition(data string) (katago.Position, error) {
+	root, err := ParseGame(data)
+	if err != nil {
+		return katago.Position{}, err
+	}
+	return Position(root)
+}
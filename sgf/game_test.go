@@ -0,0 +1,57 @@
+package sgf
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestPosition(t *testing.T) {
+	p, err := ParsePosition(`(;GM[1]FF[4]SZ[19]KM[6.5]RU[Japanese]HA[2]AB[dp][pd];W[dd];B[pp];W[];B[tt])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BoardXSize != 19 || p.BoardYSize != 19 || p.Komi != 6.5 || p.Rules != katago.Japanese {
+		t.Errorf("Unexpected game info: %+v", p)
+	}
+	if len(p.InitialStones) != 2 || p.InitialStones[0] != (katago.Move{Color: "B", Vertex: "D4"}) || p.InitialPlayer != "W" {
+		t.Errorf("Unexpected handicap stones: %v, %q", p.InitialStones, p.InitialPlayer)
+	}
+	expected := []katago.Move{{Color: "W", Vertex: "D16"}, {Color: "B", Vertex: "Q4"}, {Color: "W", Vertex: "pass"}, {Color: "B", Vertex: "pass"}}
+	if len(p.Moves) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, p.Moves)
+	}
+	for i := range expected {
+		if p.Moves[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], p.Moves[i])
+		}
+	}
+}
+
+func TestPositionDefaults(t *testing.T) {
+	p, err := ParsePosition(`(;SZ[9:7]AB[aa:bb])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.BoardXSize != 9 || p.BoardYSize != 7 || p.Rules != katago.DefaultRules || p.Komi != katago.DefaultKomi(9, 7) {
+		t.Errorf("Unexpected defaults: %+v", p)
+	}
+	if len(p.InitialStones) != 4 {
+		t.Errorf("Expected 4 stones from the compressed point list, got %v", p.InitialStones)
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	tests := map[string]katago.Rules{
+		"Japanese":                         katago.Japanese,
+		"NZ":                               katago.NewZealand,
+		"koPOSITIONALscoreAREAtaxNONEsui1": "koPOSITIONALscoreAREAtaxNONEsui1",
+		"something else":                   katago.DefaultRules,
+		"":                                 katago.DefaultRules,
+	}
+	for s, expected := range tests {
+		if rules := ParseRules(s); rules != expected {
+			t.Errorf("Expected %q for %q, got %q", expected, s, rules)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+// Package sgf reads game records in the Smart Game Format (SGF), and turns them into positions for KataGo
+package sgf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrNoGame is returned when there is no game tree in the SGF data
+var ErrNoGame = errors.New("no game found in the SGF data")
+
+// Property is an SGF property, like B[pd] or AB[dd][pp]
+type Property struct {
+	ID     string
+	Values []string
+}
+
+// Node is a node in an SGF game tree. The first child is the main line.
+type Node struct {
+	Properties []Property
+	Children   []*Node
+	Parent     *Node
+}
+
+// Values returns all the values of a property, or nil if the node does not have it
+func (n *Node) Values(id string) []string {
+	for _, p := range n.Properties {
+		if p.ID == id {
+			return p.Values
+		}
+	}
+	return nil
+}
+
+// Get returns the first value of a property, or an empty string if the node does not have it
+func (n *Node) Get(id string) string {
+	if values := n.Values(id); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Has checks if the node has a property
+func (n *Node) Has(id string) bool {
+	return n.Values(id) != nil
+}
+
+// Set replaces the values of a property, or adds the property if the node does not have it
+func (n *Node) Set(id string, values ...string) {
+	for i, p := range n.Properties {
+		if p.ID == id {
+			n.Properties[i].Values = values
+			return
+		}
+	}
+	n.Properties = append(n.Properties, Property{ID: id, Values: values})
+}
+
+// AddChild adds a child node, and returns it
+func (n *Node) AddChild(child *Node) *Node {
+	child.Parent = n
+	n.Children = append(n.Children, child)
+	return child
+}
+
+// MainLine returns the node and the nodes that follow it along the first child of each node
+func (n *Node) MainLine() []*Node {
+	var nodes []*Node
+	for node := n; node != nil; {
+		nodes = append(nodes, node)
+		if len(node.Children) == 0 {
+			break
+		}
+		node = node.Children[0]
+	}
+	return nodes
+}
+
+// parser reads SGF data
+type parser struct {
+	data string
+	pos  int
+}
+
+// errorf returns an error that includes the position in the data
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("SGF error at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace
+func (p *parser) skipSpace() {
+	for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// peek returns the next byte that is not whitespace, or 0 at the end of the data
+func (p *parser) peek() byte {
+	p.skipSpace()
+	if p.pos >= len(p.data) {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+// gameTree parses a game tree, starting at "(", and adds its nodes as children of parent
+func (p *parser) gameTree(parent *Node) (*Node, error) {
+	if p.peek() != '(' {
+		return nil, p.errorf("expected '('")
+	}
+	p.pos++
+	var first, current *Node
+	for p.peek() == ';' {
+		p.pos++
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		if current == nil {
+			first = node
+			if parent != nil {
+				parent.AddChild(node)
+			}
+		} else {
+			current.AddChild(node)
+		}
+		current = node
+	}
+	if current == nil {
+		return nil, p.errorf("expected ';'")
+	}
+	for p.peek() == '(' {
+		if _, err := p.gameTree(current); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek() != ')' {
+		return nil, p.errorf("expected ')'")
+	}
+	p.pos++
+	return first, nil
+}
+
+// node parses the properties of a node, after the ";"
+func (p *parser) node() (*Node, error) {
+	node := &Node{}
+	for {
+		c := p.peek()
+		if c < 'A' || c > 'Z' {
+			// Lower case letters in property names are from old versions of SGF, and are ignored
+			if c >= 'a' && c <= 'z' {
+				p.pos++
+				continue
+			}
+			return node, nil
+		}
+		start := p.pos
+		for p.pos < len(p.data) && ((p.data[p.pos] >= 'A' && p.data[p.pos] <= 'Z') || (p.data[p.pos] >= 'a' && p.data[p.pos] <= 'z')) {
+			p.pos++
+		}
+		var id strings.Builder
+		for _, r := range p.data[start:p.pos] {
+			if r >= 'A' && r <= 'Z' {
+				id.WriteRune(r)
+			}
+		}
+		var values []string
+		for p.peek() == '[' {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		if values == nil {
+			return nil, p.errorf("property %s has no value", id.String())
+		}
+		node.Properties = append(node.Properties, Property{ID: id.String(), Values: values})
+	}
+}
+
+// value parses a property value, starting at "[", and handles the escapes
+func (p *parser) value() (string, error) {
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		switch c {
+		case '\\':
+			p.pos++
+			if p.pos >= len(p.data) {
+				return "", p.errorf("unterminated value")
+			}
+			// An escaped line break is a soft line break, which is removed
+			if p.data[p.pos] == '\n' || p.data[p.pos] == '\r' {
+				next := p.pos + 1
+				if next < len(p.data) && (p.data[next] == '\n' || p.data[next] == '\r') && p.data[next] != p.data[p.pos] {
+					p.pos++
+				}
+			} else {
+				sb.WriteByte(p.data[p.pos])
+			}
+		case ']':
+			p.pos++
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+		p.pos++
+	}
+	return "", p.errorf("unterminated value")
+}
+
+// ParseString parses SGF data, and returns the root node of each game in the collection
+func ParseString(data string) ([]*Node, error) {
+	p := &parser{data: data}
+	// Anything before the first game tree, like a mail header, is skipped
+	start := strings.IndexByte(data, '(')
+	if start < 0 {
+		return nil, ErrNoGame
+	}
+	p.pos = start
+	var games []*Node
+	for p.peek() == '(' {
+		root, err := p.gameTree(nil)
+		if err != nil {
+			return nil, err
+		}
+		games = append(games, root)
+	}
+	return games, nil
+}
+
+// Parse reads SGF data, and returns the root node of each game in the collection
+func Parse(r io.Reader) ([]*Node, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return ParseString(string(data))
+}
+
+// ParseGame parses SGF data and returns the root node of the first game
+func ParseGame(data string) (*Node, error) {
+	games, err := ParseString(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(games) == 0 {
+		return nil, ErrNoGame
+	}
+	return games[0], nil
+}
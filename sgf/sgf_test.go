@@ -0,0 +1,50 @@
+package sgf
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	games, err := ParseString(`header (;GM[1]SZ[9]C[a \] bracket\\];B[ee];W[cc](;B[gg])(;B[gc]C[variation])) (;SZ[13])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("Expected 2 games, got %d", len(games))
+	}
+	root := games[0]
+	if root.Get("C") != `a ] bracket\` {
+		t.Errorf("Expected the escapes to be handled, got %q", root.Get("C"))
+	}
+	line := root.MainLine()
+	if len(line) != 4 || line[3].Get("B") != "gg" {
+		t.Errorf("Unexpected main line: %v", line)
+	}
+	variations := line[2].Children
+	if len(variations) != 2 || variations[1].Get("C") != "variation" || variations[1].Parent != line[2] {
+		t.Errorf("Unexpected variations: %v", variations)
+	}
+	if games[1].Get("SZ") != "13" {
+		t.Errorf("Expected the second game to have size 13, got %q", games[1].Get("SZ"))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, data := range []string{"", "(;B[aa]", "(;B)", "(C[x])", "(;C[unterminated)"} {
+		if _, err := ParseString(data); err == nil {
+			t.Errorf("Expected an error for %q", data)
+		}
+	}
+}
+
+func TestSet(t *testing.T) {
+	n := &Node{}
+	n.Set("C", "first")
+	n.Set("C", "second")
+	if len(n.Properties) != 1 || n.Get("C") != "second" {
+		t.Errorf("Expected the property to be replaced, got %v", n.Properties)
+	}
+	if n.Has("B") {
+		t.Errorf("Expected no B property")
+	}
+}
@@ -0,0 +1,77 @@
+package sgf
+
+import (
+	"bufio"
+	"io"
+	"strings"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// escapeValue escapes the characters that end a property value
+func escapeValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `]`, `\]`).Replace(s)
+}
+
+// writeNode writes a node and the nodes below it. Nodes with one child continue the sequence,
+// while several children are written as variations.
+func writeNode(w *bufio.Writer, n *Node) {
+	w.WriteByte(';')
+	for _, p := range n.Properties {
+		w.WriteString(p.ID)
+		for _, value := range p.Values {
+			w.WriteByte('[')
+			w.WriteString(escapeValue(value))
+			w.WriteByte(']')
+		}
+	}
+	switch len(n.Children) {
+	case 0:
+	case 1:
+		if len(n.Properties) > 0 {
+			w.WriteByte('\n')
+		}
+		writeNode(w, n.Children[0])
+	default:
+		for _, child := range n.Children {
+			w.WriteString("\n(")
+			writeNode(w, child)
+			w.WriteByte(')')
+		}
+	}
+}
+
+// Write writes the games as an SGF collection
+func Write(w io.Writer, games ...*Node) error {
+	bw := bufio.NewWriter(w)
+	for i, root := range games {
+		if i > 0 {
+			bw.WriteByte('\n')
+		}
+		bw.WriteByte('(')
+		writeNode(bw, root)
+		bw.WriteString(")\n")
+	}
+	return bw.Flush()
+}
+
+// Format returns the games as an SGF collection
+func Format(games ...*Node) string {
+	var sb strings.Builder
+	Write(&sb, games...)
+	return sb.String()
+}
+
+// MoveNode creates a node with a move, given as a color and a GTP vertex. Passes are written as empty values.
+func MoveNode(move katago.Move, width, height int) (*Node, error) {
+	color := strings.ToUpper(string(move.Color))
+	if move.Vertex.IsPass() {
+		return &Node{Properties: []Property{{ID: color, Values: []string{""}}}}, nil
+	}
+	coords, err := board.GTPToSGF(string(move.Vertex), width, height)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Properties: []Property{{ID: color, Values: []string{coords}}}}, nil
+}
@@ -0,0 +1,35 @@
+package sgf
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago"
+)
+
+func TestWriteRoundTrip(t *testing.T) {
+	data := "(;GM[1]SZ[9]C[a \\] bracket]\n;B[ee]\n;W[cc]\n(;B[gg])\n(;B[gc]C[variation]))\n"
+	root, err := ParseGame(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := Format(root); s != data {
+		t.Errorf("Expected %q, got %q", data, s)
+	}
+}
+
+func TestMoveNode(t *testing.T) {
+	n, err := MoveNode(katago.Move{Color: "w", Vertex: "D4"}, 19, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n.Get("W") != "dp" {
+		t.Errorf("Expected W[dp], got %v", n.Properties)
+	}
+	n, err = MoveNode(katago.Move{Color: "B", Vertex: "pass"}, 19, 19)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !n.Has("B") || n.Get("B") != "" {
+		t.Errorf("Expected B[], got %v", n.Properties)
+	}
+}
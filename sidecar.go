@@ -0,0 +1,78 @@
+package katago
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"time"
+)
+
+// connectRetryInterval is how long ConnectKataGo waits before trying to connect again
+const connectRetryInterval = 500 * time.Millisecond
+
+// ConnectKataGo connects to a KataGo analysis engine that is already running, instead of starting one,
+// for container deployments where the engine runs as a sidecar that is managed by the orchestrator.
+// The network is "unix" or "tcp", as for net.Dial, and the sidecar can be as simple as:
+//
+//	socat UNIX-LISTEN:/run/katago.sock,fork EXEC:"katago analysis -config analysis.cfg -model model.bin.gz"
+//
+// The connection is tried again until the context is done, since the sidecar may start after this process.
+// Options that change how KataGo is started, like WithOverrides, WithDevices and WithHumanModel, have no
+// effect, since the sidecar has its own command line.
+func ConnectKataGo(ctx context.Context, network, address string, options ...Option) (*KataGo, error) {
+	var dialer net.Dialer
+	for {
+		conn, err := dialer.DialContext(ctx, network, address)
+		if err == nil {
+			return NewKataGoConn(conn, options...), nil
+		}
+		select {
+		case <-time.After(connectRetryInterval):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: failed to connect to %s: %v", ErrEngineNotStarted, address, err)
+		}
+	}
+}
+
+// NewKataGoConn creates an engine for a connection to a KataGo analysis engine that is already running,
+// which reads the queries from the connection and writes the responses to it. Close ends the input, if the
+// connection can be half closed, so that KataGo finishes the running queries, and waits for the sidecar to
+// close the connection. SwapModel is not supported, and Devices is empty.
+func NewKataGoConn(conn net.Conn, options ...Option) *KataGo {
+	k := newEngine(options)
+	k.proc.Store(k.connect(conn))
+	return k
+}
+
+// connect returns a process for a connection to a running engine, which is ready right away
+func (k *KataGo) connect(conn net.Conn) *process {
+	p := &process{
+		conn:       conn,
+		stdin:      conn,
+		stdout:     bufio.NewReader(conn),
+		done:       make(chan struct{}),
+		started:    make(chan struct{}),
+		stderrDone: make(chan struct{}),
+		ready:      true,
+	}
+	close(p.started)
+	close(p.stderrDone)
+	go k.readLoop(p)
+	return p
+}
+
+// disconnect ends the input of a connected process, and waits for the sidecar to close the connection,
+// before closing it. Connections that can not be half closed are closed right away.
+func (p *process) disconnect() error {
+	if c, ok := p.conn.(interface{ CloseWrite() error }); ok {
+		if err := c.CloseWrite(); err == nil {
+			<-p.done
+		}
+	}
+	if err := p.conn.Close(); err != nil {
+		return fmt.Errorf("failed to close the connection to KataGo: %v", err)
+	}
+	<-p.done
+	return nil
+}
@@ -0,0 +1,111 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveSidecar runs KataGo for each connection to the listener, like socat with fork does
+func serveSidecar(t *testing.T, l net.Listener) {
+	t.Helper()
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				cmd := exec.Command("katago", "analysis", "-config", "analysis_example.cfg", "-model", "model.bin.gz")
+				cmd.Stdin = conn
+				cmd.Stdout = conn
+				cmd.Run()
+			}()
+		}
+	}()
+}
+
+func TestNewKataGoConn(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serveSidecar(t, l)
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := NewKataGoConn(conn)
+	if !k.Running() {
+		t.Error("Expected a connected engine to be running")
+	}
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 1 || responses[0].ID != request.ID || len(responses[0].MoveInfos) == 0 {
+		t.Errorf("Unexpected responses: %+v", responses)
+	}
+	if err := k.SwapModel(context.Background(), "model.bin.gz"); err == nil {
+		t.Error("Expected SwapModel to fail for a connected engine")
+	}
+
+	// Close lets KataGo finish the running query
+	request = NewRequest9x9()
+	request.MaxVisits = 4000
+	result := make(chan error, 1)
+	go func() {
+		_, err := k.Analyze([]AnalysisRequest{request})
+		result <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	if err := k.Close(); err != nil {
+		t.Errorf("Failed to close: %v", err)
+	}
+	if err := <-result; err != nil {
+		t.Errorf("Expected the running query to finish, got %v", err)
+	}
+	if k.Running() {
+		t.Error("Expected the engine to stop after closing")
+	}
+}
+
+func TestConnectKataGo(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "katago.sock")
+	// The sidecar starts after the engine tries to connect
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l, err := net.Listen("unix", socket)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		serveSidecar(t, l)
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	k, err := ConnectKataGo(ctx, "unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	if _, err := k.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Error(err)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := ConnectKataGo(ctx, "unix", filepath.Join(t.TempDir(), "missing.sock")); !errors.Is(err, ErrEngineNotStarted) {
+		t.Errorf("Expected ErrEngineNotStarted, got %v", err)
+	}
+}
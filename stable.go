@@ -0,0 +1,60 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// StableStartVisits is the number of visits of the first evaluation of AnalyzeStable, which doubles for each
+// evaluation after it, up to StableMaxVisits
+var (
+	StableStartVisits = 100
+	StableMaxVisits   = 100000
+)
+
+// stableScoreScale is how many points of score lead count as much as a winrate of 1 in AnalyzeStable,
+// so that an epsilon of 0.005 allows the score lead to move by 0.1 points
+const stableScoreScale = 20.0
+
+// StableResult is an evaluation that has stopped moving when given more visits
+type StableResult struct {
+	Response AnalysisResponse
+	// Visits is the number of visits of the evaluation
+	Visits int
+	// Converged is false if the evaluation was still moving at StableMaxVisits
+	Converged bool
+}
+
+// AnalyzeStable evaluates the position with more and more visits, starting at StableStartVisits and doubling
+// each time, until the winrate moves by at most epsilon and the score lead by at most 20 times epsilon points
+// between two evaluations, for evaluations that can be trusted, like in research. It returns the last
+// evaluation and the visits it needed. If the evaluation is still moving at StableMaxVisits, the result is
+// returned with Converged set to false.
+func (k *KataGo) AnalyzeStable(p Position, epsilon float64) (StableResult, error) {
+	if epsilon <= 0 {
+		return StableResult{}, fmt.Errorf("%w: epsilon must be positive, got %v", ErrBadRequest, epsilon)
+	}
+	var previous *AnalysisResponse
+	for visits := min(StableStartVisits, StableMaxVisits); ; visits = min(2*visits, StableMaxVisits) {
+		request := p.Request(k.newID("stable"))
+		request.MaxVisits = visits
+		responses, err := k.Analyze([]AnalysisRequest{request})
+		if err != nil {
+			return StableResult{}, err
+		}
+		if len(responses) == 0 {
+			return StableResult{}, errors.New("no response when evaluating the position")
+		}
+		response := responses[0]
+		if previous != nil &&
+			math.Abs(response.RootInfo.Winrate-previous.RootInfo.Winrate) <= epsilon &&
+			math.Abs(response.RootInfo.ScoreLead-previous.RootInfo.ScoreLead) <= stableScoreScale*epsilon {
+			return StableResult{Response: response, Visits: visits, Converged: true}, nil
+		}
+		if visits >= StableMaxVisits {
+			return StableResult{Response: response, Visits: visits}, nil
+		}
+		previous = &response
+	}
+}
@@ -0,0 +1,34 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnalyzeStable(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{Black, "E5"}}
+	result, err := k.AnalyzeStable(p, 0.01)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The evaluations of the test engine do not depend on the visits, so the second one is the same as the first
+	if !result.Converged || result.Visits != 2*StableStartVisits {
+		t.Errorf("Expected the evaluation to converge at %d visits, got %+v", 2*StableStartVisits, result)
+	}
+	if result.Response.RootInfo.CurrentPlayer != "W" {
+		t.Errorf("Expected white to play, got %q", result.Response.RootInfo.CurrentPlayer)
+	}
+
+	defer func(visits int) { StableMaxVisits = visits }(StableMaxVisits)
+	StableMaxVisits = StableStartVisits
+	if result, err := k.AnalyzeStable(p, 0.01); err != nil || result.Converged || result.Visits != StableStartVisits {
+		t.Errorf("Expected to give up at %d visits, got %+v and %v", StableStartVisits, result, err)
+	}
+
+	if _, err := k.AnalyzeStable(p, 0); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest without an epsilon, got %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package katago
+
+import (
+	"slices"
+	"time"
+)
+
+// latencyWindow is how many of the latest queries the latency percentiles are computed from
+const latencyWindow = 1000
+
+// Stats are the totals and timings of an engine, for dashboards
+type Stats struct {
+	// Queries is the number of queries that have been sent to KataGo, including the ones that are sent again
+	Queries int
+	// Errors is the number of queries that KataGo answered with an error
+	Errors int
+	// Restarts is the number of times that the KataGo process has been replaced, like by SwapModel
+	Restarts int
+	// QueueDepth is the number of queries that have been sent and are not answered yet
+	QueueDepth int
+	// LatencyP50, LatencyP90 and LatencyP99 are the percentiles of the time from sending a query to the
+	// last of its responses, over the latest queries
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+	// VisitsPerSecond is the average number of visits for each second that a query took
+	VisitsPerSecond float64
+}
+
+// engineStats collects the statistics, and is protected by the mut of the engine
+type engineStats struct {
+	queries   int
+	errors    int
+	restarts  int
+	latencies []time.Duration
+	// next is where the next latency goes in latencies, once the window is full
+	next    int
+	visits  int
+	elapsed time.Duration
+}
+
+// sent records that a query was sent
+func (s *engineStats) sent() {
+	s.queries++
+}
+
+// answered records that a query got all of its responses, or an error, after the given time
+func (s *engineStats) answered(latency time.Duration, visits int, failed bool) {
+	if failed {
+		s.errors++
+	}
+	s.visits += visits
+	s.elapsed += latency
+	if len(s.latencies) < latencyWindow {
+		s.latencies = append(s.latencies, latency)
+		return
+	}
+	s.latencies[s.next] = latency
+	s.next = (s.next + 1) % latencyWindow
+}
+
+// percentile returns the latency that the given fraction of the sorted latencies are at or below
+func percentile(sorted []time.Duration, fraction float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := int(fraction*float64(len(sorted))+0.5) - 1
+	return sorted[min(max(i, 0), len(sorted)-1)]
+}
+
+// Stats returns the totals, the latency percentiles, the average search speed and the current queue depth
+func (k *KataGo) Stats() Stats {
+	k.mut.Lock()
+	defer k.mut.Unlock()
+	s := Stats{
+		Queries:  k.stats.queries,
+		Errors:   k.stats.errors,
+		Restarts: k.stats.restarts,
+	}
+	for _, q := range k.pending {
+		if q.sent {
+			s.QueueDepth++
+		}
+	}
+	sorted := slices.Clone(k.stats.latencies)
+	slices.Sort(sorted)
+	s.LatencyP50 = percentile(sorted, 0.5)
+	s.LatencyP90 = percentile(sorted, 0.9)
+	s.LatencyP99 = percentile(sorted, 0.99)
+	if k.stats.elapsed > 0 {
+		s.VisitsPerSecond = float64(k.stats.visits) / k.stats.elapsed.Seconds()
+	}
+	return s
+}
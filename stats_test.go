@@ -0,0 +1,56 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	for _, vertex := range []Vertex{"C3", "E5", "D4"} {
+		request := NewRequest9x9()
+		request.MaxVisits = 100
+		request.Moves = []Move{{Black, vertex}}
+		request.AnalyzeTurns = []int{1}
+		if _, err := katago.Analyze([]AnalysisRequest{request}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The board is too large for KataGo, which answers with an error
+	defer func(size int) { MaxBoardSize = size }(MaxBoardSize)
+	MaxBoardSize = 25
+	request := NewRequest9x9()
+	request.BoardXSize, request.BoardYSize = 21, 21
+	if _, err := katago.Analyze([]AnalysisRequest{request}); err == nil {
+		t.Fatal("Expected an error for a 21x21 board")
+	}
+
+	s := katago.Stats()
+	if s.Queries != 4 || s.Errors != 1 || s.QueueDepth != 0 {
+		t.Errorf("Expected 4 queries, 1 error and an empty queue, got %+v", s)
+	}
+	if s.LatencyP50 <= 0 || s.LatencyP99 < s.LatencyP50 {
+		t.Errorf("Expected the latency percentiles to be set, got %+v", s)
+	}
+	if s.VisitsPerSecond <= 0 {
+		t.Errorf("Expected the visits per second to be set, got %v", s.VisitsPerSecond)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	var sorted []time.Duration
+	for i := 1; i <= 100; i++ {
+		sorted = append(sorted, time.Duration(i)*time.Millisecond)
+	}
+	if p := percentile(sorted, 0.5); p != 50*time.Millisecond {
+		t.Errorf("Expected 50ms, got %v", p)
+	}
+	if p := percentile(sorted, 0.99); p != 99*time.Millisecond {
+		t.Errorf("Expected 99ms, got %v", p)
+	}
+	if p := percentile(nil, 0.5); p != 0 {
+		t.Errorf("Expected 0 for no latencies, got %v", p)
+	}
+}
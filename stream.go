@@ -0,0 +1,107 @@
+package katago
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// DefaultReportInterval is how often the search results are reported by AnalyzeStream, in seconds,
+// when the request does not set ReportDuringSearchEvery
+var DefaultReportInterval = 0.5
+
+// Snapshot returns the last report that KataGo sent during the search of a running query, which has the results
+// so far. Reports are only sent for requests with ReportDuringSearchEvery set, and for a request that analyzes
+// several turns, the report can be for any of them. It returns false if there is no report yet, or if the query
+// is not running.
+func (k *KataGo) Snapshot(id string) (AnalysisResponse, bool) {
+	k.mut.Lock()
+	q, ok := k.pending[id]
+	var line []byte
+	if ok {
+		line = q.latest
+	}
+	k.mut.Unlock()
+	if line == nil {
+		return AnalysisResponse{}, false
+	}
+	var response AnalysisResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		log.Printf("Failed to unmarshal interim response: %v", err)
+		return AnalysisResponse{}, false
+	}
+	return response, true
+}
+
+// AnalyzeStream sends one request for one turn and calls interim with the results so far while the search is running,
+// before returning the final response. The interim function is called from its own goroutine, in order, and
+// it may call other methods, like Terminate. Reports are skipped if it falls behind, since the later ones
+// have more visits, and it is not called any more once AnalyzeStream returns.
+func (k *KataGo) AnalyzeStream(request AnalysisRequest, interim func(AnalysisResponse)) (AnalysisResponse, error) {
+	request = k.defaults.apply(request)
+	if err := request.Validate(); err != nil {
+		return AnalysisResponse{}, fmt.Errorf("%w: %v", ErrBadRequest, err)
+	}
+	if request.turns() > 1 {
+		return AnalysisResponse{}, fmt.Errorf("%w: request %s analyzes %d turns, use Analyze", ErrBadRequest, request.ID, len(request.AnalyzeTurns))
+	}
+	if request.ReportDuringSearchEvery <= 0 {
+		request.ReportDuringSearchEvery = DefaultReportInterval
+	}
+	if err := k.queue.acquire(1); err != nil {
+		return AnalysisResponse{}, err
+	}
+	defer k.queue.release(1)
+	visits := k.limiter.acquire([]AnalysisRequest{request})
+	defer k.limiter.release(visits)
+	channels, err := k.register(request.ID)
+	if err != nil {
+		return AnalysisResponse{}, err
+	}
+	reports := k.listen(request.ID)
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		for line := range reports {
+			var response AnalysisResponse
+			if err := json.Unmarshal(line, &response); err != nil {
+				log.Printf("Failed to unmarshal interim response: %v", err)
+				continue
+			}
+			interim(response)
+		}
+	}()
+	defer func() {
+		// Unregistering closes the reports, and the last ones are passed on before returning
+		k.unregister(request.ID)
+		<-reported
+	}()
+	p, err := k.send(request)
+	if err != nil {
+		return AnalysisResponse{}, err
+	}
+	if k.preemption {
+		k.preempt([]AnalysisRequest{request})
+	}
+	stop := k.terminateAfter(request)
+	line, err := k.wait(p, channels[0])
+	timedOut := stop()
+	if err != nil {
+		return AnalysisResponse{}, fmt.Errorf("error reading response: %w", err)
+	}
+	if err := responseError(line); err != nil {
+		return AnalysisResponse{}, err
+	}
+	var response AnalysisResponse
+	if err := json.Unmarshal(line, &response); err != nil {
+		return AnalysisResponse{}, fmt.Errorf("failed to unmarshal response: %v", err)
+	}
+	response.Warnings = k.warningsFor(request.ID)
+	if response.NoResults {
+		if timedOut {
+			return AnalysisResponse{}, fmt.Errorf("%w: %s", ErrQueryTimeout, request.ID)
+		}
+		return AnalysisResponse{}, fmt.Errorf("%w: %s", ErrQueryTerminated, request.ID)
+	}
+	return response, nil
+}
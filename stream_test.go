@@ -0,0 +1,66 @@
+package katago
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnalyzeStream(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.MaxVisits = 10000
+	request.ReportDuringSearchEvery = 0.1
+	var reports []AnalysisResponse
+	response, err := katago.AnalyzeStream(request, func(r AnalysisResponse) {
+		reports = append(reports, r)
+	})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if response.IsDuringSearch || response.RootInfo.Visits != 10000 {
+		t.Errorf("Expected the final response, got %v", response)
+	}
+	if len(reports) == 0 {
+		t.Fatal("Expected reports during the search")
+	}
+	for _, r := range reports {
+		if !r.IsDuringSearch || r.RootInfo.Visits > response.RootInfo.Visits {
+			t.Errorf("Expected an interim report, got %v", r)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.ID = "snapshot"
+	request.MaxVisits = 10000
+	request.ReportDuringSearchEvery = 0.05
+	done := make(chan []AnalysisResponse, 1)
+	go func() {
+		responses, err := katago.Analyze([]AnalysisRequest{request})
+		if err != nil {
+			t.Error(err)
+		}
+		done <- responses
+	}()
+	var snapshot AnalysisResponse
+	for ok := false; !ok; snapshot, ok = katago.Snapshot("snapshot") {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !snapshot.IsDuringSearch || snapshot.RootInfo.Visits >= 10000 {
+		t.Errorf("Expected a report during the search, got %v", snapshot)
+	}
+	// The reports during the search are not mistaken for the final response
+	responses := <-done
+	if len(responses) != 1 || responses[0].IsDuringSearch || responses[0].RootInfo.Visits != 10000 {
+		t.Errorf("Expected the final response, got %v", responses)
+	}
+	if _, ok := katago.Snapshot("snapshot"); ok {
+		t.Error("Expected no snapshot after the query is done")
+	}
+}
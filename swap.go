@@ -0,0 +1,68 @@
+package katago
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// SwapModel replaces the neural network of a running engine without downtime. A new KataGo process is
+// started with the model file and the same config file and options, and once it is ready, new requests
+// are sent to it. The old process finishes the queries that were sent to it, which are returned to their
+// callers as usual, and then exits. If the context is done before the new process is ready, it is stopped
+// and the old one keeps running. If the context is done while the old process is still busy, the old
+// process is stopped, and its queries fail with ErrEngineCrashed. The cache of WithCache and the queries
+// that concurrent calls share are kept apart for each model, so the new model does not get the old responses.
+func (k *KataGo) SwapModel(ctx context.Context, modelFile string) error {
+	if k.current().conn != nil {
+		return errors.New("the model of an engine that is connected with NewKataGoConn can not be swapped")
+	}
+	next, err := k.start(modelFile)
+	if err != nil {
+		return err
+	}
+	select {
+	case <-next.started:
+	case <-ctx.Done():
+	}
+	if err := ctx.Err(); err != nil {
+		next.kill()
+		return err
+	}
+	if !next.ready {
+		next.kill()
+		return fmt.Errorf("%w: KataGo stopped before it was ready, with model %s", ErrEngineNotStarted, modelFile)
+	}
+
+	// No request is being sent while switching, so each request goes to one of the processes
+	k.swapMut.Lock()
+	old := k.proc.Swap(next)
+	k.swapMut.Unlock()
+	k.mut.Lock()
+	k.stats.restarts++
+	k.mut.Unlock()
+
+	drained := make(chan error, 1)
+	go func() {
+		drained <- old.close()
+	}()
+	select {
+	case err := <-drained:
+		if err != nil {
+			log.Printf("The old KataGo process did not exit cleanly: %v", err)
+		}
+		return nil
+	case <-ctx.Done():
+		if err := old.cmd.Process.Kill(); err != nil {
+			log.Printf("Failed to kill KataGo: %v", err)
+		}
+		<-drained
+		return fmt.Errorf("the old KataGo process was stopped before it finished its queries: %w", ctx.Err())
+	}
+}
+
+// Model returns the model file of the KataGo process that new requests are sent to
+func (k *KataGo) Model() string {
+	return k.current().model
+}
@@ -0,0 +1,90 @@
+package katago
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSwapModel(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	// A query that is running on the old process is finished by it
+	request := NewRequest9x9()
+	request.ID = "long"
+	request.MaxVisits = 20000
+	done := make(chan error, 1)
+	go func() {
+		_, err := katago.Analyze([]AnalysisRequest{request})
+		done <- err
+	}()
+	for !katago.isPending("long") {
+		time.Sleep(time.Millisecond)
+	}
+	old := katago.current()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := katago.SwapModel(ctx, "./model.bin.gz"); err != nil {
+		t.Fatalf("Failed to swap the model: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected the running query to be answered by the old process, got %v", err)
+	}
+	if old.running() {
+		t.Error("Expected the old process to have exited")
+	}
+	if katago.Model() != "./model.bin.gz" {
+		t.Errorf("Expected the new model, got %s", katago.Model())
+	}
+	if _, err := katago.Analyze([]AnalysisRequest{NewRequest9x9()}); err != nil {
+		t.Errorf("Expected the new process to answer, got %v", err)
+	}
+	if s := katago.Stats(); s.Restarts != 1 {
+		t.Errorf("Expected 1 restart, got %d", s.Restarts)
+	}
+}
+
+func TestSwapModelCanceled(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := katago.SwapModel(ctx, "./model.bin.gz"); err == nil {
+		t.Error("Expected an error for a canceled context")
+	}
+	if katago.Model() != "model.bin.gz" {
+		t.Errorf("Expected the old model to be kept, got %s", katago.Model())
+	}
+	if _, err := katago.Analyze([]AnalysisRequest{NewRequest9x9()}); err != nil {
+		t.Errorf("Expected the old process to keep answering, got %v", err)
+	}
+}
+
+func TestSwapModelCache(t *testing.T) {
+	cache := &countingCache{LRUCache: NewLRUCache(10)}
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(cache))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+	request := NewRequest9x9()
+	request.MaxVisits = 10
+	if _, err := katago.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := katago.SwapModel(ctx, "./model.bin.gz"); err != nil {
+		t.Fatalf("Failed to swap the model: %v", err)
+	}
+	// The response of the old model is not returned for the new one
+	request.ID = "after-swap"
+	if _, err := katago.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Fatal(err)
+	}
+	if cache.hits != 0 || cache.Len() != 2 {
+		t.Errorf("Expected a new search for the new model, got %d hits and %d cached responses", cache.hits, cache.Len())
+	}
+}
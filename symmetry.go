@@ -0,0 +1,147 @@
+package katago
+
+import (
+	"fmt"
+
+	"github.com/xyproto/katago/board"
+)
+
+// cacheKey is the hash of a request in its canonical orientation, which is the same for all rotations and
+// reflections of the position, and for the position with the colors swapped when that does not change the game.
+// The symmetry, followed by swapping the colors if swapped is set, turns the position of the request into
+// the canonical one.
+type cacheKey struct {
+	hash          board.Hash
+	symmetry      board.Symmetry
+	swapped       bool
+	width, height int
+}
+
+// colorSymmetric checks if swapping the colors of the position gives the same game for the player to move,
+// which needs the komi and the handicap bonus to favor neither side
+func (r AnalysisRequest) colorSymmetric() bool {
+	return r.Komi == 0 && (r.WhiteHandicapBonus == "" || r.WhiteHandicapBonus == "0")
+}
+
+// canonicalKey returns the cache key of a request for a single turn. The position is turned into each of
+// its symmetries, and the one with the lowest hash is the canonical one. Requests with AvoidMoves or
+// AllowMoves are only hashed as they are.
+func (r AnalysisRequest) canonicalKey() (cacheKey, error) {
+	if len(r.AnalyzeTurns) > 1 {
+		return cacheKey{}, fmt.Errorf("request %s analyzes %d turns", r.ID, len(r.AnalyzeTurns))
+	}
+	p := r.Position(r.lastTurn())
+	b, err := p.Board()
+	if err != nil {
+		return cacheKey{}, err
+	}
+	symmetries := board.Symmetries(r.BoardXSize, r.BoardYSize)
+	if len(r.AvoidMoves) > 0 || len(r.AllowMoves) > 0 {
+		symmetries = []board.Symmetry{board.Identity}
+	}
+	swaps := []bool{false}
+	if r.colorSymmetric() {
+		swaps = append(swaps, true)
+	}
+	var best cacheKey
+	found := false
+	for _, swapped := range swaps {
+		for _, s := range symmetries {
+			t, err := b.Transform(s)
+			if err != nil {
+				return cacheKey{}, err
+			}
+			if swapped {
+				t = t.SwapColors()
+			}
+			positionHash, err := p.hashBoard(t)
+			if err != nil {
+				return cacheKey{}, err
+			}
+			h, err := r.hashWith(positionHash)
+			if err != nil {
+				return cacheKey{}, err
+			}
+			if !found || h < best.hash {
+				best = cacheKey{hash: h, symmetry: s, swapped: swapped, width: r.BoardXSize, height: r.BoardYSize}
+				found = true
+			}
+		}
+	}
+	return best, nil
+}
+
+// CanonicalHash returns a hash of a request for a single turn, like Hash, which is also the same for
+// all the rotations and reflections of the position. When the komi is 0, it is also the same for the position
+// with the colors swapped. Analyze uses it as the key for the cache, and stores the responses in the
+// canonical orientation, so that the analysis of a mirrored opening is found in the cache.
+func (r AnalysisRequest) CanonicalHash() (board.Hash, error) {
+	key, err := r.canonicalKey()
+	return key.hash, err
+}
+
+// toCanonical turns a response for the request into the response for the canonical position
+func (key cacheKey) toCanonical(r AnalysisResponse) AnalysisResponse {
+	return transformResponse(r, key.symmetry, key.swapped, key.width, key.height)
+}
+
+// fromCanonical turns a response for the canonical position into the response for the request
+func (key cacheKey) fromCanonical(r AnalysisResponse) AnalysisResponse {
+	return transformResponse(r, key.symmetry.Inverse(), key.swapped, key.width, key.height)
+}
+
+// transformResponse moves the vertices and the values for each point of a response by the symmetry,
+// and swaps the player to move if swapped is set. The values are from the point of view of the side to move,
+// so they stay the same when the colors are swapped.
+func transformResponse(r AnalysisResponse, s board.Symmetry, swapped bool, width, height int) AnalysisResponse {
+	if s == board.Identity && !swapped {
+		return r
+	}
+	vertex := func(v string) string {
+		p, err := board.ParseVertex(v, width, height)
+		if err != nil || p.IsPass() {
+			return v
+		}
+		return board.Vertex(s.Apply(p, width, height), height)
+	}
+	grid := func(values []float64) []float64 {
+		if values == nil {
+			return nil
+		}
+		out := append([]float64(nil), values...)
+		if len(values) < width*height {
+			return out
+		}
+		// The policy has an extra value for passing at the end, which stays where it is
+		for i := 0; i < width*height; i++ {
+			q := s.Apply(board.Point{X: i % width, Y: i / width}, width, height)
+			out[q.Y*width+q.X] = values[i]
+		}
+		return out
+	}
+	t := r
+	t.MoveInfos = make([]MoveInfoExt, len(r.MoveInfos))
+	for i, info := range r.MoveInfos {
+		info.Move = vertex(info.Move)
+		pv := make([]string, len(info.PV))
+		for j, v := range info.PV {
+			pv[j] = vertex(v)
+		}
+		info.PV = pv
+		info.Ownership = grid(info.Ownership)
+		t.MoveInfos[i] = info
+	}
+	if r.MoveInfos == nil {
+		t.MoveInfos = nil
+	}
+	t.Ownership = grid(r.Ownership)
+	t.OwnershipStdev = grid(r.OwnershipStdev)
+	t.Policy = grid(r.Policy)
+	t.HumanPolicy = grid(r.HumanPolicy)
+	if swapped {
+		if c, err := board.ParseColor(r.RootInfo.CurrentPlayer); err == nil {
+			t.RootInfo.CurrentPlayer = c.Opponent().String()
+		}
+	}
+	return t
+}
@@ -0,0 +1,133 @@
+package katago
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+func TestCanonicalHash(t *testing.T) {
+	a := NewPosition(19, 19)
+	a.Moves = []Move{{"B", "D4"}, {"W", "Q16"}}
+	b := a
+	b.Moves = []Move{{"B", "Q4"}, {"W", "D16"}}
+	ha, err := a.Request("a").CanonicalHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hb, err := b.Request("b").CanonicalHash()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ha != hb {
+		t.Errorf("Expected mirrored positions to have the same canonical hash, got %v and %v", ha, hb)
+	}
+	exactA, _ := a.Request("a").Hash()
+	exactB, _ := b.Request("b").Hash()
+	if exactA == exactB {
+		t.Error("Expected mirrored positions to have different exact hashes")
+	}
+	c := a
+	c.Moves = []Move{{"B", "D4"}, {"W", "Q17"}}
+	if hc, _ := c.Request("c").CanonicalHash(); hc == ha {
+		t.Error("Expected a different position to have a different canonical hash")
+	}
+}
+
+func TestCanonicalHashColorSwap(t *testing.T) {
+	a := NewPosition(9, 9)
+	a.Moves = []Move{{"B", "E5"}}
+	b := a
+	b.InitialStones = []Move{{"W", "E5"}}
+	b.Moves = nil
+	b.InitialPlayer = "B"
+	ra, rb := a.Request("a"), b.Request("b")
+	ha, _ := ra.CanonicalHash()
+	hb, _ := rb.CanonicalHash()
+	if ha == hb {
+		t.Error("Expected the colors not to be swapped when there is komi")
+	}
+	ra.Komi, rb.Komi = 0, 0
+	ha, _ = ra.CanonicalHash()
+	hb, _ = rb.CanonicalHash()
+	if ha != hb {
+		t.Errorf("Expected the same canonical hash with the colors swapped and no komi, got %v and %v", ha, hb)
+	}
+}
+
+func TestTransformResponse(t *testing.T) {
+	r := AnalysisResponse{
+		MoveInfos: []MoveInfoExt{{Move: "A1", PV: []string{"A1", "pass", "B1"}}},
+		Ownership: []float64{1, 2, 3, 4},
+		Policy:    []float64{1, 2, 3, 4, -1},
+		RootInfo:  RootInfo{CurrentPlayer: "B"},
+	}
+	// Flipping the columns of a 2x2 board
+	flipped := transformResponse(r, 1, true, 2, 2)
+	if got := flipped.MoveInfos[0].Move; got != "B1" {
+		t.Errorf("Expected B1, got %s", got)
+	}
+	if got := flipped.MoveInfos[0].PV; got[0] != "B1" || got[1] != "pass" || got[2] != "A1" {
+		t.Errorf("Expected B1 pass A1, got %v", got)
+	}
+	if got := flipped.Ownership; got[0] != 2 || got[1] != 1 || got[2] != 4 || got[3] != 3 {
+		t.Errorf("Expected 2 1 4 3, got %v", got)
+	}
+	if got := flipped.Policy[4]; got != -1 {
+		t.Errorf("Expected the pass policy to stay at the end, got %v", got)
+	}
+	if got := flipped.RootInfo.CurrentPlayer; got != "W" {
+		t.Errorf("Expected W, got %s", got)
+	}
+	if r.MoveInfos[0].Move != "A1" || r.Ownership[0] != 1 {
+		t.Error("Expected the original response to be unchanged")
+	}
+	for _, s := range board.Symmetries(2, 2) {
+		key := cacheKey{symmetry: s, width: 2, height: 2}
+		back := key.fromCanonical(key.toCanonical(r))
+		if back.MoveInfos[0].Move != "A1" || back.Ownership[3] != 4 {
+			t.Errorf("Expected %v to be undone, got %v", s, back)
+		}
+	}
+}
+
+func TestAnalyzeCacheMirrored(t *testing.T) {
+	cache := &countingCache{LRUCache: NewLRUCache(10)}
+	katago, err := NewKataGo("analysis_example.cfg", "model.bin.gz", WithCache(cache))
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, katago)
+
+	p := NewPosition(9, 9)
+	p.Moves = []Move{{"B", "C3"}}
+	first := p.Request("first")
+	first.MaxVisits = 10
+	first.IncludeOwnership = true
+	responses, err := katago.Analyze([]AnalysisRequest{first})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	mirrored := first
+	mirrored.ID = "mirrored"
+	mirrored.Moves = []Move{{"B", "G3"}}
+	again, err := katago.Analyze([]AnalysisRequest{mirrored})
+	if err != nil {
+		t.Fatalf("Failed to analyze: %v", err)
+	}
+	if cache.hits != 1 || again[0].ID != "mirrored" {
+		t.Fatalf("Expected the mirrored response to come from the cache, got %d hits", cache.hits)
+	}
+	flip := cacheKey{symmetry: 1, width: 9, height: 9}
+	expected := flip.toCanonical(responses[0])
+	for i, info := range again[0].MoveInfos {
+		if info.Move != expected.MoveInfos[i].Move {
+			t.Errorf("Expected move %s, got %s", expected.MoveInfos[i].Move, info.Move)
+		}
+	}
+	for i, v := range again[0].Ownership {
+		if v != expected.Ownership[i] {
+			t.Fatalf("Expected the ownership to be mirrored, got %v at %d", v, i)
+		}
+	}
+}
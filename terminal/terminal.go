@@ -0,0 +1,156 @@
+// Package terminal draws Go positions with analysis overlays as ANSI colored Unicode text
+package terminal
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+// ANSI escape codes
+const (
+	reset       = "\033[0m"
+	bold        = "\033[1m"
+	green       = "\033[32m"
+	cyan        = "\033[36m"
+	red         = "\033[31m"
+	blackOnWood = "\033[30;48;5;179m"
+	darkShade   = "\033[30;48;5;137m"
+	lightShade  = "\033[30;48;5;223m"
+)
+
+// Symbols used for drawing
+const (
+	BlackSymbol = "●"
+	WhiteSymbol = "○"
+	EmptySymbol = "·"
+	StarSymbol  = "+"
+)
+
+// candidateLetters label the candidate moves, from the best one
+const candidateLetters = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// Options for drawing a board in the terminal
+type Options struct {
+	// Color enables ANSI colors. Without it, only plain Unicode text is written.
+	Color bool
+	// Candidates are labeled A, B, C... on the board and listed below it
+	Candidates []katago.MoveInfoExt
+	// Ownership shades the background of each point by its predicted owner
+	Ownership *katago.OwnershipMap
+	// OwnershipThreshold is the certainty needed before a point is shaded. The default is 0.5.
+	OwnershipThreshold float64
+	// PV is a principal variation, like the PV of a candidate move, drawn as numbered moves
+	PV []string
+}
+
+// cell is the text and style of one point
+type cell struct {
+	text  string
+	style string
+}
+
+// Render writes the board, with analysis overlays and coordinates, to w
+func Render(w io.Writer, b *board.Board, opts Options) error {
+	cells := make([][]cell, b.Height())
+	stars := make(map[board.Point]bool)
+	for _, p := range board.StarPoints(b.Width(), b.Height()) {
+		stars[p] = true
+	}
+	for y := range cells {
+		cells[y] = make([]cell, b.Width())
+		for x := range cells[y] {
+			p := board.Point{X: x, Y: y}
+			switch b.At(p) {
+			case board.Black:
+				cells[y][x] = cell{text: BlackSymbol}
+			case board.White:
+				cells[y][x] = cell{text: WhiteSymbol}
+			default:
+				if stars[p] {
+					cells[y][x] = cell{text: StarSymbol}
+				} else {
+					cells[y][x] = cell{text: EmptySymbol}
+				}
+			}
+		}
+	}
+
+	for i, moveInfo := range opts.Candidates {
+		if i >= len(candidateLetters) {
+			break
+		}
+		p, err := b.ParseVertex(moveInfo.Move)
+		if err != nil {
+			return err
+		}
+		if !p.IsPass() && b.At(p) == board.Empty {
+			style := cyan
+			if i == 0 {
+				style = green
+			}
+			cells[p.Y][p.X] = cell{text: string(candidateLetters[i]), style: bold + style}
+		}
+	}
+
+	for i, vertex := range opts.PV {
+		p, err := b.ParseVertex(vertex)
+		if err != nil {
+			return err
+		}
+		if !p.IsPass() && b.At(p) == board.Empty {
+			cells[p.Y][p.X] = cell{text: strconv.Itoa(i + 1), style: bold + red}
+		}
+	}
+
+	threshold := opts.OwnershipThreshold
+	if threshold <= 0 {
+		threshold = 0.5
+	}
+
+	bw := bufio.NewWriter(w)
+	writeColumns(bw, b.Width())
+	for y := 0; y < b.Height(); y++ {
+		fmt.Fprintf(bw, "%2d", b.Height()-y)
+		for x := 0; x < b.Width(); x++ {
+			c := cells[y][x]
+			if !opts.Color {
+				fmt.Fprintf(bw, "%2s", c.text)
+				continue
+			}
+			background := blackOnWood
+			if opts.Ownership != nil {
+				switch opts.Ownership.Owner(x, y, threshold) {
+				case board.Black:
+					background = darkShade
+				case board.White:
+					background = lightShade
+				}
+			}
+			fmt.Fprintf(bw, "%s%s%2s%s", background, c.style, c.text, reset)
+		}
+		fmt.Fprintf(bw, " %d\n", b.Height()-y)
+	}
+	writeColumns(bw, b.Width())
+
+	for i, moveInfo := range opts.Candidates {
+		if i >= len(candidateLetters) {
+			break
+		}
+		fmt.Fprintf(bw, "%c: %-4s winrate %5.1f%%  score %+5.1f  visits %d\n", candidateLetters[i], moveInfo.Move, 100*moveInfo.Winrate, moveInfo.ScoreLead, moveInfo.Visits)
+	}
+	return bw.Flush()
+}
+
+// writeColumns writes the column letters
+func writeColumns(w io.Writer, width int) {
+	fmt.Fprint(w, "  ")
+	for x := 0; x < width; x++ {
+		fmt.Fprintf(w, "%2s", board.ColumnName(x))
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,56 @@
+package terminal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/xyproto/katago"
+	"github.com/xyproto/katago/board"
+)
+
+func TestRender(t *testing.T) {
+	b, err := board.New(9, 9)
+	if err != nil {
+		t.Fatalf("Failed to create board: %v", err)
+	}
+	b.Play(board.Black, board.Point{X: 2, Y: 2})
+	b.Play(board.White, board.Point{X: 6, Y: 6})
+	opts := Options{
+		Candidates: []katago.MoveInfoExt{{Move: "E5", Winrate: 0.55, Visits: 100}, {Move: "H8", Winrate: 0.5}},
+		PV:         []string{"E5", "D4"},
+	}
+	var buf bytes.Buffer
+	if err := Render(&buf, b, opts); err != nil {
+		t.Fatalf("Failed to render board: %v", err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 9+2+2 {
+		t.Fatalf("Expected 13 lines, got %d:\n%s", len(lines), buf.String())
+	}
+	if lines[0] != "   A B C D E F G H J" {
+		t.Errorf("Unexpected column header %q", lines[0])
+	}
+	if !strings.Contains(lines[3], BlackSymbol) || !strings.Contains(lines[7], WhiteSymbol) {
+		t.Errorf("Expected the stones on rows 7 and 3")
+	}
+	// E5 is both the best candidate and the first PV move, and the PV number is drawn on top
+	if !strings.Contains(lines[5], " 1") || !strings.Contains(lines[6], " 2") {
+		t.Errorf("Expected the PV moves to be numbered:\n%s", buf.String())
+	}
+	if !strings.Contains(lines[2], " B") {
+		t.Errorf("Expected the second candidate to be labeled B:\n%s", buf.String())
+	}
+	if !strings.HasPrefix(lines[11], "A: E5") || strings.Contains(buf.String(), "\033[") {
+		t.Errorf("Expected a plain candidate list, got %q", lines[11])
+	}
+
+	buf.Reset()
+	ownership, _ := katago.NewOwnershipMap(make([]float64, 81), 9, 9, board.Black)
+	if err := Render(&buf, b, Options{Color: true, Ownership: ownership}); err != nil {
+		t.Fatalf("Failed to render board: %v", err)
+	}
+	if !strings.Contains(buf.String(), reset) {
+		t.Errorf("Expected ANSI escape codes when colors are enabled")
+	}
+}
@@ -0,0 +1,39 @@
+package katago
+
+import "strconv"
+
+// WithAnalysisThreads sets how many positions KataGo searches at the same time, with -analysis-threads,
+// which replaces numAnalysisThreads in the config file. More analysis threads give more throughput for
+// many small queries, while more search threads for each position give lower latency for one big query.
+func WithAnalysisThreads(threads int) Option {
+	return func(k *KataGo) {
+		k.analysisThreads = threads
+	}
+}
+
+// WithSearchThreads sets how many threads KataGo uses for searching each position, with numSearchThreads
+func WithSearchThreads(threads int) Option {
+	return WithOverrides(map[string]string{"numSearchThreads": strconv.Itoa(threads)})
+}
+
+// ThreadSettings are the numbers of analysis threads and search threads for an engine
+type ThreadSettings struct {
+	AnalysisThreads int
+	SearchThreads   int
+}
+
+// Options returns the options that start KataGo with the thread settings
+func (s ThreadSettings) Options() []Option {
+	return []Option{WithAnalysisThreads(s.AnalysisThreads), WithSearchThreads(s.SearchThreads)}
+}
+
+// SuggestThreads returns the thread settings of the benchmark result with the most visits per second, where
+// each of the queries that ran at the same time had its own analysis thread. The results of Benchmark can
+// be used, and the settings are a good starting point, since the best values depend on the kind of queries.
+func SuggestThreads(results []BenchmarkResult) (ThreadSettings, bool) {
+	fastest, ok := FastestBenchmark(results)
+	if !ok {
+		return ThreadSettings{}, false
+	}
+	return ThreadSettings{AnalysisThreads: fastest.BatchSize, SearchThreads: fastest.Threads}, true
+}
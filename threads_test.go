@@ -0,0 +1,33 @@
+package katago
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithAnalysisThreads(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz", ThreadSettings{AnalysisThreads: 8, SearchThreads: 2}.Options()...)
+	if err != nil {
+		t.Fatalf("Failed to initialize KataGo: %v", err)
+	}
+	defer cleanupKataGo(t, k)
+	args := strings.Join(k.current().cmd.Args, " ")
+	if !strings.Contains(args, "-analysis-threads 8") || !strings.Contains(args, "numSearchThreads=2") {
+		t.Errorf("Expected the thread settings to be passed to KataGo, got %s", args)
+	}
+}
+
+func TestSuggestThreads(t *testing.T) {
+	results := []BenchmarkResult{
+		{Threads: 1, BatchSize: 1, VisitsPerSecond: 100},
+		{Threads: 4, BatchSize: 4, VisitsPerSecond: 900},
+		{Threads: 8, BatchSize: 1, VisitsPerSecond: 500},
+	}
+	settings, ok := SuggestThreads(results)
+	if !ok || settings != (ThreadSettings{AnalysisThreads: 4, SearchThreads: 4}) {
+		t.Errorf("Expected 4 analysis threads and 4 search threads, got %+v", settings)
+	}
+	if _, ok := SuggestThreads(nil); ok {
+		t.Error("Expected no suggestion without results")
+	}
+}
@@ -0,0 +1,32 @@
+package katago
+
+import (
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// ErrTimeout is the same as ErrQueryTimeout.
+//
+// Deprecated: use ErrQueryTimeout.
+var ErrTimeout = ErrQueryTimeout
+
+// terminateAfter terminates the query when the timeout of the request has passed. The returned function
+// stops the timer and reports if the query was terminated, and must be called when the response has arrived.
+func (k *KataGo) terminateAfter(request AnalysisRequest) func() bool {
+	if request.Timeout <= 0 {
+		return func() bool { return false }
+	}
+	var timedOut atomic.Bool
+	timer := time.AfterFunc(request.Timeout, func() {
+		timedOut.Store(true)
+		if err := k.Terminate(request.ID); err != nil && !errors.Is(err, ErrUnknownQuery) {
+			log.Printf("Failed to terminate %s after the timeout: %v", request.ID, err)
+		}
+	})
+	return func() bool {
+		timer.Stop()
+		return timedOut.Load()
+	}
+}
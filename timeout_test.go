@@ -0,0 +1,55 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	// The search for 100000 visits takes 5 seconds, so it is terminated early
+	request := NewRequest9x9()
+	request.MaxVisits = 100000
+	request.Timeout = 200 * time.Millisecond
+	start := time.Now()
+	responses, err := k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the search to be terminated, it took %v", elapsed)
+	}
+	if visits := responses[0].RootInfo.Visits; visits == 0 || visits >= 100000 {
+		t.Errorf("Expected the results found before the timeout, got %d visits", visits)
+	}
+
+	request = NewRequest9x9()
+	request.MaxVisits = 10
+	request.Timeout = time.Minute
+	if _, err := k.Analyze([]AnalysisRequest{request}); err != nil {
+		t.Errorf("Expected no error for a fast search, got %v", err)
+	}
+}
+
+func TestStreamTimeout(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	if stop := k.terminateAfter(NewRequest9x9()); stop() {
+		t.Errorf("Expected a request without a timeout to never time out")
+	}
+	request := NewRequest9x9()
+	request.MaxVisits = 100000
+	request.Timeout = 200 * time.Millisecond
+	response, err := k.AnalyzeStream(request, func(AnalysisResponse) {})
+	if errors.Is(err, ErrTimeout) {
+		t.Fatalf("Expected the results found before the timeout, got %v", err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.NoResults || response.RootInfo.Visits >= 100000 {
+		t.Errorf("Expected a terminated search, got %d visits", response.RootInfo.Visits)
+	}
+}
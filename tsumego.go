@@ -0,0 +1,179 @@
+package katago
+
+import (
+	"fmt"
+
+	"github.com/xyproto/katago/board"
+)
+
+// LifeStatus is the status of a group in a life and death problem
+type LifeStatus string
+
+// Life and death statuses
+const (
+	StatusAlive LifeStatus = "alive"
+	StatusDead  LifeStatus = "dead"
+	StatusKo    LifeStatus = "ko"
+	// StatusUnsettled is used when KataGo is not sure, and there is no ko in the expected continuation
+	StatusUnsettled LifeStatus = "unsettled"
+)
+
+// Tsumego settings
+var (
+	// TsumegoVisits is the number of visits of the first search, which is doubled until the verdict is stable
+	TsumegoVisits = 500
+	// TsumegoMaxVisits is the largest number of visits that is tried
+	TsumegoMaxVisits = 16000
+	// TsumegoLifeThreshold is how strongly a group must be owned by its own color to be alive,
+	// or by the opponent to be dead
+	TsumegoLifeThreshold = 0.6
+	// TsumegoKeyMoveWinrate is how close to the winrate of the best move another move must be to be a key move
+	TsumegoKeyMoveWinrate = 0.05
+)
+
+// Region is a rectangle on the board, from the top left corner Min to the bottom right corner Max
+type Region struct {
+	Min, Max board.Point
+}
+
+// Contains checks if the point is inside the region
+func (r Region) Contains(p board.Point) bool {
+	return p.X >= r.Min.X && p.X <= r.Max.X && p.Y >= r.Min.Y && p.Y <= r.Max.Y
+}
+
+// RegionAround returns the smallest region that holds the group at p, extended by margin points in each
+// direction, without going outside of the board
+func RegionAround(b *board.Board, p board.Point, margin int) Region {
+	points, _ := b.Group(p)
+	r := Region{Min: p, Max: p}
+	for _, q := range points {
+		r.Min.X, r.Min.Y = min(r.Min.X, q.X), min(r.Min.Y, q.Y)
+		r.Max.X, r.Max.Y = max(r.Max.X, q.X), max(r.Max.Y, q.Y)
+	}
+	r.Min.X, r.Min.Y = max(0, r.Min.X-margin), max(0, r.Min.Y-margin)
+	r.Max.X, r.Max.Y = min(b.Width()-1, r.Max.X+margin), min(b.Height()-1, r.Max.Y+margin)
+	return r
+}
+
+// TsumegoResult is the verdict for a life and death problem
+type TsumegoResult struct {
+	Status LifeStatus
+	// KeyMoves are the moves for the player to move that are about as good as the best one, best first
+	KeyMoves []string
+	// PV is the expected continuation, starting with the best move
+	PV []string
+	// Ownership is the average ownership of the target group, from the point of view of its own color (-1 to 1)
+	Ownership float64
+	// Visits is the number of visits of the search that gave the verdict
+	Visits int
+}
+
+// outside returns the vertices of the points that are not in the region
+func (r Region) outside(b *board.Board) []string {
+	var vertices []string
+	for y := 0; y < b.Height(); y++ {
+		for x := 0; x < b.Width(); x++ {
+			if p := (board.Point{X: x, Y: y}); !r.Contains(p) {
+				vertices = append(vertices, b.Vertex(p))
+			}
+		}
+	}
+	return vertices
+}
+
+// hasKo checks if a ko appears when the moves of the PV are played, starting with the player to move
+func hasKo(b *board.Board, pv []string) bool {
+	b = b.Clone()
+	for _, move := range pv {
+		p, err := b.ParseVertex(move)
+		if err != nil {
+			return false
+		}
+		if err := b.Play(b.ToPlay(), p); err != nil {
+			return false
+		}
+		if b.Ko() != board.Pass {
+			return true
+		}
+	}
+	return false
+}
+
+// SolveTsumego decides if the group at the target vertex lives or dies, with the player to move playing first.
+// The search is kept inside the region, so that KataGo does not play elsewhere, except for passing.
+// The number of visits is doubled from TsumegoVisits until the status and the best move are the same twice,
+// or TsumegoMaxVisits is reached.
+func (k *KataGo) SolveTsumego(p Position, target string, region Region) (*TsumegoResult, error) {
+	b, err := p.Board()
+	if err != nil {
+		return nil, err
+	}
+	t, err := b.ParseVertex(target)
+	if err != nil {
+		return nil, err
+	}
+	color := b.At(t)
+	if color == board.Empty {
+		return nil, fmt.Errorf("there is no stone at %s", target)
+	}
+	if !region.Contains(t) {
+		return nil, fmt.Errorf("%s is outside of the region", target)
+	}
+	group, _ := b.Group(t)
+	outside := region.outside(b)
+
+	var result *TsumegoResult
+	for visits := TsumegoVisits; ; visits *= 2 {
+		request := p.Request(k.newID("tsumego"))
+		request.MaxVisits = visits
+		request.IncludeOwnership = true
+		if len(outside) > 0 {
+			depth := p.BoardXSize * p.BoardYSize
+			request.AvoidMoves = []MoveRestriction{
+				{Player: "B", Moves: outside, UntilDepth: depth},
+				{Player: "W", Moves: outside, UntilDepth: depth},
+			}
+		}
+		responses, err := k.Analyze([]AnalysisRequest{request})
+		if err != nil {
+			return nil, err
+		}
+		response := responses[0]
+		if response.RootInfo.CurrentPlayer == "" {
+			response.RootInfo.CurrentPlayer = p.ToPlay()
+		}
+		if len(response.MoveInfos) == 0 {
+			return nil, fmt.Errorf("no moves were analyzed for request %s", request.ID)
+		}
+		ownership, err := response.OwnershipMap(p.BoardXSize, p.BoardYSize)
+		if err != nil {
+			return nil, err
+		}
+		next := &TsumegoResult{Visits: response.RootInfo.Visits, PV: response.MoveInfos[0].PV}
+		for _, q := range group {
+			next.Ownership += ownership.For(color, q.X, q.Y)
+		}
+		next.Ownership /= float64(len(group))
+		best := response.MoveInfos[0]
+		for _, info := range response.MoveInfos {
+			if best.Winrate-info.Winrate <= TsumegoKeyMoveWinrate {
+				next.KeyMoves = append(next.KeyMoves, info.Move)
+			}
+		}
+		switch {
+		case next.Ownership >= TsumegoLifeThreshold:
+			next.Status = StatusAlive
+		case next.Ownership <= -TsumegoLifeThreshold:
+			next.Status = StatusDead
+		case hasKo(b, next.PV):
+			next.Status = StatusKo
+		default:
+			next.Status = StatusUnsettled
+		}
+		stable := result != nil && result.Status == next.Status && result.KeyMoves[0] == next.KeyMoves[0]
+		result = next
+		if stable || visits*2 > TsumegoMaxVisits {
+			return result, nil
+		}
+	}
+}
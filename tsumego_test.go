@@ -0,0 +1,81 @@
+package katago
+
+import (
+	"testing"
+
+	"github.com/xyproto/katago/board"
+)
+
+// koPosition returns a 9x9 position where black can take a ko at E6 by capturing the white stone at D6
+func koPosition() Position {
+	p := NewPosition(9, 9)
+	stone := func(color string, x, y int) Move {
+		return Move{Color(color), Vertex(board.Vertex(board.Point{X: x, Y: y}, 9))}
+	}
+	p.InitialStones = []Move{
+		stone("B", 3, 2), stone("B", 2, 3), stone("B", 3, 4),
+		stone("W", 4, 2), stone("W", 3, 3), stone("W", 5, 3), stone("W", 4, 4),
+	}
+	return p
+}
+
+func TestRegion(t *testing.T) {
+	b, err := koPosition().Board()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := RegionAround(b, board.Point{X: 3, Y: 3}, 1)
+	if r.Min != (board.Point{X: 2, Y: 2}) || r.Max != (board.Point{X: 4, Y: 4}) {
+		t.Errorf("Expected the region from (2, 2) to (4, 4), got %v", r)
+	}
+	if r := RegionAround(b, board.Point{X: 3, Y: 3}, 10); r.Min != (board.Point{}) || r.Max != (board.Point{X: 8, Y: 8}) {
+		t.Errorf("Expected the region to be clipped to the board, got %v", r)
+	}
+	if n := len(r.outside(b)); n != 81-9 {
+		t.Errorf("Expected %d points outside of the region, got %d", 81-9, n)
+	}
+}
+
+func TestHasKo(t *testing.T) {
+	b, err := koPosition().Board()
+	if err != nil {
+		t.Fatal(err)
+	}
+	take := board.Vertex(board.Point{X: 4, Y: 3}, 9)
+	if !hasKo(b, []string{take}) {
+		t.Errorf("Expected a ko after %s", take)
+	}
+	if hasKo(b, []string{"A1", "J9"}) {
+		t.Errorf("Expected no ko")
+	}
+}
+
+func TestSolveTsumego(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	defer func(visits, maxVisits int) { TsumegoVisits, TsumegoMaxVisits = visits, maxVisits }(TsumegoVisits, TsumegoMaxVisits)
+	TsumegoVisits, TsumegoMaxVisits = 50, 400
+
+	p := koPosition()
+	b, err := p.Board()
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := board.Point{X: 3, Y: 3}
+	region := RegionAround(b, target, 2)
+	result, err := k.SolveTsumego(p, b.Vertex(target), region)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Status == "" || len(result.KeyMoves) == 0 || result.Visits == 0 {
+		t.Errorf("Expected a verdict and key moves, got %+v", result)
+	}
+	for _, move := range result.KeyMoves {
+		if q, err := b.ParseVertex(move); err != nil || (!q.IsPass() && !region.Contains(q)) {
+			t.Errorf("Expected the key move %s to be inside the region", move)
+		}
+	}
+	if _, err := k.SolveTsumego(p, "A1", region); err == nil {
+		t.Errorf("Expected an error for an empty target")
+	}
+}
@@ -0,0 +1,81 @@
+package katago
+
+import (
+	"fmt"
+	"math"
+)
+
+// UncertaintyWindow is how many of the latest reports an UncertaintyTracker uses for the variance between them
+var UncertaintyWindow = 5
+
+// Uncertainty is the standard error of an evaluation, for showing error bars instead of false precision
+type Uncertainty struct {
+	// Winrate is the standard error of the winrate, from 0 to 1
+	Winrate float64
+	// Score is the standard error of the score lead, in points
+	Score float64
+}
+
+// String formats the uncertainty, like "±1.5% ±0.4 points"
+func (u Uncertainty) String() string {
+	return fmt.Sprintf("±%.1f%% ±%.1f points", 100*u.Winrate, u.Score)
+}
+
+// Uncertainty estimates the standard error of the winrate and of the score lead of the response, from the
+// number of visits and the standard deviation of the score, as if each visit was an independent sample.
+// Visits are not independent, so this is a lower bound, and an UncertaintyTracker also counts how much the
+// evaluation moves during the search.
+func (r AnalysisResponse) Uncertainty() Uncertainty {
+	visits := float64(max(r.RootInfo.Visits, 1))
+	w := min(max(r.RootInfo.Winrate, 0), 1)
+	return Uncertainty{
+		Winrate: math.Sqrt(w * (1 - w) / visits),
+		Score:   r.RootInfo.ScoreStdev / math.Sqrt(visits),
+	}
+}
+
+// UncertaintyTracker estimates the uncertainty of an evaluation from the reports during the search, like the
+// ones passed to the interim function of AnalyzeStream, and the final response. The zero value is ready to use.
+type UncertaintyTracker struct {
+	winrates []float64
+	scores   []float64
+	latest   AnalysisResponse
+}
+
+// Add adds a report or the final response to the tracker
+func (t *UncertaintyTracker) Add(response AnalysisResponse) {
+	t.winrates = append(t.winrates, response.RootInfo.Winrate)
+	t.scores = append(t.scores, response.RootInfo.ScoreLead)
+	if n := max(UncertaintyWindow, 2); len(t.winrates) > n {
+		t.winrates = t.winrates[len(t.winrates)-n:]
+		t.scores = t.scores[len(t.scores)-n:]
+	}
+	t.latest = response
+}
+
+// Uncertainty returns the uncertainty of the latest response, where the standard deviation of the latest
+// UncertaintyWindow reports is added to the estimate from the visits and the standard deviation of the score
+func (t *UncertaintyTracker) Uncertainty() Uncertainty {
+	u := t.latest.Uncertainty()
+	return Uncertainty{
+		Winrate: math.Hypot(u.Winrate, stdev(t.winrates)),
+		Score:   math.Hypot(u.Score, stdev(t.scores)),
+	}
+}
+
+// stdev returns the sample standard deviation of the values, or 0 if there are fewer than two
+func stdev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
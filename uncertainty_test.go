@@ -0,0 +1,42 @@
+package katago
+
+import (
+	"math"
+	"testing"
+)
+
+func TestResponseUncertainty(t *testing.T) {
+	r := AnalysisResponse{RootInfo: RootInfo{Winrate: 0.5, ScoreLead: 2, ScoreStdev: 10, Visits: 100}}
+	u := r.Uncertainty()
+	if math.Abs(u.Winrate-0.05) > 1e-9 || math.Abs(u.Score-1) > 1e-9 {
+		t.Errorf("Expected ±0.05 and ±1 point, got %+v", u)
+	}
+	if s := u.String(); s != "±5.0% ±1.0 points" {
+		t.Errorf("Expected ±5.0%% ±1.0 points, got %s", s)
+	}
+	if u := (AnalysisResponse{}).Uncertainty(); u.Winrate != 0 || u.Score != 0 {
+		t.Errorf("Expected no uncertainty for an empty response, got %+v", u)
+	}
+}
+
+func TestUncertaintyTracker(t *testing.T) {
+	var tracker UncertaintyTracker
+	final := AnalysisResponse{RootInfo: RootInfo{Winrate: 0.5, ScoreLead: 2, ScoreStdev: 10, Visits: 100}}
+	tracker.Add(final)
+	if u := tracker.Uncertainty(); u != final.Uncertainty() {
+		t.Errorf("Expected the uncertainty of the response with one report, got %+v", u)
+	}
+
+	// The reports that are older than the window are left out
+	tracker = UncertaintyTracker{}
+	tracker.Add(AnalysisResponse{RootInfo: RootInfo{Winrate: 0.1, ScoreLead: -20}})
+	for _, winrate := range []float64{0.4, 0.6, 0.4, 0.6} {
+		tracker.Add(AnalysisResponse{RootInfo: RootInfo{Winrate: winrate, ScoreLead: 2}})
+	}
+	tracker.Add(final)
+	u := tracker.Uncertainty()
+	expected := math.Hypot(0.05, math.Sqrt(0.04/4))
+	if math.Abs(u.Winrate-expected) > 1e-9 || math.Abs(u.Score-1) > 1e-9 {
+		t.Errorf("Expected ±%f and ±1 point, got %+v", expected, u)
+	}
+}
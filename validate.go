@@ -0,0 +1,106 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxBoardSize is the largest board width or height that Validate accepts. The standard builds of KataGo
+// support boards up to 19x19, and this can be raised for builds with a larger COMPILE_MAX_BOARD_LEN.
+var MaxBoardSize = 19
+
+// maxKomi is the largest komi, for either player, that KataGo accepts
+const maxKomi = 150
+
+// Validate checks the request before it is sent, so that mistakes give a descriptive error instead of
+// an error from KataGo. It checks the board size, the komi, the rules, that all the moves are legal,
+// the analyzed turns, and the fields that can not be used together. Analyze, AnalyzeStream and
+// AnalyzeGame validate each request after applying the request defaults.
+func (r AnalysisRequest) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	if r.ID == "" {
+		fail("the request has no ID")
+	}
+	sizeOK := true
+	for _, size := range []struct {
+		name  string
+		value int
+	}{{"width", r.BoardXSize}, {"height", r.BoardYSize}} {
+		if size.value < 2 || size.value > MaxBoardSize {
+			fail("the board %s is %d, but must be from 2 to %d", size.name, size.value, MaxBoardSize)
+			sizeOK = false
+		}
+	}
+	switch {
+	case math.IsNaN(r.Komi) || math.IsInf(r.Komi, 0):
+		fail("the komi is %v", r.Komi)
+	case math.Abs(r.Komi) > maxKomi:
+		fail("the komi is %g, but must be from -%d to %d", r.Komi, maxKomi, maxKomi)
+	case r.Komi*2 != math.Trunc(r.Komi*2):
+		fail("the komi is %g, but must be an integer or half an integer", r.Komi)
+	}
+	if err := r.Rules.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	switch r.WhiteHandicapBonus {
+	case "", "0", "N-1", "N":
+	default:
+		fail("the white handicap bonus is %q, but must be \"0\", \"N-1\" or \"N\"", r.WhiteHandicapBonus)
+	}
+	if r.InitialPlayer != "" {
+		if _, err := ParseColor(r.InitialPlayer); err != nil {
+			fail("invalid initial player: %v", err)
+		}
+	}
+	if r.Moves == nil {
+		fail("the moves are nil, but KataGo needs a list, which can be empty")
+	}
+	if sizeOK && r.Rules.Validate() == nil {
+		// Replaying the position checks the colors, the vertices and that all the moves are legal
+		if _, err := r.Position(len(r.Moves)).Board(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	seen := make(map[int]bool)
+	for _, turn := range r.AnalyzeTurns {
+		if turn < 0 || turn > len(r.Moves) {
+			fail("turn %d is not between 0 and the %d moves", turn, len(r.Moves))
+		}
+		if seen[turn] {
+			fail("turn %d is analyzed more than once", turn)
+		}
+		seen[turn] = true
+	}
+	switch {
+	case r.MaxVisits < 0:
+		fail("the max visits are %d", r.MaxVisits)
+	case r.ReportDuringSearchEvery < 0:
+		fail("the report interval is %g seconds", r.ReportDuringSearchEvery)
+	case r.Timeout < 0:
+		fail("the timeout is %v", r.Timeout)
+	}
+	if len(r.AllowMoves) > 1 {
+		fail("KataGo supports only one entry in AllowMoves, got %d", len(r.AllowMoves))
+	}
+	for _, restriction := range append(append([]MoveRestriction(nil), r.AvoidMoves...), r.AllowMoves...) {
+		if _, err := ParseColor(restriction.Player); err != nil {
+			fail("invalid player in move restriction: %v", err)
+		}
+		if restriction.UntilDepth < 1 {
+			fail("the move restriction for %s has an untilDepth of %d, but must be at least 1", restriction.Player, restriction.UntilDepth)
+		}
+		for _, v := range restriction.Moves {
+			if _, err := ParseVertex(v); err != nil {
+				fail("invalid move in move restriction: %v", err)
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid request %s: %w", r.ID, err)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package katago
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	if err := NewRequest9x9().Validate(); err != nil {
+		t.Fatalf("Expected a valid request, got %v", err)
+	}
+	cases := []struct {
+		name     string
+		change   func(r *AnalysisRequest)
+		expected string
+	}{
+		{"no ID", func(r *AnalysisRequest) { r.ID = "" }, "no ID"},
+		{"small board", func(r *AnalysisRequest) { r.BoardXSize = 1 }, "width is 1"},
+		{"large board", func(r *AnalysisRequest) { r.BoardYSize = 29 }, "height is 29"},
+		{"large komi", func(r *AnalysisRequest) { r.Komi = 200 }, "komi is 200"},
+		{"fractional komi", func(r *AnalysisRequest) { r.Komi = 6.25 }, "half an integer"},
+		{"infinite komi", func(r *AnalysisRequest) { r.Komi = math.Inf(1) }, "komi is +Inf"},
+		{"rules", func(r *AnalysisRequest) { r.Rules = "unknown" }, "unknown rules"},
+		{"handicap bonus", func(r *AnalysisRequest) { r.WhiteHandicapBonus = "2" }, "handicap bonus"},
+		{"initial player", func(r *AnalysisRequest) { r.InitialPlayer = "X" }, "initial player"},
+		{"nil moves", func(r *AnalysisRequest) { r.Moves = nil }, "moves are nil"},
+		{"outside", func(r *AnalysisRequest) { r.Moves = []Move{{Black, "T19"}} }, "outside of a 9x9 board"},
+		{"occupied", func(r *AnalysisRequest) { r.Moves = []Move{{Black, "E5"}, {White, "E5"}} }, "move 2"},
+		{"turn", func(r *AnalysisRequest) { r.AnalyzeTurns = []int{0, 2} }, "turn 2"},
+		{"repeated turn", func(r *AnalysisRequest) { r.AnalyzeTurns = []int{0, 0} }, "more than once"},
+		{"visits", func(r *AnalysisRequest) { r.MaxVisits = -1 }, "max visits"},
+		{"allow moves", func(r *AnalysisRequest) {
+			r.AllowMoves = []MoveRestriction{{"B", []string{"E5"}, 1}, {"W", []string{"C3"}, 1}}
+		}, "only one entry"},
+		{"restriction depth", func(r *AnalysisRequest) {
+			r.AvoidMoves = []MoveRestriction{{"B", []string{"E5"}, 0}}
+		}, "untilDepth"},
+		{"restriction move", func(r *AnalysisRequest) {
+			r.AvoidMoves = []MoveRestriction{{"B", []string{"E55"}, 1}}
+		}, "move restriction"},
+	}
+	for _, c := range cases {
+		r := NewRequest9x9()
+		c.change(&r)
+		err := r.Validate()
+		if err == nil || !strings.Contains(err.Error(), c.expected) {
+			t.Errorf("%s: expected an error with %q, got %v", c.name, c.expected, err)
+		}
+	}
+}
+
+func TestValidateAllErrors(t *testing.T) {
+	r := NewRequest9x9()
+	r.Komi = 1000
+	r.MaxVisits = -5
+	err := r.Validate()
+	if err == nil || !strings.Contains(err.Error(), "komi") || !strings.Contains(err.Error(), "max visits") {
+		t.Errorf("Expected both problems to be reported, got %v", err)
+	}
+}
+
+func TestAnalyzeValidates(t *testing.T) {
+	katago := initKataGo(t)
+	defer cleanupKataGo(t, katago)
+
+	request := NewRequest9x9()
+	request.AnalyzeTurns = []int{5}
+	if _, err := katago.Analyze([]AnalysisRequest{request}); !errors.Is(err, ErrBadRequest) {
+		t.Errorf("Expected ErrBadRequest, got %v", err)
+	}
+	if katago.QueueLength() != 0 {
+		t.Errorf("Expected the request not to be sent, got %d pending", katago.QueueLength())
+	}
+}
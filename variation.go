@@ -0,0 +1,131 @@
+package katago
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ErrNotAnalyzed is returned when following the PV of a position that has not been analyzed
+var ErrNotAnalyzed = errors.New("the position has not been analyzed")
+
+// VariationTree is a tree of variations that branch from a position, where each position is analyzed
+// when it is needed, which is useful for exploring the alternatives that KataGo suggests.
+// A tree is not safe for concurrent use.
+type VariationTree struct {
+	k      *KataGo
+	visits int
+	Root   *VariationNode
+}
+
+// VariationNode is a position in a variation tree
+type VariationNode struct {
+	tree     *VariationTree
+	Position Position
+	// Move is the move that was played to get to this position, which is empty for the root
+	Move     Move
+	Parent   *VariationNode
+	Children []*VariationNode
+	// Analysis is the analysis of the position, once it has been analyzed
+	Analysis *AnalysisResponse
+}
+
+// NewVariationTree creates a tree of variations from the given position, where each analysis uses
+// the given number of visits, or the default of the engine if it is 0
+func (k *KataGo) NewVariationTree(p Position, visits int) (*VariationTree, error) {
+	if _, err := p.Board(); err != nil {
+		return nil, err
+	}
+	t := &VariationTree{k: k, visits: visits}
+	p.Moves = slices.Clone(p.Moves)
+	t.Root = &VariationNode{tree: t, Position: p}
+	return t, nil
+}
+
+// Analyze analyzes the position, unless it has been analyzed already
+func (n *VariationNode) Analyze() (*AnalysisResponse, error) {
+	if n.Analysis != nil {
+		return n.Analysis, nil
+	}
+	request := n.Position.Request(n.tree.k.newID("variation"))
+	request.MaxVisits = n.tree.visits
+	responses, err := n.tree.k.Analyze([]AnalysisRequest{request})
+	if err != nil {
+		return nil, err
+	}
+	n.Analysis = &responses[0]
+	return n.Analysis, nil
+}
+
+// Play returns the position after the player to move plays the given move, like "D4" or "pass".
+// The child is created if it does not exist yet, and the move must be legal.
+func (n *VariationNode) Play(vertex string) (*VariationNode, error) {
+	move, err := NewMove(n.Position.ToPlay(), vertex)
+	if err != nil {
+		return nil, err
+	}
+	for _, child := range n.Children {
+		if child.Move == move {
+			return child, nil
+		}
+	}
+	p := n.Position
+	p.Moves = append(slices.Clone(n.Position.Moves), move)
+	if _, err := p.Board(); err != nil {
+		return nil, err
+	}
+	child := &VariationNode{tree: n.tree, Position: p, Move: p.Moves[len(p.Moves)-1], Parent: n}
+	n.Children = append(n.Children, child)
+	return child, nil
+}
+
+// PlayPV follows the principal variation of one of the candidate moves of the analysis, and returns
+// the position at the end of it. The position must have been analyzed.
+func (n *VariationNode) PlayPV(candidate string) (*VariationNode, error) {
+	if n.Analysis == nil {
+		return nil, ErrNotAnalyzed
+	}
+	for _, info := range n.Analysis.MoveInfos {
+		if !strings.EqualFold(info.Move, candidate) {
+			continue
+		}
+		node := n
+		for _, move := range info.PV {
+			next, err := node.Play(move)
+			if err != nil {
+				return nil, fmt.Errorf("PV of %s: %v", candidate, err)
+			}
+			node = next
+		}
+		return node, nil
+	}
+	return nil, fmt.Errorf("%s is not one of the analyzed moves", candidate)
+}
+
+// Expand analyzes the position and creates children for up to count of the best candidate moves, in order
+func (n *VariationNode) Expand(count int) ([]*VariationNode, error) {
+	analysis, err := n.Analyze()
+	if err != nil {
+		return nil, err
+	}
+	var children []*VariationNode
+	for _, info := range analysis.MoveInfos[:min(count, len(analysis.MoveInfos))] {
+		child, err := n.Play(info.Move)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+	}
+	return children, nil
+}
+
+// Line returns the moves from the root of the tree to this position
+func (n *VariationNode) Line() []Move {
+	var line []Move
+	for node := n; node.Parent != nil; node = node.Parent {
+		line = append(line, node.Move)
+	}
+	slices.Reverse(line)
+	return line
+}
@@ -0,0 +1,53 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVariationTree(t *testing.T) {
+	k := initKataGo(t)
+	defer cleanupKataGo(t, k)
+	tree, err := k.NewVariationTree(NewPosition(9, 9), 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.Root.PlayPV("E5"); !errors.Is(err, ErrNotAnalyzed) {
+		t.Errorf("Expected ErrNotAnalyzed, got %v", err)
+	}
+	children, err := tree.Root.Expand(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 3 || len(tree.Root.Children) != 3 {
+		t.Fatalf("Expected 3 children, got %d", len(children))
+	}
+	best := tree.Root.Analysis.MoveInfos[0]
+	if children[0].Move != (Move{"B", Vertex(best.Move)}) {
+		t.Errorf("Expected the first child to be the best move %s, got %v", best.Move, children[0].Move)
+	}
+	end, err := tree.Root.PlayPV(best.Move)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := end.Line(); len(line) != len(best.PV) || string(line[1].Color) != "W" {
+		t.Errorf("Expected the line to follow the PV %v, got %v", best.PV, line)
+	}
+	// Following the same PV again reuses the nodes
+	if again, err := tree.Root.PlayPV(best.Move); err != nil || again != end {
+		t.Errorf("Expected the same node, got %v", err)
+	}
+	if len(tree.Root.Children) != 3 {
+		t.Errorf("Expected no new children, got %d", len(tree.Root.Children))
+	}
+	if _, err := children[0].Play(best.Move); err == nil {
+		t.Errorf("Expected an error for playing on an occupied point")
+	}
+	analysis, err := end.Analyze()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if analysis.TurnNumber != len(best.PV) {
+		t.Errorf("Expected turn %d, got %d", len(best.PV), analysis.TurnNumber)
+	}
+}
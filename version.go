@@ -0,0 +1,165 @@
+package katago
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// ErrNotSupported is returned when the running version of KataGo does not support a feature
+var ErrNotSupported = errors.New("not supported by this version of KataGo")
+
+// Version is a version of KataGo
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	// GitHash is the commit that KataGo was built from, if it was reported
+	GitHash string
+}
+
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.(\d+))?`)
+
+// ParseVersion parses a version like "1.15.3" or "v1.15.3". Anything after the version number is ignored.
+func ParseVersion(s string) (Version, error) {
+	m := versionPattern.FindStringSubmatch(s)
+	if m == nil {
+		return Version{}, fmt.Errorf("invalid KataGo version: %q", s)
+	}
+	var v Version
+	v.Major, _ = strconv.Atoi(m[1])
+	v.Minor, _ = strconv.Atoi(m[2])
+	if m[3] != "" {
+		v.Patch, _ = strconv.Atoi(m[3])
+	}
+	return v, nil
+}
+
+// String returns the version like "1.15.3"
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// AtLeast reports whether the version is the same as or later than the other version
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// Capability is a feature of the analysis engine that only some versions of KataGo have
+type Capability string
+
+// Capabilities
+const (
+	CapabilityReportDuringSearch Capability = "reportDuringSearchEvery"
+	CapabilityMovesOwnership     Capability = "includeMovesOwnership"
+	CapabilityOwnershipStdev     Capability = "includeOwnershipStdev"
+	CapabilityTerminateAll       Capability = "terminate_all"
+	CapabilityClearCache         Capability = "clear_cache"
+	CapabilityQueryModels        Capability = "query_models"
+	CapabilityHumanModel         Capability = "humanModel"
+)
+
+// MinimumVersions are the first versions of KataGo that have each capability
+var MinimumVersions = map[Capability]Version{
+	CapabilityReportDuringSearch: {Major: 1, Minor: 8},
+	CapabilityMovesOwnership:     {Major: 1, Minor: 9},
+	CapabilityOwnershipStdev:     {Major: 1, Minor: 10},
+	CapabilityTerminateAll:       {Major: 1, Minor: 12},
+	CapabilityClearCache:         {Major: 1, Minor: 12},
+	CapabilityQueryModels:        {Major: 1, Minor: 15},
+	CapabilityHumanModel:         {Major: 1, Minor: 15},
+}
+
+// stderrVersionPattern matches the line where KataGo reports its version when it starts
+var stderrVersionPattern = regexp.MustCompile(`KataGo v(\d+\.\d+\.\d+)`)
+
+// parseStderrVersion recognizes the line where KataGo reports its version
+func parseStderrVersion(line string) (Version, bool) {
+	m := stderrVersionPattern.FindStringSubmatch(line)
+	if m == nil {
+		return Version{}, false
+	}
+	v, err := ParseVersion(m[1])
+	return v, err == nil
+}
+
+// Version returns the version of KataGo. The version that KataGo reports when it starts is used
+// if there is one, and otherwise KataGo is asked with the query_version action. Versions of KataGo that
+// are too old to answer return an error.
+func (k *KataGo) Version() (Version, error) {
+	<-k.current().started
+	k.mut.Lock()
+	if k.version != nil {
+		defer k.mut.Unlock()
+		return *k.version, nil
+	}
+	k.mut.Unlock()
+	line, err := k.action(map[string]any{"action": "query_version"})
+	if err != nil {
+		return Version{}, err
+	}
+	if err := responseError(line); err != nil {
+		return Version{}, fmt.Errorf("failed to get the version of KataGo: %w", err)
+	}
+	var reply struct {
+		Version string `json:"version"`
+		GitHash string `json:"git_hash"`
+	}
+	if err := json.Unmarshal(line, &reply); err != nil {
+		return Version{}, fmt.Errorf("failed to unmarshal the version: %v", err)
+	}
+	v, err := ParseVersion(reply.Version)
+	if err != nil {
+		return Version{}, err
+	}
+	v.GitHash = reply.GitHash
+	k.mut.Lock()
+	k.version = &v
+	k.mut.Unlock()
+	return v, nil
+}
+
+// Supports reports whether the running version of KataGo has a capability.
+// It returns false if the version can not be found.
+func (k *KataGo) Supports(c Capability) bool {
+	minimum, ok := MinimumVersions[c]
+	if !ok {
+		return false
+	}
+	v, err := k.Version()
+	return err == nil && v.AtLeast(minimum)
+}
+
+// Capabilities returns the capabilities of the running version of KataGo
+func (k *KataGo) Capabilities() []Capability {
+	var capabilities []Capability
+	for _, c := range []Capability{
+		CapabilityReportDuringSearch, CapabilityMovesOwnership, CapabilityOwnershipStdev,
+		CapabilityTerminateAll, CapabilityClearCache, CapabilityQueryModels, CapabilityHumanModel,
+	} {
+		if k.Supports(c) {
+			capabilities = append(capabilities, c)
+		}
+	}
+	return capabilities
+}
+
+// require returns ErrNotSupported if the running version of KataGo does not have a capability
+func (k *KataGo) require(c Capability) error {
+	v, err := k.Version()
+	if err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrNotSupported, c, err)
+	}
+	if minimum, ok := MinimumVersions[c]; ok && !v.AtLeast(minimum) {
+		return fmt.Errorf("%w: %s needs KataGo %s or later, but this is KataGo %s", ErrNotSupported, c, minimum, v)
+	}
+	return nil
+}
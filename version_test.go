@@ -0,0 +1,78 @@
+package katago
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	for s, expected := range map[string]Version{
+		"1.15.3":      {Major: 1, Minor: 15, Patch: 3},
+		"v1.9.1":      {Major: 1, Minor: 9, Patch: 1},
+		"1.12":        {Major: 1, Minor: 12},
+		"1.14.0-cuda": {Major: 1, Minor: 14},
+	} {
+		v, err := ParseVersion(s)
+		if err != nil || v != expected {
+			t.Errorf("Expected %v for %q, got %v (%v)", expected, s, v, err)
+		}
+	}
+	if _, err := ParseVersion("latest"); err == nil {
+		t.Errorf("Expected an error for an invalid version")
+	}
+	if v, ok := parseStderrVersion("2025-01-01 00:00:00+0000: KataGo v1.13.2"); !ok || v.String() != "1.13.2" {
+		t.Errorf("Expected 1.13.2, got %v", v)
+	}
+}
+
+func TestAtLeast(t *testing.T) {
+	v := Version{Major: 1, Minor: 12, Patch: 4}
+	for other, expected := range map[Version]bool{
+		{Major: 1, Minor: 12, Patch: 4}: true,
+		{Major: 1, Minor: 9, Patch: 9}:  true,
+		{Major: 1, Minor: 13}:           false,
+		{Major: 2}:                      false,
+		{Major: 0, Minor: 99}:           true,
+	} {
+		if v.AtLeast(other) != expected {
+			t.Errorf("Expected %v.AtLeast(%v) to be %v", v, other, expected)
+		}
+	}
+}
+
+func TestVersion(t *testing.T) {
+	k, err := NewKataGo("analysis_example.cfg", "model.bin.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer k.Close()
+	v, err := k.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.AtLeast(Version{Major: 1, Minor: 15}) {
+		t.Errorf("Expected at least KataGo 1.15, got %v", v)
+	}
+	if !k.Supports(CapabilityHumanModel) || len(k.Capabilities()) != len(MinimumVersions) {
+		t.Errorf("Expected all capabilities, got %v", k.Capabilities())
+	}
+	// The version from the query_version action also has the git hash
+	k.mut.Lock()
+	k.version = nil
+	k.mut.Unlock()
+	if v, err := k.Version(); err != nil || v.GitHash == "" {
+		t.Errorf("Expected a git hash from query_version, got %v (%v)", v, err)
+	}
+	k.mut.Lock()
+	k.version = &Version{Major: 1, Minor: 11}
+	k.mut.Unlock()
+	if k.Supports(CapabilityTerminateAll) {
+		t.Errorf("Expected KataGo 1.11 not to support terminate_all")
+	}
+	if err := k.TerminateAll(); err != nil {
+		t.Errorf("Expected TerminateAll to work without terminate_all, got %v", err)
+	}
+	if _, err := k.SuggestHumanMove(Position{BoardXSize: 9, BoardYSize: 9}, Rank10k); !errors.Is(err, ErrNotSupported) {
+		t.Errorf("Expected ErrNotSupported, got %v", err)
+	}
+}